@@ -1,15 +1,15 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"os"
 
 	"github.com/cxt9/claude-go/internal/launcher"
 )
 
 func main() {
-	if err := launcher.Run(os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if err := launcher.Run(context.Background(), os.Args[1:]); err != nil {
+		launcher.ReportError(os.Stderr, err)
+		os.Exit(launcher.ExitCode(err))
 	}
 }
@@ -4,7 +4,6 @@ go 1.22
 
 require (
 	golang.org/x/crypto v0.21.0
+	golang.org/x/sys v0.18.0
 	golang.org/x/term v0.18.0
 )
-
-require golang.org/x/sys v0.18.0 // indirect
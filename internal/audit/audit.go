@@ -0,0 +1,258 @@
+// Package audit keeps an append-only record of credential reads. Each line
+// is a JSON event carrying an HMAC over the event and the previous line's
+// HMAC, keyed with a secret kept in the vault, so editing, reordering or
+// deleting lines breaks the chain for anyone without the key. The last
+// event's sequence number and HMAC are checkpointed in the vault too, so
+// removing lines from the end, or the whole file, is detected as well.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// Version is the version of the event format written by this build
+const Version = 1
+
+// KeySize is the length of a log key
+const KeySize = 32
+
+// ErrTampered means the log doesn't verify with its key: a line was
+// changed, removed or reordered, or the key is not the one it was
+// written with
+var ErrTampered = errors.New("audit log has been modified")
+
+// Event is one recorded credential access. It never holds secret values,
+// only the ID of the vault entry that was read.
+type Event struct {
+	Version    int       `json:"v"`
+	Seq        uint64    `json:"seq"`
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	Credential string    `json:"credential"`
+	Session    string    `json:"session,omitempty"`
+	Host       string    `json:"host,omitempty"`
+	MAC        string    `json:"mac,omitempty"`
+}
+
+// TamperError reports where verification of a log failed
+type TamperError struct {
+	Line   int
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("%v: line %d: %s", ErrTampered, e.Line, e.Reason)
+}
+
+func (e *TamperError) Unwrap() error {
+	return ErrTampered
+}
+
+// Head identifies the last event recorded, to be kept where the log can't
+// be altered
+type Head struct {
+	Seq uint64 `json:"seq"`
+	MAC string `json:"mac"`
+}
+
+// NewKey returns a random log key
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate audit key: %w", err)
+	}
+	return key, nil
+}
+
+// Log appends events to an audit file
+type Log struct {
+	path string
+	key  []byte
+	host string
+
+	mu         sync.Mutex
+	session    string
+	checkpoint func(Head) error
+}
+
+// New returns a log writing to path with key. The file is created on the
+// first Record.
+func New(path string, key []byte) *Log {
+	host, _ := os.Hostname()
+	return &Log{path: path, key: key, host: host}
+}
+
+// SetSession attributes later events to session id
+func (l *Log) SetSession(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.session = id
+}
+
+// SetCheckpoint makes Record pass the new head to save after each event
+func (l *Log) SetCheckpoint(save func(Head) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checkpoint = save
+}
+
+// Record appends an event for credential, the ID of the vault entry read.
+// The file stays locked from reading the last event until the new one is
+// written, so runs recording at the same time keep the chain intact.
+func (l *Log) Record(action, credential string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if err := fsutil.LockFile(f); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+
+	seq, prevMAC, err := last(f)
+	if err != nil {
+		return err
+	}
+
+	event := Event{
+		Version:    Version,
+		Seq:        seq + 1,
+		Time:       time.Now().UTC(),
+		Action:     action,
+		Credential: credential,
+		Session:    l.session,
+		Host:       l.host,
+	}
+	mac, err := sign(l.key, prevMAC, event)
+	if err != nil {
+		return err
+	}
+	event.MAC = mac
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to serialize audit event: %w", err)
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	if l.checkpoint != nil {
+		if err := l.checkpoint(Head{Seq: event.Seq, MAC: event.MAC}); err != nil {
+			return fmt.Errorf("failed to checkpoint audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// last returns the sequence number and MAC of the last event in f, which
+// another run may have written. It isn't verified here, so a damaged log
+// keeps recording; Read reports the damage.
+func last(f *os.File) (uint64, string, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	data = bytes.TrimRight(data, "\n")
+	if len(data) == 0 {
+		return 0, "", nil
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+
+	var event Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return 0, "", fmt.Errorf("audit log's last line is not a valid event: %w", err)
+	}
+	return event.Seq, event.MAC, nil
+}
+
+// Read returns the events in the log at path, checking the chain with key
+// and that it reaches head, the last checkpoint. If it breaks, the events
+// before the break are returned with a *TamperError.
+func Read(path string, key []byte, head Head) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && head.Seq > 0 {
+		return nil, &TamperError{Line: 1, Reason: fmt.Sprintf("the log is missing, but %d event(s) were recorded", head.Seq)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	prevMAC := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return events, &TamperError{Line: n, Reason: "not a valid event"}
+		}
+		if event.Version < 1 || event.Version > Version {
+			return events, fmt.Errorf("audit log line %d: unsupported event version %d", n, event.Version)
+		}
+		if event.Seq != uint64(n) {
+			return events, &TamperError{Line: n, Reason: fmt.Sprintf("sequence %d, want %d", event.Seq, n)}
+		}
+
+		want, err := sign(key, prevMAC, event)
+		if err != nil {
+			return events, err
+		}
+		if !hmac.Equal([]byte(event.MAC), []byte(want)) {
+			return events, &TamperError{Line: n, Reason: "signature does not match"}
+		}
+
+		events = append(events, event)
+		prevMAC = event.MAC
+	}
+	if err := scanner.Err(); err != nil {
+		return events, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	// Events after the checkpoint are fine; one that failed to checkpoint
+	// is still chained
+	if uint64(len(events)) < head.Seq {
+		return events, &TamperError{Line: len(events) + 1, Reason: fmt.Sprintf("the log ends at event %d, but %d were recorded", len(events), head.Seq)}
+	}
+	if head.Seq > 0 && events[head.Seq-1].MAC != head.MAC {
+		return events, &TamperError{Line: int(head.Seq), Reason: "not the event last recorded"}
+	}
+	return events, nil
+}
+
+// sign returns the HMAC of event, without its own MAC, chained to the
+// previous event's
+func sign(key []byte, prevMAC string, event Event) (string, error) {
+	event.MAC = ""
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize audit event: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(prevMAC))
+	mac.Write([]byte{'\n'})
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
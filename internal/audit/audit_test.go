@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// recordedLog writes n events to a new log and returns its path, key and
+// last checkpoint
+func recordedLog(t *testing.T, n int) (string, []byte, Head) {
+	t.Helper()
+
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	var head Head
+	l := New(path, key)
+	l.SetCheckpoint(func(h Head) error {
+		head = h
+		return nil
+	})
+	l.SetSession("session-1")
+	for i := 0; i < n; i++ {
+		if err := l.Record("read", "auth/console"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path, key, head
+}
+
+func TestRecordAndRead(t *testing.T) {
+	path, key, head := recordedLog(t, 3)
+
+	if head.Seq != 3 || head.MAC == "" {
+		t.Fatalf("checkpoint = %+v, want event 3", head)
+	}
+
+	events, err := Read(path, key, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("%d events, want 3", len(events))
+	}
+	for i, e := range events {
+		if e.Seq != uint64(i+1) || e.Action != "read" || e.Credential != "auth/console" || e.Session != "session-1" {
+			t.Errorf("event %d = %+v", i+1, e)
+		}
+	}
+
+	// An empty log with no checkpoint is fine
+	if _, err := Read(filepath.Join(t.TempDir(), "audit.log"), key, Head{}); !os.IsNotExist(err) {
+		t.Errorf("missing log without a checkpoint: err = %v, want not-exist", err)
+	}
+}
+
+func TestReadDetectsTampering(t *testing.T) {
+	lines := func(data []byte) [][]byte {
+		return bytes.SplitAfter(bytes.TrimRight(data, "\n"), []byte("\n"))
+	}
+
+	tests := map[string]func(data []byte) []byte{
+		"edited": func(data []byte) []byte {
+			return bytes.Replace(data, []byte("auth/console"), []byte("auth/bedrock"), 1)
+		},
+		"line removed": func(data []byte) []byte {
+			l := lines(data)
+			return bytes.Join(append(l[:1], l[2:]...), nil)
+		},
+		"reordered": func(data []byte) []byte {
+			l := lines(data)
+			l[0], l[1] = l[1], l[0]
+			return bytes.Join(l, nil)
+		},
+		"truncated": func(data []byte) []byte {
+			l := lines(data)
+			return bytes.Join(l[:2], nil)
+		},
+		"emptied": func(data []byte) []byte {
+			return nil
+		},
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, key, head := recordedLog(t, 3)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tamper(data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			var tampered *TamperError
+			if _, err := Read(path, key, head); !errors.As(err, &tampered) || !errors.Is(err, ErrTampered) {
+				t.Errorf("err = %v, want a TamperError", err)
+			}
+		})
+	}
+
+	t.Run("deleted", func(t *testing.T) {
+		path, key, head := recordedLog(t, 3)
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Read(path, key, head); !errors.Is(err, ErrTampered) {
+			t.Errorf("err = %v, want ErrTampered", err)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		path, _, head := recordedLog(t, 3)
+		other, _ := NewKey()
+		if _, err := Read(path, other, head); !errors.Is(err, ErrTampered) {
+			t.Errorf("err = %v, want ErrTampered", err)
+		}
+	})
+}
+
+func TestRecordConcurrentWriters(t *testing.T) {
+	path, key, _ := recordedLog(t, 0)
+
+	// Separate Logs stand in for separate runs sharing the file
+	const writers, each = 4, 25
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := New(path, key)
+			for j := 0; j < each; j++ {
+				if err := l.Record("read", "secret/db"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	events, err := Read(path, key, Head{})
+	if err != nil {
+		t.Fatalf("chain broken by concurrent writers: %v", err)
+	}
+	if len(events) != writers*each {
+		t.Errorf("%d events, want %d", len(events), writers*each)
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// ArtifactKind says why a vault entry is a leftover rather than a usable
+// credential
+type ArtifactKind string
+
+const (
+	// ArtifactExpiredToken is an OAuth login, for a provider or an MCP
+	// server, past its expiry with no refresh token to renew it
+	ArtifactExpiredToken ArtifactKind = "expired-token"
+	// ArtifactOrphanedIdentity is a cached account for a provider that no
+	// longer has a credential
+	ArtifactOrphanedIdentity ArtifactKind = "orphaned-identity"
+)
+
+// Artifact is a vault entry left behind by an interrupted or lapsed login
+type Artifact struct {
+	ID        string       `json:"id"`
+	Kind      ArtifactKind `json:"kind"`
+	Provider  string       `json:"provider"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"`
+}
+
+// Artifacts lists the vault's leftover auth entries. Tokens that can still
+// be refreshed, or whose expiry can't be judged because the local clock is
+// behind, are credentials, not artifacts.
+func (a *Authenticator) Artifacts() ([]Artifact, error) {
+	entries, err := a.vault.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	configured := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Type == vault.CredentialOAuth || entry.Type == vault.CredentialAPIKey {
+			configured[entry.Provider] = true
+		}
+	}
+
+	artifacts := []Artifact{}
+	for _, entry := range entries {
+		switch {
+		case entry.Type == vault.CredentialOAuth || entry.Type == vault.CredentialMCP:
+			if expiresAt, dead := a.deadToken(entry.ID); dead {
+				artifacts = append(artifacts, Artifact{
+					ID:        entry.ID,
+					Kind:      ArtifactExpiredToken,
+					Provider:  entry.Provider,
+					UpdatedAt: entry.UpdatedAt,
+					ExpiresAt: &expiresAt,
+				})
+			}
+		case entry.Type == credentialIdentity && !configured[entry.Provider]:
+			artifacts = append(artifacts, Artifact{
+				ID:        entry.ID,
+				Kind:      ArtifactOrphanedIdentity,
+				Provider:  entry.Provider,
+				UpdatedAt: entry.UpdatedAt,
+			})
+		}
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].ID < artifacts[j].ID
+	})
+
+	return artifacts, nil
+}
+
+// deadToken reports whether the OAuth entry id has expired with no refresh
+// token, and when it expired
+func (a *Authenticator) deadToken(id string) (time.Time, bool) {
+	entry, err := a.vault.GetEntry(id)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var oauthData vault.OAuthData
+	if json.Unmarshal(entry.Data, &oauthData) != nil || oauthData.RefreshToken != "" || oauthData.ExpiresAt.IsZero() {
+		return time.Time{}, false
+	}
+
+	if _, clockSuspect := a.needsRefresh(&oauthData); clockSuspect || !a.now().After(oauthData.ExpiresAt) {
+		return time.Time{}, false
+	}
+	return oauthData.ExpiresAt, true
+}
+
+// ClearArtifacts deletes the given artifacts, or all of them when ids is
+// empty, and returns those deleted. Each is checked again first, so an entry
+// renewed since it was listed is kept. A provider's cached account goes with
+// its expired login.
+func (a *Authenticator) ClearArtifacts(ids []string) ([]Artifact, error) {
+	artifacts, err := a.Artifacts()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	cleared := []Artifact{}
+	for _, artifact := range artifacts {
+		if len(ids) > 0 && !wanted[artifact.ID] {
+			continue
+		}
+		if err := a.vault.DeleteEntry(artifact.ID); err != nil {
+			return cleared, err
+		}
+		cleared = append(cleared, artifact)
+
+		if artifact.Kind == ArtifactExpiredToken && strings.HasPrefix(artifact.ID, "auth/") {
+			a.vault.DeleteEntry(identityEntryID(Provider(artifact.Provider)))
+		}
+	}
+
+	return cleared, nil
+}
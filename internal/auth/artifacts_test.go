@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+func TestClearArtifacts(t *testing.T) {
+	a := newTestAuthenticator(t)
+	now := time.Now()
+	expired := vault.OAuthData{
+		AccessToken: "dead",
+		IssuedAt:    now.Add(-2 * time.Hour),
+		ExpiresAt:   now.Add(-time.Hour),
+	}
+	renewable := expired
+	renewable.RefreshToken = "refresh"
+	valid := expired
+	valid.ExpiresAt = now.Add(time.Hour)
+
+	mcp := func(name string, data vault.OAuthData) {
+		t.Helper()
+		entry := &vault.Entry{ID: mcpEntryID(name), Type: vault.CredentialMCP, Provider: name}
+		if err := a.storeOAuthEntry(entry, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Artifacts: an expired login and its cached account, an expired MCP
+	// token, and the cached account of a provider with no credential
+	if err := a.storeOAuthData(ProviderClaudeAI, expired); err != nil {
+		t.Fatal(err)
+	}
+	a.saveIdentity(ProviderClaudeAI, cachedIdentity{Account: "me@example.com", CheckedAt: now})
+	mcp("expired", expired)
+	a.saveIdentity(ProviderBedrock, cachedIdentity{Account: "old", CheckedAt: now})
+
+	// Credentials: an API key and its account, tokens that can be
+	// refreshed, and a token not yet expired
+	if err := a.SetAPIKey(ProviderConsole, "sk-ant-test"); err != nil {
+		t.Fatal(err)
+	}
+	a.saveIdentity(ProviderConsole, cachedIdentity{Account: "console", CheckedAt: now})
+	mcp("renewable", renewable)
+	mcp("valid", valid)
+
+	artifacts, err := a.Artifacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ArtifactKind{
+		"auth/claudeai":       ArtifactExpiredToken,
+		mcpEntryID("expired"): ArtifactExpiredToken,
+		"identity/bedrock":    ArtifactOrphanedIdentity,
+	}
+	if len(artifacts) != len(want) {
+		t.Fatalf("Artifacts = %+v, want %v", artifacts, want)
+	}
+	for _, artifact := range artifacts {
+		if want[artifact.ID] != artifact.Kind {
+			t.Errorf("artifact %s is %s, want %q", artifact.ID, artifact.Kind, want[artifact.ID])
+		}
+	}
+
+	// Clearing one leaves the others
+	cleared, err := a.ClearArtifacts([]string{mcpEntryID("expired"), mcpEntryID("valid")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cleared) != 1 || cleared[0].ID != mcpEntryID("expired") {
+		t.Errorf("cleared %+v, want only the expired MCP token", cleared)
+	}
+
+	if _, err := a.ClearArtifacts(nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := a.vault.ListEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := make(map[string]bool)
+	for _, entry := range entries {
+		kept[entry.ID] = true
+	}
+	for _, id := range []string{"auth/console", "identity/console", mcpEntryID("renewable"), mcpEntryID("valid")} {
+		if !kept[id] {
+			t.Errorf("%s was cleared", id)
+		}
+	}
+	// The expired login's cached account goes with it
+	for _, id := range []string{"auth/claudeai", "identity/claudeai", mcpEntryID("expired"), "identity/bedrock"} {
+		if kept[id] {
+			t.Errorf("%s was kept", id)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cxt9/claude-go/internal/audit"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// auditKeyID is the vault entry holding the audit log's key
+const auditKeyID = "audit/key"
+
+// auditKeyData is the vault payload of the audit key, with the log's last
+// checkpoint
+type auditKeyData struct {
+	Key  string     `json:"key"` // hex
+	Head audit.Head `json:"head"`
+}
+
+// EnableAudit records every credential and secret read from now on in the
+// audit log at path, creating its key in the vault on first use. Reads
+// fail if they can't be recorded.
+func (a *Authenticator) EnableAudit(path string) error {
+	key, _, err := a.AuditKey()
+	if err != nil {
+		if key, err = audit.NewKey(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(auditKeyData{Key: hex.EncodeToString(key)})
+		if err != nil {
+			return fmt.Errorf("failed to serialize audit key: %w", err)
+		}
+		entry := &vault.Entry{ID: auditKeyID, Type: vault.CredentialAudit, Provider: "audit", Data: data}
+		if err := a.vault.SetEntry(entry); err != nil {
+			return fmt.Errorf("failed to store audit key: %w", err)
+		}
+	}
+
+	a.audit = audit.New(path, key)
+	a.audit.SetCheckpoint(a.saveAuditHead)
+	return nil
+}
+
+// AuditKey returns the audit log's key and last checkpoint from the vault
+func (a *Authenticator) AuditKey() ([]byte, audit.Head, error) {
+	_, data, err := a.auditEntry()
+	if err != nil {
+		return nil, audit.Head{}, err
+	}
+	key, err := hex.DecodeString(data.Key)
+	if err != nil || len(key) != audit.KeySize {
+		return nil, audit.Head{}, fmt.Errorf("invalid audit key")
+	}
+	return key, data.Head, nil
+}
+
+func (a *Authenticator) auditEntry() (*vault.Entry, *auditKeyData, error) {
+	entry, err := a.vault.GetEntry(auditKeyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry.Type != vault.CredentialAudit {
+		return nil, nil, fmt.Errorf("vault entry %s is not an audit key", auditKeyID)
+	}
+
+	var data auditKeyData
+	if err := json.Unmarshal(entry.Data, &data); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse audit key: %w", err)
+	}
+	return entry, &data, nil
+}
+
+// saveAuditHead checkpoints the log's last event in the vault, so losing
+// events from the end of the log shows
+func (a *Authenticator) saveAuditHead(head audit.Head) error {
+	entry, data, err := a.auditEntry()
+	if err != nil {
+		return err
+	}
+
+	data.Head = head
+	if entry.Data, err = json.Marshal(data); err != nil {
+		return fmt.Errorf("failed to serialize audit key: %w", err)
+	}
+	return a.vault.SetEntry(entry)
+}
+
+// SetAuditSession attributes later audit events to session id
+func (a *Authenticator) SetAuditSession(id string) {
+	if a.audit != nil {
+		a.audit.SetSession(id)
+	}
+}
+
+// recordRead logs a read of vault entry id when auditing is enabled
+func (a *Authenticator) recordRead(id string) error {
+	if a.audit == nil {
+		return nil
+	}
+	if err := a.audit.Record("read", id); err != nil {
+		return fmt.Errorf("failed to record credential access: %w", err)
+	}
+	return nil
+}
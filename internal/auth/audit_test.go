@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/audit"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// newTestAuthenticator returns an authenticator over a new, unlocked vault
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	v, err := vault.CreateWithOptions(path, "correct horse battery", vault.Options{
+		KDF: vault.KDFParams{Time: 1, Memory: 8 * 1024, Threads: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(v.Lock)
+	return NewAuthenticator(v)
+}
+
+func TestAuditRecordsReads(t *testing.T) {
+	a := newTestAuthenticator(t)
+	if err := a.SetSecret("db", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	if err := a.EnableAudit(logPath); err != nil {
+		t.Fatal(err)
+	}
+	a.SetAuditSession("session-1")
+
+	for i := 0; i < 2; i++ {
+		if _, err := a.Secret("db"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key, head, err := a.AuditKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Seq != 2 {
+		t.Errorf("checkpoint in the vault = %+v, want event 2", head)
+	}
+
+	events, err := audit.Read(logPath, key, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Credential != "secret/db" || events[0].Session != "session-1" {
+		t.Errorf("events = %+v", events)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) {
+		t.Error("the audit log holds the secret's value")
+	}
+
+	// The vault's checkpoint shows a log that lost its end
+	if err := os.Remove(logPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Read(logPath, key, head); !errors.Is(err, audit.ErrTampered) {
+		t.Errorf("deleted log: err = %v, want ErrTampered", err)
+	}
+}
+
+func TestHasSecretIsNotAudited(t *testing.T) {
+	a := newTestAuthenticator(t)
+	if err := a.SetSecret("db", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	if err := a.EnableAudit(logPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if !a.HasSecret("db") {
+		t.Error("HasSecret(db) = false, want true")
+	}
+	if a.HasSecret("missing") {
+		t.Error("HasSecret(missing) = true, want false")
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Errorf("checking for a secret wrote the audit log (stat err = %v)", err)
+	}
+}
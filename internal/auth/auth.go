@@ -4,13 +4,21 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/cxt9/claude-go/internal/audit"
+	"github.com/cxt9/claude-go/internal/tlspin"
 	"github.com/cxt9/claude-go/internal/vault"
 )
 
@@ -20,8 +28,23 @@ const (
 	tokenEndpoint         = "https://claude.ai/oauth/token"
 	clientID              = "claude-code-go"
 	redirectURI           = "http://localhost:9876/callback"
+
+	// Random bytes in the OAuth state (32 characters) and the PKCE code
+	// verifier (64 characters)
+	stateBytes        = 24
+	codeVerifierBytes = 48
 )
 
+// ErrReauthRequired means a provider's OAuth access token has expired
+// and there is no refresh token to renew it with; only logging in again
+// helps
+var ErrReauthRequired = errors.New("login expired")
+
+// reauthError is ErrReauthRequired for a provider
+func reauthError(provider Provider) error {
+	return fmt.Errorf("%w: %s has no refresh token; log in again with 'claude-go setup'", ErrReauthRequired, provider)
+}
+
 // Provider represents an authentication provider
 type Provider string
 
@@ -32,14 +55,52 @@ const (
 	ProviderVertex   Provider = "vertex"
 )
 
+// Metadata keys describing where a credential came from
+const (
+	MetadataLabel = "label"
+	MetadataNote  = "note"
+)
+
 // Authenticator handles OAuth and API key authentication
 type Authenticator struct {
 	vault *vault.Vault
+
+	// OAuth scopes requested for Claude.ai logins; see SetScopes
+	scopes []string
+
+	// Offset of the token server's clock from ours, once known. Token
+	// refreshes write it and expiry checks read it, concurrently under
+	// serve.
+	skewMu    sync.Mutex
+	skew      time.Duration
+	skewKnown bool
+
+	// Client for the Claude endpoints; see SetPinnedKeys
+	client *http.Client
+
+	// API gateway replacing api.anthropic.com; see SetBaseURL
+	baseURL string
+
+	// Records credential reads; see EnableAudit
+	audit *audit.Log
 }
 
 // NewAuthenticator creates a new authenticator
 func NewAuthenticator(v *vault.Vault) *Authenticator {
-	return &Authenticator{vault: v}
+	return &Authenticator{vault: v, client: http.DefaultClient}
+}
+
+// SetPinnedKeys restricts requests to the Claude login and token endpoints
+// to servers presenting one of these public keys (see tlspin); empty
+// trusts any CA-verified certificate. MCP servers' own authorization
+// servers are not affected.
+func (a *Authenticator) SetPinnedKeys(pins []string) error {
+	client, err := tlspin.Client(pins)
+	if err != nil {
+		return err
+	}
+	a.client = client
+	return nil
 }
 
 // OAuthFlowData contains the data needed to complete an OAuth flow
@@ -51,16 +112,10 @@ type OAuthFlowData struct {
 
 // StartOAuthFlow initiates the OAuth flow and returns the authorization URL and flow data
 func (a *Authenticator) StartOAuthFlow(ctx context.Context) (*OAuthFlowData, error) {
-	// Generate state for CSRF protection
-	state, err := generateRandomString(32)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate state: %w", err)
-	}
-
-	// Generate PKCE code verifier (43-128 chars, URL-safe)
-	codeVerifier, err := generateRandomString(64)
+	// State for CSRF protection and the PKCE code verifier
+	state, codeVerifier, err := newFlowSecrets()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate code verifier: %w", err)
+		return nil, err
 	}
 
 	// Generate S256 code challenge: BASE64URL(SHA256(code_verifier))
@@ -74,7 +129,7 @@ func (a *Authenticator) StartOAuthFlow(ctx context.Context) (*OAuthFlowData, err
 		"state":                 {state},
 		"code_challenge":        {codeChallenge},
 		"code_challenge_method": {"S256"},
-		"scope":                 {"claude:read claude:write"},
+		"scope":                 {strings.Join(a.requestedScopes(), " ")},
 	}
 
 	authURL := fmt.Sprintf("%s?%s", authorizationEndpoint, params.Encode())
@@ -100,25 +155,49 @@ func (a *Authenticator) CompleteOAuthFlow(ctx context.Context, code string, code
 		return fmt.Errorf("token exchange failed: %w", err)
 	}
 
-	// Store tokens in vault
-	oauthData := vault.OAuthData{
+	// A response without a scope grants exactly what was requested
+	// (RFC 6749 section 5.1)
+	if tokens.Scope == "" {
+		tokens.Scope = strings.Join(a.requestedScopes(), " ")
+	}
+
+	return a.storeOAuthTokens(ProviderClaudeAI, tokens)
+}
+
+// storeOAuthTokens writes a token response to the provider's vault entry
+func (a *Authenticator) storeOAuthTokens(provider Provider, tokens *TokenResponse) error {
+	issued := a.issuedAt(tokens)
+	return a.storeOAuthData(provider, vault.OAuthData{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
 		TokenType:    tokens.TokenType,
-		ExpiresAt:    time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second),
+		IssuedAt:     issued,
+		ExpiresAt:    issued.Add(time.Duration(tokens.ExpiresIn) * time.Second),
 		Scope:        tokens.Scope,
-	}
+	})
+}
+
+// storeOAuthData writes OAuth tokens to the provider's vault entry
+func (a *Authenticator) storeOAuthData(provider Provider, oauthData vault.OAuthData) error {
+	return a.storeOAuthEntry(&vault.Entry{
+		ID:       fmt.Sprintf("auth/%s", provider),
+		Type:     vault.CredentialOAuth,
+		Provider: string(provider),
+	}, oauthData)
+}
 
+// storeOAuthEntry serializes OAuth tokens into entry and saves it
+func (a *Authenticator) storeOAuthEntry(entry *vault.Entry, oauthData vault.OAuthData) error {
 	data, err := json.Marshal(oauthData)
 	if err != nil {
 		return fmt.Errorf("failed to serialize tokens: %w", err)
 	}
+	entry.Data = data
 
-	entry := &vault.Entry{
-		ID:       "auth/claudeai",
-		Type:     vault.CredentialOAuth,
-		Provider: string(ProviderClaudeAI),
-		Data:     data,
+	// Preserve the original creation time and labels when replacing tokens
+	if existing, err := a.vault.GetEntry(entry.ID); err == nil {
+		entry.CreatedAt = existing.CreatedAt
+		entry.Metadata = existing.Metadata
 	}
 
 	if err := a.vault.SetEntry(entry); err != nil {
@@ -128,6 +207,49 @@ func (a *Authenticator) CompleteOAuthFlow(ctx context.Context, code string, code
 	return nil
 }
 
+// RefreshOAuth renews the provider's OAuth tokens regardless of their current
+// expiry and returns the new expiry time
+func (a *Authenticator) RefreshOAuth(ctx context.Context, provider Provider) (time.Time, error) {
+	oauthData, err := a.getOAuthData(provider)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if oauthData.RefreshToken == "" {
+		return time.Time{}, reauthError(provider)
+	}
+
+	if err := a.refreshToken(ctx, provider, oauthData.RefreshToken); err != nil {
+		return time.Time{}, fmt.Errorf("token refresh failed: %w", err)
+	}
+
+	oauthData, err = a.getOAuthData(provider)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return oauthData.ExpiresAt, nil
+}
+
+// getOAuthData reads the provider's stored OAuth tokens
+func (a *Authenticator) getOAuthData(provider Provider) (*vault.OAuthData, error) {
+	entry, err := a.vault.GetEntry(fmt.Sprintf("auth/%s", provider))
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Type != vault.CredentialOAuth {
+		return nil, fmt.Errorf("provider %s does not use OAuth", provider)
+	}
+
+	var oauthData vault.OAuthData
+	if err := json.Unmarshal(entry.Data, &oauthData); err != nil {
+		return nil, fmt.Errorf("failed to parse OAuth data: %w", err)
+	}
+
+	return &oauthData, nil
+}
+
 // SetAPIKey stores an API key in the vault
 func (a *Authenticator) SetAPIKey(provider Provider, apiKey string) error {
 	apiKeyData := vault.APIKeyData{
@@ -146,6 +268,12 @@ func (a *Authenticator) SetAPIKey(provider Provider, apiKey string) error {
 		Data:     data,
 	}
 
+	// Preserve the original creation time and labels when replacing the key
+	if existing, err := a.vault.GetEntry(entry.ID); err == nil {
+		entry.CreatedAt = existing.CreatedAt
+		entry.Metadata = existing.Metadata
+	}
+
 	if err := a.vault.SetEntry(entry); err != nil {
 		return fmt.Errorf("failed to store API key: %w", err)
 	}
@@ -153,12 +281,38 @@ func (a *Authenticator) SetAPIKey(provider Provider, apiKey string) error {
 	return nil
 }
 
+// SetMetadata merges non-empty metadata values (e.g. MetadataLabel) into the
+// provider's credential entry
+func (a *Authenticator) SetMetadata(provider Provider, metadata map[string]string) error {
+	entry, err := a.vault.GetEntry(fmt.Sprintf("auth/%s", provider))
+	if err != nil {
+		return err
+	}
+
+	// Copy so the vault's entry only changes through SetEntry
+	updated := *entry
+	updated.Metadata = make(map[string]string, len(entry.Metadata)+len(metadata))
+	for k, v := range entry.Metadata {
+		updated.Metadata[k] = v
+	}
+	for k, v := range metadata {
+		if v != "" {
+			updated.Metadata[k] = v
+		}
+	}
+
+	return a.vault.SetEntry(&updated)
+}
+
 // GetCredential retrieves credentials for the given provider
 func (a *Authenticator) GetCredential(provider Provider) (string, error) {
 	entry, err := a.vault.GetEntry(fmt.Sprintf("auth/%s", provider))
 	if err != nil {
 		return "", err
 	}
+	if err := a.recordRead(entry.ID); err != nil {
+		return "", err
+	}
 
 	switch entry.Type {
 	case vault.CredentialOAuth:
@@ -167,9 +321,22 @@ func (a *Authenticator) GetCredential(provider Provider) (string, error) {
 			return "", fmt.Errorf("failed to parse OAuth data: %w", err)
 		}
 
-		// Check if token needs refresh
-		if time.Now().After(oauthData.ExpiresAt.Add(-5 * time.Minute)) {
-			if err := a.refreshToken(provider, oauthData.RefreshToken); err != nil {
+		// Check if token needs refresh. With the local clock behind the
+		// token's issue time its expiry can't be judged, so refresh, but
+		// fall back to the stored token if that fails.
+		if due, clockSuspect := a.needsRefresh(&oauthData); due {
+			// Without a refresh token, a token not yet expired is still
+			// worth using, but an expired one would fail at launch
+			if oauthData.RefreshToken == "" {
+				if clockSuspect || a.now().Before(oauthData.ExpiresAt) {
+					return oauthData.AccessToken, nil
+				}
+				return "", reauthError(provider)
+			}
+			if err := a.refreshToken(context.Background(), provider, oauthData.RefreshToken); err != nil {
+				if clockSuspect {
+					return oauthData.AccessToken, nil
+				}
 				return "", fmt.Errorf("token refresh failed: %w", err)
 			}
 			// Re-read the updated entry
@@ -214,6 +381,44 @@ func (a *Authenticator) ListProviders() ([]Provider, error) {
 	return providers, nil
 }
 
+// ProviderInfo describes a configured provider without its secret
+type ProviderInfo struct {
+	Provider  Provider             `json:"provider"`
+	Type      vault.CredentialType `json:"type"`
+	Label     string               `json:"label,omitempty"`
+	Note      string               `json:"note,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// DescribeProviders returns details of all configured authentication providers
+func (a *Authenticator) DescribeProviders() ([]ProviderInfo, error) {
+	entries, err := a.vault.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []ProviderInfo{}
+	for _, entry := range entries {
+		if entry.Type == vault.CredentialOAuth || entry.Type == vault.CredentialAPIKey {
+			infos = append(infos, ProviderInfo{
+				Provider:  Provider(entry.Provider),
+				Type:      entry.Type,
+				Label:     entry.Metadata[MetadataLabel],
+				Note:      entry.Metadata[MetadataNote],
+				CreatedAt: entry.CreatedAt,
+				UpdatedAt: entry.UpdatedAt,
+			})
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Provider < infos[j].Provider
+	})
+
+	return infos, nil
+}
+
 // TokenResponse represents an OAuth token response
 type TokenResponse struct {
 	AccessToken  string `json:"access_token"`
@@ -221,6 +426,10 @@ type TokenResponse struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 	Scope        string `json:"scope"`
+
+	// From the response's Date header, and our clock when it arrived
+	serverDate time.Time
+	receivedAt time.Time
 }
 
 func (a *Authenticator) exchangeCodeForTokens(ctx context.Context, code string, codeVerifier string) (*TokenResponse, error) {
@@ -233,7 +442,45 @@ func (a *Authenticator) exchangeCodeForTokens(ctx context.Context, code string,
 		"code_verifier": {codeVerifier},
 	}
 
-	resp, err := http.PostForm(tokenEndpoint, data)
+	return requestTokens(ctx, a.client, tokenEndpoint, data)
+}
+
+func (a *Authenticator) refreshToken(ctx context.Context, provider Provider, refreshToken string) error {
+	data := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {clientID},
+		"refresh_token": {refreshToken},
+	}
+
+	tokens, err := requestTokens(ctx, a.client, tokenEndpoint, data)
+	if err != nil {
+		return err
+	}
+
+	// Servers that don't rotate refresh tokens omit them from the response
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = refreshToken
+	}
+
+	// A refresh without a scope keeps the one granted before
+	if tokens.Scope == "" {
+		if previous, err := a.getOAuthData(provider); err == nil {
+			tokens.Scope = previous.Scope
+		}
+	}
+
+	return a.storeOAuthTokens(provider, tokens)
+}
+
+// requestTokens posts a form to a token endpoint and decodes the response
+func requestTokens(ctx context.Context, client *http.Client, endpoint string, data url.Values) (*TokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("token request failed: %w", err)
 	}
@@ -247,48 +494,178 @@ func (a *Authenticator) exchangeCodeForTokens(ctx context.Context, code string,
 	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
 		return nil, fmt.Errorf("failed to parse token response: %w", err)
 	}
+	tokens.serverDate = serverDate(resp)
+	tokens.receivedAt = time.Now()
 
 	return &tokens, nil
 }
 
-func (a *Authenticator) refreshToken(provider Provider, refreshToken string) error {
-	// This would make an actual HTTP request to refresh the token
-	// For now, this is a placeholder
-	return nil
+// AuthorizationError is an error the authorization server redirected to
+// the callback with instead of a code, e.g. access_denied when the user
+// declines (RFC 6749 section 4.1.2.1)
+type AuthorizationError struct {
+	Code        string
+	Description string
 }
 
-// StartCallbackServer starts a local HTTP server to receive OAuth callback
-func StartCallbackServer(ctx context.Context) (chan string, error) {
-	codeChan := make(chan string, 1)
+func (e *AuthorizationError) Error() string {
+	msg := "authorization failed: " + e.Code
+	if e.Denied() {
+		msg = "authorization denied"
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
 
-	server := &http.Server{Addr: ":9876"}
+// Denied reports whether the user declined to grant access
+func (e *AuthorizationError) Denied() bool {
+	return e.Code == "access_denied"
+}
 
-	http.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		if code != "" {
-			codeChan <- code
-			fmt.Fprintf(w, "<html><body><h1>Authentication successful!</h1><p>You can close this window.</p></body></html>")
-		} else {
-			errMsg := r.URL.Query().Get("error")
-			fmt.Fprintf(w, "<html><body><h1>Authentication failed</h1><p>%s</p></body></html>", errMsg)
-		}
+// StartCallbackServer starts a local HTTP server to receive the OAuth
+// callback of the flow started with state. The authorization code arrives
+// on the first channel, or an *AuthorizationError on the second if the
+// server sent an error instead; only the first callback with the flow's
+// state counts. The server is closed when ctx is done.
+func StartCallbackServer(ctx context.Context, state string) (<-chan string, <-chan error, error) {
+	addr, callbackPath, err := callbackAddr(redirectURI)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// A private mux, so a second flow in the same run can register again.
+	// Only the loopback interface is served; the code must not be
+	// reachable from the network.
+	mux := http.NewServeMux()
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	handler, codeChan, errChan := callbackHandler(state, func() {
 		// Shutdown server after handling callback
 		go func() {
 			time.Sleep(time.Second)
 			server.Shutdown(ctx)
 		}()
 	})
+	mux.HandleFunc(callbackPath, handler)
 
 	go server.ListenAndServe()
 
-	return codeChan, nil
+	// Stop listening once the caller gives up waiting
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	return codeChan, errChan, nil
+}
+
+// callbackHandler serves the OAuth callback for the flow started with
+// state, delivering the first one's code or error and then calling done.
+// A callback with any other state, as a page forging one to slip in its
+// own code would send, is refused and doesn't count.
+func callbackHandler(state string, done func()) (http.HandlerFunc, <-chan string, <-chan error) {
+	codeChan := make(chan string, 1)
+	errChan := make(chan error, 1)
+	var once sync.Once
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+			writeCallbackPage(w, false, "this response doesn't belong to the login in progress")
+			return
+		}
+
+		code := query.Get("code")
+		var callbackErr *AuthorizationError
+		if code == "" {
+			callbackErr = &AuthorizationError{Code: query.Get("error"), Description: query.Get("error_description")}
+			if callbackErr.Code == "" {
+				callbackErr.Code = "invalid_request"
+				callbackErr.Description = "the callback carried neither a code nor an error"
+			}
+		}
+
+		// Only the first callback counts; a reload must not block the handler
+		once.Do(func() {
+			if callbackErr != nil {
+				errChan <- callbackErr
+			} else {
+				codeChan <- code
+			}
+			done()
+		})
+
+		if callbackErr != nil {
+			writeCallbackPage(w, false, callbackErr.Error())
+		} else {
+			writeCallbackPage(w, true, "")
+		}
+	}
+
+	return handler, codeChan, errChan
 }
 
-func generateRandomString(length int) (string, error) {
-	bytes := make([]byte, length)
+// randomToken returns n random bytes as unpadded base64url, so a token has
+// exactly 8n bits of entropy and ceil(8n/6) characters, all unreserved
+func randomToken(n int) (string, error) {
+	bytes := make([]byte, n)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
-	return base64.URLEncoding.EncodeToString(bytes)[:length], nil
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// newFlowSecrets generates the state and PKCE code verifier of an
+// authorization-code flow
+func newFlowSecrets() (state, codeVerifier string, err error) {
+	state, err = randomToken(stateBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	codeVerifier, err = randomToken(codeVerifierBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate code verifier: %w", err)
+	}
+	if err := validateCodeVerifier(codeVerifier); err != nil {
+		return "", "", err
+	}
+
+	return state, codeVerifier, nil
+}
+
+// validateCodeVerifier checks a PKCE code verifier against RFC 7636
+// section 4.1: 43 to 128 characters from [A-Za-z0-9-._~]
+func validateCodeVerifier(v string) error {
+	if len(v) < 43 || len(v) > 128 {
+		return fmt.Errorf("invalid PKCE code verifier: %d characters, want 43-128", len(v))
+	}
+	for _, c := range v {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.ContainsRune("-._~", c)) {
+			return fmt.Errorf("invalid PKCE code verifier: character %q not allowed", c)
+		}
+	}
+	return nil
+}
+
+// callbackAddr returns the address and path the callback server listens
+// on for a redirect URI, which must be plain HTTP to a loopback host with
+// an explicit port (RFC 8252 section 7.3)
+func callbackAddr(redirect string) (addr, path string, err error) {
+	u, err := url.Parse(redirect)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid redirect URI: %w", err)
+	}
+	if u.Scheme != "http" || u.Port() == "" || u.Path == "" {
+		return "", "", fmt.Errorf("invalid redirect URI %q: want http://<loopback>:<port>/<path>", redirect)
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", "", fmt.Errorf("invalid redirect URI %q: host must be loopback", redirect)
+	}
+
+	return net.JoinHostPort(host, u.Port()), u.Path, nil
 }
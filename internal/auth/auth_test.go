@@ -0,0 +1,47 @@
+package auth
+
+import "testing"
+
+func TestMetadataRoundTrip(t *testing.T) {
+	a := newTestAuthenticator(t)
+	if err := a.SetAPIKey(ProviderConsole, "sk-ant-work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetMetadata(ProviderConsole, map[string]string{MetadataLabel: "work", MetadataNote: "org key, rotate monthly"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := a.vault.GetEntry("auth/console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Metadata[MetadataLabel] != "work" || entry.Metadata[MetadataNote] != "org key, rotate monthly" {
+		t.Errorf("Metadata = %v", entry.Metadata)
+	}
+
+	// An empty value leaves the existing one, and replacing the key keeps both
+	if err := a.SetMetadata(ProviderConsole, map[string]string{MetadataLabel: "", MetadataNote: "org key"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetAPIKey(ProviderConsole, "sk-ant-rotated"); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := a.DescribeProviders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("DescribeProviders = %+v, want one provider", infos)
+	}
+	if infos[0].Label != "work" || infos[0].Note != "org key" {
+		t.Errorf("listed label/note = %q/%q, want work/org key", infos[0].Label, infos[0].Note)
+	}
+	if key, err := a.GetCredential(ProviderConsole); err != nil || key != "sk-ant-rotated" {
+		t.Errorf("GetCredential = %q, %v", key, err)
+	}
+
+	if err := a.SetMetadata(ProviderBedrock, map[string]string{MetadataLabel: "x"}); err == nil {
+		t.Error("SetMetadata labelled a provider with no credential")
+	}
+}
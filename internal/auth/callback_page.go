@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// callbackPage is shown in the browser after the OAuth redirect. Browsers
+// only let a script close a tab it opened, so the text still says to close
+// it by hand when window.close() is refused.
+var callbackPage = template.Must(template.New("callback").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Claude Code Go</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; background: #f5f4ef; color: #1f1f1f; display: flex; align-items: center; justify-content: center; height: 100vh; margin: 0; }
+main { background: #fff; border-radius: 12px; padding: 2rem 3rem; box-shadow: 0 2px 12px rgba(0,0,0,.08); text-align: center; }
+h1 { font-size: 1.4rem; }
+.failed h1 { color: #b3261e; }
+</style>
+</head>
+<body>
+<main class="{{if .OK}}ok{{else}}failed{{end}}">
+<h1>{{if .OK}}✓ Authentication successful{{else}}Authentication failed{{end}}</h1>
+{{if .Detail}}<p>{{.Detail}}</p>{{end}}
+<p>{{if .OK}}You can close this window and return to the terminal.{{else}}Return to the terminal and try again.{{end}}</p>
+</main>
+{{if .OK}}<script>setTimeout(function () { window.close(); }, 1500);</script>{{end}}
+</body>
+</html>
+`))
+
+// writeCallbackPage renders the callback result page
+func writeCallbackPage(w http.ResponseWriter, ok bool, detail string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	callbackPage.Execute(w, struct {
+		OK     bool
+		Detail string
+	}{ok, detail})
+}
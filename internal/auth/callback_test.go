@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// callback sends the OAuth redirect with query to handler
+func callback(handler http.HandlerFunc, query url.Values) int {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest("GET", "/callback?"+query.Encode(), nil))
+	return rec.Code
+}
+
+func TestCallbackChecksState(t *testing.T) {
+	done := 0
+	handler, codeChan, errChan := callbackHandler("flow-state", func() { done++ })
+
+	// A forged or stale callback is refused and doesn't end the wait
+	for _, state := range []string{"", "other-state", "flow-stat"} {
+		if code := callback(handler, url.Values{"code": {"attacker-code"}, "state": {state}}); code != http.StatusBadRequest {
+			t.Errorf("state %q: status %d, want 400", state, code)
+		}
+	}
+	if code := callback(handler, url.Values{"error": {"access_denied"}}); code != http.StatusBadRequest {
+		t.Errorf("error without state: status %d, want 400", code)
+	}
+	if len(codeChan) != 0 || len(errChan) != 0 || done != 0 {
+		t.Fatal("a callback with the wrong state was delivered")
+	}
+
+	if code := callback(handler, url.Values{"code": {"real-code"}, "state": {"flow-state"}}); code != http.StatusOK {
+		t.Errorf("valid callback: status %d, want 200", code)
+	}
+	// A reload doesn't block or replace the code
+	callback(handler, url.Values{"code": {"second-code"}, "state": {"flow-state"}})
+
+	if got := <-codeChan; got != "real-code" || done != 1 {
+		t.Errorf("code = %q after %d deliveries, want real-code once", got, done)
+	}
+}
+
+func TestCallbackDeliversError(t *testing.T) {
+	handler, codeChan, errChan := callbackHandler("flow-state", func() {})
+
+	callback(handler, url.Values{"error": {"access_denied"}, "error_description": {"user said no"}, "state": {"flow-state"}})
+
+	select {
+	case err := <-errChan:
+		var authErr *AuthorizationError
+		if !errors.As(err, &authErr) || !authErr.Denied() || authErr.Description != "user said no" {
+			t.Errorf("err = %v, want a denial", err)
+		}
+	default:
+		t.Fatal("an error callback didn't unblock the waiter")
+	}
+	if len(codeChan) != 0 {
+		t.Error("a code was delivered for an error callback")
+	}
+}
+
+func TestCallbackPage(t *testing.T) {
+	handler, _, _ := callbackHandler("flow-state", func() {})
+
+	tests := []struct {
+		name   string
+		query  url.Values
+		status int
+		close  bool
+		text   string
+	}{
+		{"success", url.Values{"code": {"c"}, "state": {"flow-state"}}, http.StatusOK, true, "Authentication successful"},
+		{"denied", url.Values{"error": {"access_denied"}, "error_description": {"<b>no</b>"}, "state": {"flow-state"}}, http.StatusBadRequest, false, "&lt;b&gt;no&lt;/b&gt;"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("GET", "/callback?"+tt.query.Encode(), nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+			t.Errorf("%s: Content-Type = %q", tt.name, ct)
+		}
+		body := rec.Body.String()
+		if strings.Contains(body, "window.close()") != tt.close {
+			t.Errorf("%s: close script present = %v, want %v", tt.name, !tt.close, tt.close)
+		}
+		if !strings.Contains(body, tt.text) {
+			t.Errorf("%s: page lacks %q", tt.name, tt.text)
+		}
+	}
+}
+
+func TestCallbackServerDenialUnblocksWaiter(t *testing.T) {
+	addr, path, err := callbackAddr(redirectURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, err := net.Listen("tcp", addr); err != nil {
+		t.Skipf("callback port in use: %v", err)
+	} else {
+		l.Close()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	codeChan, errChan, err := StartCallbackServer(ctx, "flow-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The browser is redirected back with a denial as soon as the user
+	// declines; the server may still be starting
+	query := url.Values{"error": {"access_denied"}, "state": {"flow-state"}}
+	go func() {
+		for i := 0; i < 50; i++ {
+			resp, err := http.Get("http://" + addr + path + "?" + query.Encode())
+			if err == nil {
+				resp.Body.Close()
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		var authErr *AuthorizationError
+		if !errors.As(err, &authErr) || !authErr.Denied() {
+			t.Errorf("err = %v, want a denial", err)
+		}
+	case code := <-codeChan:
+		t.Errorf("got code %q for a denial", code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the denial didn't unblock the waiter")
+	}
+}
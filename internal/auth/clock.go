@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+const (
+	// refreshWindow is how long before expiry a token is renewed
+	refreshWindow = 5 * time.Minute
+
+	// ClockSkewTolerance is how far the local clock may differ from the
+	// token server's before it's reported as wrong
+	ClockSkewTolerance = 2 * time.Minute
+)
+
+// serverDate returns the time from a response's Date header, if any
+func serverDate(resp *http.Response) time.Time {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// issuedAt returns when tokens were issued by the server's clock, learning
+// how far the local clock is off from the response's Date header
+func (a *Authenticator) issuedAt(tokens *TokenResponse) time.Time {
+	if tokens.serverDate.IsZero() {
+		return tokens.receivedAt
+	}
+
+	a.skewMu.Lock()
+	a.skew = tokens.serverDate.Sub(tokens.receivedAt)
+	a.skewKnown = true
+	a.skewMu.Unlock()
+	return tokens.serverDate
+}
+
+// now returns the current time corrected by any clock skew learnt in this
+// run, so expiry times stored on another machine compare correctly
+func (a *Authenticator) now() time.Time {
+	a.skewMu.Lock()
+	defer a.skewMu.Unlock()
+
+	return time.Now().Add(a.skew)
+}
+
+// needsRefresh reports whether tokens are due for renewal, and whether the
+// local clock is behind the time they were issued, which makes their
+// expiry untrustworthy
+func (a *Authenticator) needsRefresh(oauthData *vault.OAuthData) (due, clockSuspect bool) {
+	a.skewMu.Lock()
+	defer a.skewMu.Unlock()
+
+	now := time.Now().Add(a.skew)
+	if !oauthData.IssuedAt.IsZero() && now.Before(oauthData.IssuedAt.Add(-ClockSkewTolerance)) {
+		if !a.skewKnown {
+			// At least this far behind; refreshing will tell exactly
+			a.skew = oauthData.IssuedAt.Sub(time.Now())
+			a.skewKnown = true
+		}
+		return true, true
+	}
+
+	return now.After(oauthData.ExpiresAt.Add(-refreshWindow)), false
+}
+
+// ClockSkew returns how far the token server's clock is ahead of the local
+// one (negative if behind), if it could be determined in this run
+func (a *Authenticator) ClockSkew() (time.Duration, bool) {
+	a.skewMu.Lock()
+	defer a.skewMu.Unlock()
+
+	return a.skew, a.skewKnown
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// learnSkew has a learn the server's clock is ahead of the local one by
+// skew, as from a token response's Date header
+func learnSkew(a *Authenticator, skew time.Duration) {
+	received := time.Now()
+	a.issuedAt(&TokenResponse{serverDate: received.Add(skew), receivedAt: received})
+}
+
+func TestNeedsRefreshSkewedClock(t *testing.T) {
+	tests := []struct {
+		name         string
+		skew         time.Duration // server clock minus local clock
+		learnt       bool
+		expiresIn    time.Duration // by the server's clock
+		due, suspect bool
+	}{
+		{"in sync, fresh", 0, true, time.Hour, false, false},
+		{"in sync, nearly expired", 0, true, time.Minute, true, false},
+		{"local behind, unknown", time.Hour, false, time.Hour, true, true},
+		{"local behind, learnt", time.Hour, true, time.Hour, false, false},
+		{"local behind, learnt, nearly expired", time.Hour, true, time.Minute, true, false},
+		{"local ahead, learnt", -time.Hour, true, 30 * time.Minute, false, false},
+		{"local ahead, learnt, nearly expired", -time.Hour, true, time.Minute, true, false},
+		{"local behind within tolerance", time.Minute, false, time.Hour, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Authenticator{}
+			if tt.learnt {
+				learnSkew(a, tt.skew)
+			}
+
+			serverNow := time.Now().Add(tt.skew)
+			tokens := &vault.OAuthData{IssuedAt: serverNow, ExpiresAt: serverNow.Add(tt.expiresIn)}
+			due, suspect := a.needsRefresh(tokens)
+			if due != tt.due || suspect != tt.suspect {
+				t.Errorf("needsRefresh = %v, %v; want %v, %v", due, suspect, tt.due, tt.suspect)
+			}
+		})
+	}
+}
+
+func TestNeedsRefreshEstimatesSkew(t *testing.T) {
+	a := &Authenticator{}
+	serverNow := time.Now().Add(time.Hour)
+	a.needsRefresh(&vault.OAuthData{IssuedAt: serverNow, ExpiresAt: serverNow.Add(time.Hour)})
+
+	skew, ok := a.ClockSkew()
+	if !ok || skew < 59*time.Minute || skew > time.Hour {
+		t.Errorf("ClockSkew = %s, %v; want about an hour", skew, ok)
+	}
+
+	// Once the server's Date is known it replaces the estimate
+	learnSkew(a, 10*time.Minute)
+	if skew, _ := a.ClockSkew(); skew != 10*time.Minute {
+		t.Errorf("ClockSkew after a refresh = %s, want 10m", skew)
+	}
+}
+
+func TestClockSkewConcurrent(t *testing.T) {
+	a := &Authenticator{}
+	tokens := &vault.OAuthData{IssuedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				learnSkew(a, time.Duration(i*j)*time.Millisecond)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a.needsRefresh(tokens)
+				a.ClockSkew()
+				a.now()
+			}
+		}()
+	}
+	wg.Wait()
+}
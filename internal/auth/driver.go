@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Prompter is what a provider's setup may ask of the person running it
+type Prompter interface {
+	// Secret asks for a value without echoing it
+	Secret(prompt string) (string, error)
+
+	// Authorize runs a browser OAuth flow: start builds the authorization
+	// URL and complete exchanges the code the callback receives
+	Authorize(
+		start func(ctx context.Context) (*OAuthFlowData, error),
+		complete func(ctx context.Context, code, codeVerifier string) error,
+	) error
+}
+
+// Driver implements one authentication provider: how its credential is
+// obtained, checked, renewed and handed to claude. The setup menu, the
+// launch environment and doctor go through the registered drivers, so a
+// new provider is a Driver and a RegisterDriver call.
+type Driver interface {
+	// Provider is the name credentials are stored under, e.g. "console"
+	Provider() Provider
+
+	// Title describes the provider in the setup menu
+	Title() string
+
+	// Setup obtains a credential from the user and stores it in the vault
+	Setup(ctx context.Context, a *Authenticator, p Prompter) error
+
+	// EnvVars returns the environment entries that give claude the
+	// credential. writeFile stores data in a private temp file for tools
+	// that only read credentials from files, returning its path.
+	EnvVars(credential string, writeFile func(pattern string, data []byte) (string, error)) ([]string, error)
+
+	// Validate checks that the stored credential is usable, renewing it
+	// first if it is due
+	Validate(ctx context.Context, a *Authenticator) error
+
+	// Refresh renews the stored credential now and returns when it
+	// expires; credentials that don't expire can't be refreshed
+	Refresh(ctx context.Context, a *Authenticator) (time.Time, error)
+}
+
+// Unattended is implemented by drivers whose credential is one secret
+// that can be stored without prompting, as provisioning needs
+type Unattended interface {
+	StoreCredential(a *Authenticator, secret string) error
+}
+
+// drivers holds the registered drivers in menu order
+var drivers []Driver
+
+// RegisterDriver adds a provider. Registering the same provider twice
+// panics, since it's a programming error.
+func RegisterDriver(d Driver) {
+	for _, existing := range drivers {
+		if existing.Provider() == d.Provider() {
+			panic(fmt.Sprintf("auth: provider %s registered twice", d.Provider()))
+		}
+	}
+	drivers = append(drivers, d)
+}
+
+// Drivers returns the registered drivers in registration order
+func Drivers() []Driver {
+	return append([]Driver(nil), drivers...)
+}
+
+// DriverFor returns the driver of a provider
+func DriverFor(provider Provider) (Driver, error) {
+	for _, d := range drivers {
+		if d.Provider() == provider {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown provider: %s", provider)
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterDriver(claudeAIDriver{})
+	RegisterDriver(apiKeyDriver{provider: ProviderConsole, title: "API Key (Claude Console)"})
+	RegisterDriver(apiKeyDriver{provider: ProviderBedrock, title: "Amazon Bedrock"})
+	RegisterDriver(vertexDriver{apiKeyDriver{provider: ProviderVertex, title: "Google Vertex AI"}})
+}
+
+// claudeAIDriver logs in to a Claude.ai subscription with OAuth
+type claudeAIDriver struct{}
+
+func (claudeAIDriver) Provider() Provider { return ProviderClaudeAI }
+
+func (claudeAIDriver) Title() string { return "Claude.ai account (Pro/Max subscription)" }
+
+func (claudeAIDriver) Setup(ctx context.Context, a *Authenticator, p Prompter) error {
+	return p.Authorize(a.StartOAuthFlow, a.CompleteOAuthFlow)
+}
+
+func (claudeAIDriver) EnvVars(credential string, writeFile func(string, []byte) (string, error)) ([]string, error) {
+	return []string{"ANTHROPIC_API_KEY=" + credential}, nil
+}
+
+func (d claudeAIDriver) Validate(ctx context.Context, a *Authenticator) error {
+	return validateCredential(a, d.Provider())
+}
+
+func (d claudeAIDriver) Refresh(ctx context.Context, a *Authenticator) (time.Time, error) {
+	return a.RefreshOAuth(ctx, d.Provider())
+}
+
+// apiKeyDriver stores a key pasted by the user
+type apiKeyDriver struct {
+	provider Provider
+	title    string
+}
+
+func (d apiKeyDriver) Provider() Provider { return d.provider }
+
+func (d apiKeyDriver) Title() string { return d.title }
+
+func (d apiKeyDriver) Setup(ctx context.Context, a *Authenticator, p Prompter) error {
+	apiKey, err := p.Secret("\nEnter your API key: ")
+	if err != nil {
+		return err
+	}
+	return d.StoreCredential(a, apiKey)
+}
+
+func (d apiKeyDriver) StoreCredential(a *Authenticator, secret string) error {
+	if strings.TrimSpace(secret) == "" {
+		return fmt.Errorf("%s: the API key is empty", d.provider)
+	}
+	return a.SetAPIKey(d.provider, secret)
+}
+
+func (apiKeyDriver) EnvVars(credential string, writeFile func(string, []byte) (string, error)) ([]string, error) {
+	return []string{"ANTHROPIC_API_KEY=" + credential}, nil
+}
+
+func (d apiKeyDriver) Validate(ctx context.Context, a *Authenticator) error {
+	return validateCredential(a, d.provider)
+}
+
+func (d apiKeyDriver) Refresh(ctx context.Context, a *Authenticator) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("%s credentials don't expire; there is nothing to refresh", d.provider)
+}
+
+// vertexDriver also accepts a service-account key, which Google's
+// libraries only read from a file
+type vertexDriver struct {
+	apiKeyDriver
+}
+
+func (d vertexDriver) EnvVars(credential string, writeFile func(string, []byte) (string, error)) ([]string, error) {
+	if !json.Valid([]byte(credential)) {
+		return d.apiKeyDriver.EnvVars(credential, writeFile)
+	}
+
+	path, err := writeFile("gcp-credentials-*.json", []byte(credential))
+	if err != nil {
+		return nil, err
+	}
+	return []string{"GOOGLE_APPLICATION_CREDENTIALS=" + path}, nil
+}
+
+func (d vertexDriver) Validate(ctx context.Context, a *Authenticator) error {
+	credential, err := a.GetCredential(d.provider)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(strings.TrimSpace(credential), "{") && !json.Valid([]byte(credential)) {
+		return fmt.Errorf("%s: the service-account key is not valid JSON", d.provider)
+	}
+	return validateCredential(a, d.provider)
+}
+
+// validateCredential checks that a provider's credential can be read,
+// refreshing OAuth tokens that are due, and isn't empty
+func validateCredential(a *Authenticator, provider Provider) error {
+	credential, err := a.GetCredential(provider)
+	if err != nil {
+		return fmt.Errorf("%s: %w", provider, err)
+	}
+	if credential == "" {
+		return fmt.Errorf("%s: the stored credential is empty", provider)
+	}
+	return nil
+}
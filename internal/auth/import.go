@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// ErrNoLocalCredentials is returned when no Claude Code credentials are
+// found on the host
+var ErrNoLocalCredentials = errors.New("no local Claude Code credentials found")
+
+// macOS keychain service Claude Code stores its OAuth tokens under
+const keychainService = "Claude Code-credentials"
+
+// localCredentials is the layout of Claude Code's .credentials.json file
+// and of its macOS keychain item
+type localCredentials struct {
+	ClaudeAIOAuth *struct {
+		AccessToken  string   `json:"accessToken"`
+		RefreshToken string   `json:"refreshToken"`
+		ExpiresAt    int64    `json:"expiresAt"` // Unix milliseconds
+		Scopes       []string `json:"scopes"`
+	} `json:"claudeAiOauth"`
+}
+
+// localConfig is the subset of ~/.claude.json holding a Console API key
+type localConfig struct {
+	PrimaryAPIKey string `json:"primaryApiKey"`
+}
+
+// ImportFromLocalClaude copies credentials from the host's own Claude Code
+// install into the vault and returns the providers it imported. It returns
+// ErrNoLocalCredentials if there is nothing to import.
+func (a *Authenticator) ImportFromLocalClaude() ([]Provider, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find home directory: %w", err)
+	}
+
+	return a.importFromLocalClaude(home, readKeychainCredentials)
+}
+
+func (a *Authenticator) importFromLocalClaude(home string, keychain func() ([]byte, error)) ([]Provider, error) {
+	var imported []Provider
+
+	creds, err := readLocalCredentials(localClaudeDir(home), keychain)
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil && creds.ClaudeAIOAuth != nil && creds.ClaudeAIOAuth.AccessToken != "" {
+		oauth := creds.ClaudeAIOAuth
+		data := vault.OAuthData{
+			AccessToken:  oauth.AccessToken,
+			RefreshToken: oauth.RefreshToken,
+			TokenType:    "Bearer",
+			ExpiresAt:    time.UnixMilli(oauth.ExpiresAt),
+			Scope:        strings.Join(oauth.Scopes, " "),
+		}
+		if err := a.storeOAuthData(ProviderClaudeAI, data); err != nil {
+			return nil, err
+		}
+		imported = append(imported, ProviderClaudeAI)
+	}
+
+	apiKey, err := readLocalAPIKey(home)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		if err := a.SetAPIKey(ProviderConsole, apiKey); err != nil {
+			return nil, err
+		}
+		imported = append(imported, ProviderConsole)
+	}
+
+	if len(imported) == 0 {
+		return nil, ErrNoLocalCredentials
+	}
+
+	return imported, nil
+}
+
+// localClaudeDir returns Claude Code's config directory, which
+// CLAUDE_CONFIG_DIR overrides
+func localClaudeDir(home string) string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".claude")
+}
+
+// readLocalCredentials reads .credentials.json, falling back to the
+// keychain where Claude Code keeps tokens on macOS. It returns nil if
+// neither exists.
+func readLocalCredentials(dir string, keychain func() ([]byte, error)) (*localCredentials, error) {
+	path := filepath.Join(dir, ".credentials.json")
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if data, err = keychain(); err != nil || len(data) == 0 {
+			return nil, nil
+		}
+		path = "keychain item " + keychainService
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var creds localCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &creds, nil
+}
+
+// readLocalAPIKey returns the Console API key from ~/.claude.json, if any
+func readLocalAPIKey(home string) (string, error) {
+	path := filepath.Join(home, ".claude.json")
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg localConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return cfg.PrimaryAPIKey, nil
+}
+
+// readKeychainCredentials reads Claude Code's keychain item on macOS
+func readKeychainCredentials() ([]byte, error) {
+	if runtime.GOOS != "darwin" {
+		return nil, nil
+	}
+
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-w").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.TrimSpace(string(out))), nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// noKeychain stands in for a host without Claude Code's keychain item
+func noKeychain() ([]byte, error) { return nil, nil }
+
+func TestImportFromLocalClaude(t *testing.T) {
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	a := newTestAuthenticator(t)
+	home := t.TempDir()
+
+	if _, err := a.importFromLocalClaude(home, noKeychain); !errors.Is(err, ErrNoLocalCredentials) {
+		t.Fatalf("empty home: err = %v, want ErrNoLocalCredentials", err)
+	}
+
+	expires := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	if err := os.MkdirAll(filepath.Join(home, ".claude"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	credentials := `{"claudeAiOauth": {"accessToken": "sk-ant-oat-local", "refreshToken": "sk-ant-ort-local", "expiresAt": ` +
+		strconv.FormatInt(expires.UnixMilli(), 10) + `, "scopes": ["user:inference", "user:profile"]}}`
+	if err := os.WriteFile(filepath.Join(home, ".claude", ".credentials.json"), []byte(credentials), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".claude.json"), []byte(`{"primaryApiKey": "sk-ant-api-local"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := a.importFromLocalClaude(home, noKeychain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 || imported[0] != ProviderClaudeAI || imported[1] != ProviderConsole {
+		t.Errorf("imported = %v, want [%s %s]", imported, ProviderClaudeAI, ProviderConsole)
+	}
+
+	oauth, err := a.getOAuthData(ProviderClaudeAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oauth.AccessToken != "sk-ant-oat-local" || oauth.RefreshToken != "sk-ant-ort-local" ||
+		!oauth.ExpiresAt.Equal(expires) || oauth.Scope != "user:inference user:profile" {
+		t.Errorf("imported OAuth data = %+v", oauth)
+	}
+	if key, err := a.GetCredential(ProviderConsole); err != nil || key != "sk-ant-api-local" {
+		t.Errorf("imported API key = %q, %v", key, err)
+	}
+}
+
+func TestImportFromLocalClaudeSources(t *testing.T) {
+	t.Run("keychain", func(t *testing.T) {
+		t.Setenv("CLAUDE_CONFIG_DIR", "")
+		a := newTestAuthenticator(t)
+		keychain := func() ([]byte, error) {
+			return []byte(`{"claudeAiOauth": {"accessToken": "sk-ant-oat-keychain"}}`), nil
+		}
+
+		imported, err := a.importFromLocalClaude(t.TempDir(), keychain)
+		if err != nil || len(imported) != 1 || imported[0] != ProviderClaudeAI {
+			t.Errorf("imported = %v, %v; want [%s]", imported, err, ProviderClaudeAI)
+		}
+	})
+
+	t.Run("CLAUDE_CONFIG_DIR", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("CLAUDE_CONFIG_DIR", dir)
+		if err := os.WriteFile(filepath.Join(dir, ".credentials.json"), []byte(`{"claudeAiOauth": {"accessToken": "sk-ant-oat-dir"}}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		a := newTestAuthenticator(t)
+
+		imported, err := a.importFromLocalClaude(t.TempDir(), noKeychain)
+		if err != nil || len(imported) != 1 || imported[0] != ProviderClaudeAI {
+			t.Errorf("imported = %v, %v; want [%s]", imported, err, ProviderClaudeAI)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		t.Setenv("CLAUDE_CONFIG_DIR", "")
+		a := newTestAuthenticator(t)
+		home := t.TempDir()
+		if err := os.WriteFile(filepath.Join(home, ".claude.json"), []byte(`{`), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := a.importFromLocalClaude(home, noKeychain); err == nil || errors.Is(err, ErrNoLocalCredentials) {
+			t.Errorf("err = %v, want a parse error", err)
+		}
+	})
+}
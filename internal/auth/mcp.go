@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// MCPOAuth describes the authorization server of an MCP server that needs
+// its own OAuth login, separate from the Claude account
+type MCPOAuth struct {
+	AuthorizationURL string
+	TokenURL         string
+	ClientID         string
+	Scopes           []string
+}
+
+func mcpEntryID(name string) string {
+	return fmt.Sprintf("mcp/%s", name)
+}
+
+// MCPAuthorized reports whether the vault holds tokens for an MCP server
+func (a *Authenticator) MCPAuthorized(name string) bool {
+	entry, err := a.vault.GetEntry(mcpEntryID(name))
+	return err == nil && entry.Type == vault.CredentialMCP
+}
+
+// StartMCPOAuthFlow builds the authorization URL for an MCP server's
+// authorization-code flow with PKCE
+func (a *Authenticator) StartMCPOAuthFlow(cfg MCPOAuth) (*OAuthFlowData, error) {
+	state, codeVerifier, err := newFlowSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {redirectURI},
+		"response_type":         {"code"},
+		"state":                 {state},
+		"code_challenge":        {generateS256Challenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if len(cfg.Scopes) > 0 {
+		params.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+
+	separator := "?"
+	if strings.Contains(cfg.AuthorizationURL, "?") {
+		separator = "&"
+	}
+
+	return &OAuthFlowData{
+		AuthURL:      cfg.AuthorizationURL + separator + params.Encode(),
+		State:        state,
+		CodeVerifier: codeVerifier,
+	}, nil
+}
+
+// CompleteMCPOAuthFlow exchanges the authorization code and stores the
+// tokens under mcp/<name>
+func (a *Authenticator) CompleteMCPOAuthFlow(ctx context.Context, name string, cfg MCPOAuth, code, codeVerifier string) error {
+	tokens, err := requestTokens(ctx, http.DefaultClient, cfg.TokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {cfg.ClientID},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+	})
+	if err != nil {
+		return fmt.Errorf("token exchange failed: %w", err)
+	}
+
+	return a.storeMCPTokens(name, tokens)
+}
+
+// MCPAccessToken returns the stored access token for an MCP server,
+// refreshing it first if it expires within five minutes
+func (a *Authenticator) MCPAccessToken(ctx context.Context, name string, cfg MCPOAuth) (string, error) {
+	entry, err := a.vault.GetEntry(mcpEntryID(name))
+	if err != nil {
+		return "", fmt.Errorf("mcp server %s is not authorized; run 'claude-go mcp auth %s'", name, name)
+	}
+	if entry.Type != vault.CredentialMCP {
+		return "", fmt.Errorf("mcp server %s: unexpected credential type %s", name, entry.Type)
+	}
+	if err := a.recordRead(entry.ID); err != nil {
+		return "", err
+	}
+
+	var oauthData vault.OAuthData
+	if err := json.Unmarshal(entry.Data, &oauthData); err != nil {
+		return "", fmt.Errorf("failed to parse OAuth data: %w", err)
+	}
+
+	if oauthData.ExpiresAt.IsZero() {
+		return oauthData.AccessToken, nil
+	}
+	due, clockSuspect := a.needsRefresh(&oauthData)
+	if !due || (clockSuspect && oauthData.RefreshToken == "") {
+		return oauthData.AccessToken, nil
+	}
+
+	if oauthData.RefreshToken == "" {
+		return "", fmt.Errorf("mcp server %s: token expired; run 'claude-go mcp auth %s'", name, name)
+	}
+
+	tokens, err := requestTokens(ctx, http.DefaultClient, cfg.TokenURL, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {cfg.ClientID},
+		"refresh_token": {oauthData.RefreshToken},
+	})
+	if err != nil {
+		if clockSuspect {
+			return oauthData.AccessToken, nil
+		}
+		return "", fmt.Errorf("mcp server %s: token refresh failed: %w", name, err)
+	}
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = oauthData.RefreshToken
+	}
+
+	if err := a.storeMCPTokens(name, tokens); err != nil {
+		return "", err
+	}
+
+	return tokens.AccessToken, nil
+}
+
+func (a *Authenticator) storeMCPTokens(name string, tokens *TokenResponse) error {
+	oauthData := vault.OAuthData{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    tokens.TokenType,
+		IssuedAt:     a.issuedAt(tokens),
+		Scope:        tokens.Scope,
+	}
+	// Some servers issue tokens without an expiry
+	if tokens.ExpiresIn > 0 {
+		oauthData.ExpiresAt = oauthData.IssuedAt.Add(time.Duration(tokens.ExpiresIn) * time.Second)
+	}
+
+	return a.storeOAuthEntry(&vault.Entry{
+		ID:       mcpEntryID(name),
+		Type:     vault.CredentialMCP,
+		Provider: name,
+	}, oauthData)
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// tokenServer answers token requests with tokens named after the grant,
+// recording the form of the last request
+func tokenServer(t *testing.T, last *map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		form := make(map[string]string)
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		*last = form
+
+		grant := r.PostForm.Get("grant_type")
+		json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken:  "access-" + grant,
+			RefreshToken: "refresh-" + grant,
+			TokenType:    "Bearer",
+			ExpiresIn:    3600,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMCPOAuthStoreAndRetrieve(t *testing.T) {
+	a := newTestAuthenticator(t)
+	var form map[string]string
+	srv := tokenServer(t, &form)
+	cfg := MCPOAuth{AuthorizationURL: "https://tracker.example.com/authorize", TokenURL: srv.URL, ClientID: "claude-go"}
+
+	if a.MCPAuthorized("tracker") {
+		t.Fatal("authorized before logging in")
+	}
+	if _, err := a.MCPAccessToken(context.Background(), "tracker", cfg); err == nil {
+		t.Fatal("got a token before logging in")
+	}
+
+	flow, err := a.StartMCPOAuthFlow(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CompleteMCPOAuthFlow(context.Background(), "tracker", cfg, "the-code", flow.CodeVerifier); err != nil {
+		t.Fatal(err)
+	}
+	if form["code"] != "the-code" || form["code_verifier"] != flow.CodeVerifier {
+		t.Errorf("token request = %v, want the code and verifier", form)
+	}
+
+	if !a.MCPAuthorized("tracker") {
+		t.Error("not authorized after logging in")
+	}
+	entry, err := a.vault.GetEntry("mcp/tracker")
+	if err != nil || entry.Type != vault.CredentialMCP {
+		t.Fatalf("stored entry = %v, %v; want a %s entry", entry, err, vault.CredentialMCP)
+	}
+
+	token, err := a.MCPAccessToken(context.Background(), "tracker", cfg)
+	if err != nil || token != "access-authorization_code" {
+		t.Errorf("MCPAccessToken = %q, %v; want the stored token", token, err)
+	}
+}
+
+func TestMCPAccessTokenRefreshes(t *testing.T) {
+	a := newTestAuthenticator(t)
+	var form map[string]string
+	srv := tokenServer(t, &form)
+	cfg := MCPOAuth{TokenURL: srv.URL, ClientID: "claude-go"}
+
+	// Stored tokens about to expire
+	issued := time.Now().Add(-time.Hour)
+	if err := a.storeOAuthEntry(&vault.Entry{ID: "mcp/tracker", Type: vault.CredentialMCP, Provider: "tracker"}, vault.OAuthData{
+		AccessToken:  "old-access",
+		RefreshToken: "old-refresh",
+		IssuedAt:     issued,
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := a.MCPAccessToken(context.Background(), "tracker", cfg)
+	if err != nil || token != "access-refresh_token" {
+		t.Fatalf("MCPAccessToken = %q, %v; want a refreshed token", token, err)
+	}
+	if form["refresh_token"] != "old-refresh" {
+		t.Errorf("refresh request = %v, want the stored refresh token", form)
+	}
+
+	// The refreshed tokens are stored, so the next launch uses them
+	if token, err := a.MCPAccessToken(context.Background(), "tracker", cfg); err != nil || token != "access-refresh_token" {
+		t.Errorf("second MCPAccessToken = %q, %v", token, err)
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestRandomTokenEntropy(t *testing.T) {
+	for _, n := range []int{1, 2, 3, stateBytes, codeVerifierBytes, 96} {
+		token, err := randomToken(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (8*n + 5) / 6; len(token) != want {
+			t.Errorf("randomToken(%d) has %d characters, want %d", n, len(token), want)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(raw) != n {
+			t.Errorf("randomToken(%d) = %q doesn't decode to %d bytes: %v", n, token, n, err)
+		}
+	}
+}
+
+func TestFlowSecrets(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		state, verifier, err := newFlowSecrets()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(state) != 32 {
+			t.Errorf("state %q has %d characters, want 32", state, len(state))
+		}
+		if len(verifier) != 64 {
+			t.Errorf("verifier %q has %d characters, want 64", verifier, len(verifier))
+		}
+		if err := validateCodeVerifier(verifier); err != nil {
+			t.Error(err)
+		}
+		if seen[state] || seen[verifier] {
+			t.Fatal("a flow secret repeated")
+		}
+		seen[state], seen[verifier] = true, true
+	}
+}
+
+func TestValidateCodeVerifier(t *testing.T) {
+	valid := []string{strings.Repeat("a", 43), strings.Repeat("Z9-._~", 21) + "xx"}
+	for _, v := range valid {
+		if err := validateCodeVerifier(v); err != nil {
+			t.Errorf("validateCodeVerifier(%q): %v", v, err)
+		}
+	}
+
+	invalid := []string{"", strings.Repeat("a", 42), strings.Repeat("a", 129), strings.Repeat("a", 42) + "+", strings.Repeat("a", 42) + "/", strings.Repeat("a", 42) + "=", strings.Repeat("a", 42) + "é"}
+	for _, v := range invalid {
+		if err := validateCodeVerifier(v); err == nil {
+			t.Errorf("validateCodeVerifier(%q) accepted an invalid verifier", v)
+		}
+	}
+}
+
+func TestS256Challenge(t *testing.T) {
+	// RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	if got, want := generateS256Challenge(verifier), "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"; got != want {
+		t.Errorf("challenge = %s, want %s", got, want)
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	if generateS256Challenge(verifier) != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Error("challenge isn't the unpadded base64url SHA-256 of the verifier")
+	}
+}
+
+func TestCallbackAddr(t *testing.T) {
+	addr, path, err := callbackAddr(redirectURI)
+	if err != nil || addr != "localhost:9876" || path != "/callback" {
+		t.Errorf("callbackAddr(%s) = %q, %q, %v", redirectURI, addr, path, err)
+	}
+	if _, _, err := callbackAddr("http://127.0.0.1:8080/cb"); err != nil {
+		t.Errorf("loopback IP: %v", err)
+	}
+
+	for _, bad := range []string{"https://localhost:9876/callback", "http://localhost/callback", "http://localhost:9876", "http://example.com:9876/callback", "http://0.0.0.0:9876/callback"} {
+		if _, _, err := callbackAddr(bad); err == nil {
+			t.Errorf("callbackAddr(%q) accepted a non-loopback or malformed URI", bad)
+		}
+	}
+}
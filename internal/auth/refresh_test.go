@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// redirectTransport sends every request to a test server in place of the
+// real endpoint
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// useServer points a's Claude token requests at srv
+func useServer(t *testing.T, a *Authenticator, srv *httptest.Server) {
+	t.Helper()
+
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.client = &http.Client{Transport: redirectTransport{target}}
+}
+
+func TestRefreshOAuth(t *testing.T) {
+	a := newTestAuthenticator(t)
+	var form map[string]string
+	useServer(t, a, tokenServer(t, &form))
+
+	// Not yet due for a refresh, which happens anyway
+	now := time.Now()
+	if err := a.storeOAuthData(ProviderClaudeAI, vault.OAuthData{
+		AccessToken:  "old-access",
+		RefreshToken: "old-refresh",
+		TokenType:    "Bearer",
+		IssuedAt:     now,
+		ExpiresAt:    now.Add(10 * time.Minute),
+		Scope:        "user:inference",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expires, err := a.RefreshOAuth(context.Background(), ProviderClaudeAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form["grant_type"] != "refresh_token" || form["refresh_token"] != "old-refresh" {
+		t.Errorf("token request = %v", form)
+	}
+	if d := time.Until(expires); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("new expiry in %s, want about an hour", d)
+	}
+
+	tokens, err := a.getOAuthData(ProviderClaudeAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.AccessToken != "access-refresh_token" || tokens.RefreshToken != "refresh-refresh_token" {
+		t.Errorf("stored tokens = %+v", tokens)
+	}
+	if tokens.Scope != "user:inference" {
+		t.Errorf("Scope = %q, want the one granted before", tokens.Scope)
+	}
+}
+
+func TestRefreshOAuthFailures(t *testing.T) {
+	a := newTestAuthenticator(t)
+	var form map[string]string
+	useServer(t, a, tokenServer(t, &form))
+
+	if err := a.SetAPIKey(ProviderConsole, "sk-test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.RefreshOAuth(context.Background(), ProviderConsole); err == nil {
+		t.Error("refreshed an API key provider")
+	}
+
+	if err := a.storeOAuthData(ProviderClaudeAI, vault.OAuthData{AccessToken: "access", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.RefreshOAuth(context.Background(), ProviderClaudeAI); !errors.Is(err, ErrReauthRequired) {
+		t.Errorf("no refresh token: err = %v, want ErrReauthRequired", err)
+	}
+	if form != nil {
+		t.Errorf("a failed precondition still requested tokens: %v", form)
+	}
+
+	// An endpoint error leaves the stored tokens alone
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	useServer(t, a, failing)
+
+	if err := a.storeOAuthData(ProviderClaudeAI, vault.OAuthData{AccessToken: "access", RefreshToken: "revoked"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.RefreshOAuth(context.Background(), ProviderClaudeAI); err == nil {
+		t.Error("a rejected refresh succeeded")
+	}
+	if tokens, _ := a.getOAuthData(ProviderClaudeAI); tokens == nil || tokens.AccessToken != "access" {
+		t.Errorf("stored tokens after a rejected refresh = %+v", tokens)
+	}
+}
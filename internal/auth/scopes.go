@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"strings"
+)
+
+// DefaultScopes are requested for Claude.ai logins unless configured
+// otherwise
+var DefaultScopes = []string{"claude:read", "claude:write"}
+
+// SetScopes sets the OAuth scopes requested for Claude.ai logins; empty
+// means DefaultScopes
+func (a *Authenticator) SetScopes(scopes []string) {
+	a.scopes = scopes
+}
+
+func (a *Authenticator) requestedScopes() []string {
+	if len(a.scopes) > 0 {
+		return a.scopes
+	}
+	return DefaultScopes
+}
+
+// ParseScopes splits a space-delimited OAuth scope string
+func ParseScopes(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// missingScopes returns the requested scopes that weren't granted
+func missingScopes(requested, granted []string) []string {
+	have := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		have[scope] = true
+	}
+
+	var missing []string
+	for _, scope := range requested {
+		if !have[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// MissingScopes returns the configured scopes the provider's stored tokens
+// were not granted
+func (a *Authenticator) MissingScopes(provider Provider) ([]string, error) {
+	oauthData, err := a.getOAuthData(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return missingScopes(a.requestedScopes(), ParseScopes(oauthData.Scope)), nil
+}
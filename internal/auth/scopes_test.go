@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMissingScopes(t *testing.T) {
+	tests := []struct {
+		requested, granted string
+		missing            string
+	}{
+		{"claude:read claude:write", "claude:read claude:write", ""},
+		{"claude:read claude:write", "claude:write  claude:read", ""},
+		{"claude:read claude:write", "claude:read", "claude:write"},
+		{"claude:read", "claude:read claude:admin", ""},
+		{"claude:read claude:write", "", "claude:read claude:write"},
+	}
+
+	for _, tt := range tests {
+		got := strings.Join(missingScopes(ParseScopes(tt.requested), ParseScopes(tt.granted)), " ")
+		if got != tt.missing {
+			t.Errorf("missingScopes(%q, %q) = %q, want %q", tt.requested, tt.granted, got, tt.missing)
+		}
+	}
+}
+
+func TestOAuthFlowScopes(t *testing.T) {
+	a := newTestAuthenticator(t)
+
+	// The configured scopes are requested, or the defaults without any
+	for _, tt := range []struct {
+		configured []string
+		want       string
+	}{
+		{nil, "claude:read claude:write"},
+		{[]string{"claude:read", "user:profile"}, "claude:read user:profile"},
+	} {
+		a.SetScopes(tt.configured)
+		flow, err := a.StartOAuthFlow(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(flow.AuthURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := u.Query().Get("scope"); got != tt.want {
+			t.Errorf("requested scope = %q, want %q", got, tt.want)
+		}
+	}
+
+	// The granted scope is stored and compared with what was asked for
+	granted := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TokenResponse{AccessToken: "access", TokenType: "Bearer", ExpiresIn: 3600, Scope: granted})
+	}))
+	defer srv.Close()
+	useServer(t, a, srv)
+
+	a.SetScopes(nil)
+	granted = "claude:read"
+	if err := a.CompleteOAuthFlow(context.Background(), "code", "verifier"); err != nil {
+		t.Fatal(err)
+	}
+	if missing, err := a.MissingScopes(ProviderClaudeAI); err != nil || strings.Join(missing, " ") != "claude:write" {
+		t.Errorf("narrower grant: MissingScopes = %q, %v; want [claude:write]", missing, err)
+	}
+
+	// No scope in the response means everything requested was granted
+	granted = ""
+	if err := a.CompleteOAuthFlow(context.Background(), "code", "verifier"); err != nil {
+		t.Fatal(err)
+	}
+	if missing, err := a.MissingScopes(ProviderClaudeAI); err != nil || len(missing) != 0 {
+		t.Errorf("implicit grant: MissingScopes = %q, %v; want none", missing, err)
+	}
+}
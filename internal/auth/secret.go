@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// secretData is the vault payload of a named secret
+type secretData struct {
+	Value string `json:"value"`
+}
+
+func secretEntryID(name string) string {
+	return fmt.Sprintf("secret/%s", name)
+}
+
+// ValidateSecretName checks a secret name: letters, digits, '-', '_' and '.'
+func ValidateSecretName(name string) error {
+	if name == "" {
+		return errors.New("secret name must not be empty")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.", r)) {
+			return fmt.Errorf("invalid secret name %q: use letters, digits, '-', '_' and '.'", name)
+		}
+	}
+	return nil
+}
+
+// SetSecret stores a named secret, replacing any with the same name
+func (a *Authenticator) SetSecret(name, value string) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(secretData{Value: value})
+	if err != nil {
+		return fmt.Errorf("failed to serialize secret: %w", err)
+	}
+
+	entry := &vault.Entry{
+		ID:       secretEntryID(name),
+		Type:     vault.CredentialSecret,
+		Provider: name,
+		Data:     data,
+	}
+	if existing, err := a.vault.GetEntry(entry.ID); err == nil {
+		entry.CreatedAt = existing.CreatedAt
+	}
+
+	if err := a.vault.SetEntry(entry); err != nil {
+		return fmt.Errorf("failed to store secret: %w", err)
+	}
+	return nil
+}
+
+// Secret returns the value of a named secret
+func (a *Authenticator) Secret(name string) (string, error) {
+	entry, err := a.vault.GetEntry(secretEntryID(name))
+	if err != nil || entry.Type != vault.CredentialSecret {
+		return "", fmt.Errorf("secret %s not found; add it with 'claude-go vault secret set %s'", name, name)
+	}
+	if err := a.recordRead(entry.ID); err != nil {
+		return "", err
+	}
+
+	var data secretData
+	if err := json.Unmarshal(entry.Data, &data); err != nil {
+		return "", fmt.Errorf("failed to parse secret %s: %w", name, err)
+	}
+	return data.Value, nil
+}
+
+// HasSecret reports whether a named secret is stored, without reading it,
+// so no audit event is recorded
+func (a *Authenticator) HasSecret(name string) bool {
+	entry, err := a.vault.GetEntry(secretEntryID(name))
+	return err == nil && entry.Type == vault.CredentialSecret
+}
+
+// DeleteSecret removes a named secret
+func (a *Authenticator) DeleteSecret(name string) error {
+	if err := a.vault.DeleteEntry(secretEntryID(name)); err != nil {
+		if errors.Is(err, vault.ErrEntryNotFound) {
+			return fmt.Errorf("secret %s not found", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// ListSecrets returns the names of the stored secrets, sorted
+func (a *Authenticator) ListSecrets() ([]string, error) {
+	entries, err := a.vault.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Type == vault.CredentialSecret {
+			names = append(names, entry.Provider)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
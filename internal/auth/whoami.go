@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+const (
+	// OAuth profile and API key organization endpoints, under the API base
+	// URL (placeholders like the endpoints above)
+	defaultBaseURL   = "https://api.anthropic.com"
+	profilePath      = "/api/oauth/profile"
+	organizationPath = "/v1/organizations/me"
+	apiVersion       = "2023-06-01"
+
+	// How long a fetched identity is reused before asking again
+	identityTTL = 5 * time.Minute
+
+	// Vault entries caching the last-known identity per provider
+	credentialIdentity vault.CredentialType = "identity"
+)
+
+// SetBaseURL sends API calls, such as the identity lookup, to an
+// Anthropic-compatible gateway instead of api.anthropic.com; empty
+// restores the default
+func (a *Authenticator) SetBaseURL(baseURL string) {
+	a.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+func (a *Authenticator) apiBaseURL() string {
+	if a.baseURL != "" {
+		return a.baseURL
+	}
+	return defaultBaseURL
+}
+
+// Identity describes who a provider's credential belongs to
+type Identity struct {
+	Provider  Provider             `json:"provider"`
+	Type      vault.CredentialType `json:"type"`
+	Label     string               `json:"label,omitempty"`
+	Account   string               `json:"account,omitempty"`
+	Org       string               `json:"organization,omitempty"`
+	CheckedAt time.Time            `json:"checked_at,omitempty"`
+	Cached    bool                 `json:"cached,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// cachedIdentity is stored in the vault under identity/<provider>
+type cachedIdentity struct {
+	Account   string    `json:"account"`
+	Org       string    `json:"organization,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type profileResponse struct {
+	Account struct {
+		EmailAddress string `json:"email_address"`
+		UUID         string `json:"uuid"`
+	} `json:"account"`
+	Organization struct {
+		Name string `json:"name"`
+	} `json:"organization"`
+}
+
+type organizationResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WhoAmI returns the identity behind each configured provider: the account
+// and organization of a Claude.ai login, or the organization owning a
+// Console API key. Other providers have no identity endpoint. If one can't
+// be reached, the last known identity is returned with Cached set.
+func (a *Authenticator) WhoAmI(ctx context.Context) ([]Identity, error) {
+	infos, err := a.DescribeProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	identities := make([]Identity, 0, len(infos))
+	for _, info := range infos {
+		identity := Identity{Provider: info.Provider, Type: info.Type, Label: info.Label}
+		if info.Type == vault.CredentialOAuth || info.Provider == ProviderConsole {
+			a.resolveIdentity(ctx, &identity)
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, nil
+}
+
+// resolveIdentity fills in the account and organization, from a fresh
+// cache entry or the identity endpoint, falling back to a stale cache entry
+// on failure
+func (a *Authenticator) resolveIdentity(ctx context.Context, identity *Identity) {
+	cached := a.loadIdentity(identity.Provider)
+	if cached != nil && time.Since(cached.CheckedAt) < identityTTL {
+		identity.Account, identity.Org, identity.CheckedAt = cached.Account, cached.Org, cached.CheckedAt
+		return
+	}
+
+	fetched, err := a.fetchIdentity(ctx, identity.Provider, identity.Type)
+	if err != nil {
+		identity.Error = err.Error()
+		if cached != nil {
+			identity.Account, identity.Org, identity.CheckedAt, identity.Cached = cached.Account, cached.Org, cached.CheckedAt, true
+		}
+		return
+	}
+
+	fetched.CheckedAt = time.Now()
+	identity.Account, identity.Org, identity.CheckedAt = fetched.Account, fetched.Org, fetched.CheckedAt
+	a.saveIdentity(identity.Provider, *fetched)
+}
+
+// fetchIdentity asks the profile endpoint about an OAuth login, or the
+// organization endpoint about an API key
+func (a *Authenticator) fetchIdentity(ctx context.Context, provider Provider, credentialType vault.CredentialType) (*cachedIdentity, error) {
+	credential, err := a.GetCredential(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if credentialType == vault.CredentialOAuth {
+		var profile profileResponse
+		if err := a.getIdentityJSON(ctx, profilePath, "profile", map[string]string{"Authorization": "Bearer " + credential}, &profile); err != nil {
+			return nil, err
+		}
+
+		account := profile.Account.EmailAddress
+		if account == "" {
+			account = profile.Account.UUID
+		}
+		return &cachedIdentity{Account: account, Org: profile.Organization.Name}, nil
+	}
+
+	var org organizationResponse
+	if err := a.getIdentityJSON(ctx, organizationPath, "organization", map[string]string{"x-api-key": credential, "anthropic-version": apiVersion}, &org); err != nil {
+		return nil, err
+	}
+	if org.Name == "" {
+		org.Name = org.ID
+	}
+	return &cachedIdentity{Org: org.Name}, nil
+}
+
+// getIdentityJSON sends a GET to an identity endpoint, named what in
+// errors, and decodes the response into v
+func (a *Authenticator) getIdentityJSON(ctx context.Context, path, what string, headers map[string]string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.apiBaseURL()+path, nil)
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s endpoint returned status %d", what, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", what, err)
+	}
+	return nil
+}
+
+func identityEntryID(provider Provider) string {
+	return fmt.Sprintf("identity/%s", provider)
+}
+
+func (a *Authenticator) loadIdentity(provider Provider) *cachedIdentity {
+	entry, err := a.vault.GetEntry(identityEntryID(provider))
+	if err != nil || entry.Type != credentialIdentity {
+		return nil
+	}
+
+	var cached cachedIdentity
+	if json.Unmarshal(entry.Data, &cached) != nil {
+		return nil
+	}
+	return &cached
+}
+
+// saveIdentity caches an identity; failures only cost a refetch
+func (a *Authenticator) saveIdentity(provider Provider, cached cachedIdentity) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+
+	a.vault.SetEntry(&vault.Entry{
+		ID:       identityEntryID(provider),
+		Type:     credentialIdentity,
+		Provider: string(provider),
+		Data:     data,
+	})
+}
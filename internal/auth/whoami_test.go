@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// identityServer mocks the profile and organization endpoints, counting
+// requests, and fails every request while down is set
+func identityServer(t *testing.T, requests *int, down *bool) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if *down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		switch {
+		case r.URL.Path == profilePath && r.Header.Get("Authorization") == "Bearer oauth-token":
+			var profile profileResponse
+			profile.Account.EmailAddress = "me@example.com"
+			profile.Organization.Name = "Example Inc"
+			json.NewEncoder(w).Encode(profile)
+		case r.URL.Path == organizationPath && r.Header.Get("x-api-key") == "sk-ant-test":
+			json.NewEncoder(w).Encode(organizationResponse{ID: "org-1", Name: "Example Console"})
+		default:
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// identityOf returns provider's identity from identities
+func identityOf(t *testing.T, identities []Identity, provider Provider) Identity {
+	t.Helper()
+
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return identity
+		}
+	}
+	t.Fatalf("no identity for %s", provider)
+	return Identity{}
+}
+
+func TestWhoAmI(t *testing.T) {
+	a := newTestAuthenticator(t)
+	var requests int
+	var down bool
+	a.SetBaseURL(identityServer(t, &requests, &down).URL)
+
+	now := time.Now()
+	if err := a.storeOAuthData(ProviderClaudeAI, vault.OAuthData{AccessToken: "oauth-token", IssuedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetAPIKey(ProviderConsole, "sk-ant-test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetAPIKey(ProviderBedrock, `{"region":"us-east-1"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	identities, err := a.WhoAmI(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := identityOf(t, identities, ProviderClaudeAI); got.Account != "me@example.com" || got.Org != "Example Inc" || got.Cached {
+		t.Errorf("claudeai = %+v", got)
+	}
+	if got := identityOf(t, identities, ProviderConsole); got.Org != "Example Console" || got.Account != "" {
+		t.Errorf("console = %+v", got)
+	}
+	if got := identityOf(t, identities, ProviderBedrock); got.Account != "" || got.Org != "" || got.Error != "" {
+		t.Errorf("bedrock has no identity endpoint, got %+v", got)
+	}
+	if requests != 2 {
+		t.Errorf("%d requests, want 2", requests)
+	}
+
+	// Within the cache lifetime nothing is fetched again
+	if _, err := a.WhoAmI(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("%d requests with a fresh cache, want 2", requests)
+	}
+
+	// Offline with a stale cache, the last known identity is shown
+	for _, p := range []Provider{ProviderClaudeAI, ProviderConsole} {
+		cached := a.loadIdentity(p)
+		cached.CheckedAt = cached.CheckedAt.Add(-2 * identityTTL)
+		a.saveIdentity(p, *cached)
+	}
+	down = true
+	identities, err = a.WhoAmI(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := identityOf(t, identities, ProviderClaudeAI); got.Account != "me@example.com" || !got.Cached || got.Error == "" {
+		t.Errorf("claudeai offline = %+v", got)
+	}
+	if got := identityOf(t, identities, ProviderConsole); got.Org != "Example Console" || !got.Cached {
+		t.Errorf("console offline = %+v", got)
+	}
+}
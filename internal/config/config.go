@@ -2,9 +2,16 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/tlspin"
+	"github.com/cxt9/claude-go/internal/vault"
 )
 
 // Config represents the portable Claude Code Go configuration
@@ -25,12 +32,52 @@ type Config struct {
 
 	// MCP server configuration
 	MCP MCPConfig `json:"mcp"`
+
+	// Commands run around each launch
+	Hooks HooksConfig `json:"hooks"`
+
+	// Authentication settings
+	Auth AuthConfig `json:"auth"`
+}
+
+// AuthConfig contains authentication settings
+type AuthConfig struct {
+	// OAuth scopes requested for Claude.ai logins; empty means
+	// claude:read and claude:write
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Public keys (sha256/<base64 SPKI hash>) the Claude login and token
+	// endpoints must present; empty trusts any CA-verified certificate
+	PinnedKeys []string `json:"pinned_keys,omitempty"`
 }
 
 // VaultConfig contains vault-related settings
 type VaultConfig struct {
 	AutoLockMinutes         int  `json:"auto_lock_minutes"`
 	RequirePasswordOnResume bool `json:"require_password_on_resume"`
+
+	// Argon2 profile for new vaults: interactive, sensitive or paranoid.
+	// Empty picks paranoid in paranoid mode and interactive otherwise.
+	KDFProfile string `json:"kdf_profile,omitempty"`
+
+	// Compress new vaults before encryption, to write less to slow flash
+	Compress bool `json:"compress,omitempty"`
+
+	// Cipher for new vaults: aes-256-gcm (the default) or
+	// chacha20-poly1305, faster on processors without AES instructions
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Allow "vault remember" to keep the master password in a computer's
+	// system keyring and unlock with it on later launches there
+	Keyring bool `json:"keyring,omitempty"`
+
+	// Refuse to run from a fixed disk, so a copy of the USB on a computer's
+	// drive doesn't work without --allow-fixed-disk. A deterrent only.
+	RequireRemovable bool `json:"require_removable,omitempty"`
+
+	// Record each credential read in vault/audit.log, signed with a key
+	// kept in the vault
+	AuditLog bool `json:"audit_log,omitempty"`
 }
 
 // SessionConfig contains session-related settings
@@ -38,6 +85,15 @@ type SessionConfig struct {
 	CleanupPeriodDays int `json:"cleanup_period_days"`
 	MaxSessions       int `json:"max_sessions"`
 	AutoSaveSeconds   int `json:"auto_save_seconds"`
+	PickerPageSize    int `json:"picker_page_size"`
+
+	// Tee claude's stderr (and stdout when not a terminal) to sessions/<id>.log
+	LogChildOutput bool `json:"log_child_output"`
+	ChildLogMaxMB  int  `json:"child_log_max_mb"`
+
+	// Starting a project used within this many hours continues its latest
+	// session instead of creating another; 0 always creates one
+	ReuseWithinHours int `json:"reuse_within_hours"`
 }
 
 // EnvironmentConfig contains runtime environment settings
@@ -45,31 +101,227 @@ type EnvironmentConfig struct {
 	ParanoidMode  bool   `json:"paranoid_mode"`
 	CleanupOnExit bool   `json:"cleanup_on_exit"`
 	DefaultModel  string `json:"default_model"`
+
+	// Directories project symlinks may resolve into in paranoid mode;
+	// empty means the home directory
+	AllowedProjectRoots []string `json:"allowed_project_roots,omitempty"`
+
+	// cache/ is trimmed to this size at launch, least recently used files
+	// first; 0 disables the limit
+	MaxCacheMB int `json:"max_cache_mb"`
+
+	// Anthropic-compatible API endpoint to use instead of
+	// api.anthropic.com, e.g. a corporate gateway; passed to claude as
+	// ANTHROPIC_BASE_URL
+	BaseURL string `json:"base_url,omitempty"`
+
+	// Which host environment variables claude inherits: IsolationStrict
+	// (the default), IsolationBalanced or IsolationPassthrough
+	Isolation string `json:"isolation,omitempty"`
+
+	// How claude receives an API key or token: HandoffEnv or HandoffFD.
+	// Empty means HandoffFD in paranoid mode and HandoffEnv otherwise.
+	CredentialHandoff string `json:"credential_handoff,omitempty"`
 }
 
+// Credential handoff modes
+const (
+	// HandoffEnv passes the credential in ANTHROPIC_API_KEY
+	HandoffEnv = "env"
+	// HandoffFD passes it through an inherited pipe, so it isn't in the
+	// process environment; not available on Windows
+	HandoffFD = "fd"
+)
+
+// Environment isolation levels
+const (
+	// IsolationStrict passes only HOME, USER, PATH and TERM from the host
+	IsolationStrict = "strict"
+	// IsolationBalanced also passes locale, ssh agent, terminal and XDG
+	// directory variables
+	IsolationBalanced = "balanced"
+	// IsolationPassthrough passes the host environment except credentials
+	// and variables claude-go sets itself
+	IsolationPassthrough = "passthrough"
+)
+
 // UpdateConfig contains update-related settings
 type UpdateConfig struct {
 	AutoCheck     bool       `json:"auto_check"`
 	Channel       string     `json:"channel"` // stable, beta, nightly
 	PinnedVersion string     `json:"pinned_version,omitempty"`
 	LastCheck     *time.Time `json:"last_check,omitempty"`
+
+	// Empty cache/ after installing an update, except the subdirectories
+	// in KeepCacheDirs, e.g. offline docs that are slow to fetch again
+	ClearCacheOnUpdate bool     `json:"clear_cache_on_update"`
+	KeepCacheDirs      []string `json:"keep_cache_dirs,omitempty"`
+
+	// Public keys (sha256/<base64 SPKI hash>) the release hosts must
+	// present, including any they redirect downloads to
+	PinnedKeys []string `json:"pinned_keys,omitempty"`
+}
+
+// HooksConfig holds commands run before claude starts and after it exits.
+// They run as you, from whatever the config names, so enabling them trusts
+// everyone who can write to the USB.
+type HooksConfig struct {
+	Enabled   bool         `json:"enabled"`
+	PreLaunch *HookCommand `json:"pre_launch,omitempty"` // failure aborts the launch
+	PostExit  *HookCommand `json:"post_exit,omitempty"`  // failure is only reported
+}
+
+// HookCommand is a command run without a shell. $USB_ROOT and $PROJECT_DIR
+// are substituted in Command and Args, as for MCP servers.
+type HookCommand struct {
+	Command        string   `json:"command"`
+	Args           []string `json:"args,omitempty"`
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // default 60
+}
+
+// SubstituteVars replaces $USB_ROOT and $PROJECT_DIR (or their ${...}
+// forms) in s
+func SubstituteVars(s, usbRoot, projectDir string) string {
+	s = strings.ReplaceAll(s, "$USB_ROOT", usbRoot)
+	s = strings.ReplaceAll(s, "${USB_ROOT}", usbRoot)
+	s = strings.ReplaceAll(s, "$PROJECT_DIR", projectDir)
+	s = strings.ReplaceAll(s, "${PROJECT_DIR}", projectDir)
+	return s
 }
 
 // MCPConfig contains MCP server configuration
 type MCPConfig struct {
 	Servers map[string]MCPServer `json:"servers"`
+
+	// How long availability results are cached under cache/ (0 disables)
+	CacheTTLSeconds int `json:"cache_ttl_seconds"`
+
+	// Launch without warning about optional host-local servers that aren't
+	// installed on this computer
+	QuietMissingHostLocal bool `json:"quiet_missing_host_local,omitempty"`
+
+	// Refer to the USB root in the generated MCP config through the
+	// CLAUDE_CODE_GO_USB_ROOT variable instead of its current mount point
+	PortablePaths bool `json:"portable_paths,omitempty"`
+
+	// Named subsets of Servers, e.g. "debug", chosen with --mcp-profile and
+	// remembered by the session. Without one every server is used.
+	Profiles map[string][]string `json:"profiles,omitempty"`
 }
 
+// AllMCPServersProfile is the reserved profile name selecting every server
+const AllMCPServersProfile = "all"
+
 // MCPServer represents a single MCP server configuration
 type MCPServer struct {
-	Portability   string            `json:"portability"` // remote, bundled, usb-local, host-local
-	Type          string            `json:"type"`        // stdio, http, websocket
-	URL           string            `json:"url,omitempty"`
-	Command       string            `json:"command,omitempty"`
-	Args          []string          `json:"args,omitempty"`
-	Env           map[string]string `json:"env,omitempty"`
-	CredentialRef string            `json:"credential_ref,omitempty"`
-	Required      bool              `json:"required"`
+	Portability string            `json:"portability"` // remote, bundled, usb-local, host-local
+	Type        string            `json:"type"`        // stdio, http, sse, websocket
+	URL         string            `json:"url,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	// Accept self-signed certificates when claude-go probes an https server
+	InsecureSkipVerify bool              `json:"insecure_skip_verify,omitempty"`
+	Command            string            `json:"command,omitempty"`
+	Args               []string          `json:"args,omitempty"`
+	Env                map[string]string `json:"env,omitempty"`
+	CredentialRef      string            `json:"credential_ref,omitempty"`
+	Required           bool              `json:"required"`
+	// Allow credentials to be sent over plain http/ws
+	AllowInsecureHTTP bool `json:"allow_insecure_http,omitempty"`
+	// The server's own OAuth login, if it needs one
+	OAuth *MCPOAuthConfig `json:"oauth,omitempty"`
+	// Set to false to keep the server configured but leave it out of
+	// checks and claude's config; unset means enabled
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// IsEnabled reports whether the server is in use
+func (s MCPServer) IsEnabled() bool {
+	return s.Enabled == nil || *s.Enabled
+}
+
+// MCPOAuthConfig holds the endpoints of an MCP server's authorization server
+type MCPOAuthConfig struct {
+	AuthorizationURL string   `json:"authorization_url"`
+	TokenURL         string   `json:"token_url"`
+	ClientID         string   `json:"client_id"`
+	Scopes           []string `json:"scopes,omitempty"`
+}
+
+// ValidateURL checks a remote server's URL: it must be absolute, use a
+// scheme matching the server type, and use TLS if the server is given
+// credentials, unless AllowInsecureHTTP is set
+func (s MCPServer) ValidateURL() error {
+	if s.URL == "" {
+		return fmt.Errorf("%s servers require a url", s.Type)
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("malformed url: %s", s.URL)
+	}
+
+	secure, insecure := "https", "http"
+	if s.Type == "websocket" {
+		secure, insecure = "wss", "ws"
+	}
+
+	switch u.Scheme {
+	case secure:
+		return nil
+	case insecure:
+		if s.InsecureSkipVerify {
+			return fmt.Errorf("insecure_skip_verify only applies to %s urls", secure)
+		}
+		if s.carriesCredentials() && !s.AllowInsecureHTTP {
+			return fmt.Errorf("credentials would be sent in cleartext; use %s or set allow_insecure_http", secure)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s servers need a %s:// url, got %s", s.Type, secure, s.URL)
+	}
+}
+
+// carriesCredentials reports whether requests to the server include secrets
+func (s MCPServer) carriesCredentials() bool {
+	return s.CredentialRef != "" || len(s.Headers) > 0 || s.OAuth != nil
+}
+
+// validate checks that the OAuth endpoints are set and use https
+func (o *MCPOAuthConfig) validate() error {
+	if o.ClientID == "" {
+		return fmt.Errorf("oauth requires a client_id")
+	}
+	for _, endpoint := range []string{o.AuthorizationURL, o.TokenURL} {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("oauth endpoints must be https urls, got %q", endpoint)
+		}
+	}
+	return nil
+}
+
+// ValidateBaseURL checks an API base URL: https, or http to this machine
+// only, with no credentials, query or fragment
+func ValidateBaseURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("malformed base url: %s", raw)
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if host := u.Hostname(); host != "localhost" && host != "127.0.0.1" && host != "::1" {
+			return fmt.Errorf("base url %s must use https (http is only allowed for localhost)", raw)
+		}
+	default:
+		return fmt.Errorf("base url %s must use https", raw)
+	}
+
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("base url %s must not contain credentials, a query or a fragment", u.Redacted())
+	}
+	return nil
 }
 
 // DefaultConfig returns the default configuration
@@ -84,17 +336,23 @@ func DefaultConfig() *Config {
 			CleanupPeriodDays: 30,
 			MaxSessions:       100,
 			AutoSaveSeconds:   30,
+			PickerPageSize:    10,
+			ChildLogMaxMB:     5,
+			ReuseWithinHours:  24,
 		},
 		Environment: EnvironmentConfig{
 			ParanoidMode:  false,
 			CleanupOnExit: true,
 			DefaultModel:  "claude-sonnet-4-20250514",
+			MaxCacheMB:    512,
 		},
 		Updates: UpdateConfig{
-			AutoCheck: true,
-			Channel:   "stable",
+			AutoCheck:          true,
+			Channel:            "stable",
+			ClearCacheOnUpdate: true,
 		},
 		MCP: MCPConfig{
+			CacheTTLSeconds: 300,
 			Servers: map[string]MCPServer{
 				"filesystem": {
 					Portability: "bundled",
@@ -123,9 +381,115 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
+// Validate checks the configuration for values that cannot work at runtime
+func (c *Config) Validate() error {
+	for name, server := range c.MCP.Servers {
+		if err := server.Validate(); err != nil {
+			return fmt.Errorf("mcp server %q: %w", name, err)
+		}
+	}
+
+	for name, servers := range c.MCP.Profiles {
+		if name == "" || name == AllMCPServersProfile {
+			return fmt.Errorf("mcp profile name %q is reserved", name)
+		}
+		for _, server := range servers {
+			if _, ok := c.MCP.Servers[server]; !ok {
+				return fmt.Errorf("mcp profile %q: unknown server %q", name, server)
+			}
+		}
+	}
+
+	for _, dir := range c.Updates.KeepCacheDirs {
+		if dir == "" || dir == "." || dir == ".." || strings.ContainsAny(dir, `/\`) {
+			return fmt.Errorf("updates.keep_cache_dirs: %q is not a directory name directly under cache/", dir)
+		}
+	}
+
+	if c.Environment.BaseURL != "" {
+		if err := ValidateBaseURL(c.Environment.BaseURL); err != nil {
+			return fmt.Errorf("environment.base_url: %w", err)
+		}
+	}
+
+	switch c.Environment.Isolation {
+	case "", IsolationStrict, IsolationBalanced, IsolationPassthrough:
+	default:
+		return fmt.Errorf("environment.isolation: unknown level %q (want %s, %s or %s)", c.Environment.Isolation, IsolationStrict, IsolationBalanced, IsolationPassthrough)
+	}
+
+	switch c.Environment.CredentialHandoff {
+	case "", HandoffEnv, HandoffFD:
+	default:
+		return fmt.Errorf("environment.credential_handoff: unknown mode %q (want %s or %s)", c.Environment.CredentialHandoff, HandoffEnv, HandoffFD)
+	}
+
+	if _, err := vault.ParseAlgorithm(c.Vault.Algorithm); err != nil {
+		return fmt.Errorf("vault.algorithm: %w", err)
+	}
+	if c.Vault.KDFProfile != "" {
+		if _, err := vault.ProfileParams(c.Vault.KDFProfile); err != nil {
+			return fmt.Errorf("vault.kdf_profile: %w", err)
+		}
+	}
+
+	if _, err := tlspin.ParsePins(c.Updates.PinnedKeys); err != nil {
+		return fmt.Errorf("updates.pinned_keys: %w", err)
+	}
+	if _, err := tlspin.ParsePins(c.Auth.PinnedKeys); err != nil {
+		return fmt.Errorf("auth.pinned_keys: %w", err)
+	}
+
+	for name, hook := range map[string]*HookCommand{"pre_launch": c.Hooks.PreLaunch, "post_exit": c.Hooks.PostExit} {
+		if hook != nil && hook.Command == "" {
+			return fmt.Errorf("hook %s requires a command", name)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that a server has the fields its type needs
+func (s MCPServer) Validate() error {
+	switch s.Type {
+	case "stdio":
+		if s.Command == "" {
+			return fmt.Errorf("stdio servers require a command")
+		}
+		if s.InsecureSkipVerify {
+			return fmt.Errorf("insecure_skip_verify only applies to remote servers")
+		}
+		if s.OAuth != nil {
+			return fmt.Errorf("oauth only applies to remote servers")
+		}
+	case "http", "sse", "websocket":
+		if err := s.ValidateURL(); err != nil {
+			return err
+		}
+		if s.OAuth != nil {
+			// claude takes no headers for websocket servers, so there's
+			// no way to pass the token
+			if s.Type == "websocket" {
+				return fmt.Errorf("oauth isn't supported for websocket servers")
+			}
+			if err := s.OAuth.validate(); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type: %q", s.Type)
+	}
+
+	return nil
+}
+
 // Save writes configuration to the given path
 func (c *Config) Save(path string) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
@@ -137,5 +501,5 @@ func (c *Config) Save(path string) error {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0600)
+	return fsutil.WriteFileAtomic(path, data, 0600)
 }
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+func TestMCPServerValidateOAuth(t *testing.T) {
+	oauth := &MCPOAuthConfig{
+		AuthorizationURL: "https://tracker.example.com/oauth/authorize",
+		TokenURL:         "https://tracker.example.com/oauth/token",
+		ClientID:         "claude-go",
+	}
+
+	tests := []struct {
+		name   string
+		server MCPServer
+		ok     bool
+	}{
+		{"http", MCPServer{Type: "http", URL: "https://mcp.example.com/mcp", OAuth: oauth}, true},
+		{"sse", MCPServer{Type: "sse", URL: "https://mcp.example.com/sse", OAuth: oauth}, true},
+		{"websocket", MCPServer{Type: "websocket", URL: "wss://mcp.example.com/ws", OAuth: oauth}, false},
+		{"stdio", MCPServer{Type: "stdio", Command: "server", OAuth: oauth}, false},
+		{"no client id", MCPServer{Type: "http", URL: "https://mcp.example.com/mcp", OAuth: &MCPOAuthConfig{
+			AuthorizationURL: oauth.AuthorizationURL, TokenURL: oauth.TokenURL,
+		}}, false},
+		{"http token endpoint", MCPServer{Type: "http", URL: "https://mcp.example.com/mcp", OAuth: &MCPOAuthConfig{
+			AuthorizationURL: oauth.AuthorizationURL, TokenURL: "http://tracker.example.com/oauth/token", ClientID: "claude-go",
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.server.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestMCPServerValidateURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		server MCPServer
+		ok     bool
+	}{
+		{"https with credential", MCPServer{Type: "http", URL: "https://mcp.example.com/mcp", CredentialRef: "mcp/tracker"}, true},
+		{"http without credentials", MCPServer{Type: "http", URL: "http://localhost:8080/mcp"}, true},
+		{"http with credential", MCPServer{Type: "http", URL: "http://mcp.example.com/mcp", CredentialRef: "mcp/tracker"}, false},
+		{"http with headers", MCPServer{Type: "sse", URL: "http://mcp.example.com/sse", Headers: map[string]string{"Authorization": "Bearer x"}}, false},
+		{"http with opt-out", MCPServer{Type: "http", URL: "http://mcp.internal/mcp", CredentialRef: "mcp/tracker", AllowInsecureHTTP: true}, true},
+		{"ws with credential", MCPServer{Type: "websocket", URL: "ws://mcp.example.com/ws", CredentialRef: "mcp/tracker"}, false},
+		{"wrong scheme", MCPServer{Type: "http", URL: "wss://mcp.example.com/ws"}, false},
+		{"malformed", MCPServer{Type: "http", URL: "mcp.example.com/mcp"}, false},
+		{"not a url", MCPServer{Type: "http", URL: "://"}, false},
+		{"missing", MCPServer{Type: "http"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.server.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestMCPServerValidateInsecureSkipVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		server MCPServer
+		ok     bool
+	}{
+		{"https", MCPServer{Type: "http", URL: "https://mcp.internal/mcp", InsecureSkipVerify: true}, true},
+		{"wss", MCPServer{Type: "websocket", URL: "wss://mcp.internal/ws", InsecureSkipVerify: true}, true},
+		{"http", MCPServer{Type: "http", URL: "http://mcp.internal/mcp", InsecureSkipVerify: true}, false},
+		{"stdio", MCPServer{Type: "stdio", Command: "server", InsecureSkipVerify: true}, false},
+	}
+
+	for _, tt := range tests {
+		if err := tt.server.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateVaultSettings(t *testing.T) {
+	tests := []struct {
+		name  string
+		vault VaultConfig
+		ok    bool
+	}{
+		{"defaults", VaultConfig{}, true},
+		{"chacha20", VaultConfig{Algorithm: "chacha20-poly1305", KDFProfile: "paranoid"}, true},
+		{"unknown algorithm", VaultConfig{Algorithm: "aes-cbc"}, false},
+		{"unknown profile", VaultConfig{KDFProfile: "fast"}, false},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.Vault = tt.vault
+		if err := cfg.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestLoadSSEServer(t *testing.T) {
+	tests := []struct {
+		name   string
+		server string
+		ok     bool
+	}{
+		{"https", `{"portability": "remote", "type": "sse", "url": "https://mcp.example.com/sse", "headers": {"Authorization": "Bearer ${TOKEN}"}}`, true},
+		{"http without credentials", `{"portability": "remote", "type": "sse", "url": "http://localhost:8080/sse"}`, true},
+		{"http with credentials", `{"portability": "remote", "type": "sse", "url": "http://mcp.example.com/sse", "headers": {"Authorization": "Bearer x"}}`, false},
+		{"websocket scheme", `{"portability": "remote", "type": "sse", "url": "wss://mcp.example.com/sse"}`, false},
+		{"relative url", `{"portability": "remote", "type": "sse", "url": "/sse"}`, false},
+		{"no url", `{"portability": "remote", "type": "sse"}`, false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		data := `{"mcp": {"servers": {"events": ` + tt.server + `}}}`
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := Load(path)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Load() = %v, want ok=%v", tt.name, err, tt.ok)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		server := cfg.MCP.Servers["events"]
+		if server.Type != "sse" || server.URL == "" {
+			t.Errorf("%s: parsed server = %+v", tt.name, server)
+		}
+	}
+}
+
+// countingSyncer counts what is synced
+type countingSyncer struct {
+	fsutil.Syncer
+	files, dirs int
+}
+
+func (c *countingSyncer) SyncFile(f *os.File) error {
+	c.files++
+	return c.Syncer.SyncFile(f)
+}
+
+func (c *countingSyncer) SyncDir(path string) error {
+	c.dirs++
+	return c.Syncer.SyncDir(path)
+}
+
+func TestSaveSyncs(t *testing.T) {
+	rec := &countingSyncer{Syncer: fsutil.DefaultSyncer}
+	defer func(orig fsutil.Syncer) { fsutil.DefaultSyncer = orig }(fsutil.DefaultSyncer)
+	fsutil.DefaultSyncer = rec
+
+	path := filepath.Join(t.TempDir(), "config", "settings.json")
+	if err := DefaultConfig().Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if rec.files != 1 || rec.dirs != 1 {
+		t.Errorf("synced %d files and %d directories, want the config and its directory", rec.files, rec.dirs)
+	}
+}
@@ -0,0 +1,15 @@
+package fsutil
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func fileAtime(info os.FileInfo) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(st.Atim.Unix()), true
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package fsutil
+
+import (
+	"os"
+	"time"
+)
+
+// Access times aren't read on other systems; modification time stands in
+func fileAtime(info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
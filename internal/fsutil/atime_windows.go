@@ -0,0 +1,15 @@
+package fsutil
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func fileAtime(info os.FileInfo) (time.Time, bool) {
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, attrs.LastAccessTime.Nanoseconds()), true
+}
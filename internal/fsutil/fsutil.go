@@ -0,0 +1,93 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Syncer flushes files and directories to stable storage
+type Syncer interface {
+	SyncFile(f *os.File) error
+	SyncDir(path string) error
+}
+
+// DefaultSyncer is used by WriteFileAtomic; replace it to observe or stub syncing
+var DefaultSyncer Syncer = osSyncer{}
+
+type osSyncer struct{}
+
+func (osSyncer) SyncFile(f *os.File) error {
+	return f.Sync()
+}
+
+// SyncDir persists a directory entry (e.g. a rename). Windows can't sync
+// a directory, so there it is a no-op.
+func (osSyncer) SyncDir(path string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// writeSlack is room kept free beyond a file's size for filesystem
+// metadata, so a write doesn't fill the volume to the last block
+const writeSlack = 64 << 10
+
+// WriteFileAtomic writes data to a temp file beside path, syncs it, renames
+// it over path and syncs the parent directory, so a crash or an unplugged
+// USB leaves either the old or the new contents, never a torn file. Each
+// write has its own temp file, so concurrent writers don't clobber each
+// other's. It fails with ErrInsufficientSpace before writing if the volume
+// is too full to hold the new copy beside the old one.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := RequireSpace(dir, uint64(len(data))+writeSlack); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+
+	// CreateTemp makes the file 0600. FAT drives, and Windows, don't keep
+	// permissions, so failing to change them isn't an error.
+	f.Chmod(perm)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := DefaultSyncer.SyncFile(f); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(path), err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := DefaultSyncer.SyncDir(dir); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", dir, err)
+	}
+	return nil
+}
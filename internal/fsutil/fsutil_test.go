@@ -0,0 +1,116 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomicConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.json")
+
+	const writers = 8
+	contents := make(map[string]bool)
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		data := strings.Repeat(fmt.Sprintf("writer %d\n", i), 4096)
+		contents[data] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- WriteFileAtomic(path, []byte(data), 0644)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent write: %v", err)
+		}
+	}
+
+	// The file is one writer's whole contents, with no temp files left
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contents[string(data)] {
+		t.Errorf("file holds a mix of writes (%d bytes)", len(data))
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory, want only %s", len(entries), filepath.Base(path))
+	}
+
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+			t.Errorf("mode = %v, %v; want 0644", info.Mode().Perm(), err)
+		}
+	}
+}
+
+// failingSyncer fails to sync directories
+type failingSyncer struct{ osSyncer }
+
+var errSync = errors.New("injected sync failure")
+
+func (failingSyncer) SyncDir(path string) error { return errSync }
+
+func TestWriteFileAtomicReportsSyncDir(t *testing.T) {
+	defer func(orig Syncer) { DefaultSyncer = orig }(DefaultSyncer)
+	DefaultSyncer = failingSyncer{}
+
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	if err := WriteFileAtomic(path, []byte("data"), 0600); !errors.Is(err, errSync) {
+		t.Errorf("err = %v, want the directory sync failure", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if err := (osSyncer{}).SyncDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("syncing a missing directory succeeded")
+	}
+}
+
+// recordingSyncer notes what is synced, and whether the destination existed
+// yet when the file was
+type recordingSyncer struct {
+	dest   string
+	synced []string
+}
+
+func (r *recordingSyncer) SyncFile(f *os.File) error {
+	_, err := os.Stat(r.dest)
+	r.synced = append(r.synced, fmt.Sprintf("file renamed=%v", err == nil))
+	return f.Sync()
+}
+
+func (r *recordingSyncer) SyncDir(path string) error {
+	r.synced = append(r.synced, "dir "+path)
+	return nil
+}
+
+func TestWriteFileAtomicSyncs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.json")
+	rec := &recordingSyncer{dest: path}
+	defer func(orig Syncer) { DefaultSyncer = orig }(DefaultSyncer)
+	DefaultSyncer = rec
+
+	if err := WriteFileAtomic(path, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The temp file is flushed before the rename, the directory after
+	want := []string{"file renamed=false", "dir " + dir}
+	if fmt.Sprint(rec.synced) != fmt.Sprint(want) {
+		t.Errorf("synced %v, want %v", rec.synced, want)
+	}
+}
@@ -0,0 +1,9 @@
+package fsutil
+
+import "os"
+
+// LockFile takes an exclusive advisory lock on f, waiting for other
+// processes holding it. Closing f releases the lock.
+func LockFile(f *os.File) error {
+	return lockFile(f)
+}
@@ -0,0 +1,111 @@
+package fsutil
+
+import (
+	"bytes"
+	"os"
+	"sync"
+)
+
+// LogWriter appends process output to a log file, rotating it to
+// <name>.1 when it would exceed its size cap. Write never fails, so a full
+// disk or a rotation error can't break the output it is copied from.
+type LogWriter struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	file     *os.File
+	size     int64
+	secrets  [][]byte
+}
+
+// OpenLogWriter opens the log at path for appending; maxBytes 0 means no
+// cap. Any of the given secrets that appear in the output are masked
+// before being written.
+func OpenLogWriter(path string, maxBytes int64, secrets []string) (*LogWriter, error) {
+	w := &LogWriter{path: path, maxBytes: maxBytes}
+	for _, secret := range secrets {
+		if secret != "" {
+			w.secrets = append(w.secrets, []byte(secret))
+		}
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *LogWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate moves the current log to <path>.1, replacing an older one
+func (w *LogWriter) rotate() error {
+	w.file.Close()
+	w.file = nil
+
+	if err := Rename(w.path, w.path+".1"); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Write implements io.Writer
+func (w *LogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+
+	// Masking works per chunk; a secret split across two writes is missed,
+	// but the child never prints its credentials in the first place
+	for _, secret := range w.secrets {
+		p = bytes.ReplaceAll(p, secret, []byte("[redacted]"))
+	}
+
+	if w.maxBytes > 0 && w.size+int64(len(p)) > w.maxBytes {
+		// An empty log isn't worth keeping as the rotated copy
+		if w.file == nil || w.size > 0 && w.rotate() != nil {
+			return n, nil
+		}
+
+		// A single chunk larger than the cap keeps only its tail
+		if int64(len(p)) > w.maxBytes {
+			p = p[int64(len(p))-w.maxBytes:]
+		}
+	}
+
+	if w.file == nil {
+		return n, nil
+	}
+
+	written, _ := w.file.Write(p)
+	w.size += int64(written)
+	return n, nil
+}
+
+// Close closes the log file
+func (w *LogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
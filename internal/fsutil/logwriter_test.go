@@ -0,0 +1,94 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readLog returns a log file's contents, or "" if it doesn't exist
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLogWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	w, err := OpenLogWriter(path, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	write := func(s string) {
+		t.Helper()
+		if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+
+	write("aaaa")
+	write("bbbb")
+	if got := readLog(t, path); got != "aaaabbbb" {
+		t.Errorf("log = %q, want aaaabbbb", got)
+	}
+
+	// Past the cap, the log moves to .1 and a new one starts
+	write("cccc")
+	if got, rotated := readLog(t, path), readLog(t, path+".1"); got != "cccc" || rotated != "aaaabbbb" {
+		t.Errorf("after rotating: log = %q, .1 = %q", got, rotated)
+	}
+
+	// Only one older log is kept
+	write("dddddddd")
+	if got, rotated := readLog(t, path), readLog(t, path+".1"); got != "dddddddd" || rotated != "cccc" {
+		t.Errorf("after rotating again: log = %q, .1 = %q", got, rotated)
+	}
+}
+
+func TestLogWriterEmptyLogNotRotated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	if err := os.WriteFile(path+".1", []byte("previous run"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := OpenLogWriter(path, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// A first chunk over the cap keeps its tail, and doesn't replace the
+	// rotated log with an empty one
+	w.Write([]byte("0123456789abcdef"))
+	if got := readLog(t, path); got != "6789abcdef" {
+		t.Errorf("log = %q, want the last 10 bytes", got)
+	}
+	if got := readLog(t, path+".1"); got != "previous run" {
+		t.Errorf(".1 = %q, want it kept", got)
+	}
+}
+
+func TestLogWriterReopenAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	if err := os.WriteFile(path, []byte("123456789012"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The existing size counts towards the cap
+	w, err := OpenLogWriter(path, 16, []string{"hunter2", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("pw hunter2"))
+	if got, rotated := readLog(t, path), readLog(t, path+".1"); got != "pw [redacted]" || rotated != "123456789012" {
+		t.Errorf("log = %q, .1 = %q", got, rotated)
+	}
+}
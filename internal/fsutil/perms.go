@@ -0,0 +1,27 @@
+package fsutil
+
+import (
+	"os"
+)
+
+// PermissionIssue is a private file or directory that other users can access
+type PermissionIssue struct {
+	Path  string
+	Mode  os.FileMode
+	Fixed bool
+}
+
+// AuditPrivate walks the given trees and reports entries that grant any
+// access to group or others. With fix, files are reset to 0600 and
+// directories to 0700. Missing roots are skipped, and so are filesystems
+// that don't store unix permissions (FAT/exFAT sticks, Windows).
+func AuditPrivate(fix bool, roots ...string) ([]PermissionIssue, error) {
+	return auditPrivate(fix, roots)
+}
+
+// StoresPermissions reports whether the filesystem under dir keeps unix
+// file modes. FAT and exFAT mounts, where chmod fails or is ignored, and
+// Windows don't.
+func StoresPermissions(dir string) bool {
+	return storesPermissions(dir)
+}
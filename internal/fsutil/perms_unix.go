@@ -0,0 +1,64 @@
+//go:build !windows
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func auditPrivate(fix bool, roots []string) ([]PermissionIssue, error) {
+	var issues []PermissionIssue
+
+	for _, root := range roots {
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+		if !storesPermissions(root) {
+			continue
+		}
+
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.Mode()&os.ModeSymlink != 0 || info.Mode().Perm()&0077 == 0 {
+				return nil
+			}
+
+			issue := PermissionIssue{Path: path, Mode: info.Mode().Perm()}
+			if fix {
+				mode := os.FileMode(0600)
+				if info.IsDir() {
+					mode = 0700
+				}
+				issue.Fixed = os.Chmod(path, mode) == nil
+			}
+			issues = append(issues, issue)
+			return nil
+		})
+		if err != nil {
+			return issues, err
+		}
+	}
+
+	return issues, nil
+}
+
+// storesPermissions reports whether the filesystem under dir keeps the mode
+// of a new private file. FAT and exFAT mounts report fixed, usually
+// permissive, modes whatever is set.
+func storesPermissions(dir string) bool {
+	f, err := os.CreateTemp(dir, ".permcheck-*")
+	if err != nil {
+		return true
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := f.Chmod(0600); err != nil {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode().Perm() == 0600
+}
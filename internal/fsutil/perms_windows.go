@@ -0,0 +1,14 @@
+//go:build windows
+
+package fsutil
+
+// auditPrivate is a no-op on Windows: file modes there don't reflect NTFS
+// ACLs, which would need the Windows security API to inspect
+func auditPrivate(fix bool, roots []string) ([]PermissionIssue, error) {
+	return nil, nil
+}
+
+// storesPermissions is false on Windows, whose modes don't reflect ACLs
+func storesPermissions(dir string) bool {
+	return false
+}
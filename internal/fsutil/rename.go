@@ -0,0 +1,31 @@
+package fsutil
+
+import "time"
+
+// Renames that fail transiently, e.g. while a virus scanner holds the
+// target open, are retried with doubling delays for up to ~2.5s in all
+const (
+	renameAttempts = 8
+	renameDelay    = 10 * time.Millisecond
+)
+
+// Rename moves oldpath to newpath, replacing newpath if it exists. On
+// Windows it retries while the target is briefly locked; elsewhere it is
+// os.Rename.
+func Rename(oldpath, newpath string) error {
+	return rename(oldpath, newpath)
+}
+
+// retryRename calls rename until it succeeds, fails with an error that
+// transient rejects, or runs out of attempts, sleeping between tries
+func retryRename(rename func() error, transient func(error) bool, sleep func(time.Duration)) error {
+	delay := renameDelay
+	for attempt := 1; ; attempt++ {
+		err := rename()
+		if err == nil || attempt == renameAttempts || !transient(err) {
+			return err
+		}
+		sleep(delay)
+		delay *= 2
+	}
+}
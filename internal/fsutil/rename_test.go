@@ -0,0 +1,84 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	errLocked   = errors.New("sharing violation")
+	errNotFound = errors.New("file not found")
+)
+
+func isLocked(err error) bool { return err == errLocked }
+
+func TestRetryRenameTransientlyLocked(t *testing.T) {
+	// The target is locked for the first three attempts
+	calls := 0
+	var slept []time.Duration
+	err := retryRename(func() error {
+		calls++
+		if calls <= 3 {
+			return errLocked
+		}
+		return nil
+	}, isLocked, func(d time.Duration) { slept = append(slept, d) })
+
+	if err != nil || calls != 4 {
+		t.Fatalf("err = %v after %d calls, want success on the 4th", err, calls)
+	}
+	want := []time.Duration{renameDelay, 2 * renameDelay, 4 * renameDelay}
+	if len(slept) != len(want) {
+		t.Fatalf("slept %v, want %v", slept, want)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Errorf("sleep %d = %s, want %s", i+1, slept[i], want[i])
+		}
+	}
+}
+
+func TestRetryRenameGivesUp(t *testing.T) {
+	calls := 0
+	err := retryRename(func() error {
+		calls++
+		return errLocked
+	}, isLocked, func(time.Duration) {})
+	if err != errLocked || calls != renameAttempts {
+		t.Errorf("always locked: err = %v after %d calls, want errLocked after %d", err, calls, renameAttempts)
+	}
+
+	// Other errors aren't retried
+	calls = 0
+	err = retryRename(func() error {
+		calls++
+		return errNotFound
+	}, isLocked, func(time.Duration) { t.Error("slept before a permanent error") })
+	if err != errNotFound || calls != 1 {
+		t.Errorf("permanent error: err = %v after %d calls", err, calls)
+	}
+}
+
+func TestRenameReplacesExisting(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "new.tmp"), filepath.Join(dir, "settings.json")
+	if err := os.WriteFile(src, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rename(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "new" {
+		t.Errorf("target = %q, want new", data)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("the source is still there")
+	}
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// rename replaces newpath with MoveFileEx, retrying while another process
+// (typically a virus scanner or indexer) has either file open. Some
+// filesystems refuse to replace an existing file; then newpath is removed
+// first, which is safe because oldpath still holds the new contents.
+func rename(oldpath, newpath string) error {
+	from, err := windows.UTF16PtrFromString(oldpath)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	to, err := windows.UTF16PtrFromString(newpath)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+
+	err = retryRename(func() error {
+		err := windows.MoveFileEx(from, to, windows.MOVEFILE_REPLACE_EXISTING|windows.MOVEFILE_WRITE_THROUGH)
+		if errors.Is(err, windows.ERROR_ALREADY_EXISTS) || errors.Is(err, windows.ERROR_FILE_EXISTS) {
+			if rmErr := os.Remove(newpath); rmErr != nil && !os.IsNotExist(rmErr) {
+				return rmErr
+			}
+			err = windows.MoveFileEx(from, to, windows.MOVEFILE_WRITE_THROUGH)
+		}
+		return err
+	}, transientRenameError, time.Sleep)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	return nil
+}
+
+// transientRenameError reports errors a locked file causes, which clear
+// once the other process lets go
+func transientRenameError(err error) bool {
+	return errors.Is(err, windows.ERROR_ACCESS_DENIED) ||
+		errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
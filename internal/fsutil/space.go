@@ -0,0 +1,56 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientSpace means a volume doesn't have room for a write
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+// SpaceError reports how much room an operation needed on a volume
+type SpaceError struct {
+	Path string
+	Need uint64
+	Free uint64
+}
+
+func (e *SpaceError) Error() string {
+	return fmt.Sprintf("%v on %s: need %s, %s free", ErrInsufficientSpace, e.Path, formatBytes(e.Need), formatBytes(e.Free))
+}
+
+func (e *SpaceError) Unwrap() error {
+	return ErrInsufficientSpace
+}
+
+// freeSpace returns the bytes available to this user on the volume
+// holding path; replaced in tests
+var freeSpace = diskFree
+
+// RequireSpace checks that the volume holding path has need bytes free
+// before anything is written, failing with a *SpaceError otherwise. When
+// free space can't be determined the check passes, so an unsupported
+// system or filesystem never blocks writes.
+func RequireSpace(path string, need uint64) error {
+	free, err := freeSpace(path)
+	if err != nil {
+		return nil
+	}
+	if free < need {
+		return &SpaceError{Path: path, Need: need, Free: free}
+	}
+	return nil
+}
+
+func formatBytes(n uint64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package fsutil
+
+import "errors"
+
+// Free space isn't queried on other systems; RequireSpace always passes
+func diskFree(path string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,58 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useFreeSpace makes freeSpace report free bytes, or err, for the test
+func useFreeSpace(t *testing.T, free uint64, err error) {
+	t.Helper()
+
+	orig := freeSpace
+	freeSpace = func(string) (uint64, error) { return free, err }
+	t.Cleanup(func() { freeSpace = orig })
+}
+
+func TestWriteFileAtomicInsufficientSpace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.vault")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 1<<20)
+	useFreeSpace(t, uint64(len(data)), nil)
+
+	var space *SpaceError
+	err := WriteFileAtomic(path, data, 0600)
+	if !errors.As(err, &space) || !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want a SpaceError", err)
+	}
+	if space.Need <= uint64(len(data)) || space.Free != uint64(len(data)) {
+		t.Errorf("SpaceError = %+v", space)
+	}
+
+	// Nothing was written, not even a temp file
+	if got, _ := os.ReadFile(path); string(got) != "old" {
+		t.Errorf("file = %q, want the old contents", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files in the directory, want only the original", len(entries))
+	}
+
+	useFreeSpace(t, uint64(len(data))+writeSlack, nil)
+	if err := WriteFileAtomic(path, data, 0600); err != nil {
+		t.Errorf("write with exactly enough room: %v", err)
+	}
+}
+
+func TestRequireSpaceUnknown(t *testing.T) {
+	// A volume whose free space can't be read never blocks a write
+	useFreeSpace(t, 0, errors.New("not supported"))
+	if err := RequireSpace(t.TempDir(), 1<<30); err != nil {
+		t.Errorf("RequireSpace = %v, want it to pass", err)
+	}
+}
@@ -0,0 +1,85 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// TrimResult summarizes a TrimDir pass
+type TrimResult struct {
+	Removed int   // files deleted
+	Freed   int64 // bytes deleted
+	Size    int64 // bytes left
+}
+
+// TrimDir deletes the least recently accessed files under dir until its
+// total size is at most maxBytes. Files accessed within recent are assumed
+// to be in use and kept, as is anything under the skip directories.
+func TrimDir(dir string, maxBytes int64, recent time.Duration, skip ...string) (TrimResult, error) {
+	type cached struct {
+		path     string
+		size     int64
+		accessed time.Time
+	}
+
+	var files []cached
+	var result TrimResult
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			for _, s := range skip {
+				if path == s {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		result.Size += info.Size()
+		files = append(files, cached{path: path, size: info.Size(), accessed: accessTime(info)})
+		return nil
+	})
+	if err != nil || result.Size <= maxBytes {
+		return result, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].accessed.Before(files[j].accessed)
+	})
+
+	cutoff := time.Now().Add(-recent)
+	for _, f := range files {
+		if result.Size <= maxBytes || f.accessed.After(cutoff) {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			continue // e.g. held open on Windows
+		}
+		result.Removed++
+		result.Freed += f.size
+		result.Size -= f.size
+	}
+
+	return result, nil
+}
+
+// accessTime returns when a file was last read or written. Filesystems
+// mounted noatime only track writes, so the later of the two is used.
+func accessTime(info os.FileInfo) time.Time {
+	atime, ok := fileAtime(info)
+	if !ok || atime.Before(info.ModTime()) {
+		return info.ModTime()
+	}
+	return atime
+}
@@ -0,0 +1,82 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrimDir(t *testing.T) {
+	dir := t.TempDir()
+	skip := filepath.Join(dir, "mcp-logs")
+	now := time.Now()
+
+	// 100 bytes each, last used one to five hours ago, then one in use
+	files := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"a/oldest", 5 * time.Hour},
+		{"b", 4 * time.Hour},
+		{"a/c", 3 * time.Hour},
+		{"d", 2 * time.Hour},
+		{"e", time.Hour},
+		{"in-use", time.Second},
+		{"mcp-logs/server.log", 10 * time.Hour},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", 100)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		used := now.Add(-f.age)
+		if err := os.Chtimes(path, used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		return err == nil
+	}
+
+	// Under the limit, nothing goes
+	if result, err := TrimDir(dir, 1000, time.Minute, skip); err != nil || result.Removed != 0 || result.Size != 600 {
+		t.Fatalf("under the limit: %+v, %v", result, err)
+	}
+
+	// Over it, the least recently used files go first
+	result, err := TrimDir(dir, 350, time.Minute, skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Removed != 3 || result.Freed != 300 || result.Size != 300 {
+		t.Errorf("result = %+v, want 3 files and 300 bytes removed, 300 left", result)
+	}
+	for _, name := range []string{"a/oldest", "b", "a/c"} {
+		if exists(name) {
+			t.Errorf("%s was kept", name)
+		}
+	}
+	for _, name := range []string{"d", "e", "in-use", "mcp-logs/server.log"} {
+		if !exists(name) {
+			t.Errorf("%s was removed", name)
+		}
+	}
+
+	// Files in use stay even when the limit can't be met
+	result, err = TrimDir(dir, 0, time.Minute, skip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists("in-use") || exists("d") || exists("e") {
+		t.Errorf("with a zero limit: in-use kept=%v, d kept=%v, e kept=%v", exists("in-use"), exists("d"), exists("e"))
+	}
+	if result.Size != 100 {
+		t.Errorf("size left = %d, want the 100 bytes in use", result.Size)
+	}
+}
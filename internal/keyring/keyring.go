@@ -0,0 +1,27 @@
+// Package keyring stores secrets in the operating system's credential
+// store: the Keychain on macOS, Secret Service on Linux and Credential
+// Manager on Windows.
+package keyring
+
+import "errors"
+
+// Service names the items claude-go keeps in the keyring
+const Service = "claude-go"
+
+var (
+	// ErrNotFound means the keyring has no secret for the account
+	ErrNotFound = errors.New("not found in keyring")
+
+	// ErrUnsupported means this system has no usable keyring
+	ErrUnsupported = errors.New("no system keyring available")
+)
+
+// Backend is a keyring holding one secret per account
+type Backend interface {
+	Get(account string) (string, error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// Default is the system keyring; replace it to use another store
+var Default Backend = systemKeyring{}
@@ -0,0 +1,65 @@
+//go:build linux
+
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// systemKeyring uses the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool(1), which reads secrets from stdin
+type systemKeyring struct{}
+
+func secretTool(args ...string) (*exec.Cmd, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret-tool is not installed", ErrUnsupported)
+	}
+	return exec.Command(path, args...), nil
+}
+
+func (systemKeyring) Get(account string) (string, error) {
+	cmd, err := secretTool("lookup", "service", Service, "account", account)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		// lookup exits 1 with no output when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(out) == 0 && len(exitErr.Stderr) == 0 {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read keyring: %w", err)
+	}
+	return string(out), nil
+}
+
+func (systemKeyring) Set(account, secret string) error {
+	cmd, err := secretTool("store", "--label=claude-go master password", "service", Service, "account", account)
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to write keyring: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (systemKeyring) Delete(account string) error {
+	cmd, err := secretTool("clear", "service", Service, "account", account)
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to delete from keyring: %w", err)
+	}
+	return nil
+}
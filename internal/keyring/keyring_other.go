@@ -0,0 +1,17 @@
+//go:build !darwin && !linux && !windows
+
+package keyring
+
+type systemKeyring struct{}
+
+func (systemKeyring) Get(account string) (string, error) {
+	return "", ErrUnsupported
+}
+
+func (systemKeyring) Set(account, secret string) error {
+	return ErrUnsupported
+}
+
+func (systemKeyring) Delete(account string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,77 @@
+package launcher
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/audit"
+)
+
+// runAuditShow prints the credential access log, newest last, after
+// checking that it hasn't been modified
+func (app *App) runAuditShow(args []string) error {
+	fs := flag.NewFlagSet("audit show", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "show only the last N events (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+	defer app.vault.Lock()
+
+	key, head, err := app.auth.AuditKey()
+	if err != nil {
+		return fmt.Errorf("no audit log: set vault.audit_log in config/settings.json to start one")
+	}
+
+	events, readErr := audit.Read(app.auditLogPath(), key, head)
+	if os.IsNotExist(readErr) {
+		events, readErr = nil, nil
+	}
+	if readErr != nil && !errors.Is(readErr, audit.ErrTampered) {
+		return readErr
+	}
+
+	shown := events
+	if *limit > 0 && len(shown) > *limit {
+		shown = shown[len(shown)-*limit:]
+	}
+
+	if app.opts.JSON {
+		if shown == nil {
+			shown = []audit.Event{}
+		}
+		result := map[string]interface{}{"events": shown, "verified": readErr == nil}
+		if readErr != nil {
+			result["error"] = readErr.Error()
+		}
+		if err := printJSON(result); err != nil {
+			return err
+		}
+		return readErr
+	}
+
+	if len(shown) == 0 && readErr == nil {
+		fmt.Println("No credential reads recorded")
+		return nil
+	}
+	for _, e := range shown {
+		session := e.Session
+		if session == "" {
+			session = "-"
+		}
+		fmt.Printf("%5d  %s  %-5s %-24s %s  %s\n", e.Seq, e.Time.Local().Format(time.DateTime), e.Action, e.Credential, session, e.Host)
+	}
+
+	if readErr != nil {
+		fmt.Printf("\n"+markFail+" %v\n", readErr)
+		return fmt.Errorf("audit log failed verification; events after line %d can't be trusted", len(events))
+	}
+	fmt.Printf("\n"+markOK+" %d event(s), signatures verified\n", len(events))
+	return nil
+}
@@ -0,0 +1,271 @@
+package launcher
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/auth"
+)
+
+// runAuthList prints the configured providers without their secrets
+func (app *App) runAuthList(args []string) error {
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	infos, err := app.auth.DescribeProviders()
+	if err != nil {
+		return err
+	}
+
+	if app.opts.JSON {
+		return printJSON(infos)
+	}
+
+	if len(infos) == 0 {
+		fmt.Println("No providers configured (run 'claude-go setup')")
+		return nil
+	}
+
+	fmt.Println("Configured providers:")
+	for _, info := range infos {
+		name := string(info.Provider)
+		if info.Label != "" {
+			name = fmt.Sprintf("%s \"%s\"", info.Provider, info.Label)
+		}
+		fmt.Printf("  "+markItem+" %s (%s), updated %s\n", name, info.Type, formatAge(time.Since(info.UpdatedAt)))
+		if info.Note != "" {
+			fmt.Printf("      %s\n", info.Note)
+		}
+	}
+
+	return nil
+}
+
+// runAuthAdd adds or replaces a provider credential via the setup menu
+func (app *App) runAuthAdd(args []string) error {
+	fs := flag.NewFlagSet("auth add", flag.ContinueOnError)
+	label := fs.String("label", "", "label for the credential, e.g. \"work\"")
+	note := fs.String("note", "", "free-form note stored with the credential")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	return app.setupAuth(credentialMetadata(*label, *note), false)
+}
+
+// runAuthImport copies credentials from the host's Claude Code install
+func (app *App) runAuthImport(args []string) error {
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	imported, err := app.auth.ImportFromLocalClaude()
+	if errors.Is(err, auth.ErrNoLocalCredentials) {
+		fmt.Println("No Claude Code credentials found on this computer (run 'claude-go auth add' instead)")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("import failed: %w", err)
+	}
+
+	for _, provider := range imported {
+		fmt.Printf(markOK+" Imported %s credentials\n", provider)
+	}
+
+	return nil
+}
+
+// runAuthRefresh forces an OAuth token refresh, e.g. before going offline
+func (app *App) runAuthRefresh(args []string) error {
+	fs := flag.NewFlagSet("auth refresh", flag.ContinueOnError)
+	provider := fs.String("provider", string(auth.ProviderClaudeAI), "provider whose credential to refresh")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	driver, err := auth.DriverFor(auth.Provider(*provider))
+	if err != nil {
+		return err
+	}
+	expiresAt, err := driver.Refresh(app.ctx, app.auth)
+	if err != nil {
+		return err
+	}
+	app.warnClockSkew()
+	app.warnMissingScopes(auth.Provider(*provider))
+
+	fmt.Printf(markOK+" %s token refreshed, valid until %s\n", *provider, expiresAt.Local().Format(time.RFC1123))
+	return nil
+}
+
+// warnMissingScopes reports configured OAuth scopes the server didn't grant
+func (app *App) warnMissingScopes(provider auth.Provider) {
+	if provider != auth.ProviderClaudeAI {
+		return
+	}
+
+	missing, err := app.auth.MissingScopes(provider)
+	if err != nil || len(missing) == 0 {
+		return
+	}
+	fmt.Fprintf(app.out, markWarn+" %s was not granted scope(s) %s; features needing them won't work\n", provider, strings.Join(missing, ", "))
+}
+
+// runAuthWhoami prints the account behind each configured provider
+func (app *App) runAuthWhoami(args []string) error {
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	identities, err := app.auth.WhoAmI(app.ctx)
+	if err != nil {
+		return err
+	}
+
+	if app.opts.JSON {
+		return printJSON(identities)
+	}
+
+	if len(identities) == 0 {
+		fmt.Println("No providers configured (run 'claude-go setup')")
+		return nil
+	}
+
+	for _, identity := range identities {
+		name := string(identity.Provider)
+		if identity.Label != "" {
+			name = fmt.Sprintf("%s \"%s\"", identity.Provider, identity.Label)
+		}
+
+		who := identity.Account
+		switch {
+		case who == "":
+			who = identity.Org
+		case identity.Org != "":
+			who = fmt.Sprintf("%s (%s)", who, identity.Org)
+		}
+
+		switch {
+		case who != "" && identity.Cached:
+			fmt.Printf("  "+markItem+" %s: %s (last known, %s; %s)\n", name, who, formatAge(time.Since(identity.CheckedAt)), identity.Error)
+		case who != "":
+			fmt.Printf("  "+markItem+" %s: %s\n", name, who)
+		case identity.Error != "":
+			fmt.Printf("  "+markItem+" %s: unknown (%s)\n", name, identity.Error)
+		default:
+			fmt.Printf("  "+markItem+" %s: %s credential\n", name, identity.Type)
+		}
+	}
+
+	return nil
+}
+
+// runAuthListArtifacts prints vault entries left behind by lapsed logins
+func (app *App) runAuthListArtifacts(args []string) error {
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	artifacts, err := app.auth.Artifacts()
+	if err != nil {
+		return err
+	}
+
+	if app.opts.JSON {
+		return printJSON(artifacts)
+	}
+
+	if len(artifacts) == 0 {
+		fmt.Println("No leftover auth entries")
+		return nil
+	}
+
+	printArtifacts(artifacts)
+	return nil
+}
+
+// runAuthClearArtifacts deletes leftover auth entries, all of them or those
+// named as arguments, leaving usable credentials alone
+func (app *App) runAuthClearArtifacts(args []string) error {
+	fs := flag.NewFlagSet("auth clear-artifacts", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "delete without asking for confirmation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ids := fs.Args()
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	if !*yes {
+		artifacts, err := app.auth.Artifacts()
+		if err != nil {
+			return err
+		}
+		artifacts = selectArtifacts(artifacts, ids)
+		if len(artifacts) == 0 {
+			fmt.Println("No leftover auth entries")
+			return nil
+		}
+
+		printArtifacts(artifacts)
+		if !app.confirm(fmt.Sprintf("Delete %d entry(s)?", len(artifacts))) {
+			return fmt.Errorf("%w: nothing deleted", errCancelled)
+		}
+	}
+
+	cleared, err := app.auth.ClearArtifacts(ids)
+	if err != nil {
+		return fmt.Errorf("failed to clear auth entries: %w", err)
+	}
+	for _, id := range ids {
+		if len(selectArtifacts(cleared, []string{id})) == 0 {
+			fmt.Fprintf(app.out, markWarn+" %s is not a leftover auth entry; kept\n", id)
+		}
+	}
+
+	fmt.Printf(markOK+" Deleted %d entry(s)\n", len(cleared))
+	return nil
+}
+
+// selectArtifacts keeps the artifacts named in ids, or all when it's empty
+func selectArtifacts(artifacts []auth.Artifact, ids []string) []auth.Artifact {
+	if len(ids) == 0 {
+		return artifacts
+	}
+
+	var selected []auth.Artifact
+	for _, artifact := range artifacts {
+		for _, id := range ids {
+			if artifact.ID == id {
+				selected = append(selected, artifact)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+func printArtifacts(artifacts []auth.Artifact) {
+	for _, artifact := range artifacts {
+		switch artifact.Kind {
+		case auth.ArtifactExpiredToken:
+			fmt.Printf("  "+markItem+" %s: expired %s with no refresh token\n", artifact.ID, formatAge(time.Since(*artifact.ExpiresAt)))
+		default:
+			fmt.Printf("  "+markItem+" %s: cached account of a removed credential\n", artifact.ID)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package launcher
+
+import (
+	"sort"
+	"strings"
+)
+
+// commandFunc runs a subcommand with the arguments that follow its name
+type commandFunc func(app *App, args []string) error
+
+// commands maps top-level subcommand names to their handlers
+var commands = map[string]commandFunc{
+	"setup":  (*App).runSetup,
+	"doctor": (*App).runDoctor,
+	"audit": subcommands("audit", map[string]commandFunc{
+		"show": (*App).runAuditShow,
+	}),
+	"auth": subcommands("auth", map[string]commandFunc{
+		"add":             (*App).runAuthAdd,
+		"clear-artifacts": (*App).runAuthClearArtifacts,
+		"import":          (*App).runAuthImport,
+		"list":            (*App).runAuthList,
+		"list-artifacts":  (*App).runAuthListArtifacts,
+		"refresh":         (*App).runAuthRefresh,
+		"whoami":          (*App).runAuthWhoami,
+	}),
+	"export": subcommands("export", map[string]commandFunc{
+		"checksums": (*App).runExportChecksums,
+		"manifest":  (*App).runExportManifest,
+	}),
+	"mcp": subcommands("mcp", map[string]commandFunc{
+		"auth":    (*App).runMCPAuth,
+		"disable": (*App).runMCPDisable,
+		"enable":  (*App).runMCPEnable,
+		"list":    (*App).runMCPList,
+		"test":    (*App).runMCPTest,
+		"verify":  (*App).runMCPVerify,
+	}),
+	"provision": (*App).runProvision,
+	"serve":     (*App).runServe,
+	"sessions": subcommands("sessions", map[string]commandFunc{
+		"env":      (*App).runSessionsEnv,
+		"export":   (*App).runSessionsExport,
+		"gc":       (*App).runSessionsGC,
+		"import":   (*App).runSessionsImport,
+		"list":     (*App).runSessionsList,
+		"model":    (*App).runSessionsModel,
+		"sanitize": (*App).runSessionsSanitize,
+		"show":     (*App).runSessionsShow,
+		"tag":      (*App).runSessionsTag,
+		"untag":    (*App).runSessionsUntag,
+		"verify":   (*App).runSessionsVerify,
+	}),
+	"stage": subcommands("stage", map[string]commandFunc{
+		"check": (*App).runStageCheck,
+	}),
+	"update": subcommands("update", map[string]commandFunc{
+		"check":   (*App).runUpdateCheck,
+		"install": (*App).runUpdateInstall,
+	}),
+	"vault": subcommands("vault", map[string]commandFunc{
+		"forget":    (*App).runVaultForget,
+		"harden":    (*App).runVaultHarden,
+		"reencrypt": (*App).runVaultReEncrypt,
+		"remember":  (*App).runVaultRemember,
+		"reset":     (*App).runVaultReset,
+		"secret": subcommands("vault secret", map[string]commandFunc{
+			"delete": (*App).runVaultSecretDelete,
+			"list":   (*App).runVaultSecretList,
+			"set":    (*App).runVaultSecretSet,
+		}),
+		"verify": (*App).runVaultVerify,
+	}),
+}
+
+// runCommand dispatches args[0] to the matching subcommand
+func (app *App) runCommand(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return usagef("unknown command: %s", args[0])
+	}
+	return cmd(app, args[1:])
+}
+
+// subcommands returns a handler that dispatches to a nested command table,
+// e.g. "vault verify"
+func subcommands(group string, table map[string]commandFunc) commandFunc {
+	return func(app *App, args []string) error {
+		if len(args) == 0 {
+			return usagef("usage: claude-go %s <%s>", group, strings.Join(commandNames(table), "|"))
+		}
+
+		cmd, ok := table[args[0]]
+		if !ok {
+			return usagef("unknown command: %s %s", group, args[0])
+		}
+		return cmd(app, args[1:])
+	}
+}
+
+func commandNames(table map[string]commandFunc) []string {
+	names := make([]string, 0, len(table))
+	for name := range table {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
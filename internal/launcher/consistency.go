@@ -0,0 +1,99 @@
+package launcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/session"
+)
+
+// providerProblems validates each linked provider's credential with its
+// driver, renewing tokens that are due
+func (app *App) providerProblems() []string {
+	providers, err := app.auth.ListProviders()
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	for _, provider := range providers {
+		driver, err := auth.DriverFor(provider)
+		if err == nil {
+			err = driver.Validate(app.ctx, app.auth)
+		}
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// vaultMismatches lists what the config and saved sessions expect to find
+// in the unlocked vault but don't, as happens when vault/ is copied from
+// another USB: MCP credential_refs, MCP OAuth logins and the secrets of
+// session environments
+func (app *App) vaultMismatches() ([]string, error) {
+	var problems []string
+
+	names := make([]string, 0, len(app.config.MCP.Servers))
+	for name := range app.config.MCP.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		server := app.config.MCP.Servers[name]
+		if server.CredentialRef != "" {
+			if _, err := app.vault.GetEntry(server.CredentialRef); err != nil {
+				problems = append(problems, fmt.Sprintf("mcp server %s: credential_ref %s is not in the vault", name, server.CredentialRef))
+			}
+		}
+		if server.OAuth != nil && !app.auth.MCPAuthorized(name) {
+			problems = append(problems, fmt.Sprintf("mcp server %s: not logged in; run 'claude-go mcp auth %s'", name, name))
+		}
+	}
+
+	sessions, err := app.sessionManager.List()
+	if err != nil {
+		return nil, err
+	}
+	missing := make(map[string][]string)
+	for _, s := range sessions {
+		for _, value := range s.Env {
+			ref, ok := strings.CutPrefix(value, session.SecretRefPrefix)
+			if !ok {
+				continue
+			}
+			if !app.auth.HasSecret(ref) {
+				missing[ref] = append(missing[ref], s.ID)
+			}
+		}
+	}
+	refs := make([]string, 0, len(missing))
+	for ref := range missing {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		problems = append(problems, fmt.Sprintf("secret %s is not in the vault but session(s) %s use it", ref, strings.Join(missing[ref], ", ")))
+	}
+
+	return problems, nil
+}
+
+// warnVaultMismatches reports vaultMismatches after unlocking, before they
+// turn into confusing failures at launch
+func (app *App) warnVaultMismatches() {
+	problems, err := app.vaultMismatches()
+	if err != nil || len(problems) == 0 {
+		return
+	}
+
+	fmt.Println(markWarn + " The vault is missing credentials this USB's config or sessions expect:")
+	for _, problem := range problems {
+		fmt.Printf("    %s\n", problem)
+	}
+	fmt.Println("  Was vault/ copied from another USB? 'claude-go doctor --unlock' repeats this check.")
+}
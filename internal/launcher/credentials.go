@@ -0,0 +1,135 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/securetemp"
+	"github.com/cxt9/claude-go/internal/session"
+	"golang.org/x/term"
+)
+
+// envCredentialVars are passed through to claude in --no-vault mode
+var envCredentialVars = session.CredentialEnv
+
+// secretEnvVars are masked in child output logs
+var secretEnvVars = map[string]bool{
+	"ANTHROPIC_API_KEY":       true,
+	"ANTHROPIC_AUTH_TOKEN":    true,
+	"CLAUDE_CODE_OAUTH_TOKEN": true,
+	"AWS_ACCESS_KEY_ID":       true,
+	"AWS_SECRET_ACCESS_KEY":   true,
+	"AWS_SESSION_TOKEN":       true,
+}
+
+// envCredentialMarkers are the variables of which at least one must be set
+// for --no-vault to have something to authenticate with
+var envCredentialMarkers = []string{
+	"ANTHROPIC_API_KEY",
+	"ANTHROPIC_AUTH_TOKEN",
+	"CLAUDE_CODE_OAUTH_TOKEN",
+	"CLAUDE_CODE_USE_BEDROCK",
+	"CLAUDE_CODE_USE_VERTEX",
+}
+
+// credentialEnv returns the environment entries carrying claude's
+// credentials, and the secret values among them
+func (app *App) credentialEnv(tmp *securetemp.Dir) ([]string, []string, error) {
+	if app.opts.NoVault {
+		return environmentCredentials()
+	}
+
+	providers, err := app.auth.ListProviders()
+	if err != nil || len(providers) == 0 {
+		return nil, nil, errNoCredentials
+	}
+
+	driver, err := auth.DriverFor(providers[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credential, err := app.auth.GetCredential(providers[0])
+	if errors.Is(err, auth.ErrReauthRequired) {
+		credential, err = app.reauthenticate(driver, err)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get credential: %w", err)
+	}
+	app.warnClockSkew()
+
+	env, err := driver.EnvVars(credential, tmp.WriteFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return env, []string{credential}, nil
+}
+
+// stdinIsTerminal reports whether the user can answer a prompt; replaced
+// in tests
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// reauthenticate offers to log in again when a provider's login expired
+// with no refresh token, rather than launching claude with a dead token
+func (app *App) reauthenticate(driver auth.Driver, expired error) (string, error) {
+	if !stdinIsTerminal() {
+		return "", expired
+	}
+
+	fmt.Fprintf(app.out, markWarn+" The %s login has expired and cannot be renewed.\n", driver.Provider())
+	if !app.confirm("Log in again now?") {
+		return "", fmt.Errorf("%w: %w", errCancelled, expired)
+	}
+	if err := driver.Setup(app.ctx, app.auth, setupPrompter{app}); err != nil {
+		return "", err
+	}
+	fmt.Fprintln(app.out, markOK+" Credential stored!")
+	return app.auth.GetCredential(driver.Provider())
+}
+
+// warnClockSkew reports a local clock that is off from the token server's,
+// which makes token expiry unreliable on this machine
+func (app *App) warnClockSkew() {
+	skew, ok := app.auth.ClockSkew()
+	if !ok || (skew < auth.ClockSkewTolerance && skew > -auth.ClockSkewTolerance) {
+		return
+	}
+
+	direction := "behind"
+	if skew < 0 {
+		direction, skew = "ahead of", -skew
+	}
+	fmt.Fprintf(app.out, markWarn+" This computer's clock is %s %s the token server's; token expiry is judged by the server's time\n", skew.Round(time.Second), direction)
+}
+
+// environmentCredentials passes credential variables from our own
+// environment through to claude, without writing them anywhere
+func environmentCredentials() ([]string, []string, error) {
+	found := false
+	for _, name := range envCredentialMarkers {
+		if os.Getenv(name) != "" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("--no-vault needs ANTHROPIC_API_KEY (or CLAUDE_CODE_USE_BEDROCK / CLAUDE_CODE_USE_VERTEX with their provider variables) in the environment")
+	}
+
+	var env, secrets []string
+	for _, name := range envCredentialVars {
+		if value := os.Getenv(name); value != "" {
+			env = append(env, fmt.Sprintf("%s=%s", name, value))
+			if secretEnvVars[name] {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+
+	return env, secrets, nil
+}
@@ -0,0 +1,154 @@
+package launcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/securetemp"
+	"github.com/cxt9/claude-go/internal/vault"
+	"golang.org/x/term"
+)
+
+// expiredLoginApp returns an app whose only provider is a gateway login
+// that has expired with no refresh token
+func expiredLoginApp(t *testing.T) *App {
+	t.Helper()
+
+	app := newTestApp(t)
+	app.out = io.Discard
+	createTestVault(t, app, "correct horse battery")
+	v, _ := vault.Open(app.vaultPath())
+	if err := v.Unlock("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(v.Lock)
+	if err := app.useVault(v); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	data, _ := json.Marshal(vault.OAuthData{
+		AccessToken: "dead-token",
+		TokenType:   "Bearer",
+		IssuedAt:    now.Add(-2 * time.Hour),
+		ExpiresAt:   now.Add(-time.Hour),
+	})
+	if err := v.SetEntry(&vault.Entry{
+		ID:       "auth/" + string(providerGateway),
+		Type:     vault.CredentialOAuth,
+		Provider: string(providerGateway),
+		Data:     data,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+// useTerminal makes prompts count as answerable for the test
+func useTerminal(t *testing.T, isTerminal bool) {
+	t.Helper()
+
+	orig := stdinIsTerminal
+	stdinIsTerminal = func() bool { return isTerminal }
+	t.Cleanup(func() { stdinIsTerminal = orig })
+}
+
+func TestExpiredLoginRoutesToReauth(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the token prompt would read the terminal")
+	}
+
+	tmp, err := securetemp.New(filepath.Join(t.TempDir(), "tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.Cleanup()
+
+	// Without a terminal there's no one to ask; the launch stops
+	app := expiredLoginApp(t)
+	useTerminal(t, false)
+	_, _, err = app.credentialEnv(tmp)
+	if !errors.Is(err, auth.ErrReauthRequired) {
+		t.Fatalf("no terminal: err = %v, want ErrReauthRequired", err)
+	}
+	if got := ExitCode(err); got != ExitAuth {
+		t.Errorf("ExitCode = %d for an expired login, want %d", got, ExitAuth)
+	}
+
+	// Declining the new login cancels the launch
+	useTerminal(t, true)
+	app.stdin = bufio.NewReader(strings.NewReader("n\n"))
+	if _, _, err := app.credentialEnv(tmp); !errors.Is(err, errCancelled) || !errors.Is(err, auth.ErrReauthRequired) {
+		t.Fatalf("declined: err = %v, want a cancelled ErrReauthRequired", err)
+	}
+
+	// Accepting runs the provider's setup, and the launch gets the new token
+	app.stdin = bufio.NewReader(strings.NewReader("y\nfresh-token\n"))
+	env, secrets, err := app.credentialEnv(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := envValue(env, "ANTHROPIC_AUTH_TOKEN"); got != "fresh-token" {
+		t.Errorf("ANTHROPIC_AUTH_TOKEN = %q, want the new token", got)
+	}
+	if len(secrets) != 1 || secrets[0] != "fresh-token" {
+		t.Errorf("secrets = %v, want the new token", secrets)
+	}
+}
+
+func TestNoVaultCredentialsFromEnvironment(t *testing.T) {
+	for _, names := range [][]string{envCredentialVars, envCredentialMarkers} {
+		for _, name := range names {
+			t.Setenv(name, "")
+		}
+	}
+
+	app := newTestApp(t)
+	app.opts.NoVault = true
+
+	if _, _, err := app.credentialEnv(nil); err == nil {
+		t.Error("credentialEnv succeeded with no credentials in the environment")
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-env-key")
+	env, secrets, err := app.credentialEnv(nil)
+	if err != nil {
+		t.Fatalf("credentialEnv: %v", err)
+	}
+	if !containsString(env, "ANTHROPIC_API_KEY=sk-ant-env-key") {
+		t.Errorf("env = %q, want the API key passed through", env)
+	}
+	if !containsString(secrets, "sk-ant-env-key") {
+		t.Errorf("secrets = %q, want the API key masked in logs", secrets)
+	}
+
+	// Nothing is created on the USB, let alone the key
+	if vault.Exists(app.vaultPath()) {
+		t.Error("a vault was created in --no-vault mode")
+	}
+	filepath.Walk(app.profileRoot, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			if data, _ := os.ReadFile(path); strings.Contains(string(data), "sk-ant-env-key") {
+				t.Errorf("%s contains the API key", path)
+			}
+		}
+		return nil
+	})
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package launcher
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// doctorCheck is the result of a single diagnostic
+type doctorCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// runDoctor diagnoses the USB install. The vault is only unlocked with
+// --unlock, to check that it holds the credentials the config expects.
+func (app *App) runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	unlock := fs.Bool("unlock", false, "unlock the vault to check it holds the credentials the config and sessions expect")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if app.opts.FixPermissions {
+		app.auditPermissions()
+	}
+
+	checks := app.doctorChecks()
+	checks = append(checks, app.vaultContentsCheck(*unlock))
+
+	failed := 0
+	for _, check := range checks {
+		if !check.OK {
+			failed++
+		}
+	}
+
+	if app.opts.JSON {
+		if err := printJSON(map[string]interface{}{"checks": checks}); err != nil {
+			return err
+		}
+	} else {
+		for _, check := range checks {
+			mark := markOK
+			if !check.OK {
+				mark = markFail
+			}
+			if check.Detail != "" {
+				fmt.Printf("  %s %s - %s\n", mark, check.Name, check.Detail)
+			} else {
+				fmt.Printf("  %s %s\n", mark, check.Name)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", failed)
+	}
+	return nil
+}
+
+func (app *App) doctorChecks() []doctorCheck {
+	var checks []doctorCheck
+
+	// Storage media; informational unless vault.require_removable is set
+	check := doctorCheck{Name: "storage", OK: true, Detail: string(app.storage)}
+	if err := removableVerdict(app.storage, app.config.Vault.RequireRemovable, app.opts.AllowFixedDisk); err != nil {
+		check.OK, check.Detail = false, err.Error()
+	}
+	checks = append(checks, check)
+
+	// Configuration
+	check = doctorCheck{Name: "config", OK: true}
+	err := app.configErr
+	if err == nil {
+		err = app.config.Validate()
+	}
+	if err != nil {
+		check.OK, check.Detail = false, err.Error()
+	}
+	checks = append(checks, check)
+
+	// Vault structure
+	check = doctorCheck{Name: "vault", OK: true}
+	if v, err := vault.Open(app.vaultPath()); err != nil {
+		check.OK, check.Detail = false, err.Error()
+	} else if err := v.VerifyStructure(); err != nil {
+		check.OK, check.Detail = false, err.Error()
+	}
+	checks = append(checks, check)
+
+	// Permissions; --fix-permissions has already run by now (see runDoctor)
+	check = doctorCheck{Name: "permissions", OK: true}
+	if issues, err := fsutil.AuditPrivate(false, app.privateDirs()...); err != nil {
+		check.OK, check.Detail = false, err.Error()
+	} else if len(issues) > 0 {
+		check.OK, check.Detail = false, fmt.Sprintf("%d file(s) accessible by other users", len(issues))
+	}
+	checks = append(checks, check)
+
+	// Sessions
+	check = doctorCheck{Name: "sessions", OK: true}
+	if sessions, err := app.sessionManager.List(); err != nil {
+		check.OK, check.Detail = false, err.Error()
+	} else {
+		check.Detail = fmt.Sprintf("%d saved", len(sessions))
+		if problems, err := app.sessionManager.Verify(); err != nil {
+			check.OK, check.Detail = false, err.Error()
+		} else if broken, stray := countProblems(problems); broken > 0 {
+			check.OK = false
+			check.Detail += fmt.Sprintf(", %d corrupt or unreadable; see claude-go sessions verify", broken)
+		} else if stray > 0 {
+			check.Detail += fmt.Sprintf(", %d stray file(s); see claude-go sessions verify", stray)
+		}
+	}
+	checks = append(checks, check)
+
+	// Claude binary
+	check = doctorCheck{Name: "claude binary", OK: true}
+	claudeBinary := app.findClaudeBinary()
+	if _, err := exec.LookPath(claudeBinary); err != nil {
+		check.OK, check.Detail = false, "not found on USB or in PATH"
+	} else {
+		check.Detail = claudeBinary
+	}
+	checks = append(checks, check)
+
+	// Node.js runtime
+	check = doctorCheck{Name: "node", OK: true}
+	if node := app.checkNode(); node.Err != nil {
+		check.OK, check.Detail = false, node.Err.Error()
+	} else {
+		check.Detail = fmt.Sprintf("%s (%s)", node.Version, node.Path)
+	}
+	checks = append(checks, check)
+
+	// MCP servers
+	cwd, _ := os.Getwd()
+	m, err := app.newMCPManager(cwd)
+	if err != nil {
+		return append(checks, doctorCheck{Name: "mcp", Detail: err.Error()})
+	}
+	// Servers the project's .mcp.json redefines; reported, since a project
+	// replacing a USB server may be a mistake, but not a failure
+	check = doctorCheck{Name: "mcp overrides", OK: true}
+	if collisions, err := m.Collisions(); err != nil {
+		check.OK, check.Detail = false, err.Error()
+	} else if len(collisions) > 0 {
+		names := make([]string, len(collisions))
+		for i, c := range collisions {
+			names[i] = c.Name
+		}
+		check.Detail = fmt.Sprintf("%s defined in both the USB config and %s; the project's definition is used", strings.Join(names, ", "), collisions[0].ProjectFile)
+	} else {
+		check.Detail = "none"
+	}
+	checks = append(checks, check)
+
+	statuses, _ := m.CheckServers(app.ctx)
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	for _, status := range statuses {
+		// Optional servers being down is normal on a guest machine
+		check := doctorCheck{
+			Name:   "mcp " + status.Name,
+			OK:     status.Available || !status.Required || status.Disabled,
+			Detail: status.Error,
+		}
+		if status.Disabled {
+			check.Detail = "disabled"
+		}
+		checks = append(checks, check)
+	}
+
+	return checks
+}
+
+// vaultContentsCheck cross-references the vault's entries with the config
+// and sessions when unlock is set
+func (app *App) vaultContentsCheck(unlock bool) doctorCheck {
+	check := doctorCheck{Name: "vault contents", OK: true}
+	if !unlock {
+		check.Detail = "not checked; run 'claude-go doctor --unlock'"
+		return check
+	}
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		check.OK, check.Detail = false, err.Error()
+		return check
+	}
+	defer app.vault.Lock()
+
+	problems, err := app.vaultMismatches()
+	if err == nil {
+		problems = append(app.providerProblems(), problems...)
+	}
+	switch {
+	case err != nil:
+		check.OK, check.Detail = false, err.Error()
+	case len(problems) > 0:
+		check.OK, check.Detail = false, strings.Join(problems, "; ")
+	default:
+		check.Detail = "credentials usable and matching the config and sessions"
+	}
+	return check
+}
@@ -0,0 +1,125 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/securetemp"
+	"github.com/cxt9/claude-go/internal/vault"
+	"golang.org/x/term"
+)
+
+// gatewayDriver is a provider defined entirely in this file, as a new one
+// would be: a self-hosted gateway token, also handed over as a file
+type gatewayDriver struct{}
+
+const providerGateway auth.Provider = "test-gateway"
+
+func (gatewayDriver) Provider() auth.Provider { return providerGateway }
+func (gatewayDriver) Title() string           { return "Test gateway token" }
+
+func (d gatewayDriver) Setup(ctx context.Context, a *auth.Authenticator, p auth.Prompter) error {
+	token, err := p.Secret("Gateway token: ")
+	if err != nil {
+		return err
+	}
+	return a.SetAPIKey(d.Provider(), token)
+}
+
+func (gatewayDriver) EnvVars(credential string, writeFile func(string, []byte) (string, error)) ([]string, error) {
+	path, err := writeFile("gateway-*.token", []byte(credential))
+	if err != nil {
+		return nil, err
+	}
+	return []string{"ANTHROPIC_AUTH_TOKEN=" + credential, "GATEWAY_TOKEN_FILE=" + path}, nil
+}
+
+func (d gatewayDriver) Validate(ctx context.Context, a *auth.Authenticator) error {
+	if !a.HasCredential(d.Provider()) {
+		return errors.New("no gateway token")
+	}
+	return nil
+}
+
+func (gatewayDriver) Refresh(ctx context.Context, a *auth.Authenticator) (time.Time, error) {
+	return time.Time{}, errors.New("gateway tokens don't expire")
+}
+
+func init() {
+	auth.RegisterDriver(gatewayDriver{})
+}
+
+func TestRegisteredDriverSetupAndEnv(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the token prompt would read the terminal")
+	}
+
+	app := newTestApp(t)
+	app.out = io.Discard
+	createTestVault(t, app, "correct horse battery")
+	v, _ := vault.Open(app.vaultPath())
+	if err := v.Unlock("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Lock()
+	if err := app.useVault(v); err != nil {
+		t.Fatal(err)
+	}
+
+	// The setup menu offers the new provider
+	choice := 0
+	for i, d := range auth.Drivers() {
+		if d.Provider() == providerGateway {
+			choice = i + 1
+		}
+	}
+	if choice == 0 {
+		t.Fatal("the registered driver isn't in the menu")
+	}
+	app.stdin = bufio.NewReader(strings.NewReader(fmt.Sprintf("%d\ngw-secret\n", choice)))
+	if err := app.setupAuth(nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := (gatewayDriver{}).Validate(context.Background(), app.auth); err != nil {
+		t.Fatal(err)
+	}
+
+	// The launch environment comes from the driver
+	tmp, err := securetemp.New(filepath.Join(t.TempDir(), "tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.Cleanup()
+	env, secrets, err := app.credentialEnv(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := envValue(env, "ANTHROPIC_AUTH_TOKEN"); got != "gw-secret" {
+		t.Errorf("ANTHROPIC_AUTH_TOKEN = %q, want the stored token", got)
+	}
+	path, _ := envValue(env, "GATEWAY_TOKEN_FILE")
+	if data, err := os.ReadFile(path); err != nil || string(data) != "gw-secret" {
+		t.Errorf("token file %q = %q, %v", path, data, err)
+	}
+	if len(secrets) != 1 || secrets[0] != "gw-secret" {
+		t.Errorf("secrets = %v, want the token", secrets)
+	}
+}
+
+func TestRegisterDriverTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a provider twice didn't panic")
+		}
+	}()
+	auth.RegisterDriver(gatewayDriver{})
+}
@@ -0,0 +1,117 @@
+package launcher
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/session"
+)
+
+// sessionEnv resolves the session's env overlay into NAME=VALUE pairs,
+// reading vault secrets it refers to. It also returns the secret values,
+// so they can be kept out of the session log.
+func (app *App) sessionEnv(s *session.Session) ([]string, []string, error) {
+	if s == nil || len(s.Env) == 0 {
+		return nil, nil, nil
+	}
+
+	names := make([]string, 0, len(s.Env))
+	for name := range s.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var env, secrets []string
+	for _, name := range names {
+		// Session files can be edited by hand
+		if err := session.ValidateEnvName(name); err != nil {
+			fmt.Printf(markWarn+" Ignoring session variable: %v\n", err)
+			continue
+		}
+
+		value := s.Env[name]
+		if ref, ok := strings.CutPrefix(value, session.SecretRefPrefix); ok {
+			if app.auth == nil {
+				return nil, nil, fmt.Errorf("session variable %s needs vault secret %s, which --no-vault can't read", name, ref)
+			}
+			secret, err := app.auth.Secret(ref)
+			if err != nil {
+				return nil, nil, fmt.Errorf("session variable %s: %w", name, err)
+			}
+			value = secret
+			secrets = append(secrets, secret)
+		}
+		env = append(env, name+"="+value)
+	}
+
+	return env, secrets, nil
+}
+
+// mergeEnv returns base with the variables of overlay added, replacing
+// any base variable of the same name
+func mergeEnv(base, overlay []string) []string {
+	merged := make([]string, 0, len(base)+len(overlay))
+	replaced := make(map[string]bool, len(overlay))
+	for _, kv := range overlay {
+		name, _, _ := strings.Cut(kv, "=")
+		replaced[name] = true
+	}
+
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if !replaced[name] {
+			merged = append(merged, kv)
+		}
+	}
+	return append(merged, overlay...)
+}
+
+// runSessionsEnv shows a session's env overlay, or sets NAME=VALUE pairs
+// in it; NAME= removes a variable
+func (app *App) runSessionsEnv(args []string) error {
+	fs := flag.NewFlagSet("sessions env", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: claude-go sessions env <id> [NAME=VALUE | NAME=vault:SECRET | NAME=]...")
+	}
+
+	s, err := app.sessionManager.Resolve(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range fs.Args()[1:] {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid assignment %q: want NAME=VALUE, or NAME= to remove", pair)
+		}
+		if err := app.sessionManager.SetEnv(s, name, value); err != nil {
+			return err
+		}
+	}
+
+	if app.opts.JSON {
+		return printJSON(s.Env)
+	}
+
+	if len(s.Env) == 0 {
+		fmt.Printf("%s has no environment variables\n", s.ID)
+		return nil
+	}
+
+	names := make([]string, 0, len(s.Env))
+	for name := range s.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("%s environment:\n", s.ID)
+	for _, name := range names {
+		fmt.Printf("  %s=%s\n", name, s.Env[name])
+	}
+	return nil
+}
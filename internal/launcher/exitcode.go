@@ -0,0 +1,97 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/update"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// Exit codes returned by ExitCode. They are part of the command-line
+// interface: scripts rely on them, so existing values never change.
+const (
+	ExitOK        = 0
+	ExitError     = 1 // any failure without a more specific code
+	ExitUsage     = 2 // invalid global flags or unknown command
+	ExitAuth      = 3 // vault not unlocked, no credential, or login refused
+	ExitCancelled = 4 // the user declined a prompt or interrupted
+	ExitUpdate    = 5 // an update failed verification or was refused
+)
+
+// errCancelled is wrapped by errors for a prompt the user declined
+var errCancelled = errors.New("cancelled")
+
+// errNoCredentials means the vault holds no provider credential
+var errNoCredentials = errors.New("no authentication configured")
+
+// usageError is a command line claude-go doesn't understand
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// childExitError is claude exiting unsuccessfully. claude has reported
+// the problem itself, so it isn't printed again.
+type childExitError struct {
+	code int
+}
+
+func (e *childExitError) Error() string {
+	return fmt.Sprintf("claude exited with status %d", e.code)
+}
+
+// childExit turns a failed exec.Cmd.Run into a childExitError carrying
+// the child's exit status, or 128 plus the signal that killed it
+func childExit(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	code := exitErr.ExitCode()
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		code = 128 + int(ws.Signal())
+	}
+	if code <= 0 {
+		code = ExitError
+	}
+	return &childExitError{code: code}
+}
+
+// ExitCode maps an error returned by Run to the process exit status: the
+// exit status of claude when claude itself failed, otherwise one of the
+// Exit constants
+func ExitCode(err error) int {
+	var child *childExitError
+	var usage *usageError
+	var lockout *vault.LockoutError
+	var denied *auth.AuthorizationError
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &child):
+		return child.code
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, errCancelled), errors.Is(err, errAuthSkipped), errors.Is(err, context.Canceled):
+		return ExitCancelled
+	case errors.Is(err, errIncorrectPassword), errors.Is(err, vault.ErrWrongPassword), errors.As(err, &lockout),
+		errors.Is(err, errNoCredentials), errors.Is(err, auth.ErrReauthRequired), errors.As(err, &denied):
+		return ExitAuth
+	case errors.Is(err, update.ErrDowngrade), errors.Is(err, update.ErrUntrustedManifest):
+		return ExitUpdate
+	default:
+		return ExitError
+	}
+}
@@ -0,0 +1,47 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestHelperExit is run as a child process by TestChildExitCode
+func TestHelperExit(t *testing.T) {
+	if os.Getenv("CLAUDE_GO_TEST_EXIT") != "1" {
+		t.Skip("run as a child process")
+	}
+	os.Exit(42)
+}
+
+func TestChildExitCode(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperExit$")
+	cmd.Env = append(os.Environ(), "CLAUDE_GO_TEST_EXIT=1")
+
+	err := childExit(cmd.Run())
+	if got := ExitCode(err); got != 42 {
+		t.Errorf("ExitCode = %d, want the child's status 42 (err %v)", got, err)
+	}
+	if got := ExitCode(childExit(nil)); got != ExitOK {
+		t.Errorf("ExitCode of a successful run = %d, want %d", got, ExitOK)
+	}
+}
+
+func TestExitCodeCancelled(t *testing.T) {
+	declined := []error{
+		fmt.Errorf("%w: nothing deleted", errCancelled),
+		fmt.Errorf("setup %w: vault file is incomplete: %s", errCancelled, "credentials.vault"),
+		fmt.Errorf("launch %w: required MCP servers unavailable: %s", errCancelled, "github"),
+	}
+	for _, err := range declined {
+		if got := ExitCode(err); got != ExitCancelled {
+			t.Errorf("ExitCode(%q) = %d, want %d", err, got, ExitCancelled)
+		}
+	}
+
+	if got := ExitCode(errors.New("required MCP servers unavailable: github")); got != ExitError {
+		t.Errorf("ExitCode of a plain failure = %d, want %d", got, ExitError)
+	}
+}
@@ -0,0 +1,73 @@
+package launcher
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/mcp"
+	"github.com/cxt9/claude-go/internal/update"
+)
+
+// runExportManifest writes the manifest.json for a directory of release
+// bundles, so it never has to be edited by hand
+func (app *App) runExportManifest(args []string) error {
+	fs := flag.NewFlagSet("export manifest", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "directory holding claude-go-<version>-<platform> bundles")
+	out := fs.String("out", "manifest.json", "where to write the manifest")
+	var opts update.ManifestOptions
+	fs.StringVar(&opts.Version, "version", "", "release version (required)")
+	fs.StringVar(&opts.ReleaseDate, "date", "", "release date as YYYY-MM-DD (default today)")
+	fs.StringVar(&opts.MinVersion, "min-version", "", "oldest version that can update directly")
+	fs.StringVar(&opts.BaseURL, "base-url", "", "download URL prefix (default the GitHub release)")
+	fs.Func("changelog", "changelog entry (repeatable)", func(entry string) error {
+		opts.Changelog = append(opts.Changelog, entry)
+		return nil
+	})
+	fs.Func("path", "path the release installs beyond bin/ and the scripts, e.g. mcp/bundled/ (repeatable)", func(p string) error {
+		opts.Paths = append(opts.Paths, p)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	manifest, err := update.BuildManifest(*dir, opts)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(*out, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if app.opts.JSON {
+		return printJSON(manifest)
+	}
+
+	fmt.Fprintf(app.out, markOK+" Wrote %s for %s (%d platforms)\n", *out, manifest.Version, len(manifest.Downloads))
+	return nil
+}
+
+// runExportChecksums writes mcp/bundled/checksums.json for a release tree,
+// which 'mcp verify' checks the bundled server binaries against
+func (app *App) runExportChecksums(args []string) error {
+	fs := flag.NewFlagSet("export checksums", flag.ContinueOnError)
+	root := fs.String("root", ".", "release tree holding mcp/bundled/")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	count, err := mcp.WriteBundledChecksums(*root)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(app.out, markOK+" Wrote %s/%s (%d files)\n", mcp.BundledDir, mcp.ChecksumsFile, count)
+	return nil
+}
@@ -0,0 +1,78 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// apiKeyFDEnv tells claude to read its API key from an inherited file
+// descriptor instead of ANTHROPIC_API_KEY
+const apiKeyFDEnv = "CLAUDE_CODE_API_KEY_FILE_DESCRIPTOR"
+
+// credentialHandoff returns the configured handoff mode, defaulting to fd
+// in paranoid mode
+func (app *App) credentialHandoff() string {
+	mode := app.config.Environment.CredentialHandoff
+	if mode == "" {
+		mode = config.HandoffEnv
+		if app.config.Environment.ParanoidMode {
+			mode = config.HandoffFD
+		}
+	}
+	if mode == config.HandoffFD && runtime.GOOS == "windows" {
+		fmt.Println(markWarn + " Credential handoff through a file descriptor isn't supported on Windows; using the environment")
+		mode = config.HandoffEnv
+	}
+	return mode
+}
+
+// handOffAPIKey moves ANTHROPIC_API_KEY out of cmd's environment into a
+// pipe the child inherits as its first extra file, so the key doesn't
+// show in /proc/<pid>/environ or in the environment of processes claude
+// starts. The returned func closes the parent's end of the pipe and must
+// be called after the child has started.
+func handOffAPIKey(cmd *exec.Cmd) (func(), error) {
+	var key string
+	env := cmd.Env[:0:0]
+	for _, kv := range cmd.Env {
+		if value, ok := strings.CutPrefix(kv, "ANTHROPIC_API_KEY="); ok {
+			key = value
+			continue
+		}
+		env = append(env, kv)
+	}
+	if key == "" {
+		return func() {}, nil
+	}
+
+	r, err := writePipe([]byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hand off credential: %w", err)
+	}
+
+	// ExtraFiles[i] is descriptor 3+i in the child
+	fd := 3 + len(cmd.ExtraFiles)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, r)
+	cmd.Env = append(env, fmt.Sprintf("%s=%d", apiKeyFDEnv, fd))
+	return func() { r.Close() }, nil
+}
+
+// writePipe returns the read end of a pipe holding data followed by EOF.
+// Data larger than the pipe buffer is written as the reader drains it.
+func writePipe(data []byte) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer w.Close()
+		w.Write(data)
+	}()
+	return r, nil
+}
@@ -0,0 +1,128 @@
+package launcher
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// TestHelperReadKey is run as a child process by TestHandOffAPIKey; it
+// prints the key read from the inherited descriptor
+func TestHelperReadKey(t *testing.T) {
+	if os.Getenv("CLAUDE_GO_TEST_HANDOFF") != "1" {
+		t.Skip("run as a child process")
+	}
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		fmt.Print("key left in the environment")
+		os.Exit(1)
+	}
+	fd, err := strconv.Atoi(os.Getenv(apiKeyFDEnv))
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	key, err := io.ReadAll(os.NewFile(uintptr(fd), "api-key"))
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	fmt.Print(string(key))
+	os.Exit(0)
+}
+
+func TestHandOffAPIKey(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file descriptor handoff isn't supported on Windows")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperReadKey$")
+	cmd.Env = append(os.Environ(), "CLAUDE_GO_TEST_HANDOFF=1", "ANTHROPIC_API_KEY=sk-test")
+	done, err := handOffAPIKey(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := envValue(cmd.Env, "ANTHROPIC_API_KEY"); found {
+		t.Error("ANTHROPIC_API_KEY is still in the environment")
+	}
+	if got, _ := envValue(cmd.Env, apiKeyFDEnv); got != "3" {
+		t.Errorf("%s = %q, want 3", apiKeyFDEnv, got)
+	}
+	if len(cmd.ExtraFiles) != 1 {
+		t.Fatalf("%d extra files, want 1", len(cmd.ExtraFiles))
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done()
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("child: %v: %s", err, stdout.String())
+	}
+	if got := stdout.String(); got != "sk-test" {
+		t.Errorf("child read %q, want sk-test", got)
+	}
+}
+
+func TestHandOffWithoutKey(t *testing.T) {
+	cmd := exec.Command("true")
+	cmd.Env = []string{"PATH=/usr/bin"}
+	done, err := handOffAPIKey(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done()
+
+	if _, found := envValue(cmd.Env, apiKeyFDEnv); found || len(cmd.ExtraFiles) != 0 {
+		t.Errorf("a handoff was set up without a key: %v", cmd.Env)
+	}
+}
+
+func TestWritePipeLargerThanBuffer(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1<<16)
+
+	r, err := writePipe(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("read %d bytes, want the %d written", len(got), len(data))
+	}
+}
+
+func TestCredentialHandoffMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file descriptor handoff isn't supported on Windows")
+	}
+
+	app := newTestApp(t)
+	if got := app.credentialHandoff(); got != config.HandoffEnv {
+		t.Errorf("default mode = %s, want %s", got, config.HandoffEnv)
+	}
+
+	app.config.Environment.ParanoidMode = true
+	if got := app.credentialHandoff(); got != config.HandoffFD {
+		t.Errorf("paranoid mode = %s, want %s", got, config.HandoffFD)
+	}
+
+	// An explicit setting wins over the paranoid default
+	app.config.Environment.CredentialHandoff = config.HandoffEnv
+	if got := app.credentialHandoff(); got != config.HandoffEnv {
+		t.Errorf("configured mode = %s, want %s", got, config.HandoffEnv)
+	}
+}
@@ -0,0 +1,109 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// runVaultHarden re-encrypts the vault with a stronger Argon2 profile,
+// showing how long each step should take. The original vault stays in
+// place until the re-encrypted copy has been unlocked, so Ctrl-C at any
+// point leaves it usable.
+func (app *App) runVaultHarden(args []string) error {
+	fs := flag.NewFlagSet("vault harden", flag.ContinueOnError)
+	profile := fs.String("profile", vault.ProfileParanoid, "Argon2 profile: sensitive or paranoid")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params, err := vault.ProfileParams(*profile)
+	if err != nil {
+		return err
+	}
+
+	v, err := vault.Open(app.vaultPath())
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+	if wait := v.LockoutRemaining(); wait > 0 {
+		return &vault.LockoutError{RetryAfter: wait}
+	}
+
+	password, err := app.promptPassword("Master password: ", false)
+	if err != nil {
+		return err
+	}
+
+	// Time the unlock to estimate the new profile's cost on this computer
+	start := time.Now()
+	if err := app.unlockWith(v, password); err != nil {
+		return err
+	}
+	defer v.Lock()
+	measured := time.Since(start)
+
+	current := v.Params()
+	if kdfCost(params) <= kdfCost(current) {
+		return fmt.Errorf("the vault already uses Argon2 parameters at least as strong as %s (time=%d, memory=%d MiB); use 'vault reencrypt' to change them",
+			*profile, current.Time, current.Memory/1024)
+	}
+
+	estimates := map[string]time.Duration{
+		vault.HardenCheck:  measured,
+		vault.HardenDerive: estimateKDF(measured, current, params),
+		vault.HardenVerify: estimateKDF(measured, current, params),
+	}
+	labels := map[string]string{
+		vault.HardenCheck:  "Checking the master password",
+		vault.HardenDerive: "Deriving the new key",
+		vault.HardenVerify: "Verifying the re-encrypted vault",
+	}
+	total := estimates[vault.HardenCheck] + estimates[vault.HardenDerive] + estimates[vault.HardenVerify]
+	fmt.Printf("Hardening the vault to the %s profile (time=%d, memory=%d MiB), about %s. Press Ctrl-C to stop; the vault is only replaced at the end.\n",
+		*profile, params.Time, params.Memory/1024, formatEstimate(total))
+
+	ctx, stop := signal.NotifyContext(app.ctx, os.Interrupt)
+	defer stop()
+
+	step := 0
+	err = v.Harden(ctx, password, params, func(name string) {
+		step++
+		fmt.Printf("  [%d/3] %s (about %s)...\n", step, labels[name], formatEstimate(estimates[name]))
+	})
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("hardening interrupted; the vault is unchanged")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to harden vault: %w", err)
+	}
+
+	fmt.Printf(markOK+" Vault hardened in %s (time=%d, memory=%d MiB, threads=%d)\n",
+		formatEstimate(time.Since(start)), params.Time, params.Memory/1024, params.Threads)
+	return nil
+}
+
+// kdfCost is the relative work of an Argon2 derivation
+func kdfCost(p vault.KDFParams) float64 {
+	return float64(p.Time) * float64(p.Memory)
+}
+
+// estimateKDF scales how long a derivation with from took to the cost of to
+func estimateKDF(measured time.Duration, from, to vault.KDFParams) time.Duration {
+	return time.Duration(float64(measured) * kdfCost(to) / kdfCost(from))
+}
+
+// formatEstimate rounds a duration to whole seconds for display, e.g.
+// "12s" or "1m30s"
+func formatEstimate(d time.Duration) string {
+	if d < time.Second {
+		d = time.Second
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,77 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/session"
+)
+
+// defaultHookTimeout applies to hooks without timeout_seconds
+const defaultHookTimeout = 60 * time.Second
+
+// runHook runs a configured hook in the project directory with claude's
+// environment minus credentials. Its output goes to the terminal and, with
+// a session, to the session log.
+func (app *App) runHook(name string, hook *config.HookCommand, projectPath string, env []string, s *session.Session) error {
+	timeout := defaultHookTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(app.ctx, timeout)
+	defer cancel()
+
+	args := make([]string, len(hook.Args))
+	for i, arg := range hook.Args {
+		args[i] = config.SubstituteVars(arg, app.usbRoot, projectPath)
+	}
+
+	cmd := exec.CommandContext(ctx, config.SubstituteVars(hook.Command, app.usbRoot, projectPath), args...)
+	cmd.Dir = projectPath
+	cmd.Env = env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Don't wait forever on output held open by a killed hook's children
+	cmd.WaitDelay = 5 * time.Second
+
+	if s != nil {
+		maxBytes := int64(app.config.Sessions.ChildLogMaxMB) << 20
+		if logw, err := app.sessionManager.OpenLog(s.ID, maxBytes, nil); err == nil {
+			defer logw.Close()
+			fmt.Fprintf(logw, "--- hook %s: %s\n", name, cmd.String())
+			cmd.Stdout = io.MultiWriter(os.Stdout, logw)
+			cmd.Stderr = io.MultiWriter(os.Stderr, logw)
+		}
+	}
+
+	fmt.Printf("Running %s hook...\n", name)
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %s", name, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+
+	return nil
+}
+
+// hooksEnabled reports whether hooks should run, noting configured hooks
+// that are switched off
+func (app *App) hooksEnabled() bool {
+	hooks := app.config.Hooks
+	if hooks.PreLaunch == nil && hooks.PostExit == nil {
+		return false
+	}
+	if !hooks.Enabled {
+		fmt.Println(markWarn + " Launch hooks are configured but hooks.enabled is false; not running them")
+		return false
+	}
+	return true
+}
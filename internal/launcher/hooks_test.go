@@ -0,0 +1,99 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestHelperHook(t *testing.T) {
+	mode := os.Getenv("CLAUDE_GO_TEST_HOOK")
+	if mode == "" {
+		t.Skip("run as a child process")
+	}
+
+	switch mode {
+	case "fail":
+		os.Exit(3)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+	fmt.Printf("hook arg %s\n", os.Args[len(os.Args)-1])
+	os.Exit(0)
+}
+
+// testHook returns a hook running TestHelperHook, and the environment that
+// selects its behavior
+func testHook(mode string, timeout int) (*config.HookCommand, []string) {
+	hook := &config.HookCommand{
+		Command:        os.Args[0],
+		Args:           []string{"-test.run=^TestHelperHook$", "$PROJECT_DIR/mounted"},
+		TimeoutSeconds: timeout,
+	}
+	return hook, append(os.Environ(), "CLAUDE_GO_TEST_HOOK="+mode)
+}
+
+func TestRunHook(t *testing.T) {
+	app := newTestApp(t)
+	app.ctx = context.Background()
+	project := t.TempDir()
+	s, err := app.sessionManager.Create(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Success: variables are substituted and the output is logged
+	hook, env := testHook("ok", 0)
+	if err := app.runHook("pre_launch", hook, project, env, s); err != nil {
+		t.Fatalf("successful hook: %v", err)
+	}
+	log, err := os.ReadFile(app.sessionManager.LogPath(s.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "--- hook pre_launch") {
+		t.Errorf("session log doesn't record the hook:\n%s", log)
+	}
+	if want := "hook arg " + filepath.Join(project, "mounted"); !strings.Contains(string(log), want) {
+		t.Errorf("session log is missing %q:\n%s", want, log)
+	}
+
+	// A non-zero exit is an error, which aborts a launch
+	hook, env = testHook("fail", 0)
+	if err := app.runHook("pre_launch", hook, project, env, nil); err == nil || !strings.Contains(err.Error(), "pre_launch hook failed") {
+		t.Errorf("failing hook = %v, want a failure", err)
+	}
+
+	// A hook that doesn't finish is killed at its timeout
+	hook, env = testHook("hang", 1)
+	start := time.Now()
+	if err := app.runHook("post_exit", hook, project, env, nil); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("hanging hook = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("hanging hook took %s to stop", elapsed)
+	}
+}
+
+func TestHooksEnabled(t *testing.T) {
+	app := newTestApp(t)
+	if app.hooksEnabled() {
+		t.Error("hooks enabled with none configured")
+	}
+
+	// Configured hooks stay off until enabled
+	app.config.Hooks.PreLaunch, _ = testHook("ok", 0)
+	if app.hooksEnabled() {
+		t.Error("hooks enabled by default")
+	}
+	app.config.Hooks.Enabled = true
+	if !app.hooksEnabled() {
+		t.Error("enabled hooks don't run")
+	}
+}
@@ -0,0 +1,96 @@
+package launcher
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// balancedEnv are the host variables passed at the balanced isolation
+// level: locale, ssh agent, terminal, temp and per-user directories, and
+// the Windows variables programs need to start
+var balancedEnv = []string{
+	"LANG", "LANGUAGE", "TZ",
+	"SSH_AUTH_SOCK", "SSH_AGENT_PID",
+	"COLORTERM", "TERM_PROGRAM", "TERM_PROGRAM_VERSION", "COLUMNS", "LINES",
+	"SHELL", "LOGNAME", "TMPDIR",
+	"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME", "XDG_RUNTIME_DIR",
+	"SYSTEMROOT", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP",
+	"USERPROFILE", "APPDATA", "LOCALAPPDATA", "USERNAME",
+}
+
+// balancedEnvPrefixes are prefixes of variables passed at the balanced
+// level
+var balancedEnvPrefixes = []string{"LC_"}
+
+// passthroughDroppedPrefixes are host variable families dropped even at
+// the passthrough level: claude-go's and claude's settings, and Anthropic
+// and AWS credentials and endpoints
+var passthroughDroppedPrefixes = []string{"CLAUDE_", "ANTHROPIC_", "AWS_"}
+
+// inheritedEnv returns the variables of host that claude inherits at the
+// given isolation level. Credentials never pass: the provider credential
+// variables, ANTHROPIC_*, AWS_* and CLAUDE_* are dropped even at the
+// passthrough level, so the vault decides which account is used.
+func inheritedEnv(level string, host []string) []string {
+	var env []string
+	for _, kv := range host {
+		name, _, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			continue
+		}
+
+		switch level {
+		case config.IsolationPassthrough:
+			if hostCredentialName(name) {
+				continue
+			}
+		case config.IsolationBalanced:
+			if !balancedName(name) {
+				continue
+			}
+		default:
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// balancedName reports whether a host variable passes at the balanced
+// level. Windows names are case-insensitive.
+func balancedName(name string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, allowed := range balancedEnv {
+		if name == allowed {
+			return true
+		}
+	}
+	for _, prefix := range balancedEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostCredentialName reports whether a host variable carries credentials or
+// settings the launcher provides itself. Compared case-insensitively, as
+// Windows does.
+func hostCredentialName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, prefix := range passthroughDroppedPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	for _, credential := range envCredentialVars {
+		if upper == credential {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package launcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+var testHostEnv = []string{
+	"HOME=/home/me",
+	"LANG=en_US.UTF-8",
+	"LC_ALL=en_US.UTF-8",
+	"SSH_AUTH_SOCK=/tmp/agent.sock",
+	"XDG_CONFIG_HOME=/home/me/.config",
+	"EDITOR=vim",
+	"GOPATH=/home/me/go",
+	"ANTHROPIC_API_KEY=sk-host",
+	"ANTHROPIC_BEDROCK_BASE_URL=https://proxy.example",
+	"CLAUDE_CONFIG_DIR=/home/me/.claude",
+	"AWS_ACCESS_KEY_ID=AKIA",
+	"AWS_SECRET_ACCESS_KEY=secret",
+	"AWS_PROFILE=prod",
+	"GOOGLE_APPLICATION_CREDENTIALS=/home/me/gcp.json",
+	"CLOUD_ML_REGION=us-east5",
+	"=C:=C:\\",
+}
+
+func inheritedNames(level string) map[string]bool {
+	names := make(map[string]bool)
+	for _, kv := range inheritedEnv(level, testHostEnv) {
+		name, _, _ := strings.Cut(kv, "=")
+		names[name] = true
+	}
+	return names
+}
+
+func TestInheritedEnvLevels(t *testing.T) {
+	credentials := []string{
+		"ANTHROPIC_API_KEY", "ANTHROPIC_BEDROCK_BASE_URL", "CLAUDE_CONFIG_DIR",
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE",
+		"GOOGLE_APPLICATION_CREDENTIALS", "CLOUD_ML_REGION",
+	}
+
+	tests := []struct {
+		level   string
+		passed  []string
+		dropped []string
+	}{
+		{
+			level:   config.IsolationStrict,
+			dropped: []string{"HOME", "LANG", "SSH_AUTH_SOCK", "EDITOR"},
+		},
+		{
+			level:   config.IsolationBalanced,
+			passed:  []string{"LANG", "LC_ALL", "SSH_AUTH_SOCK", "XDG_CONFIG_HOME"},
+			dropped: []string{"EDITOR", "GOPATH"},
+		},
+		{
+			level:  config.IsolationPassthrough,
+			passed: []string{"HOME", "LANG", "LC_ALL", "SSH_AUTH_SOCK", "XDG_CONFIG_HOME", "EDITOR", "GOPATH"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			names := inheritedNames(tt.level)
+			for _, name := range tt.passed {
+				if !names[name] {
+					t.Errorf("%s was dropped", name)
+				}
+			}
+			for _, name := range append(tt.dropped, credentials...) {
+				if names[name] {
+					t.Errorf("%s was passed", name)
+				}
+			}
+			if names[""] {
+				t.Error("a variable without a name was passed")
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package launcher
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/keyring"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// keyringIDFile holds the random ID the vault's keyring entries are stored
+// under, so they follow the USB rather than its drive letter or mount point
+const keyringIDFile = "keyring-id"
+
+// keyringAccount returns the keyring account of this USB's vault, creating
+// its ID if asked to
+func (app *App) keyringAccount(create bool) (string, error) {
+	path := filepath.Join(app.dataDir("vault"), keyringIDFile)
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return "", err
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate keyring ID: %w", err)
+	}
+	id := hex.EncodeToString(b)
+	if err := fsutil.WriteFileAtomic(path, []byte(id+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to save keyring ID: %w", err)
+	}
+	return id, nil
+}
+
+// unlockFromKeyring tries the master password saved in this computer's
+// keyring, reporting whether it unlocked the vault. A saved password that
+// no longer works is removed so the user is asked instead.
+func (app *App) unlockFromKeyring(v *vault.Vault) (bool, error) {
+	if !app.config.Vault.Keyring {
+		return false, nil
+	}
+
+	account, err := app.keyringAccount(false)
+	if err != nil {
+		return false, nil
+	}
+	password, err := keyring.Default.Get(account)
+	if err != nil {
+		if !errors.Is(err, keyring.ErrNotFound) && !errors.Is(err, keyring.ErrUnsupported) {
+			fmt.Fprintf(app.out, markWarn+" Couldn't read the system keyring: %v\n", err)
+		}
+		return false, nil
+	}
+
+	if err := app.unlockWith(v, password); err != nil {
+		if !errors.Is(err, errIncorrectPassword) {
+			return false, err
+		}
+		keyring.Default.Delete(account)
+		fmt.Fprint(app.out, markWarn+" The master password saved in this computer's keyring no longer works; removed it\n")
+		return false, nil
+	}
+
+	fmt.Fprint(app.out, markOK+" Vault unlocked with the password saved in this computer's keyring\n")
+	if n := v.FailedAttempts(); n > 0 {
+		fmt.Fprintf(app.out, markWarn+" %d failed unlock attempt(s) since this vault was last opened\n", n)
+	}
+	fmt.Fprintln(app.out)
+	return true, nil
+}
+
+// runVaultRemember saves the master password in this computer's keyring
+func (app *App) runVaultRemember(args []string) error {
+	if !app.config.Vault.Keyring {
+		return fmt.Errorf("keyring support is off; set \"keyring\": true under \"vault\" in the config to allow it")
+	}
+
+	fmt.Println(markWarn + " Anyone who can log in to this computer as you will be able to open")
+	fmt.Println("  the vault on this USB without the master password.")
+	if !app.confirm("Save the master password in this computer's keyring?") {
+		return errCancelled
+	}
+
+	v, err := vault.Open(app.vaultPath())
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+	password, err := app.promptPassword("Master password: ", false)
+	if err != nil {
+		return err
+	}
+	if err := app.unlockWith(v, password); err != nil {
+		return err
+	}
+	defer v.Lock()
+
+	account, err := app.keyringAccount(true)
+	if err != nil {
+		return err
+	}
+	if err := keyring.Default.Set(account, password); err != nil {
+		return err
+	}
+
+	fmt.Println(markOK + " Master password saved; 'claude-go vault forget' removes it")
+	return nil
+}
+
+// runVaultForget removes the master password from this computer's keyring
+func (app *App) runVaultForget(args []string) error {
+	account, err := app.keyringAccount(false)
+	if os.IsNotExist(err) {
+		fmt.Println("No master password saved for this vault")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := keyring.Default.Delete(account); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			fmt.Println("No master password saved on this computer")
+			return nil
+		}
+		return err
+	}
+
+	fmt.Println(markOK + " Master password removed from this computer's keyring")
+	return nil
+}
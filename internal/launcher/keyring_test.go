@@ -0,0 +1,125 @@
+package launcher
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/keyring"
+	"github.com/cxt9/claude-go/internal/vault"
+	"golang.org/x/term"
+)
+
+// fakeKeyring is an in-memory keyring backend
+type fakeKeyring map[string]string
+
+func (k fakeKeyring) Get(account string) (string, error) {
+	secret, ok := k[account]
+	if !ok {
+		return "", keyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k fakeKeyring) Set(account, secret string) error {
+	k[account] = secret
+	return nil
+}
+
+func (k fakeKeyring) Delete(account string) error {
+	if _, ok := k[account]; !ok {
+		return keyring.ErrNotFound
+	}
+	delete(k, account)
+	return nil
+}
+
+// useKeyring replaces the system keyring for the rest of the test
+func useKeyring(t *testing.T) fakeKeyring {
+	t.Helper()
+
+	k := fakeKeyring{}
+	orig := keyring.Default
+	keyring.Default = k
+	t.Cleanup(func() { keyring.Default = orig })
+	return k
+}
+
+func TestUnlockFromKeyring(t *testing.T) {
+	k := useKeyring(t)
+	app := newTestApp(t)
+	app.out = io.Discard
+	createTestVault(t, app, "correct horse battery")
+
+	account, err := app.keyringAccount(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := app.keyringAccount(false); again != account {
+		t.Fatalf("keyring account changed from %q to %q", account, again)
+	}
+	k[account] = "correct horse battery"
+
+	// Opt-in only
+	v, _ := vault.Open(app.vaultPath())
+	if ok, err := app.unlockFromKeyring(v); ok || err != nil {
+		t.Fatalf("keyring off: unlockFromKeyring = %v, %v", ok, err)
+	}
+
+	app.config.Vault.Keyring = true
+	if ok, err := app.unlockFromKeyring(v); !ok || err != nil || !v.IsUnlocked() {
+		t.Fatalf("unlockFromKeyring = %v, %v", ok, err)
+	}
+	v.Lock()
+
+	// A saved password that stopped working is dropped, falling back to
+	// the prompt
+	k[account] = "old master password"
+	v, _ = vault.Open(app.vaultPath())
+	if ok, err := app.unlockFromKeyring(v); ok || err != nil {
+		t.Fatalf("stale password: unlockFromKeyring = %v, %v", ok, err)
+	}
+	if _, ok := k[account]; ok {
+		t.Error("the stale password was kept")
+	}
+}
+
+func TestVaultRememberAndForget(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the password prompt would read the terminal")
+	}
+
+	k := useKeyring(t)
+	app := newTestApp(t)
+	app.out = io.Discard
+	createTestVault(t, app, "correct horse battery")
+
+	app.stdin = bufio.NewReader(strings.NewReader("y\ncorrect horse battery\n"))
+	if err := app.runVaultRemember(nil); err == nil {
+		t.Fatal("remembered the password with keyring support off")
+	}
+
+	app.config.Vault.Keyring = true
+	app.stdin = bufio.NewReader(strings.NewReader("y\nwrong password\n"))
+	if err := app.runVaultRemember(nil); err == nil || len(k) != 0 {
+		t.Fatalf("wrong password: err = %v, keyring = %v", err, k)
+	}
+
+	app.stdin = bufio.NewReader(strings.NewReader("y\ncorrect horse battery\n"))
+	if err := app.runVaultRemember(nil); err != nil {
+		t.Fatal(err)
+	}
+	account, _ := app.keyringAccount(false)
+	if k[account] != "correct horse battery" {
+		t.Fatalf("keyring = %v, want the password under %q", k, account)
+	}
+
+	if err := app.runVaultForget(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(k) != 0 {
+		t.Errorf("keyring = %v after forget, want it empty", k)
+	}
+}
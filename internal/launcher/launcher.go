@@ -3,19 +3,26 @@ package launcher
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/cxt9/claude-go/internal/auth"
 	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/fsutil"
 	"github.com/cxt9/claude-go/internal/mcp"
 	"github.com/cxt9/claude-go/internal/platform"
+	"github.com/cxt9/claude-go/internal/securetemp"
 	"github.com/cxt9/claude-go/internal/session"
 	"github.com/cxt9/claude-go/internal/vault"
 	"golang.org/x/term"
@@ -33,61 +40,184 @@ const (
 
 // App holds the application state
 type App struct {
+	ctx            context.Context // cancels OAuth waits, MCP checks and downloads
+	opts           *Options
+	out            io.Writer // prompts and progress; stderr in --json mode
 	usbRoot        string
+	dataRoot       string // holds vault/, sessions/, config/, cache/ and profiles/
+	profileRoot    string // data root, or profiles/<name> under it with --profile
 	platform       platform.Platform
+	storage        platform.Storage // media the data root lives on
 	config         *config.Config
+	configErr      error // why the config file was ignored; see newApp
 	vault          *vault.Vault
 	auth           *auth.Authenticator
 	sessionManager *session.Manager
 	mcpManager     *mcp.Manager
+	node           *nodeStatus   // cached result of checkNode
+	stdin          *bufio.Reader // shared by prompts; see stdinReader
 }
 
-// Run is the main entry point
-func Run(args []string) error {
-	fmt.Print(banner)
+// Run is the main entry point. Cancelling ctx aborts the long-running
+// steps: the OAuth wait, MCP checks and update downloads.
+func Run(ctx context.Context, args []string) error {
+	opts, args, err := parseOptions(args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+
+	// Runs inside claude as an MCP server, so nothing may reach stdout
+	if len(args) > 0 && args[0] == mcp.ExecCommand {
+		return runMCPExec(ctx, args[1:], opts.ClaudeArgs)
+	}
+
+	if opts.JSON {
+		// Errors are reported as JSON too; see ReportError
+		if err := run(ctx, opts, args); err != nil {
+			return &jsonError{err: err}
+		}
+		return nil
+	}
+
+	return run(ctx, opts, args)
+}
+
+func run(ctx context.Context, opts *Options, args []string) error {
+	plain := plainOutput(opts)
+	if plain {
+		usePlainMarks()
+	}
+	printBanner(os.Stdout, opts, plain)
+
+	app, err := newApp(ctx, opts, recoveryCommand(args))
+	if err != nil {
+		return err
+	}
+
+	if opts.Profile != "" && !opts.JSON && !opts.Quiet {
+		fmt.Printf("Profile: %s\n\n", opts.Profile)
+	}
+
+	// The audit writes a probe file into each directory, so it only runs
+	// where it matters: before launching claude, and in doctor
+	if len(args) == 0 {
+		app.auditPermissions()
+	} else if opts.FixPermissions && args[0] != "doctor" {
+		return usagef("--fix-permissions only applies when launching claude or with doctor")
+	}
+
+	// doctor still runs, to show why
+	if len(args) == 0 || args[0] != "doctor" {
+		if err := app.checkRemovable(); err != nil {
+			return err
+		}
+	}
+
+	if len(args) > 0 {
+		if len(opts.ClaudeArgs) > 0 {
+			return fmt.Errorf("arguments after -- are only used when launching claude, not with %q", args[0])
+		}
+		return app.runCommand(args)
+	}
+
+	// Credentials come from the environment; the vault is never touched
+	if opts.NoVault {
+		if _, _, err := environmentCredentials(); err != nil {
+			return err
+		}
+		return app.showSessionPicker()
+	}
+
+	// Check if vault exists
+	vaultPath := app.vaultPath()
+	if !vault.Exists(vaultPath) {
+		return app.runFirstTimeSetup(vaultPath, nil)
+	}
 
-	// Detect USB root (directory containing this binary)
-	usbRoot, err := detectUSBRoot()
+	return app.runNormalLaunch(vaultPath)
+}
+
+// recoveryCommand reports whether args run doctor or vault reset, which
+// must work even when the config file is broken
+func recoveryCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	return args[0] == "doctor" || args[0] == "vault" && len(args) > 1 && args[1] == "reset"
+}
+
+// newApp detects the USB root and loads the state shared by all commands.
+// With recovery set, an invalid config file is reported and the defaults
+// used in its place, instead of failing.
+func newApp(ctx context.Context, opts *Options, recovery bool) (*App, error) {
+	// Detect USB root (directory containing this binary) and where the
+	// data lives, normally the same
+	usbRoot, dataRoot, err := resolveRoots(opts.DataRoot)
 	if err != nil {
-		return fmt.Errorf("failed to detect USB root: %w", err)
+		return nil, fmt.Errorf("failed to detect USB root: %w", err)
 	}
 
 	plat, err := platform.Current()
 	if err != nil {
-		return fmt.Errorf("unsupported platform: %w", err)
+		return nil, fmt.Errorf("unsupported platform: %w", err)
+	}
+
+	profileDir, err := profileRoot(dataRoot, opts.Profile)
+	if err != nil {
+		return nil, err
 	}
 
 	app := &App{
-		usbRoot:  usbRoot,
-		platform: plat,
+		ctx:         ctx,
+		opts:        opts,
+		out:         os.Stdout,
+		usbRoot:     usbRoot,
+		dataRoot:    dataRoot,
+		profileRoot: profileDir,
+		platform:    plat,
+		storage:     platform.DetectStorage(dataRoot),
+	}
+	if opts.JSON {
+		app.out = os.Stderr
 	}
 
 	// Load or create configuration
-	configPath := filepath.Join(usbRoot, "config", "settings.json")
-	app.config, err = config.Load(configPath)
+	app.config, err = config.Load(app.configPath())
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		if !recovery {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		fmt.Fprintf(app.out, markWarn+" Ignoring %s: %v; using the defaults\n\n", app.configPath(), err)
+		app.config, app.configErr = config.DefaultConfig(), err
 	}
 
 	// Initialize session manager
-	sessionsDir := filepath.Join(usbRoot, "sessions")
-	app.sessionManager = session.NewManager(sessionsDir)
+	app.sessionManager = session.NewManager(app.dataDir("sessions"))
 
-	// Check if vault exists
-	vaultPath := filepath.Join(usbRoot, "vault", "credentials.vault")
-	if !vault.Exists(vaultPath) {
-		return app.runFirstTimeSetup(vaultPath)
-	}
+	return app, nil
+}
 
-	return app.runNormalLaunch(vaultPath)
+func (app *App) configPath() string {
+	return filepath.Join(app.dataDir("config"), "settings.json")
+}
+
+func (app *App) vaultPath() string {
+	return filepath.Join(app.dataDir("vault"), "credentials.vault")
 }
 
-func (app *App) runFirstTimeSetup(vaultPath string) error {
-	fmt.Println("\nWelcome! Let's set up your portable Claude environment.\n")
+func (app *App) runFirstTimeSetup(vaultPath string, metadata map[string]string) error {
+	fmt.Print("\nWelcome! Let's set up your portable Claude environment.\n\n")
+
+	if err := app.createScaffold(); err != nil {
+		return err
+	}
 
 	// Step 1: Create master password
 	fmt.Println("Step 1: Create a master password to protect your credentials")
-	fmt.Println("        This password encrypts everything stored on this USB.\n")
+	fmt.Print("        This password encrypts everything stored on this USB.\n\n")
 
 	password, err := app.promptPassword("Master password (min 12 chars): ", true)
 	if err != nil {
@@ -108,86 +238,305 @@ func (app *App) runFirstTimeSetup(vaultPath string) error {
 	}
 
 	// Create vault
-	v, err := vault.Create(vaultPath, password)
+	params, err := app.kdfParams("")
+	if err != nil {
+		return err
+	}
+
+	v, err := vault.CreateWithOptions(vaultPath, password, vault.Options{KDF: params, Compress: app.config.Vault.Compress, Algorithm: vault.Algorithm(app.config.Vault.Algorithm)})
 	if err != nil {
 		return fmt.Errorf("failed to create vault: %w", err)
 	}
 	app.vault = v
-	app.auth = auth.NewAuthenticator(v)
+	if err := app.useVault(v); err != nil {
+		return err
+	}
 
-	fmt.Println("✓ Vault created\n")
+	fmt.Print(markOK + " Vault created\n\n")
 
 	// Step 2: Authentication
-	fmt.Println("Step 2: Link your Claude account\n")
-	fmt.Println("How would you like to authenticate?")
-	fmt.Println("  [1] Claude.ai account (Pro/Max subscription)")
-	fmt.Println("  [2] API Key (Claude Console)")
-	fmt.Println("  [3] Amazon Bedrock")
-	fmt.Println("  [4] Google Vertex AI")
-	fmt.Print("\n> ")
+	fmt.Print("Step 2: Link your Claude account\n\n")
+	skipped := false
+	if err := app.setupAuth(metadata, true); errors.Is(err, errAuthSkipped) {
+		skipped = true
+	} else if err != nil {
+		return err
+	}
+
+	// Save configuration
+	if err := app.config.Save(app.configPath()); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
 
-	var choice string
-	fmt.Scanln(&choice)
+	if skipped {
+		fmt.Print("\n" + markOK + " Vault and settings saved. You'll be asked to link an account the next time you launch.\n\n")
+		return nil
+	}
 
-	switch choice {
-	case "1":
-		if err := app.setupOAuth(); err != nil {
-			return err
-		}
-	case "2":
-		if err := app.setupAPIKey(auth.ProviderConsole); err != nil {
-			return err
-		}
-	case "3":
-		if err := app.setupAPIKey(auth.ProviderBedrock); err != nil {
-			return err
+	fmt.Print("\n" + markOK + " Setup complete! Claude Code Go is ready to use.\n\n")
+
+	return app.startSession("", nil)
+}
+
+// runSetup re-enters the setup flow on an existing vault so providers and
+// settings can be changed without recreating it
+func (app *App) runSetup(args []string) error {
+	fs := flag.NewFlagSet("setup", flag.ContinueOnError)
+	label := fs.String("label", "", "label for the credential, e.g. \"work\"")
+	note := fs.String("note", "", "free-form note stored with the credential")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	metadata := credentialMetadata(*label, *note)
+
+	vaultPath := app.vaultPath()
+	if !vault.Exists(vaultPath) {
+		return app.runFirstTimeSetup(vaultPath, metadata)
+	}
+
+	if err := app.unlockVault(vaultPath); err != nil {
+		if errors.Is(err, vault.ErrVaultIncomplete) {
+			return app.recoverIncompleteVault(vaultPath)
 		}
-	case "4":
-		if err := app.setupAPIKey(auth.ProviderVertex); err != nil {
-			return err
+		return err
+	}
+
+	providers, err := app.auth.ListProviders()
+	if err != nil {
+		return fmt.Errorf("failed to list providers: %w", err)
+	}
+	if len(providers) > 0 {
+		fmt.Println("Configured providers:")
+		for _, p := range providers {
+			fmt.Printf("  "+markItem+" %s\n", p)
 		}
-	default:
-		return fmt.Errorf("invalid choice: %s", choice)
+		fmt.Println()
 	}
 
-	// Save configuration
-	configPath := filepath.Join(app.usbRoot, "config", "settings.json")
-	if err := app.config.Save(configPath); err != nil {
+	// Step 1: Add or replace a provider (others are left untouched)
+	fmt.Print("Step 1: Add or replace a Claude account\n\n")
+	if err := app.setupAuth(metadata, false); err != nil {
+		return err
+	}
+
+	// Step 2: Adjust settings
+	fmt.Print("\nStep 2: Adjust settings\n\n")
+	if err := app.setupConfig(); err != nil {
+		return err
+	}
+
+	if err := app.config.Save(app.configPath()); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Println("\n✓ Setup complete! Claude Code Go is ready to use.\n")
+	fmt.Print("\n" + markOK + " Setup updated\n\n")
+	return nil
+}
+
+// errAuthSkipped is returned by setupAuth when the user defers linking an
+// account
+var errAuthSkipped = errors.New("authentication skipped")
+
+// setupAuth shows the provider menu, stores the chosen credential and
+// attaches any label/note metadata to it. With allowSkip the menu offers
+// to defer linking an account, and choosing that returns errAuthSkipped.
+func (app *App) setupAuth(metadata map[string]string, allowSkip bool) error {
+	drivers := auth.Drivers()
+
+	fmt.Println("How would you like to authenticate?")
+	for i, d := range drivers {
+		fmt.Printf("  [%d] %s\n", i+1, d.Title())
+	}
+	skip := len(drivers) + 1
+	if allowSkip {
+		fmt.Printf("  [%d] Skip for now (link an account on the next launch)\n", skip)
+	}
+	fmt.Print("\n> ")
+
+	choice, _ := app.stdinReader().ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	n, err := strconv.Atoi(choice)
+	switch {
+	case err != nil || n < 1 || n > skip || (n == skip && !allowSkip):
+		return fmt.Errorf("invalid choice: %s", choice)
+	case n == skip:
+		return errAuthSkipped
+	}
+
+	driver := drivers[n-1]
+	if err := driver.Setup(app.ctx, app.auth, setupPrompter{app}); err != nil {
+		return err
+	}
+	fmt.Println(markOK + " Credential stored!")
+	app.warnMissingScopes(driver.Provider())
+
+	if len(metadata) > 0 {
+		if err := app.auth.SetMetadata(driver.Provider(), metadata); err != nil {
+			return fmt.Errorf("failed to store credential label: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// credentialMetadata builds entry metadata from the --label/--note flags
+func credentialMetadata(label, note string) map[string]string {
+	if label == "" && note == "" {
+		return nil
+	}
+	return map[string]string{
+		auth.MetadataLabel: label,
+		auth.MetadataNote:  note,
+	}
+}
+
+// setupConfig prompts for adjustable settings, keeping current values on empty input
+func (app *App) setupConfig() error {
+	reader := app.stdinReader()
+
+	fmt.Printf("Default model [%s]: ", app.config.Environment.DefaultModel)
+	model, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if model = strings.TrimSpace(model); model != "" {
+		app.config.Environment.DefaultModel = model
+	}
 
-	return app.startSession("")
+	return nil
 }
 
 func (app *App) runNormalLaunch(vaultPath string) error {
+	if err := app.unlockVault(vaultPath); err != nil {
+		if errors.Is(err, vault.ErrVaultIncomplete) {
+			return app.recoverIncompleteVault(vaultPath)
+		}
+		return err
+	}
+
+	if err := app.ensureProvider(); err != nil {
+		return err
+	}
+	app.warnVaultMismatches()
+
+	// Show session picker
+	return app.showSessionPicker()
+}
+
+// ensureProvider runs just the authentication step of setup when the
+// unlocked vault holds no provider credential, as after a first run that
+// deferred linking an account
+func (app *App) ensureProvider() error {
+	providers, err := app.auth.ListProviders()
+	if err != nil {
+		return err
+	}
+	if len(providers) > 0 {
+		return nil
+	}
+
+	fmt.Print("\nNo Claude account is linked to this vault yet.\n\n")
+	return app.setupAuth(nil, false)
+}
+
+// recoverIncompleteVault offers to restart setup when vault creation was
+// interrupted. The stub holds no credentials but is kept as a backup.
+func (app *App) recoverIncompleteVault(vaultPath string) error {
+	fmt.Println(markWarn + " The vault file is incomplete; setting it up was probably interrupted.")
+	fmt.Println("  It contains no credentials.")
+	if !app.confirm("Back it up and run first-time setup again?") {
+		return fmt.Errorf("setup %w: vault file is incomplete: %s", errCancelled, vaultPath)
+	}
+
+	backup, err := vault.MoveAside(vaultPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(markOK+" Incomplete vault moved to %s\n\n", backup)
+
+	return app.runFirstTimeSetup(vaultPath, nil)
+}
+
+// unlockVault opens the vault and prompts for the master password
+func (app *App) unlockVault(vaultPath string) error {
 	// Open vault (locked)
 	v, err := vault.Open(vaultPath)
+	if errors.Is(err, vault.ErrVaultIncomplete) {
+		return fmt.Errorf("failed to open vault: %w (run 'claude-go setup' to back it up and start again)", err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open vault: %w", err)
 	}
 	app.vault = v
 
+	// Don't ask for a password that would be refused anyway
+	if wait := v.LockoutRemaining(); wait > 0 {
+		return &vault.LockoutError{RetryAfter: wait}
+	}
+
+	if ok, err := app.unlockFromKeyring(v); ok || err != nil {
+		return err
+	}
+
 	// Prompt for password
-	fmt.Print("Unlock your portable vault\n")
+	fmt.Fprint(app.out, "Unlock your portable vault\n")
 	password, err := app.promptPassword("Master password: ", false)
 	if err != nil {
 		return err
 	}
 
+	if err := app.unlockWith(v, password); err != nil {
+		return err
+	}
+	fmt.Fprint(app.out, markOK+" Vault unlocked\n")
+	if n := v.FailedAttempts(); n > 0 {
+		fmt.Fprintf(app.out, markWarn+" %d failed unlock attempt(s) since this vault was last opened\n", n)
+	}
+	fmt.Fprintln(app.out)
+
+	return nil
+}
+
+// errIncorrectPassword is returned by unlockWith for a wrong master password
+var errIncorrectPassword = errors.New("incorrect password")
+
+// unlockWith unlocks the opened vault and sets up the authenticator
+func (app *App) unlockWith(v *vault.Vault, password string) error {
 	if err := v.Unlock(password); err != nil {
+		var lockout *vault.LockoutError
+		if errors.As(err, &lockout) {
+			return lockout
+		}
 		if err == vault.ErrWrongPassword {
-			return fmt.Errorf("incorrect password")
+			return errIncorrectPassword
 		}
 		return fmt.Errorf("failed to unlock vault: %w", err)
 	}
-	fmt.Println("✓ Vault unlocked\n")
 
+	app.vault = v
+	return app.useVault(v)
+}
+
+// useVault sets up the authenticator for an unlocked vault
+func (app *App) useVault(v *vault.Vault) error {
 	app.auth = auth.NewAuthenticator(v)
+	app.auth.SetScopes(app.config.Auth.Scopes)
+	app.auth.SetBaseURL(app.baseURL())
+	if err := app.auth.SetPinnedKeys(app.config.Auth.PinnedKeys); err != nil {
+		return fmt.Errorf("auth.pinned_keys: %w", err)
+	}
+	if app.config.Vault.AuditLog {
+		if err := app.auth.EnableAudit(app.auditLogPath()); err != nil {
+			return fmt.Errorf("failed to enable the audit log: %w", err)
+		}
+	}
+	return nil
+}
 
-	// Show session picker
-	return app.showSessionPicker()
+// auditLogPath is where credential reads are recorded with vault.audit_log
+func (app *App) auditLogPath() string {
+	return filepath.Join(app.dataDir("vault"), "audit.log")
 }
 
 func (app *App) showSessionPicker() error {
@@ -195,193 +544,719 @@ func (app *App) showSessionPicker() error {
 	if err != nil {
 		return err
 	}
+	if app.opts.Tag != "" {
+		sessions = session.FilterByTag(sessions, app.opts.Tag)
+		if len(sessions) == 0 {
+			fmt.Printf("No sessions tagged %q\n\n", app.opts.Tag)
+		}
+	}
+
+	pageSize := app.pickerPageSize()
+	pages := (len(sessions) + pageSize - 1) / pageSize
+	page := 0
+
+	for len(sessions) > 0 {
+		start, end := pageBounds(len(sessions), page, pageSize)
 
-	if len(sessions) > 0 {
 		fmt.Println("Previous sessions:")
-		for i, s := range sessions {
-			if i >= 10 {
-				fmt.Printf("  ... and %d more\n", len(sessions)-10)
-				break
-			}
-			age := formatAge(time.Since(s.LastUsedAt))
-			projectName := filepath.Base(s.Project.OriginalPath)
-			fmt.Printf("  [%d] %s - %s: \"%s\"\n", i+1, age, projectName, truncate(s.Summary, 40))
+		for i := start; i < end; i++ {
+			fmt.Println(formatSessionLine(i+1, sessions[i]))
+		}
+		if pages > 1 {
+			fmt.Printf("  Page %d/%d - [n] next, [p] previous\n", page+1, pages)
 		}
 		fmt.Printf("  [%d] Start new session\n", len(sessions)+1)
 		fmt.Print("\n> ")
 
-		var choice string
-		fmt.Scanln(&choice)
+		choice, _ := app.stdinReader().ReadString('\n')
+		choice = strings.TrimSpace(choice)
+
+		switch choice {
+		case "n":
+			if page < pages-1 {
+				page++
+			}
+			fmt.Println()
+			continue
+		case "p":
+			if page > 0 {
+				page--
+			}
+			fmt.Println()
+			continue
+		}
 
 		idx, err := strconv.Atoi(choice)
 		if err == nil && idx >= 1 && idx <= len(sessions) {
 			// Resume existing session
 			return app.resumeSession(sessions[idx-1])
 		}
+		break
 	}
 
 	// Start new session
 	return app.promptNewSession()
 }
 
+func (app *App) pickerPageSize() int {
+	if app.config.Sessions.PickerPageSize > 0 {
+		return app.config.Sessions.PickerPageSize
+	}
+	return 10
+}
+
+// pageBounds returns the [start, end) slice indices of a page, clamped to n
+func pageBounds(n, page, pageSize int) (int, int) {
+	start := page * pageSize
+	if start > n {
+		start = n
+	}
+	end := start + pageSize
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+// formatSessionLine renders a session as a numbered picker entry
+func formatSessionLine(num int, s *session.Session) string {
+	age := formatAge(time.Since(s.LastUsedAt))
+	projectName := filepath.Base(s.Project.OriginalPath)
+	line := fmt.Sprintf("  [%d] %s - %s: \"%s\"", num, age, projectName, truncate(s.Summary, 40))
+	if len(s.Tags) > 0 {
+		line += " [" + strings.Join(s.Tags, ", ") + "]"
+	}
+	return line
+}
+
 func (app *App) promptNewSession() error {
 	fmt.Print("Enter project directory on this machine: ")
 
-	reader := bufio.NewReader(os.Stdin)
+	reader := app.stdinReader()
 	projectPath, err := reader.ReadString('\n')
 	if err != nil {
 		return err
 	}
-	projectPath = strings.TrimSpace(projectPath)
-
-	// Expand ~ to home directory
-	if strings.HasPrefix(projectPath, "~") {
-		home, _ := os.UserHomeDir()
-		projectPath = filepath.Join(home, projectPath[1:])
+	if projectPath, err = expandPath(projectPath); err != nil {
+		return err
 	}
 
-	// Validate path exists
-	if _, err := os.Stat(projectPath); os.IsNotExist(err) {
-		return fmt.Errorf("directory does not exist: %s", projectPath)
+	if err := app.checkProjectPath(projectPath); err != nil {
+		return err
 	}
 
-	return app.startSession(projectPath)
+	return app.startSession(projectPath, nil)
 }
 
 func (app *App) resumeSession(s *session.Session) error {
 	fmt.Printf("\nResuming session...\n")
 
-	// Check if original project path exists on this machine
-	if _, err := os.Stat(s.Project.OriginalPath); err == nil {
-		s.Project.RemappedPath = s.Project.OriginalPath
+	projectPath, err := app.resumePath(s)
+	if err != nil {
+		return err
+	}
+	return app.startSession(projectPath, s)
+}
+
+// resumePath finds the session's project directory on this machine: where
+// it was last used on this platform, else its original path, else a path
+// the user enters, which is recorded as the session's remapped path
+func (app *App) resumePath(s *session.Session) (string, error) {
+	if path, ok := app.remappedPath(s); ok {
+		return path, nil
+	}
+
+	// Check if original project path exists on this machine, first
+	// filling in the placeholders of a sanitized session
+	if expanded, ok := session.ExpandPlaceholders(s.Project.OriginalPath); ok && app.checkProjectPath(expanded) == nil {
+		if err := app.sessionManager.RemapProjectPath(s, expanded); err != nil {
+			return "", err
+		}
+		fmt.Printf("Project path remapped: %s -> %s\n", s.Project.OriginalPath, expanded)
+	} else if err := app.checkProjectPath(s.Project.OriginalPath); err == nil {
+		if _, moved := s.CrossPlatform(); moved {
+			// Same path on another OS; record the platform it now runs on
+			if err := app.sessionManager.RemapProjectPath(s, s.Project.OriginalPath); err != nil {
+				return "", err
+			}
+		} else {
+			s.Project.RemappedPath = s.Project.OriginalPath
+		}
 	} else {
 		// Prompt for new path
 		fmt.Printf("Original path not found: %s\n", s.Project.OriginalPath)
+		if previous, moved := s.CrossPlatform(); moved {
+			fmt.Printf("The session was last used on %s; its paths don't carry over to this OS.\n", previous)
+			fmt.Printf("Enter where the project folder %q is on this machine.\n", session.ProjectName(s.Project.OriginalPath))
+		}
 		fmt.Printf("Enter project directory on this machine: ")
 
-		reader := bufio.NewReader(os.Stdin)
+		reader := app.stdinReader()
 		newPath, err := reader.ReadString('\n')
 		if err != nil {
-			return err
+			return "", err
+		}
+		if newPath, err = expandPath(newPath); err != nil {
+			return "", err
 		}
-		newPath = strings.TrimSpace(newPath)
 
+		if err := app.checkProjectPath(newPath); err != nil {
+			return "", err
+		}
 		if err := app.sessionManager.RemapProjectPath(s, newPath); err != nil {
-			return err
+			return "", err
 		}
 
 		fmt.Printf("Project path remapped: %s -> %s\n", s.Project.OriginalPath, newPath)
 	}
 
-	return app.startSession(s.Project.RemappedPath)
+	return s.Project.RemappedPath, nil
+}
+
+// remappedPath returns where the session was last used, if that was on
+// this platform and the directory is still there
+func (app *App) remappedPath(s *session.Session) (string, bool) {
+	if current, err := platform.Current(); err == nil && s.Platform == current &&
+		s.Project.RemappedPath != "" && app.checkProjectPath(s.Project.RemappedPath) == nil {
+		return s.Project.RemappedPath, true
+	}
+	return "", false
+}
+
+// checkProjectPath validates a project directory. In paranoid mode it must
+// also not resolve through symlinks to somewhere outside the allowed roots.
+func (app *App) checkProjectPath(path string) error {
+	if err := session.ValidateProjectDir(path); err != nil {
+		return err
+	}
+
+	if !app.config.Environment.ParanoidMode {
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project path: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if resolved == filepath.Clean(abs) {
+		return nil
+	}
+
+	roots := app.config.Environment.AllowedProjectRoots
+	if len(roots) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to find home directory: %w", err)
+		}
+		roots = []string{home}
+	}
+
+	for _, root := range roots {
+		if root, err := filepath.EvalSymlinks(root); err == nil && isWithin(root, resolved) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("project path %s links to %s, outside the allowed roots (paranoid mode)", path, resolved)
+}
+
+// isWithin reports whether path is root or inside it
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
-func (app *App) startSession(projectPath string) error {
+// startSession launches claude in projectPath. A session picked to resume
+// is continued as is; otherwise one is created, or the project's recent
+// one reused.
+func (app *App) startSession(projectPath string, resume *session.Session) error {
 	// Create or update session
 	var s *session.Session
 	var err error
 
-	if projectPath != "" {
-		s, err = app.sessionManager.Create(projectPath)
+	if resume != nil {
+		s, err = app.sessionManager.Touch(resume.ID)
+		if err != nil {
+			return fmt.Errorf("failed to resume session: %w", err)
+		}
+		s.Project.RemappedPath = projectPath
+	} else if projectPath != "" {
+		if app.opts.NewSession {
+			s, err = app.sessionManager.Create(projectPath)
+		} else {
+			var resumed bool
+			reuseWithin := time.Duration(app.config.Sessions.ReuseWithinHours) * time.Hour
+			s, resumed, err = app.sessionManager.CreateOrResume(projectPath, reuseWithin)
+			if resumed {
+				fmt.Printf("Continuing session %s for this project (--new starts a fresh one)\n", s.ID)
+			}
+		}
 		if err != nil {
 			return fmt.Errorf("failed to create session: %w", err)
 		}
 	}
 
 	// Initialize MCP manager
-	app.mcpManager, err = mcp.NewManager(app.usbRoot, projectPath, &app.config.MCP)
+	app.mcpManager, err = app.newMCPManager(projectPath)
 	if err != nil {
-		return fmt.Errorf("failed to initialize MCP: %w", err)
+		return err
+	}
+	if err := app.selectMCPProfile(s); err != nil {
+		return err
+	}
+	if err := app.rememberModel(s); err != nil {
+		return err
 	}
 
 	// Check MCP servers
-	fmt.Println("\nChecking MCP servers...")
-	available, unavailable, err := app.mcpManager.GetAvailableServers()
+	if !app.opts.Quiet {
+		fmt.Println("\nChecking MCP servers...")
+	}
+	available, unavailable, err := app.mcpManager.GetAvailableServers(app.ctx)
 	if err != nil {
 		return fmt.Errorf("failed to check MCP servers: %w", err)
 	}
+	app.printMCPStatus(available, unavailable)
+
+	// Check for required unavailable servers
+	hasRequired, missing := app.mcpManager.HasRequiredUnavailable(app.ctx)
+	if hasRequired {
+		if err := app.continueWithoutRequired(missing); err != nil {
+			return err
+		}
+	}
+	if err := app.recordIgnoredRequired(s, missing); err != nil {
+		return err
+	}
+
+	// Setup environment and launch Claude Code
+	return app.launchClaudeCode(projectPath, s)
+}
+
+// recordIgnoredRequired remembers the required MCP servers s launched
+// without, which are already left out of the generated config. A launch
+// with all of them available clears the record.
+func (app *App) recordIgnoredRequired(s *session.Session, missing []string) error {
+	if s == nil || len(missing) == 0 && len(s.IgnoredRequiredMCP) == 0 {
+		return nil
+	}
+	s.IgnoredRequiredMCP = missing
+	return app.sessionManager.Save(s)
+}
+
+// selectMCPProfile limits the MCP servers to --mcp-profile, remembering it
+// on the session, or else to the profile the session last used
+func (app *App) selectMCPProfile(s *session.Session) error {
+	profile := app.opts.MCPProfile
+	if profile == "" && s != nil {
+		profile = s.MCPProfile
+		if _, ok := app.config.MCP.Profiles[profile]; profile != "" && !ok {
+			fmt.Printf(markWarn+" MCP profile %q is no longer configured; using all servers\n", profile)
+			profile = ""
+		}
+	}
+
+	if err := app.mcpManager.SetProfile(profile); err != nil {
+		return err
+	}
+	if profile == mcp.AllServersProfile {
+		profile = ""
+	}
+
+	if s != nil && s.MCPProfile != profile {
+		s.MCPProfile = profile
+		if err := app.sessionManager.Save(s); err != nil {
+			return err
+		}
+	}
+
+	if profile != "" && !app.opts.Quiet {
+		fmt.Printf("MCP profile: %s\n", profile)
+	}
+	return nil
+}
+
+// rememberModel stores --model on the session, so resuming it later uses
+// the same model
+func (app *App) rememberModel(s *session.Session) error {
+	if app.opts.Model == "" || s == nil || s.Model == app.opts.Model {
+		return nil
+	}
+	return app.sessionManager.SetModel(s, app.opts.Model)
+}
+
+// model returns the model claude should use: --model, else the session's,
+// else environment.default_model. A session file edited into an invalid
+// model falls back to the default.
+func (app *App) model(s *session.Session) string {
+	if app.opts.Model != "" {
+		return app.opts.Model
+	}
+	if s != nil && s.Model != "" {
+		if err := session.ValidateModel(s.Model); err == nil {
+			return s.Model
+		}
+		fmt.Printf(markWarn+" Ignoring the session's model: invalid model %q\n", s.Model)
+	}
+	return app.config.Environment.DefaultModel
+}
+
+// newMCPManager creates an MCP manager for the project honoring --refresh
+func (app *App) newMCPManager(projectPath string) (*mcp.Manager, error) {
+	m, err := mcp.NewManager(app.usbRoot, projectPath, &app.config.MCP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize MCP: %w", err)
+	}
+	m.SetRefresh(app.opts.Refresh)
+	m.SetCacheDir(app.dataDir("cache"))
+	if app.opts.MCPLogs {
+		launcher, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("--mcp-logs: failed to locate claude-go: %w", err)
+		}
+		m.SetServerLogs(filepath.Join(app.dataDir("cache"), "mcp-logs"), int64(app.config.Sessions.ChildLogMaxMB)<<20, launcher)
+	}
+	return m, nil
+}
+
+// printMCPStatus reports the MCP check before launch: a line per server,
+// or a one-line summary with --quiet
+func (app *App) printMCPStatus(available map[string]config.MCPServer, unavailable []mcp.ServerStatus) {
+	collisions, collisionErr := app.mcpManager.Collisions()
+	disabled := app.mcpManager.Disabled()
+
+	var down []string
+	for _, status := range unavailable {
+		if !app.mcpManager.Quiet(status) {
+			down = append(down, status.Name)
+		}
+	}
+
+	if app.opts.Quiet {
+		line := fmt.Sprintf("MCP servers: %d available", len(available))
+		if len(down) > 0 {
+			sort.Strings(down)
+			line += fmt.Sprintf(", %d unavailable (%s)", len(down), strings.Join(down, ", "))
+		}
+		if len(disabled) > 0 {
+			line += fmt.Sprintf(", %d disabled", len(disabled))
+		}
+		if len(collisions) > 0 {
+			names := make([]string, len(collisions))
+			for i, c := range collisions {
+				names[i] = c.Name
+			}
+			line += fmt.Sprintf(", overridden by the project: %s", strings.Join(names, ", "))
+		}
+		fmt.Println(line)
+		if collisionErr != nil {
+			fmt.Printf(markWarn+" %v\n", collisionErr)
+		}
+		return
+	}
 
 	for name := range available {
-		fmt.Printf("  ✓ %s\n", name)
+		fmt.Printf("  "+markOK+" %s\n", name)
+	}
+	if collisionErr != nil {
+		fmt.Printf("  "+markWarn+" %v\n", collisionErr)
+	}
+	for _, c := range collisions {
+		fmt.Printf("  "+markWarn+" %s is also defined in %s; using the project's definition\n", c.Name, c.ProjectFile)
 	}
 	for _, status := range unavailable {
-		fmt.Printf("  ⚠ %s (%s) - %s\n", status.Name, status.Portability, status.Error)
+		if app.mcpManager.Quiet(status) {
+			continue
+		}
+		fmt.Printf("  "+markWarn+" %s (%s) - %s\n", status.Name, status.Portability, status.Error)
+		if status.Hint != "" {
+			fmt.Printf("    %s\n", status.Hint)
+		}
 	}
+	for _, name := range disabled {
+		fmt.Printf("  "+markItem+" %s (disabled)\n", name)
+	}
+}
 
-	// Check for required unavailable servers
-	hasRequired, missing := app.mcpManager.HasRequiredUnavailable()
-	if hasRequired {
-		return fmt.Errorf("required MCP servers unavailable: %v", missing)
+// continueWithoutRequired decides whether to launch despite unavailable
+// required MCP servers. The flag always allows it; otherwise the user is
+// asked, and non-interactive runs abort.
+func (app *App) continueWithoutRequired(missing []string) error {
+	list := strings.Join(missing, ", ")
+	if app.opts.IgnoreRequiredMCP {
+		fmt.Printf("\n"+markWarn+" Continuing without required MCP servers: %s\n", list)
+		return nil
 	}
 
-	// Setup environment and launch Claude Code
-	return app.launchClaudeCode(projectPath, s)
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("required MCP servers unavailable: %s (pass --ignore-required-mcp to launch without them)", list)
+	}
+
+	fmt.Printf("\nRequired MCP servers unavailable: %s\n", list)
+	if !app.confirm("Continue anyway?") {
+		return fmt.Errorf("launch %w: required MCP servers unavailable: %s", errCancelled, list)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question, defaulting to no
+func (app *App) confirm(question string) bool {
+	fmt.Fprintf(app.out, "%s [y/N] ", question)
+
+	answer, _ := app.stdinReader().ReadString('\n')
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
 }
 
 func (app *App) launchClaudeCode(projectPath string, s *session.Session) error {
-	fmt.Println("\nStarting Claude Code Go...")
-	fmt.Printf("Portable Mode • Project: %s\n\n", projectPath)
+	if !app.opts.Quiet {
+		fmt.Println("\nStarting Claude Code Go...")
+		fmt.Printf("Portable Mode "+markItem+" Project: %s\n\n", projectPath)
+	}
 
-	// Setup environment variables for isolation
-	env := app.buildEnvironment(projectPath)
+	if err := app.requireNode(); err != nil {
+		return err
+	}
 
-	// Get the credential for Claude
-	providers, err := app.auth.ListProviders()
-	if err != nil || len(providers) == 0 {
-		return fmt.Errorf("no authentication configured")
+	// Setup environment variables for isolation; hooks get it without
+	// credentials
+	env := app.buildEnvironment(projectPath, s)
+	hookEnv := env
+
+	app.trimCache()
+
+	// Credential files live in a private dir on the USB, removed on exit
+	tmp, err := securetemp.New(filepath.Join(app.dataDir("cache"), "tmp"))
+	if err != nil {
+		return err
 	}
+	defer tmp.Cleanup()
 
-	credential, err := app.auth.GetCredential(providers[0])
+	// Add credentials to environment
+	if s != nil && app.auth != nil {
+		app.auth.SetAuditSession(s.ID)
+	}
+	credentialEnv, secrets, err := app.credentialEnv(tmp)
 	if err != nil {
-		return fmt.Errorf("failed to get credential: %w", err)
+		return err
 	}
+	env = append(env, credentialEnv...)
 
-	// Add credential to environment
-	env = append(env, fmt.Sprintf("ANTHROPIC_API_KEY=%s", credential))
+	// The session's own variables come last, so they win over the base
+	// environment; names the launcher sets are refused
+	overlay, overlaySecrets, err := app.sessionEnv(s)
+	if err != nil {
+		return err
+	}
+	env = mergeEnv(env, overlay)
+	secrets = append(secrets, overlaySecrets...)
 
 	// Generate MCP config
-	mcpConfig, err := app.mcpManager.GenerateClaudeConfig()
+	app.applyMCPTokens()
+	mcpConfig, err := app.mcpManager.GenerateClaudeConfig(app.ctx)
 	if err != nil {
 		return fmt.Errorf("failed to generate MCP config: %w", err)
 	}
 
-	// Write MCP config to temp file
-	// (In practice, Claude Code would read this from the portable config)
-	_ = mcpConfig
+	// Write MCP config to a temp file (server env and headers may hold secrets)
+	mcpData, err := json.Marshal(mcpConfig)
+	if err != nil {
+		return fmt.Errorf("failed to serialize MCP config: %w", err)
+	}
+	mcpConfigPath, err := tmp.WriteFile("mcp-*.json", mcpData)
+	if err != nil {
+		return err
+	}
 
 	// Find claude binary (would be bundled on USB)
 	claudeBinary := app.findClaudeBinary()
 
 	// Launch Claude Code
-	cmd := exec.Command(claudeBinary)
+	cmd := exec.Command(claudeBinary, app.claudeArgs(mcpConfigPath, s)...)
 	cmd.Dir = projectPath
 	cmd.Env = env
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	return cmd.Run()
+	if s != nil && (app.opts.LogChild || app.config.Sessions.LogChildOutput) {
+		if closeLog := app.teeChildOutput(cmd, s, secrets); closeLog != nil {
+			defer closeLog()
+		}
+	}
+
+	// Let the child handle Ctrl-C while we wait, so temp files are still
+	// cleaned up afterwards
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	hooks := app.config.Hooks
+	runHooks := app.hooksEnabled()
+	if runHooks && hooks.PreLaunch != nil {
+		if err := app.runHook("pre_launch", hooks.PreLaunch, projectPath, hookEnv, s); err != nil {
+			return fmt.Errorf("launch aborted: %w", err)
+		}
+	}
+
+	if app.credentialHandoff() == config.HandoffFD {
+		closeHandoff, err := handOffAPIKey(cmd)
+		if err != nil {
+			return err
+		}
+		defer closeHandoff()
+	}
+
+	// Rules already in the project's settings, so those the user removes
+	// during the run can be revoked from the session too
+	var granted []session.Permission
+	if s != nil {
+		granted, _ = session.ReadPermissionGrants(session.ProjectSettingsPath(projectPath))
+	}
+
+	err = cmd.Run()
+
+	if s != nil {
+		app.capturePermissions(projectPath, s, granted)
+	}
+
+	if runHooks && hooks.PostExit != nil {
+		if hookErr := app.runHook("post_exit", hooks.PostExit, projectPath, hookEnv, s); hookErr != nil {
+			fmt.Printf(markWarn+" %v\n", hookErr)
+		}
+	}
+
+	return childExit(err)
+}
+
+// trimCache evicts least recently used files from cache/ down to the
+// configured size. Credential temp files and anything touched in the last
+// few minutes, e.g. by another running instance, are left alone.
+func (app *App) trimCache() {
+	maxMB := app.config.Environment.MaxCacheMB
+	if maxMB <= 0 {
+		return
+	}
+
+	cacheDir := app.dataDir("cache")
+	result, err := fsutil.TrimDir(cacheDir, int64(maxMB)<<20, 10*time.Minute, filepath.Join(cacheDir, "tmp"))
+	if err != nil {
+		fmt.Printf(markWarn+" Failed to trim cache: %v\n", err)
+		return
+	}
+	if result.Removed > 0 {
+		fmt.Printf("Trimmed cache: removed %d file(s), freed %.1f MB\n", result.Removed, float64(result.Freed)/(1<<20))
+	}
+}
+
+// teeChildOutput copies the child's stderr to the session log. Stdout is
+// only copied when it isn't a terminal (e.g. claude -p), since piping it
+// would make claude drop its interactive UI. Returns a func closing the log.
+func (app *App) teeChildOutput(cmd *exec.Cmd, s *session.Session, secrets []string) func() {
+	maxBytes := int64(app.config.Sessions.ChildLogMaxMB) << 20
+
+	logw, err := app.sessionManager.OpenLog(s.ID, maxBytes, secrets)
+	if err != nil {
+		fmt.Printf(markWarn+" Not logging claude output: %v\n", err)
+		return nil
+	}
+
+	cmd.Stderr = io.MultiWriter(os.Stderr, logw)
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		cmd.Stdout = io.MultiWriter(os.Stdout, logw)
+	}
+
+	return func() { logw.Close() }
 }
 
-func (app *App) buildEnvironment(projectPath string) []string {
-	// Start with minimal environment
-	env := []string{
+// claudeArgs builds the claude command line; user arguments from after "--"
+// come last so they can add to or override the generated ones. A resumed
+// session's permission grants are passed as allowed tools, so they carry
+// over to computers where the project's settings don't have them.
+func (app *App) claudeArgs(mcpConfigPath string, s *session.Session) []string {
+	var args []string
+	if s != nil && len(s.Permissions) > 0 {
+		// --allowedTools takes several values, so it goes before another
+		// flag rather than next to user arguments
+		args = append(args, "--allowedTools")
+		for _, p := range s.Permissions {
+			args = append(args, p.Rule())
+		}
+	}
+	args = append(args, "--mcp-config", mcpConfigPath)
+	return append(args, app.opts.ClaudeArgs...)
+}
+
+// capturePermissions applies the permissions granted and revoked in the
+// project during claude's run to the session, so resuming it elsewhere
+// keeps them. before holds the project's rules at launch.
+func (app *App) capturePermissions(projectPath string, s *session.Session, before []session.Permission) {
+	grants, err := session.ReadPermissionGrants(session.ProjectSettingsPath(projectPath))
+	if err != nil {
+		fmt.Printf(markWarn+" Not saving granted permissions: %v\n", err)
+		return
+	}
+
+	if s.UpdatePermissions(before, grants, time.Now()) == 0 {
+		return
+	}
+	err = app.sessionManager.Save(s)
+	if errors.Is(err, session.ErrSessionConflict) {
+		// Changed elsewhere during the run; merge into that version instead
+		var fresh *session.Session
+		if fresh, err = app.sessionManager.Load(s.ID); err == nil {
+			fresh.UpdatePermissions(before, grants, time.Now())
+			if err = app.sessionManager.Save(fresh); err == nil {
+				*s = *fresh
+			}
+		}
+	}
+	if err != nil {
+		fmt.Printf(markWarn+" Failed to save granted permissions: %v\n", err)
+	}
+}
+
+func (app *App) buildEnvironment(projectPath string, s *session.Session) []string {
+	// Host variables allowed by environment.isolation, then the minimal
+	// environment, which wins over them
+	env := inheritedEnv(app.config.Environment.Isolation, os.Environ())
+	env = mergeEnv(env, []string{
 		fmt.Sprintf("HOME=%s", os.Getenv("HOME")),
 		fmt.Sprintf("USER=%s", os.Getenv("USER")),
 		fmt.Sprintf("PATH=%s", app.buildPath()),
 		fmt.Sprintf("TERM=%s", os.Getenv("TERM")),
 
 		// Claude Code Go specific
-		fmt.Sprintf("CLAUDE_CONFIG_DIR=%s", filepath.Join(app.usbRoot, "config")),
-		fmt.Sprintf("CLAUDE_DATA_DIR=%s", filepath.Join(app.usbRoot, "sessions")),
-		fmt.Sprintf("CLAUDE_CACHE_DIR=%s", filepath.Join(app.usbRoot, "cache")),
+		fmt.Sprintf("CLAUDE_CONFIG_DIR=%s", app.dataDir("config")),
+		fmt.Sprintf("CLAUDE_DATA_DIR=%s", app.dataDir("sessions")),
+		fmt.Sprintf("CLAUDE_CACHE_DIR=%s", app.dataDir("cache")),
 		fmt.Sprintf("CLAUDE_CODE_GO=1"),
-		fmt.Sprintf("CLAUDE_CODE_GO_USB_ROOT=%s", app.usbRoot),
+		fmt.Sprintf("%s=%s", mcp.USBRootEnv, app.usbRoot),
+	})
+	if baseURL := app.baseURL(); baseURL != "" {
+		env = append(env, fmt.Sprintf("ANTHROPIC_BASE_URL=%s", baseURL))
+	}
+	if model := app.model(s); model != "" {
+		env = append(env, fmt.Sprintf("ANTHROPIC_MODEL=%s", model))
 	}
 
 	return env
 }
 
+// baseURL returns the API gateway claude should use: --base-url, else
+// environment.base_url, else none
+func (app *App) baseURL() string {
+	if app.opts.BaseURL != "" {
+		return app.opts.BaseURL
+	}
+	return app.config.Environment.BaseURL
+}
+
 func (app *App) buildPath() string {
 	// Prioritize USB-bundled binaries
 	usbBinPath := filepath.Join(app.usbRoot, "bin", string(app.platform))
@@ -407,23 +1282,45 @@ func (app *App) findClaudeBinary() string {
 	return "claude"
 }
 
-func (app *App) setupOAuth() error {
-	fmt.Println("\nOpening browser for Claude.ai login...")
+// setupPrompter lets provider drivers prompt through the app
+type setupPrompter struct {
+	app *App
+}
 
-	ctx := context.Background()
+func (p setupPrompter) Secret(prompt string) (string, error) {
+	return p.app.promptPassword(prompt, false)
+}
 
-	// Start callback server
-	codeChan, err := auth.StartCallbackServer(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to start callback server: %w", err)
-	}
+func (p setupPrompter) Authorize(
+	start func(ctx context.Context) (*auth.OAuthFlowData, error),
+	complete func(ctx context.Context, code, codeVerifier string) error,
+) error {
+	fmt.Println("\nOpening browser to log in...")
+	return p.app.runOAuthFlow(start, complete)
+}
+
+// runOAuthFlow opens the authorization URL from start in the browser and
+// passes the code delivered to the local callback to complete
+func (app *App) runOAuthFlow(
+	start func(ctx context.Context) (*auth.OAuthFlowData, error),
+	complete func(ctx context.Context, code, codeVerifier string) error,
+) error {
+	// The wait below is bounded by the app's context as well as the timeout
+	ctx, cancel := context.WithTimeout(app.ctx, 5*time.Minute)
+	defer cancel()
 
 	// Get authorization URL and flow data
-	flowData, err := app.auth.StartOAuthFlow(ctx)
+	flowData, err := start(ctx)
 	if err != nil {
 		return err
 	}
 
+	// Start callback server, which only accepts this flow's state
+	codeChan, errChan, err := auth.StartCallbackServer(ctx, flowData.State)
+	if err != nil {
+		return fmt.Errorf("failed to start callback server: %w", err)
+	}
+
 	// Open browser
 	if err := openBrowser(flowData.AuthURL); err != nil {
 		fmt.Printf("Please open this URL in your browser:\n%s\n", flowData.AuthURL)
@@ -432,77 +1329,65 @@ func (app *App) setupOAuth() error {
 	// Wait for callback
 	select {
 	case code := <-codeChan:
-		if err := app.auth.CompleteOAuthFlow(ctx, code, flowData.CodeVerifier); err != nil {
-			return err
-		}
-		fmt.Println("✓ Authentication successful!")
-
-	case <-time.After(5 * time.Minute):
-		return fmt.Errorf("authentication timed out")
-	}
-
-	return nil
-}
-
-func (app *App) setupAPIKey(provider auth.Provider) error {
-	fmt.Print("\nEnter your API key: ")
+		return complete(ctx, code, flowData.CodeVerifier)
 
-	apiKey, err := app.promptPassword("", false)
-	if err != nil {
+	case err := <-errChan:
 		return err
-	}
 
-	if err := app.auth.SetAPIKey(provider, apiKey); err != nil {
-		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("authentication timed out")
+		}
+		return ctx.Err()
 	}
-
-	fmt.Println("✓ API key stored!")
-	return nil
 }
 
 func (app *App) promptPassword(prompt string, showRequirements bool) (string, error) {
 	if prompt != "" {
-		fmt.Print(prompt)
+		fmt.Fprint(app.out, prompt)
+	}
+
+	// os.Stdin.Fd rather than syscall.Stdin, which is a handle on Windows
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(app.out)
+		fmt.Fprintln(os.Stderr, markWarn+" stdin is not a terminal; reading the password from it as plain text")
+		return readSecretLine(app.stdinReader())
 	}
 
-	password, err := term.ReadPassword(int(syscall.Stdin))
+	password, err := term.ReadPassword(fd)
 	if err != nil {
 		return "", err
 	}
-	fmt.Println()
+	fmt.Fprintln(app.out)
 
 	return string(password), nil
 }
 
-func detectUSBRoot() (string, error) {
-	// Get the directory containing the executable
-	exe, err := os.Executable()
-	if err != nil {
-		return "", err
-	}
-
-	// Resolve symlinks
-	exe, err = filepath.EvalSymlinks(exe)
-	if err != nil {
-		return "", err
+// readSecretLine reads a password piped in on one line, without its line
+// ending. A last line without a newline is accepted.
+func readSecretLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		if err == io.EOF {
+			return "", fmt.Errorf("no password on stdin")
+		}
+		return "", fmt.Errorf("failed to read password: %w", err)
 	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
 
-	// Go up from bin/<platform>/ to USB root
-	binDir := filepath.Dir(exe)
-	platformDir := filepath.Dir(binDir)
-	usbRoot := filepath.Dir(platformDir)
-
-	// Verify it looks like a USB root
-	if _, err := os.Stat(filepath.Join(usbRoot, "config")); os.IsNotExist(err) {
-		// Maybe we're running from a different location, use current directory
-		cwd, _ := os.Getwd()
-		return cwd, nil
+// stdinReader returns the one buffered reader used for all prompts, so
+// piped answers aren't lost in a reader that's dropped
+func (app *App) stdinReader() *bufio.Reader {
+	if app.stdin == nil {
+		app.stdin = bufio.NewReader(os.Stdin)
 	}
-
-	return usbRoot, nil
+	return app.stdin
 }
 
-func openBrowser(url string) error {
+// openBrowser opens url in the default browser; replaced in tests
+var openBrowser = func(url string) error {
 	var cmd *exec.Cmd
 
 	switch plat, _ := platform.Current(); plat {
@@ -0,0 +1,462 @@
+package launcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/mcp"
+	"github.com/cxt9/claude-go/internal/platform"
+	"github.com/cxt9/claude-go/internal/session"
+	"github.com/cxt9/claude-go/internal/vault"
+	"golang.org/x/term"
+)
+
+func TestResumePathAfterMigration(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("migrates onto a macOS/Linux machine")
+	}
+
+	app := newTestApp(t)
+	s, err := app.sessionManager.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Platform = platform.WindowsAMD64
+	s.Project.OriginalPath = `C:\Users\me\project`
+	s.Project.RemappedPath = s.Project.OriginalPath
+
+	// The Windows path typed in again is refused rather than remapped
+	app.stdin = bufio.NewReader(strings.NewReader(`C:\Users\me\project` + "\n"))
+	if _, err := app.resumePath(s); !errors.Is(err, session.ErrForeignPath) {
+		t.Fatalf("err = %v, want ErrForeignPath", err)
+	}
+
+	project := t.TempDir()
+	app.stdin = bufio.NewReader(strings.NewReader(project + "\n"))
+	if path, err := app.resumePath(s); err != nil || path != project {
+		t.Fatalf("resumePath = %q, %v; want %q", path, err, project)
+	}
+
+	// Later resumes on this platform go straight to the remapped path,
+	// without asking again
+	loaded, err := app.sessionManager.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.stdin = bufio.NewReader(strings.NewReader(""))
+	if path, err := app.resumePath(loaded); err != nil || path != project {
+		t.Errorf("second resumePath = %q, %v; want %q", path, err, project)
+	}
+}
+
+func TestNoProviderLaunchRunsAuthSetup(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the API key prompt would read the terminal")
+	}
+
+	app := newTestApp(t)
+	app.out = io.Discard
+	createTestVault(t, app, "correct horse battery")
+	v, err := vault.Open(app.vaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unlock("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Lock()
+	if err := app.useVault(v); err != nil {
+		t.Fatal(err)
+	}
+
+	choice := 0
+	for i, d := range auth.Drivers() {
+		if d.Provider() == auth.ProviderConsole {
+			choice = i + 1
+		}
+	}
+
+	// With nothing linked, the auth step runs and stores the credential
+	app.stdin = bufio.NewReader(strings.NewReader(fmt.Sprintf("%d\nsk-ant-test\n", choice)))
+	if err := app.ensureProvider(); err != nil {
+		t.Fatal(err)
+	}
+	if !app.auth.HasCredential(auth.ProviderConsole) {
+		t.Fatal("no credential was stored")
+	}
+
+	// Once one is linked, nothing is asked
+	app.stdin = bufio.NewReader(strings.NewReader(""))
+	if err := app.ensureProvider(); err != nil {
+		t.Errorf("with a provider linked: %v", err)
+	}
+}
+
+func TestLaunchRecoversHeaderOnlyVault(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the password prompts would read the terminal")
+	}
+
+	app := newTestApp(t)
+	app.out = io.Discard
+	if err := os.MkdirAll(filepath.Dir(app.vaultPath()), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// As if creation stopped after writing the header
+	stub := []byte{'C', 'C', 'G', 'O', 0, 3, 0, 0, 0, 1}
+	if err := os.WriteFile(app.vaultPath(), stub, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Declining leaves the stub alone, and says the file is incomplete
+	app.stdin = bufio.NewReader(strings.NewReader("n\n"))
+	err := app.runNormalLaunch(app.vaultPath())
+	if !errors.Is(err, errCancelled) || !strings.Contains(err.Error(), "incomplete") {
+		t.Fatalf("declined: err = %v, want a cancelled incomplete-vault error", err)
+	}
+	if data, _ := os.ReadFile(app.vaultPath()); string(data) != string(stub) {
+		t.Fatal("declining changed the vault file")
+	}
+
+	// Accepting backs up the stub and runs setup again, here deferring auth
+	skip := len(auth.Drivers()) + 1
+	app.stdin = bufio.NewReader(strings.NewReader(fmt.Sprintf("y\nnew master password\nnew master password\n%d\n", skip)))
+	if err := app.runSetup(nil); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := filepath.Glob(app.vaultPath() + ".*.bak")
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want the stub", backups)
+	}
+	v, err := vault.Open(app.vaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unlock("new master password"); err != nil {
+		t.Errorf("new vault: %v", err)
+	}
+	v.Lock()
+}
+
+func TestSetupKeepsOtherProviders(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("the password prompt would read the terminal")
+	}
+
+	app := newTestApp(t)
+	app.out = io.Discard
+	createTestVault(t, app, "correct horse battery")
+	v, err := vault.Open(app.vaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unlock("correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	existing := auth.NewAuthenticator(v)
+	if err := existing.SetAPIKey(auth.ProviderConsole, "sk-ant-old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := existing.SetAPIKey(auth.ProviderVertex, "vertex-key"); err != nil {
+		t.Fatal(err)
+	}
+	v.Lock()
+
+	choice := 0
+	for i, d := range auth.Drivers() {
+		if d.Provider() == auth.ProviderConsole {
+			choice = i + 1
+		}
+	}
+
+	// Unlock, replace the Console key, then change the default model
+	app.stdin = bufio.NewReader(strings.NewReader(fmt.Sprintf("correct horse battery\n%d\nsk-ant-new\nclaude-opus-4\n", choice)))
+	if err := app.runSetup(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer app.vault.Lock()
+
+	if key, err := app.auth.GetCredential(auth.ProviderConsole); err != nil || key != "sk-ant-new" {
+		t.Errorf("Console key = %q, %v; want the new key", key, err)
+	}
+	if !app.auth.HasCredential(auth.ProviderVertex) {
+		t.Error("setup removed the Vertex credential")
+	}
+	if app.config.Environment.DefaultModel != "claude-opus-4" {
+		t.Errorf("DefaultModel = %q, want claude-opus-4", app.config.Environment.DefaultModel)
+	}
+	if _, err := os.Stat(app.configPath()); err != nil {
+		t.Errorf("settings weren't saved: %v", err)
+	}
+}
+
+func TestRecoveryCommandsRunWithInvalidConfig(t *testing.T) {
+	data := t.TempDir()
+	configPath := filepath.Join(data, "config", "settings.json")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"vault": {"algorithm": "rot13"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opts := &Options{DataRoot: data}
+
+	if _, err := newApp(context.Background(), opts, recoveryCommand([]string{"sessions", "list"})); err == nil {
+		t.Fatal("an invalid config was accepted")
+	}
+
+	for _, args := range [][]string{{"doctor"}, {"vault", "reset"}} {
+		if !recoveryCommand(args) {
+			t.Fatalf("%v isn't a recovery command", args)
+		}
+	}
+	app, err := newApp(context.Background(), opts, true)
+	if err != nil {
+		t.Fatalf("recovery command: %v", err)
+	}
+	if app.config.Vault.Algorithm != config.DefaultConfig().Vault.Algorithm {
+		t.Errorf("Vault.Algorithm = %q, want the default", app.config.Vault.Algorithm)
+	}
+
+	// doctor reports what is wrong with the file
+	for _, check := range app.doctorChecks() {
+		if check.Name == "config" && (check.OK || !strings.Contains(check.Detail, "rot13")) {
+			t.Errorf("config check = %+v, want the invalid algorithm reported", check)
+		}
+	}
+}
+
+func TestIgnoredRequiredMCPCleared(t *testing.T) {
+	app := newTestApp(t)
+	s, err := app.sessionManager.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.recordIgnoredRequired(s, []string{"github"}); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := app.sessionManager.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.IgnoredRequiredMCP) != 1 || loaded.IgnoredRequiredMCP[0] != "github" {
+		t.Fatalf("IgnoredRequiredMCP = %v, want [github]", loaded.IgnoredRequiredMCP)
+	}
+
+	// The next launch has every required server
+	if err := app.recordIgnoredRequired(loaded, nil); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ = app.sessionManager.Load(s.ID); len(loaded.IgnoredRequiredMCP) != 0 {
+		t.Errorf("IgnoredRequiredMCP = %v after a launch with all servers", loaded.IgnoredRequiredMCP)
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		n, page, size int
+		start, end    int
+	}{
+		{25, 0, 10, 0, 10},
+		{25, 1, 10, 10, 20},
+		{25, 2, 10, 20, 25}, // last page is short
+		{25, 3, 10, 25, 25}, // past the end is empty
+		{10, 0, 10, 0, 10},
+		{0, 0, 10, 0, 0},
+		{7, 1, 3, 3, 6},
+	}
+
+	for _, tt := range tests {
+		start, end := pageBounds(tt.n, tt.page, tt.size)
+		if start != tt.start || end != tt.end {
+			t.Errorf("pageBounds(%d, %d, %d) = [%d, %d), want [%d, %d)", tt.n, tt.page, tt.size, start, end, tt.start, tt.end)
+		}
+	}
+
+	app := newTestApp(t)
+	if got := app.pickerPageSize(); got != 10 {
+		t.Errorf("default page size = %d, want 10", got)
+	}
+	app.config.Sessions.PickerPageSize = 25
+	if got := app.pickerPageSize(); got != 25 {
+		t.Errorf("configured page size = %d, want 25", got)
+	}
+}
+
+func TestClaudeArgsPassthrough(t *testing.T) {
+	opts, args, err := parseOptions([]string{"--quiet", "--", "--print", "--quiet", "hello"})
+	if err != nil {
+		t.Fatalf("parseOptions: %v", err)
+	}
+	if !opts.Quiet {
+		t.Error("--quiet before -- was not parsed as a claude-go flag")
+	}
+	if len(args) != 0 {
+		t.Errorf("remaining args = %q, want none", args)
+	}
+
+	app := newTestApp(t)
+	app.opts = opts
+	got := strings.Join(app.claudeArgs("/tmp/mcp.json", nil), " ")
+	if want := "--mcp-config /tmp/mcp.json --print --quiet hello"; got != want {
+		t.Errorf("claude command line = %q, want %q", got, want)
+	}
+
+	// A resumed session's grants come first; user arguments still come last
+	s := &session.Session{Permissions: []session.Permission{{Tool: "Bash"}}}
+	args = app.claudeArgs("/tmp/mcp.json", s)
+	if args[0] != "--allowedTools" || args[len(args)-1] != "hello" {
+		t.Errorf("claude command line with session = %q", args)
+	}
+}
+
+func TestOAuthWaitCancelled(t *testing.T) {
+	defer func(orig func(string) error) { openBrowser = orig }(openBrowser)
+	openBrowser = func(string) error { return nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app := newTestApp(t)
+	app.ctx = ctx
+
+	start := func(ctx context.Context) (*auth.OAuthFlowData, error) {
+		return &auth.OAuthFlowData{AuthURL: "https://example.com/authorize", State: "flow-state"}, nil
+	}
+	complete := func(ctx context.Context, code, codeVerifier string) error {
+		t.Error("complete called without a callback")
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.runOAuthFlow(start, complete) }()
+
+	// Ctrl-C while waiting for the browser ends the wait, well before the
+	// five minute timeout
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("runOAuthFlow = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelling the context didn't end the OAuth wait")
+	}
+}
+
+func TestReadSecretLine(t *testing.T) {
+	tests := []struct {
+		input, want string
+		ok          bool
+	}{
+		{"hunter2\n", "hunter2", true},
+		{"hunter2\r\n", "hunter2", true},
+		{"no newline", "no newline", true},
+		{"  spaces kept  \n", "  spaces kept  ", true},
+		{"\n", "", true},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := readSecretLine(bufio.NewReader(strings.NewReader(tt.input)))
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("readSecretLine(%q) = %q, %v; want %q, ok=%v", tt.input, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPromptPasswordPiped(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal")
+	}
+
+	app := newTestApp(t)
+	app.out = io.Discard
+	// Both prompts of a confirmation read from the same piped input
+	app.stdin = bufio.NewReader(strings.NewReader("correct horse battery\ncorrect horse battery\n"))
+
+	for i := 0; i < 2; i++ {
+		password, err := app.promptPassword("Master password: ", false)
+		if err != nil || password != "correct horse battery" {
+			t.Errorf("prompt %d = %q, %v", i+1, password, err)
+		}
+	}
+	if _, err := app.promptPassword("Master password: ", false); err == nil {
+		t.Error("prompt after the input ran out succeeded")
+	}
+}
+
+func TestSelectMCPProfile(t *testing.T) {
+	app := newTestApp(t)
+	app.config.MCP.Profiles = map[string][]string{"debug": {"debugger"}}
+	m, err := mcp.NewManager(t.TempDir(), t.TempDir(), &app.config.MCP)
+	if err != nil {
+		t.Skip(err)
+	}
+	app.mcpManager = m
+	app.opts.Quiet = true
+
+	s, err := app.sessionManager.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := func() string {
+		t.Helper()
+		loaded, err := app.sessionManager.Load(s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loaded.MCPProfile
+	}
+
+	// --mcp-profile is remembered on the session
+	app.opts.MCPProfile = "debug"
+	if err := app.selectMCPProfile(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := saved(); got != "debug" {
+		t.Errorf("saved profile = %q, want debug", got)
+	}
+
+	// and used when resuming without the flag
+	app.opts.MCPProfile = ""
+	if err := app.selectMCPProfile(s); err != nil || s.MCPProfile != "debug" {
+		t.Errorf("resumed profile = %q, %v; want debug", s.MCPProfile, err)
+	}
+
+	// "all" goes back to every server
+	app.opts.MCPProfile = mcp.AllServersProfile
+	if err := app.selectMCPProfile(s); err != nil {
+		t.Fatal(err)
+	}
+	if got := saved(); got != "" {
+		t.Errorf("saved profile after --mcp-profile all = %q, want none", got)
+	}
+
+	// A remembered profile that was since removed falls back to all servers
+	app.opts.MCPProfile = ""
+	s.MCPProfile = "removed"
+	if err := app.selectMCPProfile(s); err != nil {
+		t.Errorf("stale profile: %v", err)
+	}
+	if got := saved(); got != "" {
+		t.Errorf("saved profile after a stale one = %q, want none", got)
+	}
+
+	app.opts.MCPProfile = "missing"
+	if err := app.selectMCPProfile(s); err == nil {
+		t.Error("an unknown --mcp-profile was accepted")
+	}
+}
@@ -0,0 +1,222 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// runMCPList checks and prints every configured MCP server, resolving
+// $PROJECT_DIR against the current directory
+func (app *App) runMCPList(args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	m, err := app.newMCPManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := m.CheckServers(app.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check MCP servers: %w", err)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	if app.opts.JSON {
+		return printJSON(statuses)
+	}
+
+	if len(statuses) == 0 {
+		fmt.Println("No MCP servers configured")
+		return nil
+	}
+
+	for _, status := range statuses {
+		if status.Disabled {
+			fmt.Printf("  "+markItem+" %s (%s) - disabled\n", status.Name, status.Portability)
+		} else if status.Available {
+			fmt.Printf("  "+markOK+" %s (%s)\n", status.Name, status.Portability)
+		} else {
+			fmt.Printf("  "+markWarn+" %s (%s) - %s\n", status.Name, status.Portability, status.Error)
+			if status.Hint != "" {
+				fmt.Printf("    %s\n", status.Hint)
+			}
+		}
+		if status.OverriddenBy != "" {
+			fmt.Printf("    "+markWarn+" also defined in %s; claude uses the project's definition\n", status.OverriddenBy)
+		}
+	}
+
+	return nil
+}
+
+// runMCPTest performs a real MCP handshake with one server
+func (app *App) runMCPTest(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-go mcp test <name>")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	m, err := app.newMCPManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	result, err := m.TestServer(app.ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+
+	if app.opts.JSON {
+		return printJSON(result)
+	}
+
+	fmt.Printf("  "+markOK+" %s speaks MCP (protocol %s)\n", result.Name, result.ProtocolVersion)
+	if result.ServerName != "" {
+		fmt.Printf("    Server: %s %s\n", result.ServerName, result.ServerVersion)
+	}
+
+	capabilities := make([]string, 0, len(result.Capabilities))
+	for name := range result.Capabilities {
+		capabilities = append(capabilities, name)
+	}
+	sort.Strings(capabilities)
+	if len(capabilities) > 0 {
+		fmt.Printf("    Capabilities: %s\n", strings.Join(capabilities, ", "))
+	}
+
+	return nil
+}
+
+// runMCPAuth runs an MCP server's own OAuth login and stores the tokens
+func (app *App) runMCPAuth(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-go mcp auth <name>")
+	}
+	name := args[0]
+
+	server, ok := app.config.MCP.Servers[name]
+	if !ok {
+		return fmt.Errorf("unknown MCP server: %s", name)
+	}
+	if server.OAuth == nil {
+		return fmt.Errorf("mcp server %s has no oauth settings", name)
+	}
+	cfg := mcpOAuth(server.OAuth)
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nOpening browser to authorize %s...\n", name)
+	err := app.runOAuthFlow(
+		func(ctx context.Context) (*auth.OAuthFlowData, error) {
+			return app.auth.StartMCPOAuthFlow(cfg)
+		},
+		func(ctx context.Context, code, codeVerifier string) error {
+			return app.auth.CompleteMCPOAuthFlow(ctx, name, cfg, code, codeVerifier)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf(markOK+" %s authorized\n", name)
+	return nil
+}
+
+// applyMCPTokens passes the OAuth tokens of servers with their own login to
+// the MCP manager, refreshing them as needed. A server that can't get a
+// token is launched without one and will ask for auth itself.
+func (app *App) applyMCPTokens() {
+	if app.auth == nil {
+		return
+	}
+
+	for name, server := range app.config.MCP.Servers {
+		if server.OAuth == nil {
+			continue
+		}
+
+		token, err := app.auth.MCPAccessToken(app.ctx, name, mcpOAuth(server.OAuth))
+		if err != nil {
+			fmt.Printf("  "+markWarn+" %v\n", err)
+			continue
+		}
+		app.mcpManager.SetAccessToken(name, token)
+	}
+}
+
+func mcpOAuth(cfg *config.MCPOAuthConfig) auth.MCPOAuth {
+	return auth.MCPOAuth{
+		AuthorizationURL: cfg.AuthorizationURL,
+		TokenURL:         cfg.TokenURL,
+		ClientID:         cfg.ClientID,
+		Scopes:           cfg.Scopes,
+	}
+}
+
+// runMCPDisable keeps a server configured but leaves it out of launches
+func (app *App) runMCPDisable(args []string) error {
+	return app.setMCPServerEnabled("disable", args, false)
+}
+
+// runMCPEnable undoes runMCPDisable
+func (app *App) runMCPEnable(args []string) error {
+	return app.setMCPServerEnabled("enable", args, true)
+}
+
+// setMCPServerEnabled sets a USB server's "enabled" and saves the config.
+// Enabling removes the field, so the server reads as it did before.
+func (app *App) setMCPServerEnabled(command string, args []string, enabled bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-go mcp %s <name>", command)
+	}
+	name := args[0]
+
+	server, ok := app.config.MCP.Servers[name]
+	if !ok {
+		return fmt.Errorf("unknown MCP server: %s", name)
+	}
+
+	state := "enabled"
+	if !enabled {
+		state = "disabled"
+	}
+	if server.IsEnabled() == enabled {
+		fmt.Fprintf(app.out, markOK+" %s is already %s\n", name, state)
+		return nil
+	}
+
+	if enabled {
+		server.Enabled = nil
+	} else {
+		server.Enabled = &enabled
+	}
+	app.config.MCP.Servers[name] = server
+
+	if err := app.config.Save(app.configPath()); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Fprintf(app.out, markOK+" %s %s\n", name, state)
+	if !enabled && server.Required {
+		fmt.Fprintf(app.out, "  %s is required, but won't block launches while disabled\n", name)
+	}
+	return nil
+}
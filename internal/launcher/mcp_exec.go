@@ -0,0 +1,67 @@
+package launcher
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/mcp"
+)
+
+// runMCPExec runs a stdio MCP server on claude's behalf with its stderr
+// copied to a log; see mcp.ExecCommand. stdin and stdout are the server's
+// own, and signals from claude are passed on. A log that can't be opened
+// doesn't stop the server.
+func runMCPExec(ctx context.Context, args, command []string) error {
+	fs := flag.NewFlagSet(mcp.ExecCommand, flag.ContinueOnError)
+	logPath := fs.String("log", "", "file to copy the server's stderr to")
+	maxBytes := fs.Int64("max-bytes", 0, "rotate the log beyond this size (0 for no limit)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *logPath == "" || len(command) == 0 {
+		return fmt.Errorf("usage: claude-go %s --log FILE [--max-bytes N] -- command [args...]", mcp.ExecCommand)
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := os.MkdirAll(filepath.Dir(*logPath), 0700); err == nil {
+		if logw, err := fsutil.OpenLogWriter(*logPath, *maxBytes, nil); err == nil {
+			defer logw.Close()
+			cmd.Stderr = io.MultiWriter(os.Stderr, logw)
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start MCP server: %w", err)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		for {
+			select {
+			case sig := <-sigCh:
+				if cmd.Process.Signal(sig) != nil {
+					cmd.Process.Kill()
+				}
+			case <-ctx.Done():
+				cmd.Process.Kill()
+				return
+			}
+		}
+	}()
+
+	return childExit(cmd.Wait())
+}
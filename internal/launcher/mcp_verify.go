@@ -0,0 +1,182 @@
+package launcher
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/mcp"
+	"github.com/cxt9/claude-go/internal/update"
+)
+
+// runMCPVerify checks bundled and usb-local server binaries against their
+// recorded SHA256s. --record trusts the current usb-local binaries from
+// now on; --repair restores damaged bundled ones from the release bundle.
+func (app *App) runMCPVerify(args []string) error {
+	fs := flag.NewFlagSet("mcp verify", flag.ContinueOnError)
+	record := fs.Bool("record", false, "record the current hash of usb-local binaries outside mcp/bundled/")
+	repair := fs.Bool("repair", false, "restore damaged or missing bundled binaries from the installed release's bundle")
+	bundle := fs.String("bundle", "", "with --repair, restore from this .zip or .tar.gz bundle instead of downloading it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *bundle != "" && !*repair {
+		return fmt.Errorf("--bundle is only used with --repair")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	m, err := app.newMCPManager(cwd)
+	if err != nil {
+		return err
+	}
+
+	checks, err := m.VerifyBinaries()
+	if err != nil {
+		return err
+	}
+
+	if *record {
+		recorded, err := m.RecordChecksums(checks)
+		if err != nil {
+			return err
+		}
+		for _, path := range recorded {
+			fmt.Fprintf(app.out, markOK+" Recorded %s\n", path)
+		}
+	}
+	if *repair {
+		if err := app.repairBinaries(checks, *bundle); err != nil {
+			return err
+		}
+	}
+	if *record || *repair {
+		if checks, err = m.VerifyBinaries(); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	for _, check := range checks {
+		if check.Failed() {
+			failed++
+		}
+	}
+
+	if app.opts.JSON {
+		if err := printJSON(checks); err != nil {
+			return err
+		}
+	} else {
+		printBinaryChecks(checks)
+	}
+
+	if failed > 0 && *repair {
+		return fmt.Errorf("%d MCP server binary(s) still damaged or missing", failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d MCP server binary(s) damaged or missing (run 'claude-go mcp verify --repair')", failed)
+	}
+	return nil
+}
+
+func printBinaryChecks(checks []mcp.BinaryCheck) {
+	if len(checks) == 0 {
+		fmt.Println("No bundled or usb-local MCP servers configured")
+		return
+	}
+
+	for _, check := range checks {
+		switch check.Status {
+		case mcp.BinaryOK:
+			fmt.Printf("  "+markOK+" %s: %s\n", check.Server, check.Path)
+		case mcp.BinaryMismatch:
+			fmt.Printf("  "+markFail+" %s: %s does not match its recorded SHA256\n", check.Server, check.Path)
+		case mcp.BinaryMissing:
+			fmt.Printf("  "+markFail+" %s: %s is missing\n", check.Server, check.Path)
+		case mcp.BinaryUnrecorded:
+			fmt.Printf("  "+markWarn+" %s: %s has no recorded SHA256\n", check.Server, check.Path)
+		case mcp.BinaryOffUSB:
+			fmt.Printf("  "+markItem+" %s: %s is not on the USB; not checked\n", check.Server, check.Path)
+		}
+	}
+}
+
+// repairBinaries restores the damaged bundled binaries among checks from a
+// release bundle, downloading the installed version's if none is given.
+// Each restored file must match its recorded hash.
+func (app *App) repairBinaries(checks []mcp.BinaryCheck, bundle string) error {
+	var damaged []mcp.BinaryCheck
+	var names []string
+	for _, check := range checks {
+		switch {
+		case !check.Failed():
+		case !strings.HasPrefix(check.Path, mcp.BundledDir+"/"):
+			fmt.Fprintf(app.out, markWarn+" %s is not part of the release; reinstall it by hand\n", check.Path)
+		case check.Expected == "":
+			fmt.Fprintf(app.out, markWarn+" %s has no recorded SHA256 to restore it by\n", check.Path)
+		default:
+			damaged = append(damaged, check)
+			names = append(names, check.Path)
+		}
+	}
+	if len(damaged) == 0 {
+		return nil
+	}
+
+	if bundle == "" {
+		updater, err := update.NewUpdater(app.usbRoot)
+		if err != nil {
+			return err
+		}
+		if err := updater.SetPinnedKeys(app.config.Updates.PinnedKeys); err != nil {
+			return fmt.Errorf("updates.pinned_keys: %w", err)
+		}
+
+		bundle, err = updater.DownloadInstalled(app.ctx, func(downloaded, total int64) {
+			if total > 0 {
+				fmt.Fprintf(app.out, "\rDownloading... %d%%", downloaded*100/total)
+			}
+		})
+		fmt.Fprintln(app.out)
+		if err != nil {
+			return fmt.Errorf("cannot repair: %w (pass --bundle)", err)
+		}
+		defer os.Remove(bundle)
+	}
+
+	// Extract beside the files, so they can be renamed into place
+	staging, err := os.MkdirTemp(app.usbRoot, ".repair-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := update.ExtractFiles(bundle, staging, names); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", bundle, err)
+	}
+
+	for _, check := range damaged {
+		staged := filepath.Join(staging, filepath.FromSlash(check.Path))
+		sum, _, err := fsutil.SHA256File(staged)
+		if err != nil || sum != check.Expected {
+			fmt.Fprintf(app.out, markWarn+" The bundle has no matching copy of %s; not restored\n", check.Path)
+			continue
+		}
+
+		dest := filepath.Join(app.usbRoot, filepath.FromSlash(check.Path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", check.Path, err)
+		}
+		if err := fsutil.Rename(staged, dest); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", check.Path, err)
+		}
+		fmt.Fprintf(app.out, markOK+" Restored %s\n", check.Path)
+	}
+	return nil
+}
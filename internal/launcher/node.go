@@ -0,0 +1,101 @@
+package launcher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minNodeMajor is the oldest Node.js major version Claude Code runs on
+const minNodeMajor = 18
+
+// nodeStatus is the result of checking the Node.js runtime
+type nodeStatus struct {
+	Path    string
+	Version string
+	Err     error
+}
+
+// nodeBinary returns the bundled node if present, else node from PATH
+func (app *App) nodeBinary() (string, error) {
+	nodeDir := filepath.Join(app.usbRoot, "bin", string(app.platform), "node")
+	for _, path := range []string{
+		filepath.Join(nodeDir, "bin", app.platform.BinaryName("node")),
+		filepath.Join(nodeDir, app.platform.BinaryName("node")), // Windows zip layout
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return exec.LookPath("node")
+}
+
+// checkNode locates node and checks its version, once per run
+func (app *App) checkNode() *nodeStatus {
+	if app.node != nil {
+		return app.node
+	}
+
+	status := &nodeStatus{}
+	app.node = status
+
+	path, err := app.nodeBinary()
+	if err != nil {
+		status.Err = fmt.Errorf("node not found on USB or in PATH")
+		return status
+	}
+	status.Path = path
+
+	ctx, cancel := context.WithTimeout(app.ctx, 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		status.Err = fmt.Errorf("failed to run %s --version: %w", path, err)
+		return status
+	}
+	status.Version = strings.TrimSpace(string(out))
+
+	major, err := parseNodeMajor(status.Version)
+	if err != nil {
+		status.Err = err
+	} else if major < minNodeMajor {
+		status.Err = fmt.Errorf("node %s is too old, Claude Code needs v%d or newer", status.Version, minNodeMajor)
+	}
+
+	return status
+}
+
+// parseNodeMajor extracts the major version from "v20.11.1"
+func parseNodeMajor(version string) (int, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	majorStr, _, _ := strings.Cut(v, ".")
+
+	major, err := strconv.Atoi(majorStr)
+	if err != nil {
+		return 0, fmt.Errorf("unrecognized node version: %q", version)
+	}
+	return major, nil
+}
+
+// requireNode warns about a missing or outdated node, or fails with
+// --strict-runtime
+func (app *App) requireNode() error {
+	status := app.checkNode()
+	if status.Err == nil {
+		return nil
+	}
+
+	if app.opts.StrictRuntime {
+		return status.Err
+	}
+
+	fmt.Printf(markWarn+" %v; Claude Code may fail to start\n", status.Err)
+	return nil
+}
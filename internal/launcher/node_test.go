@@ -0,0 +1,50 @@
+package launcher
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseNodeMajor(t *testing.T) {
+	tests := []struct {
+		version string
+		major   int
+		ok      bool
+	}{
+		{"v20.11.1", 20, true},
+		{"v18.0.0\n", 18, true},
+		{"22.1.0", 22, true},
+		{"v16", 16, true},
+		{"", 0, false},
+		{"node", 0, false},
+		{"vx.1.2", 0, false},
+	}
+
+	for _, tt := range tests {
+		major, err := parseNodeMajor(tt.version)
+		if (err == nil) != tt.ok || major != tt.major {
+			t.Errorf("parseNodeMajor(%q) = %d, %v; want %d, ok=%v", tt.version, major, err, tt.major, tt.ok)
+		}
+	}
+}
+
+func TestRequireNode(t *testing.T) {
+	tooOld := errors.New("node v16.20.0 is too old")
+
+	app := newTestApp(t)
+	app.node = &nodeStatus{Path: "/usr/bin/node", Version: "v16.20.0", Err: tooOld}
+
+	// The result is cached for the run, so node isn't run again
+	if err := app.requireNode(); err != nil {
+		t.Errorf("requireNode without --strict-runtime = %v, want only a warning", err)
+	}
+	app.opts.StrictRuntime = true
+	if err := app.requireNode(); !errors.Is(err, tooOld) {
+		t.Errorf("requireNode with --strict-runtime = %v, want %v", err, tooOld)
+	}
+
+	app.node = &nodeStatus{Path: "/usr/bin/node", Version: "v20.11.1"}
+	if err := app.requireNode(); err != nil {
+		t.Errorf("requireNode with a current node = %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package launcher
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/session"
+)
+
+// Options holds the global flags, which must precede any subcommand
+type Options struct {
+	// Re-probe MCP servers instead of using cached availability
+	Refresh bool
+
+	// Launch even when required MCP servers are unavailable
+	IgnoreRequiredMCP bool
+
+	// Emit machine-readable JSON from commands that support it
+	JSON bool
+
+	// Plain text without the banner, symbols or per-server MCP lines
+	Quiet bool
+
+	// Tee claude's output to the session log
+	LogChild bool
+
+	// Capture stdio MCP servers' stderr in cache/mcp-logs
+	MCPLogs bool
+
+	// Launch with credentials from the environment instead of the vault
+	NoVault bool
+
+	// Refuse to launch when node is missing or too old
+	StrictRuntime bool
+
+	// Restrict group/world-accessible files under vault/, config/ and sessions/
+	FixPermissions bool
+
+	// Use the vault, sessions, config and cache under profiles/<name>
+	Profile string
+
+	// Directory holding vault/, sessions/, config/, cache/ and profiles/
+	// when it isn't the USB root
+	DataRoot string
+
+	// Always create a new session instead of continuing a recent one
+	NewSession bool
+
+	// Only offer sessions with this tag in the picker
+	Tag string
+
+	// The mcp.profiles entry to launch with; remembered by the session
+	MCPProfile string
+
+	// Model to launch claude with; remembered by the session
+	Model string
+
+	// Anthropic-compatible API endpoint overriding environment.base_url
+	BaseURL string
+
+	// Run from a fixed disk despite vault.require_removable
+	AllowFixedDisk bool
+
+	// Arguments after "--", appended to the claude command line
+	ClaudeArgs []string
+}
+
+// parseOptions parses global flags and returns the remaining arguments.
+// Everything after the first "--" is passed to claude untouched, even if it
+// looks like a claude-go flag.
+func parseOptions(args []string) (*Options, []string, error) {
+	opts := &Options{}
+
+	for i, arg := range args {
+		if arg == "--" {
+			opts.ClaudeArgs = append([]string(nil), args[i+1:]...)
+			args = args[:i]
+			break
+		}
+	}
+
+	fs := flag.NewFlagSet("claude-go", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: claude-go [flags] [command] [-- claude args...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&opts.Profile, "profile", "", "use the separate vault, sessions and config of profiles/<name>")
+	fs.StringVar(&opts.DataRoot, "data-root", os.Getenv(dataRootEnv), "keep vault/, sessions/, config/ and cache/ under this directory instead of the USB root (default $"+dataRootEnv+")")
+	fs.BoolVar(&opts.Refresh, "refresh", false, "re-check MCP servers, ignoring cached availability")
+	fs.BoolVar(&opts.Quiet, "quiet", false, "plain output: no banner or symbols, and MCP status on one line")
+	fs.BoolVar(&opts.JSON, "json", false, "emit JSON from list/check commands, and errors as JSON on stderr")
+	fs.BoolVar(&opts.NoVault, "no-vault", false, "launch with ANTHROPIC_API_KEY (or other provider variables) from the environment, without a vault")
+	fs.BoolVar(&opts.FixPermissions, "fix-permissions", false, "restrict vault, config and session files readable by other users")
+	fs.BoolVar(&opts.StrictRuntime, "strict-runtime", false, "fail instead of warning when node is missing or too old")
+	fs.BoolVar(&opts.LogChild, "log-child", false, "copy claude's output to sessions/<id>.log")
+	fs.BoolVar(&opts.MCPLogs, "mcp-logs", false, "copy stdio MCP servers' stderr to cache/mcp-logs/<name>.log")
+	fs.BoolVar(&opts.NewSession, "new", false, "start a new session even if this project has a recent one")
+	fs.StringVar(&opts.Tag, "tag", "", "only offer sessions with this tag in the session picker")
+	fs.StringVar(&opts.MCPProfile, "mcp-profile", "", "launch with the MCP servers of this mcp.profiles entry (\"all\" for every server)")
+	fs.StringVar(&opts.Model, "model", "", "launch claude with this model instead of environment.default_model; the session remembers it")
+	fs.StringVar(&opts.BaseURL, "base-url", "", "send claude's API traffic to this Anthropic-compatible gateway (ANTHROPIC_BASE_URL)")
+	fs.BoolVar(&opts.AllowFixedDisk, "allow-fixed-disk", false, "run from a fixed disk even if vault.require_removable is set")
+	fs.BoolVar(&opts.IgnoreRequiredMCP, "ignore-required-mcp", false, "launch even if required MCP servers are unavailable")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if opts.Model != "" {
+		if err := session.ValidateModel(opts.Model); err != nil {
+			return nil, nil, fmt.Errorf("--model: %w", err)
+		}
+	}
+	if opts.BaseURL != "" {
+		if err := config.ValidateBaseURL(opts.BaseURL); err != nil {
+			return nil, nil, fmt.Errorf("--base-url: %w", err)
+		}
+	}
+
+	return opts, fs.Args(), nil
+}
@@ -0,0 +1,80 @@
+package launcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// jsonError marks an error raised in --json mode so it is reported as JSON
+type jsonError struct {
+	err error
+}
+
+func (e *jsonError) Error() string { return e.err.Error() }
+func (e *jsonError) Unwrap() error { return e.err }
+
+// ReportError writes an error returned by Run to w, as {"error": "..."} when
+// it was raised in --json mode and as plain text otherwise
+func ReportError(w io.Writer, err error) {
+	// claude has already said why it failed
+	var child *childExitError
+	if errors.As(err, &child) {
+		return
+	}
+
+	var je *jsonError
+	if errors.As(err, &je) {
+		json.NewEncoder(w).Encode(map[string]string{"error": je.err.Error()})
+		return
+	}
+	fmt.Fprintf(w, "Error: %v\n", err)
+}
+
+// Status marks in terminal output. Plain output replaces them with words
+// that read well in logs and screen readers.
+var (
+	markOK   = "✓"
+	markWarn = "⚠"
+	markFail = "✗"
+	markItem = "•"
+)
+
+// plainOutput reports whether output should be plain text without the
+// banner or symbols: with --quiet or NO_COLOR, or when stdout isn't a
+// terminal
+func plainOutput(opts *Options) bool {
+	if opts.Quiet || os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	return !term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// printBanner prints the startup banner, unless output is plain or JSON
+func printBanner(w io.Writer, opts *Options, plain bool) {
+	if !opts.JSON && !plain {
+		fmt.Fprint(w, banner)
+	}
+}
+
+// usePlainMarks switches the status marks to plain text
+func usePlainMarks() {
+	markOK = "OK:"
+	markWarn = "Warning:"
+	markFail = "FAIL:"
+	markItem = "-"
+}
+
+// jsonOut receives --json output; replaced in tests
+var jsonOut io.Writer = os.Stdout
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(jsonOut)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
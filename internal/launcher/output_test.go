@@ -0,0 +1,217 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/mcp"
+	"github.com/cxt9/claude-go/internal/vault"
+	"golang.org/x/term"
+)
+
+// captureJSON runs fn in --json mode and returns what it printed, decoded
+func captureJSON(t *testing.T, app *App, fn func() error) interface{} {
+	t.Helper()
+
+	var buf bytes.Buffer
+	defer func(orig io.Writer) { jsonOut = orig }(jsonOut)
+	jsonOut = &buf
+
+	app.opts.JSON = true
+	// A command may report failures after printing them
+	fn()
+
+	var v interface{}
+	if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+		t.Fatalf("output isn't JSON: %v\n%s", err, buf.Bytes())
+	}
+	return v
+}
+
+// checkFields fails unless each object in list has every key in fields
+func checkFields(t *testing.T, what string, list interface{}, fields ...string) {
+	t.Helper()
+
+	items, ok := list.([]interface{})
+	if !ok || len(items) == 0 {
+		t.Fatalf("%s: got %v, want a non-empty list", what, list)
+	}
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: item %v isn't an object", what, item)
+		}
+		for _, field := range fields {
+			if _, ok := obj[field]; !ok {
+				t.Errorf("%s: item %v lacks %q", what, obj, field)
+			}
+		}
+	}
+}
+
+func TestJSONOutputSchemas(t *testing.T) {
+	app := newTestApp(t)
+	app.ctx = context.Background()
+	app.out = io.Discard
+	app.usbRoot = t.TempDir()
+
+	t.Run("sessions list", func(t *testing.T) {
+		if _, err := app.sessionManager.Create(t.TempDir()); err != nil {
+			t.Fatal(err)
+		}
+		out := captureJSON(t, app, func() error { return app.runSessionsList(nil) })
+		checkFields(t, "sessions list", out, "id", "project", "created_at", "last_used_at")
+	})
+
+	t.Run("mcp list", func(t *testing.T) {
+		app.config.MCP.Servers = map[string]config.MCPServer{
+			"missing": {Portability: "usb-local", Type: "stdio", Command: filepath.Join(app.usbRoot, "missing")},
+		}
+		out := captureJSON(t, app, func() error { return app.runMCPList(nil) })
+		checkFields(t, "mcp list", out, "name", "portability", "available", "required")
+	})
+
+	t.Run("doctor", func(t *testing.T) {
+		out := captureJSON(t, app, func() error { return app.runDoctor(nil) })
+		obj, ok := out.(map[string]interface{})
+		if !ok {
+			t.Fatalf("doctor: got %v, want an object", out)
+		}
+		checkFields(t, "doctor", obj["checks"], "name", "ok")
+	})
+
+	t.Run("auth list", func(t *testing.T) {
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			t.Skip("the password prompt would read the terminal")
+		}
+		createTestVault(t, app, "correct horse battery")
+		v, _ := vault.Open(app.vaultPath())
+		if err := v.Unlock("correct horse battery"); err != nil {
+			t.Fatal(err)
+		}
+		if err := auth.NewAuthenticator(v).SetAPIKey(auth.ProviderConsole, "sk-ant-test"); err != nil {
+			t.Fatal(err)
+		}
+		v.Lock()
+
+		app.stdin = bufio.NewReader(strings.NewReader("correct horse battery\n"))
+		out := captureJSON(t, app, func() error { return app.runAuthList(nil) })
+		defer app.vault.Lock()
+		checkFields(t, "auth list", out, "provider", "type", "created_at", "updated_at")
+		if strings.Contains(string(mustMarshal(t, out)), "sk-ant-test") {
+			t.Error("auth list printed the secret")
+		}
+	})
+}
+
+func TestReportErrorJSON(t *testing.T) {
+	var buf bytes.Buffer
+	ReportError(&buf, &jsonError{errors.New("no vault")})
+
+	var body map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &body); err != nil || body["error"] != "no vault" {
+		t.Errorf("ReportError = %q, want {\"error\": \"no vault\"}", buf.String())
+	}
+
+	buf.Reset()
+	ReportError(&buf, errors.New("no vault"))
+	if buf.String() != "Error: no vault\n" {
+		t.Errorf("ReportError = %q, want plain text", buf.String())
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(orig *os.File) { os.Stdout = orig }(os.Stdout)
+	os.Stdout = w
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestQuietOutput(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	if !plainOutput(&Options{Quiet: true}) {
+		t.Error("--quiet output isn't plain")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if !plainOutput(&Options{}) {
+		t.Error("NO_COLOR output isn't plain")
+	}
+
+	var buf bytes.Buffer
+	printBanner(&buf, &Options{}, false)
+	if buf.Len() == 0 {
+		t.Error("no banner on a terminal")
+	}
+	for _, opts := range []*Options{{Quiet: true}, {JSON: true}} {
+		buf.Reset()
+		printBanner(&buf, opts, opts.Quiet)
+		if buf.Len() != 0 {
+			t.Errorf("banner printed with %+v", opts)
+		}
+	}
+
+	// Plain marks are words, and the MCP check is a single line
+	defer func(ok, warn, fail, item string) {
+		markOK, markWarn, markFail, markItem = ok, warn, fail, item
+	}(markOK, markWarn, markFail, markItem)
+	usePlainMarks()
+
+	app := newTestApp(t)
+	m, err := mcp.NewManager(t.TempDir(), t.TempDir(), &config.MCPConfig{})
+	if err != nil {
+		t.Skip(err)
+	}
+	app.mcpManager = m
+	available := map[string]config.MCPServer{"fetch": {}, "git": {}}
+	unavailable := []mcp.ServerStatus{{Name: "tracker", Portability: "remote", Error: "unreachable"}}
+
+	app.opts.Quiet = true
+	out := captureStdout(t, func() { app.printMCPStatus(available, unavailable) })
+	if want := "MCP servers: 2 available, 1 unavailable (tracker)\n"; out != want {
+		t.Errorf("quiet MCP status = %q, want %q", out, want)
+	}
+
+	app.opts.Quiet = false
+	out = captureStdout(t, func() { app.printMCPStatus(available, unavailable) })
+	if strings.Count(out, "\n") != 3 || !strings.Contains(out, "Warning: tracker") {
+		t.Errorf("MCP status = %q, want a plain line per server", out)
+	}
+	if strings.ContainsAny(out, "✓⚠✗•") {
+		t.Errorf("plain MCP status has symbols: %q", out)
+	}
+}
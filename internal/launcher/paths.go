@@ -0,0 +1,121 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/session"
+)
+
+// expandPath turns a path typed by the user into a clean absolute path:
+// a leading ~ or ~user becomes that home directory, $VAR and ${VAR} are
+// replaced from the environment (see expandVars), and a relative path is
+// taken from the current directory. A path in another OS's format is an error rather than
+// a relative name.
+func expandPath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("no path entered")
+	}
+	if err := session.CheckPathFormat(path); err != nil {
+		return "", err
+	}
+
+	path, err := expandTilde(path)
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(expandVars(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+	return abs, nil
+}
+
+// expandTilde replaces a leading ~ (the current user's home) or ~name
+// (that user's home)
+func expandTilde(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexAny(name, `/`+string(filepath.Separator)); i >= 0 {
+		name, rest = name[:i], name[i+1:]
+	}
+
+	var home string
+	if name == "" {
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		home = dir
+	} else {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("cannot expand ~%s: no such user on this computer", name)
+		}
+		home = u.HomeDir
+	}
+
+	return filepath.Join(home, rest), nil
+}
+
+// expandVars replaces $NAME and ${NAME} with the environment variables
+// that are set. Anything else is kept as typed, since $ is valid in file
+// names on every OS; $$ is a literal $ for a name that would be expanded.
+func expandVars(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '$' || i+1 == len(path) {
+			b.WriteByte(path[i])
+			continue
+		}
+
+		if path[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		name, end := "", i+1
+		if path[i+1] == '{' {
+			if j := strings.IndexByte(path[i+2:], '}'); j >= 0 && isEnvName(path[i+2:i+2+j]) {
+				name, end = path[i+2:i+2+j], i+3+j
+			}
+		} else {
+			for end < len(path) && isEnvNameChar(path[end], end == i+1) {
+				end++
+			}
+			name = path[i+1 : end]
+		}
+
+		value, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			b.WriteByte('$')
+			continue
+		}
+		b.WriteString(value)
+		i = end - 1
+	}
+	return b.String()
+}
+
+// isEnvName reports whether name can be an environment variable in a path
+func isEnvName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if !isEnvNameChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func isEnvNameChar(c byte, first bool) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || (!first && '0' <= c && c <= '9')
+}
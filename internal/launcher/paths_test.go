@@ -0,0 +1,125 @@
+package launcher
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/session"
+)
+
+func TestExpandPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix paths")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PROJECTS", "/srv/projects")
+	os.Unsetenv("CLAUDE_GO_UNSET")
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"~", home},
+		{"~/", home},
+		{"~/sub/dir", filepath.Join(home, "sub/dir")},
+		{"  ~/padded  ", filepath.Join(home, "padded")},
+		{"$HOME/x", filepath.Join(home, "x")},
+		{"${HOME}/x", filepath.Join(home, "x")},
+		{"$PROJECTS/app", "/srv/projects/app"},
+		{"${PROJECTS}app", "/srv/projectsapp"},
+		{"relative/dir", filepath.Join(cwd, "relative/dir")},
+		{"/a/../b/./c", "/b/c"},
+
+		// A $ that isn't a set variable is part of the name
+		{"/tmp/price$5", "/tmp/price$5"},
+		{"/tmp/$CLAUDE_GO_UNSET/x", "/tmp/$CLAUDE_GO_UNSET/x"},
+		{"/tmp/${CLAUDE_GO_UNSET}", "/tmp/${CLAUDE_GO_UNSET}"},
+		{"/tmp/${not a name}", "/tmp/${not a name}"},
+		{"/tmp/${HOME", "/tmp/${HOME"},
+		{"/tmp/trailing$", "/tmp/trailing$"},
+		{"/tmp/$$HOME", "/tmp/$HOME"},
+		{"/tmp/a$$b", "/tmp/a$b"},
+	}
+
+	for _, tt := range tests {
+		got, err := expandPath(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("expandPath(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpandPathErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix paths")
+	}
+
+	for _, in := range []string{"", "   ", "~claude-go-no-such-user/x"} {
+		if got, err := expandPath(in); err == nil {
+			t.Errorf("expandPath(%q) = %q, want an error", in, got)
+		}
+	}
+
+	if _, err := expandPath(`C:\Users\me\project`); !errors.Is(err, session.ErrForeignPath) {
+		t.Errorf("Windows path: err = %v, want ErrForeignPath", err)
+	}
+}
+
+func TestCheckProjectPathParanoid(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs extra privileges on Windows")
+	}
+
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := filepath.Join(base, "allowed")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(allowed, "project"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inLink := filepath.Join(base, "in-link")
+	outLink := filepath.Join(allowed, "out-link")
+	if err := os.Symlink(filepath.Join(allowed, "project"), inLink); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, outLink); err != nil {
+		t.Fatal(err)
+	}
+
+	app := newTestApp(t)
+	app.config.Environment.AllowedProjectRoots = []string{allowed}
+
+	// Without paranoid mode, any readable directory will do
+	for _, path := range []string{inLink, outLink, outside} {
+		if err := app.checkProjectPath(path); err != nil {
+			t.Errorf("checkProjectPath(%s) = %v", path, err)
+		}
+	}
+
+	app.config.Environment.ParanoidMode = true
+	if err := app.checkProjectPath(inLink); err != nil {
+		t.Errorf("symlink into an allowed root: %v", err)
+	}
+	if err := app.checkProjectPath(outLink); err == nil {
+		t.Error("symlink out of the allowed roots was accepted")
+	}
+	// A real path isn't a symlink, wherever it is
+	if err := app.checkProjectPath(outside); err != nil {
+		t.Errorf("plain directory: %v", err)
+	}
+	if err := app.checkProjectPath(filepath.Join(base, "missing")); err == nil {
+		t.Error("missing path was accepted")
+	}
+}
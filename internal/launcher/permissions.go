@@ -0,0 +1,64 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// scaffoldDirs are the data directories a new install starts with
+var scaffoldDirs = []string{"config", "sessions", "cache", "vault"}
+
+// createScaffold creates the data directories, private to the user, before
+// setup writes anything, so setup failing halfway still leaves the layout
+// later launches expect
+func (app *App) createScaffold() error {
+	for _, name := range scaffoldDirs {
+		dir := app.dataDir(name)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+		// MkdirAll leaves an existing directory's mode as it was. FAT and
+		// exFAT sticks have no modes to set, and refuse chmod.
+		if err := os.Chmod(dir, 0700); err != nil && fsutil.StoresPermissions(dir) {
+			return fmt.Errorf("failed to restrict %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// privateDirs are the USB directories holding credentials or history
+func (app *App) privateDirs() []string {
+	return []string{
+		app.dataDir("vault"),
+		app.dataDir("config"),
+		app.dataDir("sessions"),
+	}
+}
+
+// auditPermissions warns about private files other users can read, fixing
+// them with --fix-permissions
+func (app *App) auditPermissions() {
+	issues, err := fsutil.AuditPrivate(app.opts.FixPermissions, app.privateDirs()...)
+	if err != nil {
+		fmt.Fprintf(app.out, markWarn+" Permission check failed: %v\n", err)
+	}
+	if len(issues) == 0 {
+		return
+	}
+
+	unfixed := 0
+	for _, issue := range issues {
+		if issue.Fixed {
+			fmt.Fprintf(app.out, markOK+" Restricted permissions on %s (was %04o)\n", issue.Path, issue.Mode)
+			continue
+		}
+		unfixed++
+		fmt.Fprintf(app.out, markWarn+" %s is accessible by other users (%04o)\n", issue.Path, issue.Mode)
+	}
+	if unfixed > 0 {
+		fmt.Fprintln(app.out, "  Run with --fix-permissions to restrict them")
+	}
+	fmt.Fprintln(app.out)
+}
@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+func TestCreateScaffold(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory modes don't reflect ACLs on Windows")
+	}
+
+	app := &App{profileRoot: t.TempDir()}
+
+	// An existing directory is restricted too
+	if err := os.Mkdir(app.dataDir("config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.createScaffold(); err != nil {
+		t.Fatal(err)
+	}
+	// Running again over the finished layout is harmless
+	if err := app.createScaffold(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range scaffoldDirs {
+		info, err := os.Stat(app.dataDir(name))
+		if err != nil {
+			t.Fatalf("%s not created: %v", name, err)
+		}
+		if !info.IsDir() {
+			t.Errorf("%s is not a directory", name)
+		}
+		if mode := info.Mode().Perm(); mode != 0700 {
+			t.Errorf("%s has mode %04o, want 0700", name, mode)
+		}
+	}
+}
+
+func TestAuditPermissionsWarns(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes don't reflect ACLs on Windows")
+	}
+
+	var out bytes.Buffer
+	app := newTestApp(t)
+	app.out = &out
+	if err := app.createScaffold(); err != nil {
+		t.Fatal(err)
+	}
+	if !fsutil.StoresPermissions(app.dataDir("vault")) {
+		t.Skip("the temp directory doesn't store permissions")
+	}
+
+	// As copied by a tool that reset the mode
+	if err := os.WriteFile(app.vaultPath(), []byte("vault"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app.auditPermissions()
+	if !strings.Contains(out.String(), app.vaultPath()) || !strings.Contains(out.String(), "--fix-permissions") {
+		t.Errorf("no warning about the vault:\n%s", out.String())
+	}
+	if info, _ := os.Stat(app.vaultPath()); info.Mode().Perm() != 0644 {
+		t.Errorf("the vault's mode changed to %04o without --fix-permissions", info.Mode().Perm())
+	}
+
+	out.Reset()
+	app.opts.FixPermissions = true
+	app.auditPermissions()
+	if info, _ := os.Stat(app.vaultPath()); info.Mode().Perm() != 0600 {
+		t.Errorf("vault mode = %04o after fixing, want 0600", info.Mode().Perm())
+	}
+	if !strings.Contains(out.String(), "Restricted permissions") {
+		t.Errorf("the fix wasn't reported:\n%s", out.String())
+	}
+}
+
+func TestFixPermissionsOnlyAtLaunch(t *testing.T) {
+	opts := &Options{DataRoot: t.TempDir(), FixPermissions: true, Quiet: true}
+
+	var usage *usageError
+	if err := run(context.Background(), opts, []string{"sessions", "list"}); !errors.As(err, &usage) {
+		t.Errorf("--fix-permissions with sessions list: err = %v, want a usage error", err)
+	}
+}
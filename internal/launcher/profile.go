@@ -0,0 +1,29 @@
+package launcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// profileNamePattern keeps profile names usable as a directory on any
+// filesystem the USB might be formatted with
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// profileRoot returns the directory holding a profile's vault/, sessions/,
+// config/ and cache/. The default profile (no name) uses the data root
+// itself, so existing installs keep working.
+func profileRoot(dataRoot, name string) (string, error) {
+	if name == "" {
+		return dataRoot, nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use up to 32 lowercase letters, digits, '-' or '_'", name)
+	}
+	return filepath.Join(dataRoot, "profiles", name), nil
+}
+
+// dataDir returns a per-profile directory such as "sessions"
+func (app *App) dataDir(name string) string {
+	return filepath.Join(app.profileRoot, name)
+}
@@ -0,0 +1,75 @@
+package launcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestProfileRoot(t *testing.T) {
+	dataRoot := t.TempDir()
+
+	root, err := profileRoot(dataRoot, "")
+	if err != nil || root != dataRoot {
+		t.Errorf("default profile = %q, %v; want the data root", root, err)
+	}
+	root, err = profileRoot(dataRoot, "work")
+	if want := filepath.Join(dataRoot, "profiles", "work"); err != nil || root != want {
+		t.Errorf("work profile = %q, %v; want %q", root, err, want)
+	}
+
+	for _, name := range []string{"Work", "../work", "a/b", "-work", "has space"} {
+		if _, err := profileRoot(dataRoot, name); err == nil {
+			t.Errorf("profileRoot accepted %q", name)
+		}
+	}
+}
+
+func TestProfileCachesSeparate(t *testing.T) {
+	dataRoot := t.TempDir()
+	binary := filepath.Join(dataRoot, "tools", "server")
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var caches []string
+	for _, name := range []string{"work", "personal"} {
+		app := newTestApp(t)
+		app.usbRoot = dataRoot
+		root, err := profileRoot(dataRoot, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		app.profileRoot = root
+		app.config.MCP.Servers = map[string]config.MCPServer{
+			name: {Portability: "usb-local", Type: "stdio", Command: binary},
+		}
+
+		m, err := app.newMCPManager(t.TempDir())
+		if err != nil {
+			t.Skip(err)
+		}
+		if _, err := m.CheckServers(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		caches = append(caches, app.dataDir("cache"))
+	}
+
+	if caches[0] == caches[1] {
+		t.Fatalf("both profiles cache in %s", caches[0])
+	}
+	for _, dir := range caches {
+		if entries, _ := os.ReadDir(dir); len(entries) == 0 {
+			t.Errorf("nothing cached in %s", dir)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dataRoot, "cache")); !os.IsNotExist(err) {
+		t.Error("a profile wrote to the default profile's cache")
+	}
+}
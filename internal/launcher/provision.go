@@ -0,0 +1,216 @@
+package launcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// provisionSpec is the file read by "claude-go provision". Secrets are
+// never written in it, only where to read them from.
+type provisionSpec struct {
+	Password   secretSource `json:"password"`
+	Provider   string       `json:"provider,omitempty"` // console, bedrock or vertex; empty links none
+	Credential secretSource `json:"credential"`
+	Label      string       `json:"label,omitempty"`
+	Note       string       `json:"note,omitempty"`
+
+	// Written as config/settings.json, over the defaults
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// secretSource names where a secret is read from: an environment variable,
+// a file or an inherited file descriptor. One trailing newline is dropped.
+type secretSource struct {
+	Env  string `json:"env,omitempty"`
+	File string `json:"file,omitempty"`
+	FD   *int   `json:"fd,omitempty"`
+}
+
+func (src secretSource) empty() bool {
+	return src.Env == "" && src.File == "" && src.FD == nil
+}
+
+// read returns the secret; what names it for error messages
+func (src secretSource) read(what string) (string, error) {
+	var data []byte
+	switch {
+	case src.Env != "" && src.File == "" && src.FD == nil:
+		data = []byte(os.Getenv(src.Env))
+	case src.File != "" && src.Env == "" && src.FD == nil:
+		b, err := os.ReadFile(src.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", what, err)
+		}
+		data = b
+	case src.FD != nil && src.Env == "" && src.File == "":
+		f := os.NewFile(uintptr(*src.FD), what)
+		if f == nil {
+			return "", fmt.Errorf("failed to read %s: invalid file descriptor %d", what, *src.FD)
+		}
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s from fd %d: %w", what, *src.FD, err)
+		}
+		data = b
+	default:
+		return "", fmt.Errorf("%s needs exactly one of env, file or fd", what)
+	}
+
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	if len(data) == 0 {
+		return "", fmt.Errorf("%s is empty", what)
+	}
+	return string(data), nil
+}
+
+// runProvision creates the vault and settings from a spec file without
+// prompting, for preparing many USBs from a script. Everything is read and
+// validated before anything is written, and an existing vault is never
+// touched.
+func (app *App) runProvision(args []string) error {
+	fs := flag.NewFlagSet("provision", flag.ContinueOnError)
+	specPath := fs.String("config", "", "provisioning spec (JSON) to read")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *specPath == "" {
+		return fmt.Errorf("usage: claude-go provision --config provision.json")
+	}
+
+	vaultPath := app.vaultPath()
+	if vault.Exists(vaultPath) {
+		return fmt.Errorf("a vault already exists at %s; provisioning never replaces one", vaultPath)
+	}
+
+	spec, err := readProvisionSpec(*specPath)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.DefaultConfig()
+	if len(spec.Settings) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(spec.Settings))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("invalid settings in %s: %w", *specPath, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid settings in %s: %w", *specPath, err)
+		}
+	}
+
+	password, err := spec.Password.read("password")
+	if err != nil {
+		return err
+	}
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+
+	var provider auth.Provider
+	var unattended auth.Unattended
+	var credential string
+	if spec.Provider != "" {
+		provider = auth.Provider(spec.Provider)
+		if unattended, err = provisionDriver(provider); err != nil {
+			return err
+		}
+		if credential, err = spec.Credential.read("credential"); err != nil {
+			return err
+		}
+	} else if !spec.Credential.empty() {
+		return fmt.Errorf("credential given without a provider")
+	}
+
+	app.config = cfg
+	params, err := app.kdfParams("")
+	if err != nil {
+		return err
+	}
+	if err := app.createScaffold(); err != nil {
+		return err
+	}
+
+	v, err := vault.CreateWithOptions(vaultPath, password, vault.Options{KDF: params, Compress: cfg.Vault.Compress, Algorithm: vault.Algorithm(cfg.Vault.Algorithm)})
+	if err != nil {
+		return fmt.Errorf("failed to create vault: %w", err)
+	}
+	defer v.Lock()
+	app.vault = v
+	app.auth = auth.NewAuthenticator(v)
+
+	if provider != "" {
+		if err := unattended.StoreCredential(app.auth, credential); err != nil {
+			return err
+		}
+		if metadata := credentialMetadata(spec.Label, spec.Note); metadata != nil {
+			if err := app.auth.SetMetadata(provider, metadata); err != nil {
+				return fmt.Errorf("failed to store credential label: %w", err)
+			}
+		}
+	}
+
+	if err := cfg.Save(app.configPath()); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	linked := "no provider linked"
+	if provider != "" {
+		linked = string(provider) + " linked"
+	}
+	fmt.Fprintf(app.out, markOK+" Provisioned %s (%s, %d MCP servers)\n", app.profileRoot, linked, len(cfg.MCP.Servers))
+	return nil
+}
+
+// provisionDriver returns the driver of a provider that can be linked
+// without prompting
+func provisionDriver(provider auth.Provider) (auth.Unattended, error) {
+	var names []string
+	for _, d := range auth.Drivers() {
+		if _, ok := d.(auth.Unattended); ok {
+			names = append(names, string(d.Provider()))
+		}
+	}
+
+	driver, err := auth.DriverFor(provider)
+	if err != nil {
+		return nil, fmt.Errorf("cannot provision provider %q: use %s", provider, strings.Join(names, ", "))
+	}
+	unattended, ok := driver.(auth.Unattended)
+	if !ok {
+		return nil, fmt.Errorf("cannot provision provider %q: it needs a browser login; use %s", provider, strings.Join(names, ", "))
+	}
+	return unattended, nil
+}
+
+// readProvisionSpec parses a spec file, rejecting unknown fields so a typo
+// doesn't silently provision the defaults
+func readProvisionSpec(path string) (*provisionSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var spec provisionSpec
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid provisioning spec %s: %w", path, err)
+	}
+	if spec.Password.empty() {
+		return nil, errors.New("the provisioning spec needs a password source (env, file or fd)")
+	}
+	spec.Provider = strings.ToLower(strings.TrimSpace(spec.Provider))
+	return &spec, nil
+}
@@ -0,0 +1,104 @@
+package launcher
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/auth"
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// writeSpec writes a provisioning spec and returns its path
+func writeSpec(t *testing.T, spec string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "provision.json")
+	if err := os.WriteFile(path, []byte(spec), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProvision(t *testing.T) {
+	app := newTestApp(t)
+	app.out = io.Discard
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("provisioned password\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_PROVISION_KEY", "sk-ant-provisioned")
+
+	spec := writeSpec(t, `{
+		"password": {"file": "`+filepath.ToSlash(passwordFile)+`"},
+		"provider": "console",
+		"credential": {"env": "TEST_PROVISION_KEY"},
+		"label": "fleet",
+		"settings": {"mcp": {"servers": {"fetch": {"type": "stdio", "command": "uvx"}}}}
+	}`)
+	if err := app.runProvision([]string{"--config", spec}); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := vault.Open(app.vaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Unlock("provisioned password"); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Lock()
+	a := auth.NewAuthenticator(v)
+	if key, err := a.GetCredential(auth.ProviderConsole); err != nil || key != "sk-ant-provisioned" {
+		t.Errorf("console credential = %q, %v", key, err)
+	}
+
+	cfg, err := config.Load(app.configPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.MCP.Servers["fetch"]; !ok {
+		t.Error("the spec's MCP server wasn't saved")
+	}
+
+	// A second run never replaces the vault
+	before, _ := os.ReadFile(app.vaultPath())
+	if err := app.runProvision([]string{"--config", spec}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second provision: err = %v", err)
+	}
+	if after, _ := os.ReadFile(app.vaultPath()); string(after) != string(before) {
+		t.Error("the existing vault was changed")
+	}
+}
+
+func TestProvisionRejectsBadSpecs(t *testing.T) {
+	t.Setenv("TEST_PROVISION_PASSWORD", "provisioned password")
+	t.Setenv("TEST_PROVISION_SHORT", "short")
+
+	specs := map[string]string{
+		"no password":         `{"provider": "console", "credential": {"env": "TEST_PROVISION_PASSWORD"}}`,
+		"short password":      `{"password": {"env": "TEST_PROVISION_SHORT"}}`,
+		"two sources":         `{"password": {"env": "TEST_PROVISION_PASSWORD", "file": "/tmp/x"}}`,
+		"browser provider":    `{"password": {"env": "TEST_PROVISION_PASSWORD"}, "provider": "claudeai", "credential": {"env": "TEST_PROVISION_PASSWORD"}}`,
+		"credential only":     `{"password": {"env": "TEST_PROVISION_PASSWORD"}, "credential": {"env": "TEST_PROVISION_PASSWORD"}}`,
+		"unknown field":       `{"password": {"env": "TEST_PROVISION_PASSWORD"}, "pasword": {}}`,
+		"invalid settings":    `{"password": {"env": "TEST_PROVISION_PASSWORD"}, "settings": {"vault": {"algorithm": "rot13"}}}`,
+		"empty credential":    `{"password": {"env": "TEST_PROVISION_PASSWORD"}, "provider": "console", "credential": {"env": "TEST_PROVISION_UNSET"}}`,
+		"unknown setting key": `{"password": {"env": "TEST_PROVISION_PASSWORD"}, "settings": {"valut": {}}}`,
+	}
+
+	for name, spec := range specs {
+		app := newTestApp(t)
+		app.out = io.Discard
+		if err := app.runProvision([]string{"--config", writeSpec(t, spec)}); err == nil {
+			t.Errorf("%s: provisioned", name)
+		}
+		if vault.Exists(app.vaultPath()) {
+			t.Errorf("%s: a vault was created", name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+// errFixedDisk is returned when vault.require_removable is set and the USB
+// root is on a fixed disk
+var errFixedDisk = errors.New("vault.require_removable is set, but this copy is on a fixed disk; run it from the USB, or pass --allow-fixed-disk")
+
+// removableVerdict decides whether claude-go may run from storage. Only a
+// known fixed disk is refused; when detection fails the USB gets the
+// benefit of the doubt.
+func removableVerdict(storage platform.Storage, require, allowFixed bool) error {
+	if !require || allowFixed || storage != platform.StorageFixed {
+		return nil
+	}
+	return errFixedDisk
+}
+
+// checkRemovable enforces vault.require_removable. It only deters casual
+// copying: anyone with the files can edit the setting or pass the flag.
+func (app *App) checkRemovable() error {
+	if err := removableVerdict(app.storage, app.config.Vault.RequireRemovable, app.opts.AllowFixedDisk); err != nil {
+		return err
+	}
+
+	if app.config.Vault.RequireRemovable && app.storage == platform.StorageUnknown {
+		fmt.Fprintln(app.out, markWarn+" Couldn't tell whether this is removable media; allowing it despite vault.require_removable")
+	}
+	return nil
+}
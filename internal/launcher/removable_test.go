@@ -0,0 +1,52 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+func TestRemovableVerdict(t *testing.T) {
+	tests := []struct {
+		storage    platform.Storage
+		require    bool
+		allowFixed bool
+		refused    bool
+	}{
+		{platform.StorageFixed, false, false, false},
+		{platform.StorageFixed, true, false, true},
+		{platform.StorageFixed, true, true, false},
+		{platform.StorageRemovable, true, false, false},
+		{platform.StorageUnknown, true, false, false},
+	}
+
+	for _, tt := range tests {
+		err := removableVerdict(tt.storage, tt.require, tt.allowFixed)
+		if refused := errors.Is(err, errFixedDisk); refused != tt.refused || (err != nil && !refused) {
+			t.Errorf("removableVerdict(%s, require=%v, allowFixed=%v) = %v, want refused=%v", tt.storage, tt.require, tt.allowFixed, err, tt.refused)
+		}
+	}
+}
+
+func TestCheckRemovableWarnsWhenUnknown(t *testing.T) {
+	app := newTestApp(t)
+	var out bytes.Buffer
+	app.out = &out
+	app.config.Vault.RequireRemovable = true
+
+	app.storage = platform.StorageUnknown
+	if err := app.checkRemovable(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Couldn't tell") {
+		t.Errorf("no warning for unknown storage: %q", out.String())
+	}
+
+	app.storage = platform.StorageFixed
+	if err := app.checkRemovable(); !errors.Is(err, errFixedDisk) {
+		t.Errorf("fixed disk: err = %v, want errFixedDisk", err)
+	}
+}
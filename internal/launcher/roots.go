@@ -0,0 +1,76 @@
+package launcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// dataRootEnv sets the data root when --data-root isn't given
+const dataRootEnv = "CLAUDE_GO_DATA_ROOT"
+
+// resolveRoots returns the app root, holding bin/ and mcp/, and the data
+// root, holding vault/, sessions/, config/, cache/ and profiles/. They are
+// the same directory unless dataRoot is set, e.g. to run the executables
+// from a read-only drive and keep credentials on a writable one.
+func resolveRoots(dataRoot string) (string, string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", "", err
+	}
+
+	// Resolve symlinks
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", "", err
+	}
+
+	return rootsFor(exe, dataRoot)
+}
+
+// rootsFor resolves the roots for the executable at exe
+func rootsFor(exe, dataRoot string) (string, string, error) {
+	if dataRoot == "" {
+		appRoot := detectUSBRoot(exe, false)
+		return appRoot, appRoot, nil
+	}
+
+	dataRoot, err := expandPath(dataRoot)
+	if err != nil {
+		return "", "", fmt.Errorf("data root: %w", err)
+	}
+	info, err := os.Stat(dataRoot)
+	if err != nil {
+		return "", "", fmt.Errorf("data root %s is not available: %w", dataRoot, err)
+	}
+	if !info.IsDir() {
+		return "", "", fmt.Errorf("data root %s is not a directory", dataRoot)
+	}
+
+	return detectUSBRoot(exe, true), dataRoot, nil
+}
+
+// detectUSBRoot finds the root above the executable's bin/<platform>/. With
+// the data on another volume (split), there is no config/ to recognize it
+// by, so the bin/ directory itself has to be there. Otherwise the current
+// directory is used.
+func detectUSBRoot(exe string, split bool) string {
+	// Go up from bin/<platform>/ to USB root
+	binDir := filepath.Dir(exe)
+	platformDir := filepath.Dir(binDir)
+	usbRoot := filepath.Dir(platformDir)
+
+	// Verify it looks like a USB root
+	found := filepath.Base(platformDir) == "bin"
+	if !split {
+		_, err := os.Stat(filepath.Join(usbRoot, "config"))
+		found = !os.IsNotExist(err)
+	}
+	if !found {
+		// Maybe we're running from a different location, use current directory
+		cwd, _ := os.Getwd()
+		return cwd
+	}
+
+	return usbRoot
+}
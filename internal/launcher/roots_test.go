@@ -0,0 +1,105 @@
+package launcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// appLayout creates a USB root holding the launcher under bin/<platform>/
+// and returns the root and the executable's path
+func appLayout(t *testing.T, withConfig bool) (string, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	exe := filepath.Join(root, "bin", "linux-x64", "claude-go")
+	if err := os.MkdirAll(filepath.Dir(exe), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(exe, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if withConfig {
+		if err := os.Mkdir(filepath.Join(root, "config"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root, exe
+}
+
+func TestRootsForSingleRoot(t *testing.T) {
+	root, exe := appLayout(t, true)
+
+	appRoot, dataRoot, err := rootsFor(exe, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appRoot != root || dataRoot != root {
+		t.Errorf("rootsFor = %s, %s; want %s for both", appRoot, dataRoot, root)
+	}
+}
+
+func TestRootsForSplitRoots(t *testing.T) {
+	// The app drive is read-only and has no config/ of its own
+	root, exe := appLayout(t, false)
+	data := t.TempDir()
+
+	appRoot, dataRoot, err := rootsFor(exe, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appRoot != root || dataRoot != data {
+		t.Errorf("rootsFor = %s, %s; want %s and %s", appRoot, dataRoot, root, data)
+	}
+
+	// Profiles live on the data drive
+	profile, err := profileRoot(dataRoot, "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(data, "profiles", "work"); profile != want {
+		t.Errorf("profileRoot = %s, want %s", profile, want)
+	}
+
+	// The data root may name a variable
+	t.Setenv("CLAUDE_GO_TEST_DATA", data)
+	if _, dataRoot, err := rootsFor(exe, "$CLAUDE_GO_TEST_DATA"); err != nil || dataRoot != data {
+		t.Errorf("rootsFor with a variable = %s, %v; want %s", dataRoot, err, data)
+	}
+
+	// An unplugged or mistyped data drive is an error, never a fallback
+	// to the app drive
+	if _, _, err := rootsFor(exe, filepath.Join(data, "missing")); err == nil {
+		t.Error("a missing data root was accepted")
+	}
+	file := filepath.Join(data, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := rootsFor(exe, file); err == nil {
+		t.Error("a data root that is a file was accepted")
+	}
+}
+
+func TestDataRootFromEnvironment(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv(dataRootEnv, data)
+
+	opts, _, err := parseOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.DataRoot != data {
+		t.Errorf("DataRoot = %q, want %s from the environment", opts.DataRoot, data)
+	}
+
+	// The flag wins over the environment
+	other := t.TempDir()
+	opts, _, err = parseOptions([]string{"--data-root", other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.DataRoot != other {
+		t.Errorf("DataRoot = %q, want %s from the flag", opts.DataRoot, other)
+	}
+}
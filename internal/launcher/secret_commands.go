@@ -0,0 +1,86 @@
+package launcher
+
+import (
+	"fmt"
+
+	"github.com/cxt9/claude-go/internal/auth"
+)
+
+// runVaultSecretSet stores a named secret for session env overlays
+func (app *App) runVaultSecretSet(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-go vault secret set <name>")
+	}
+	name := args[0]
+	if err := auth.ValidateSecretName(name); err != nil {
+		return err
+	}
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+	defer app.vault.Lock()
+
+	value, err := app.promptPassword(fmt.Sprintf("Value of %s: ", name), false)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("secret value must not be empty")
+	}
+
+	if err := app.auth.SetSecret(name, value); err != nil {
+		return err
+	}
+
+	fmt.Printf(markOK+" Secret %s stored; use it with 'claude-go sessions env <id> NAME=vault:%s'\n", name, name)
+	return nil
+}
+
+// runVaultSecretList lists the names of stored secrets
+func (app *App) runVaultSecretList(args []string) error {
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+	defer app.vault.Lock()
+
+	names, err := app.auth.ListSecrets()
+	if err != nil {
+		return err
+	}
+
+	if app.opts.JSON {
+		if names == nil {
+			names = []string{}
+		}
+		return printJSON(names)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No secrets stored")
+		return nil
+	}
+	for _, name := range names {
+		fmt.Printf("  "+markItem+" %s\n", name)
+	}
+	return nil
+}
+
+// runVaultSecretDelete removes a stored secret
+func (app *App) runVaultSecretDelete(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-go vault secret delete <name>")
+	}
+
+	if err := app.unlockVault(app.vaultPath()); err != nil {
+		return err
+	}
+	defer app.vault.Lock()
+
+	if err := app.auth.DeleteSecret(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf(markOK+" Secret %s deleted\n", args[0])
+	return nil
+}
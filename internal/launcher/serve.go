@@ -0,0 +1,411 @@
+package launcher
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/session"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// apiTokenEnv lets a front-end choose the API token before starting serve
+const apiTokenEnv = "CLAUDE_GO_API_TOKEN"
+
+// maxAPIRequestSize bounds request bodies
+const maxAPIRequestSize = 64 << 10
+
+// apiServer exposes the launcher to GUI front-ends over loopback HTTP. A
+// launched claude runs in serve's terminal; while it does, requests that
+// touch the vault or sessions are refused, so handlers never race it.
+type apiServer struct {
+	app   *App
+	token string
+
+	// launch runs claude for POST /v1/launch; app.startSession outside tests
+	launch func(projectPath string, resume *session.Session) error
+
+	mu        sync.Mutex
+	running   bool
+	lastError string
+}
+
+// serveInfo is printed on startup, as JSON with --json
+type serveInfo struct {
+	Addr  string `json:"addr"`
+	Token string `json:"token,omitempty"`
+}
+
+// runServe serves the local HTTP API until interrupted
+func (app *App) runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:0", "loopback address to listen on (port 0 picks a free one)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	listenAddr, err := loopbackAddr(*addr)
+	if err != nil {
+		return err
+	}
+
+	token := os.Getenv(apiTokenEnv)
+	generated := token == ""
+	if generated {
+		if token, err = newAPIToken(); err != nil {
+			return err
+		}
+	}
+
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", listenAddr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           newAPIServer(app, token).routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(app.ctx, os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	// A token chosen through the environment is already known to the caller
+	info := serveInfo{Addr: ln.Addr().String()}
+	if generated {
+		info.Token = token
+	}
+
+	if app.opts.JSON {
+		if err := printJSON(info); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Serving the claude-go API on http://%s\n", info.Addr)
+		if generated {
+			fmt.Printf("Token: %s\n", info.Token)
+		}
+		fmt.Println("Send the token as \"Authorization: Bearer <token>\". Press Ctrl-C to stop.")
+	}
+
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// loopbackAddr validates that addr only listens on a loopback interface
+func loopbackAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+
+	if host == "localhost" {
+		host = "127.0.0.1"
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return "", fmt.Errorf("refusing to listen on %q: the API only binds to loopback addresses such as 127.0.0.1", addr)
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+func newAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func newAPIServer(app *App, token string) *apiServer {
+	return &apiServer{app: app, token: token, launch: app.startSession}
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", s.handleStatus)
+	mux.HandleFunc("POST /v1/unlock", s.exclusive(s.handleUnlock))
+	mux.HandleFunc("POST /v1/lock", s.exclusive(s.handleLock))
+	mux.HandleFunc("GET /v1/sessions", s.exclusive(s.handleSessions))
+	mux.HandleFunc("GET /v1/providers", s.exclusive(s.handleProviders))
+	mux.HandleFunc("GET /v1/mcp", s.exclusive(s.handleMCP))
+	mux.HandleFunc("POST /v1/launch", s.exclusive(s.handleLaunch))
+	return s.authenticate(mux)
+}
+
+// authenticate rejects requests without the bearer token
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// exclusive runs a handler alone, and not while claude is running
+func (s *apiServer) exclusive(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.running {
+			writeAPIError(w, http.StatusConflict, errors.New("claude is running"))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// apiStatus is the response of GET /v1/status
+type apiStatus struct {
+	VaultExists bool   `json:"vault_exists"`
+	Unlocked    bool   `json:"unlocked"`
+	Running     bool   `json:"running"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+func (s *apiServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := apiStatus{Running: s.running, LastError: s.lastError}
+	if !s.running {
+		status.VaultExists = vault.Exists(s.app.vaultPath())
+		status.Unlocked = s.app.vault != nil && s.app.vault.IsUnlocked()
+	}
+	s.mu.Unlock()
+
+	writeAPIJSON(w, http.StatusOK, status)
+}
+
+func (s *apiServer) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	v, err := vault.Open(s.app.vaultPath())
+	if err != nil {
+		writeAPIError(w, http.StatusConflict, fmt.Errorf("failed to open vault: %w", err))
+		return
+	}
+
+	// The vault unlocked earlier must not stay in memory once replaced
+	previous := s.app.vault
+	if err := s.app.unlockWith(v, req.Password); err != nil {
+		var lockout *vault.LockoutError
+		switch {
+		case errors.As(err, &lockout):
+			w.Header().Set("Retry-After", strconv.Itoa(int(lockout.RetryAfter.Seconds())+1))
+			writeAPIError(w, http.StatusTooManyRequests, err)
+		case errors.Is(err, errIncorrectPassword):
+			writeAPIError(w, http.StatusForbidden, err)
+		default:
+			writeAPIError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if previous != nil && previous != v {
+		previous.Lock()
+	}
+
+	writeAPIJSON(w, http.StatusOK, map[string]int{"failed_attempts": v.FailedAttempts()})
+}
+
+func (s *apiServer) handleLock(w http.ResponseWriter, r *http.Request) {
+	if s.app.vault != nil {
+		s.app.vault.Lock()
+	}
+	s.app.auth = nil
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *apiServer) handleSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.app.sessionManager.List()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, sessions)
+}
+
+func (s *apiServer) handleProviders(w http.ResponseWriter, r *http.Request) {
+	if s.app.auth == nil {
+		writeAPIError(w, http.StatusLocked, vault.ErrVaultLocked)
+		return
+	}
+
+	infos, err := s.app.auth.DescribeProviders()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, infos)
+}
+
+// handleMCP checks the MCP servers for ?project=DIR, or serve's working
+// directory
+func (s *apiServer) handleMCP(w http.ResponseWriter, r *http.Request) {
+	project := r.URL.Query().Get("project")
+	if project == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		project = cwd
+	}
+
+	m, err := s.app.newMCPManager(project)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	statuses, err := m.CheckServers(r.Context())
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, fmt.Errorf("failed to check MCP servers: %w", err))
+		return
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	writeAPIJSON(w, http.StatusOK, statuses)
+}
+
+// launchRequest is the body of POST /v1/launch: a session to resume, a
+// project to start in, or both to resume a session at a new path
+type launchRequest struct {
+	SessionID   string `json:"session_id"`
+	ProjectPath string `json:"project_path"`
+}
+
+// handleLaunch starts claude in serve's terminal and returns at once;
+// GET /v1/status reports when it has exited
+func (s *apiServer) handleLaunch(w http.ResponseWriter, r *http.Request) {
+	var req launchRequest
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if s.app.auth == nil {
+		writeAPIError(w, http.StatusLocked, vault.ErrVaultLocked)
+		return
+	}
+
+	projectPath, sess, err := s.launchPath(req)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.running = true
+	s.lastError = ""
+	go func() {
+		err := s.launch(projectPath, sess)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.running = false
+		if err != nil {
+			s.lastError = err.Error()
+		}
+	}()
+
+	writeAPIJSON(w, http.StatusAccepted, map[string]string{"project_path": projectPath})
+}
+
+// launchPath resolves where a launch request runs claude, and the session it
+// resumes: like resumePath, where the session was last used on this
+// platform, else its original path, else the request's project_path, which
+// becomes the session's remapped path
+func (s *apiServer) launchPath(req launchRequest) (string, *session.Session, error) {
+	if req.ProjectPath != "" {
+		path, err := expandPath(req.ProjectPath)
+		if err != nil {
+			return "", nil, err
+		}
+		req.ProjectPath = path
+	}
+
+	if req.SessionID == "" {
+		if req.ProjectPath == "" {
+			return "", nil, errors.New("session_id or project_path is required")
+		}
+		return req.ProjectPath, nil, s.app.checkProjectPath(req.ProjectPath)
+	}
+
+	sess, err := s.app.sessionManager.Resolve(req.SessionID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if req.ProjectPath == "" {
+		if path, ok := s.app.remappedPath(sess); ok {
+			return path, sess, nil
+		}
+		if expanded, ok := session.ExpandPlaceholders(sess.Project.OriginalPath); ok {
+			req.ProjectPath = expanded
+		}
+	}
+	if req.ProjectPath == "" {
+		if err := s.app.checkProjectPath(sess.Project.OriginalPath); err != nil {
+			return "", nil, fmt.Errorf("original path not found, pass project_path to remap it: %w", err)
+		}
+		return sess.Project.OriginalPath, sess, nil
+	}
+
+	if err := s.app.checkProjectPath(req.ProjectPath); err != nil {
+		return "", nil, err
+	}
+	if err := s.app.sessionManager.RemapProjectPath(sess, req.ProjectPath); err != nil {
+		return "", nil, err
+	}
+	return req.ProjectPath, sess, nil
+}
+
+func decodeAPIRequest(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxAPIRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,222 @@
+package launcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/session"
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+const testAPIToken = "test-token"
+
+// testKDF keeps vault creation fast in tests
+var testKDF = vault.KDFParams{Time: 1, Memory: 8 * 1024, Threads: 1}
+
+// newTestApp returns an app for a profile in a temporary directory
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+
+	app := &App{
+		opts:        &Options{},
+		profileRoot: t.TempDir(),
+		config:      config.DefaultConfig(),
+	}
+	app.sessionManager = session.NewManager(app.dataDir("sessions"))
+	return app
+}
+
+// newTestAPIServer returns a server for a profile in a temporary directory
+func newTestAPIServer(t *testing.T) *apiServer {
+	t.Helper()
+
+	return newAPIServer(newTestApp(t), testAPIToken)
+}
+
+// createTestVault creates the profile's vault with password
+func createTestVault(t *testing.T, app *App, password string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(app.vaultPath()), 0700); err != nil {
+		t.Fatal(err)
+	}
+	v, err := vault.CreateWithOptions(app.vaultPath(), password, vault.Options{KDF: testKDF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Lock()
+}
+
+func apiRequest(t *testing.T, s *apiServer, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAPIToken)
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIRequiresToken(t *testing.T) {
+	s := newTestAPIServer(t)
+
+	for _, header := range []string{"", "Bearer wrong", testAPIToken} {
+		req := httptest.NewRequest("GET", "/v1/status", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", header, rec.Code)
+		}
+	}
+
+	if rec := apiRequest(t, s, "GET", "/v1/status", ""); rec.Code != http.StatusOK {
+		t.Errorf("valid token: status %d, want 200", rec.Code)
+	}
+}
+
+func TestAPIRefusesWhileRunning(t *testing.T) {
+	s := newTestAPIServer(t)
+	s.running = true
+
+	if rec := apiRequest(t, s, "GET", "/v1/sessions", ""); rec.Code != http.StatusConflict {
+		t.Errorf("sessions while running: status %d, want 409", rec.Code)
+	}
+	if rec := apiRequest(t, s, "GET", "/v1/status", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"running":true`) {
+		t.Errorf("status while running: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAPIUnlockLocksPreviousVault(t *testing.T) {
+	s := newTestAPIServer(t)
+	createTestVault(t, s.app, "first password")
+
+	if rec := apiRequest(t, s, "POST", "/v1/unlock", `{"password":"wrong"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("wrong password: status %d, want 403", rec.Code)
+	}
+	if rec := apiRequest(t, s, "POST", "/v1/unlock", `{"password":"first password"}`); rec.Code != http.StatusOK {
+		t.Fatalf("unlock: status %d %s", rec.Code, rec.Body)
+	}
+	previous := s.app.vault
+
+	// A failed unlock leaves the unlocked vault in place
+	if rec := apiRequest(t, s, "POST", "/v1/unlock", `{"password":"wrong"}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("wrong password: status %d, want 403", rec.Code)
+	}
+	if s.app.vault != previous || !previous.IsUnlocked() {
+		t.Fatal("a failed unlock replaced or locked the unlocked vault")
+	}
+
+	if rec := apiRequest(t, s, "POST", "/v1/unlock", `{"password":"first password"}`); rec.Code != http.StatusOK {
+		t.Fatalf("second unlock: status %d %s", rec.Code, rec.Body)
+	}
+	if s.app.vault == previous {
+		t.Fatal("unlock kept the previous vault")
+	}
+	if previous.IsUnlocked() {
+		t.Error("the replaced vault is still unlocked")
+	}
+
+	if rec := apiRequest(t, s, "POST", "/v1/lock", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("lock: status %d", rec.Code)
+	}
+	if s.app.vault.IsUnlocked() || s.app.auth != nil {
+		t.Error("lock left the vault unlocked")
+	}
+}
+
+func TestAPILaunchNeedsUnlock(t *testing.T) {
+	s := newTestAPIServer(t)
+
+	rec := apiRequest(t, s, "POST", "/v1/launch", `{"project_path":"`+t.TempDir()+`"}`)
+	if rec.Code != http.StatusLocked {
+		t.Errorf("launch while locked: status %d, want 423", rec.Code)
+	}
+	if rec := apiRequest(t, s, "POST", "/v1/launch", `{"unknown":1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown field: status %d, want 400", rec.Code)
+	}
+}
+
+func TestAPILaunchRemappedSession(t *testing.T) {
+	s := newTestAPIServer(t)
+	createTestVault(t, s.app, "password")
+	if rec := apiRequest(t, s, "POST", "/v1/unlock", `{"password":"password"}`); rec.Code != http.StatusOK {
+		t.Fatalf("unlock: status %d %s", rec.Code, rec.Body)
+	}
+
+	// Both paths exist, but the session was last used at the remapped one
+	original, remapped := t.TempDir(), t.TempDir()
+	created, err := s.app.sessionManager.Create(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.app.sessionManager.RemapProjectPath(created, remapped); err != nil {
+		t.Fatal(err)
+	}
+
+	launched := make(chan string, 1)
+	s.launch = func(projectPath string, resume *session.Session) error {
+		launched <- projectPath
+		return nil
+	}
+
+	rec := apiRequest(t, s, "POST", "/v1/launch", `{"session_id":"`+created.ID+`"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("launch: status %d %s", rec.Code, rec.Body)
+	}
+	if got := <-launched; got != remapped {
+		t.Errorf("launched in %q, want the remapped path %q", got, remapped)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["project_path"] != remapped {
+		t.Errorf("response %s, want project_path %q", rec.Body, remapped)
+	}
+}
+
+func TestLaunchPathResolvesSession(t *testing.T) {
+	s := newTestAPIServer(t)
+	project := t.TempDir()
+
+	created, err := s.app.sessionManager.Create(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A prefix of the ID names the session, which the launch then resumes
+	path, sess, err := s.launchPath(launchRequest{SessionID: created.ID[:8]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != project || sess == nil || sess.ID != created.ID {
+		t.Errorf("launchPath = %q, %v; want %q and session %s", path, sess, project, created.ID)
+	}
+
+	moved := t.TempDir()
+	path, sess, err = s.launchPath(launchRequest{SessionID: created.ID, ProjectPath: moved})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != moved || sess == nil || sess.ID != created.ID {
+		t.Errorf("remapped launchPath = %q, %v; want %q", path, sess, moved)
+	}
+
+	path, sess, err = s.launchPath(launchRequest{ProjectPath: project})
+	if err != nil || path != project || sess != nil {
+		t.Errorf("new session launchPath = %q, %v, %v; want %q and no session", path, sess, err, project)
+	}
+
+	if _, _, err := s.launchPath(launchRequest{}); err == nil {
+		t.Error("an empty request was accepted")
+	}
+	if _, _, err := s.launchPath(launchRequest{SessionID: "no-such-session"}); err == nil {
+		t.Error("an unknown session was accepted")
+	}
+}
@@ -0,0 +1,521 @@
+package launcher
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/session"
+	"github.com/cxt9/claude-go/internal/vault"
+	"golang.org/x/term"
+)
+
+// runSessionsList prints saved sessions, most recent first. On a terminal
+// it shows one picker page unless --all or --limit is given; piped and
+// --json output list everything.
+func (app *App) runSessionsList(args []string) error {
+	fs := flag.NewFlagSet("sessions list", flag.ContinueOnError)
+	all := fs.Bool("all", false, "list every session")
+	limit := fs.Int("limit", 0, "maximum number of sessions to list")
+	project := fs.String("project", "", "only list sessions of the project at this path")
+	tag := fs.String("tag", "", "only list sessions with this tag")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var sessions []*session.Session
+	var err error
+	if *project != "" {
+		path, expandErr := expandPath(*project)
+		if expandErr != nil {
+			return expandErr
+		}
+		sessions, err = app.sessionManager.ListByProject(path)
+	} else {
+		sessions, err = app.sessionManager.List()
+	}
+	if err != nil {
+		return err
+	}
+	if *tag != "" {
+		sessions = session.FilterByTag(sessions, *tag)
+	}
+
+	n := len(sessions)
+	switch {
+	case *limit > 0:
+		n = *limit
+	case !*all && !app.opts.JSON && term.IsTerminal(int(os.Stdout.Fd())):
+		n = app.pickerPageSize()
+	}
+	_, end := pageBounds(len(sessions), 0, n)
+
+	if app.opts.JSON {
+		return printJSON(sessions[:end])
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("No sessions")
+		return nil
+	}
+
+	for i, s := range sessions[:end] {
+		fmt.Println(formatSessionLine(i+1, s))
+	}
+	if end < len(sessions) {
+		fmt.Printf("  ... and %d more (use --all)\n", len(sessions)-end)
+	}
+
+	return nil
+}
+
+// sessionDetail is the --json shape of "sessions show"
+type sessionDetail struct {
+	*session.Session
+	OriginalPathExists bool `json:"original_path_exists"`
+}
+
+// runSessionsShow prints everything stored for one session
+func (app *App) runSessionsShow(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-go sessions show <id>")
+	}
+
+	s, err := app.sessionManager.Resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(s.Project.OriginalPath)
+	detail := sessionDetail{Session: s, OriginalPathExists: statErr == nil}
+
+	if app.opts.JSON {
+		return printJSON(detail)
+	}
+
+	fmt.Print(formatSessionDetail(detail))
+	return nil
+}
+
+func formatSessionDetail(d sessionDetail) string {
+	var b strings.Builder
+	s := d.Session
+
+	fmt.Fprintf(&b, "Session %s\n", s.ID)
+	fmt.Fprintf(&b, "  Project:     %s\n", filepath.Base(s.Project.OriginalPath))
+	if d.OriginalPathExists {
+		fmt.Fprintf(&b, "  Original:    %s\n", s.Project.OriginalPath)
+	} else {
+		fmt.Fprintf(&b, "  Original:    %s (not on this machine; resuming will ask for a new path)\n", s.Project.OriginalPath)
+	}
+	fmt.Fprintf(&b, "  Remapped:    %s\n", s.Project.RemappedPath)
+	fmt.Fprintf(&b, "  Relative:    %s\n", s.Project.RelativePath)
+	fmt.Fprintf(&b, "  Host:        %s (%s)\n", s.HostMachine, s.Platform)
+	fmt.Fprintf(&b, "  Created:     %s (%s)\n", s.CreatedAt.Local().Format(time.RFC1123), formatAge(time.Since(s.CreatedAt)))
+	fmt.Fprintf(&b, "  Last used:   %s (%s)\n", s.LastUsedAt.Local().Format(time.RFC1123), formatAge(time.Since(s.LastUsedAt)))
+	fmt.Fprintf(&b, "  Summary:     %s\n", s.Summary)
+	if len(s.Tags) > 0 {
+		fmt.Fprintf(&b, "  Tags:        %s\n", strings.Join(s.Tags, ", "))
+	}
+
+	if len(s.Env) > 0 {
+		names := make([]string, 0, len(s.Env))
+		for name := range s.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "  Environment: %s\n", strings.Join(names, ", "))
+	}
+
+	if s.MCPProfile != "" {
+		fmt.Fprintf(&b, "  MCP profile: %s\n", s.MCPProfile)
+	}
+
+	if s.Model != "" {
+		fmt.Fprintf(&b, "  Model:       %s\n", s.Model)
+	}
+
+	if len(s.IgnoredRequiredMCP) > 0 {
+		fmt.Fprintf(&b, "  Launched without required MCP: %s\n", strings.Join(s.IgnoredRequiredMCP, ", "))
+	}
+
+	if len(s.Permissions) == 0 {
+		fmt.Fprintf(&b, "  Permissions: none\n")
+	} else {
+		fmt.Fprintf(&b, "  Permissions:\n")
+		for _, p := range s.Permissions {
+			fmt.Fprintf(&b, "    "+markItem+" %s (granted %s)\n", p.Rule(), formatAge(time.Since(p.GrantedAt)))
+		}
+	}
+
+	return b.String()
+}
+
+// gcResult is the --json shape of "sessions gc"
+type gcResult struct {
+	Sessions []*session.Session `json:"sessions"`
+	DryRun   bool               `json:"dry_run"`
+	Deleted  int                `json:"deleted"`
+}
+
+// runSessionsGC deletes sessions unused for longer than
+// sessions.cleanup_period_days after listing them. --dry-run only lists;
+// --yes skips the confirmation.
+func (app *App) runSessionsGC(args []string) error {
+	fs := flag.NewFlagSet("sessions gc", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "list the sessions that would be deleted without deleting them")
+	yes := fs.Bool("yes", false, "delete without asking for confirmation")
+	days := fs.Int("days", app.config.Sessions.CleanupPeriodDays, "delete sessions unused for more than this many days")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+	if app.opts.JSON && !*dryRun && !*yes {
+		return fmt.Errorf("sessions gc --json needs --dry-run or --yes")
+	}
+
+	expired, err := app.sessionManager.Expired(time.Duration(*days) * 24 * time.Hour)
+	if err != nil {
+		return err
+	}
+
+	result := gcResult{Sessions: expired, DryRun: *dryRun}
+	if result.Sessions == nil {
+		result.Sessions = []*session.Session{}
+	}
+
+	if !app.opts.JSON {
+		if len(expired) == 0 {
+			fmt.Printf("No sessions unused for more than %d days\n", *days)
+			return nil
+		}
+
+		fmt.Printf("Sessions unused for more than %d days:\n", *days)
+		for _, s := range expired {
+			fmt.Printf("  %s  %s (last used %s)\n", s.ID, s.Project.OriginalPath, formatAge(time.Since(s.LastUsedAt)))
+		}
+
+		if *dryRun {
+			fmt.Printf("Dry run: %d session(s) would be deleted\n", len(expired))
+			return nil
+		}
+		if !*yes && !app.confirm(fmt.Sprintf("Delete %d session(s)?", len(expired))) {
+			return fmt.Errorf("%w: nothing deleted", errCancelled)
+		}
+	}
+
+	if !*dryRun {
+		result.Deleted = app.sessionManager.DeleteAll(expired)
+	}
+
+	if app.opts.JSON {
+		return printJSON(result)
+	}
+
+	fmt.Printf(markOK+" Deleted %d session(s)\n", result.Deleted)
+	return nil
+}
+
+// runSessionsTag adds tags to a session
+func (app *App) runSessionsTag(args []string) error {
+	return app.editSessionTags("tag", args, app.sessionManager.AddTag)
+}
+
+// runSessionsUntag removes tags from a session
+func (app *App) runSessionsUntag(args []string) error {
+	return app.editSessionTags("untag", args, app.sessionManager.RemoveTag)
+}
+
+func (app *App) editSessionTags(cmd string, args []string, edit func(*session.Session, string) error) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: claude-go sessions %s <id> <tag>...", cmd)
+	}
+
+	s, err := app.sessionManager.Resolve(args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range args[1:] {
+		if err := edit(s, tag); err != nil {
+			return err
+		}
+	}
+
+	if len(s.Tags) == 0 {
+		fmt.Printf(markOK+" %s has no tags\n", s.ID)
+	} else {
+		fmt.Printf(markOK+" %s tags: %s\n", s.ID, strings.Join(s.Tags, ", "))
+	}
+	return nil
+}
+
+// runSessionsModel shows the model a session launches claude with, or sets
+// it; --clear goes back to environment.default_model
+func (app *App) runSessionsModel(args []string) error {
+	fs := flag.NewFlagSet("sessions model", flag.ContinueOnError)
+	clearModel := fs.Bool("clear", false, "use environment.default_model again")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || (*clearModel && fs.NArg() == 2) {
+		return fmt.Errorf("usage: claude-go sessions model [--clear] <id> [model]")
+	}
+
+	s, err := app.sessionManager.Resolve(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *clearModel:
+		if err := app.sessionManager.SetModel(s, ""); err != nil {
+			return err
+		}
+	case fs.NArg() == 2:
+		if err := app.sessionManager.SetModel(s, fs.Arg(1)); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case s.Model != "":
+		fmt.Printf("%s model: %s\n", s.ID, s.Model)
+	case app.config.Environment.DefaultModel != "":
+		fmt.Printf("%s uses the default model (%s)\n", s.ID, app.config.Environment.DefaultModel)
+	default:
+		fmt.Printf("%s uses claude's default model\n", s.ID)
+	}
+	return nil
+}
+
+// runSessionsSanitize replaces the home directory, and --workspace if
+// given, in a session's paths with placeholders so it can be shared
+func (app *App) runSessionsSanitize(args []string) error {
+	fs := flag.NewFlagSet("sessions sanitize", flag.ContinueOnError)
+	workspace := fs.String("workspace", "", "also replace this directory with $WORKSPACE")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: claude-go sessions sanitize [--workspace DIR] <id>")
+	}
+
+	workspaceDir := ""
+	if *workspace != "" {
+		dir, err := expandPath(*workspace)
+		if err != nil {
+			return err
+		}
+		workspaceDir = dir
+	}
+	home, _ := os.UserHomeDir()
+
+	s, err := app.sessionManager.Resolve(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	before := s.Project.OriginalPath
+	if err := app.sessionManager.Sanitize(s, home, workspaceDir); err != nil {
+		return err
+	}
+
+	if app.opts.JSON {
+		return printJSON(s)
+	}
+	if !session.IsSanitized(s.Project.OriginalPath) {
+		fmt.Printf(markWarn+" %s is outside the home and workspace directories; left as is\n", before)
+	}
+	fmt.Printf(markOK+" %s sanitized: %s\n", s.ID, s.Project.OriginalPath)
+	return nil
+}
+
+// runSessionsExport writes one session to a file that can be handed to
+// someone else and brought in with sessions import
+func (app *App) runSessionsExport(args []string) error {
+	fs := flag.NewFlagSet("sessions export", flag.ContinueOnError)
+	id := fs.String("id", "", "session to export (ID or unique prefix)")
+	out := fs.String("out", "", "file to write (default <id>.session.json)")
+	encrypt := fs.Bool("encrypt", false, "encrypt the file with a passphrase")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" || fs.NArg() != 0 {
+		return fmt.Errorf("usage: claude-go sessions export --id ID [--out FILE] [--encrypt]")
+	}
+
+	s, err := app.sessionManager.Resolve(*id)
+	if err != nil {
+		return err
+	}
+
+	passphrase := ""
+	if *encrypt {
+		passphrase, err = app.promptPassword("Passphrase: ", false)
+		if err != nil {
+			return err
+		}
+		if passphrase == "" {
+			return fmt.Errorf("passphrase cannot be empty")
+		}
+		confirm, err := app.promptPassword("Confirm passphrase: ", false)
+		if err != nil {
+			return err
+		}
+		if confirm != passphrase {
+			return fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	path := *out
+	if path == "" {
+		path = s.ID + ".session.json"
+	}
+	path, err = expandPath(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create export: %w", err)
+	}
+	if err := app.sessionManager.Export(s.ID, f, passphrase); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	fmt.Printf(markOK+" Exported %s to %s\n", s.ID, path)
+	if !*encrypt {
+		fmt.Println("  The file is not encrypted; it includes the session's permissions and environment.")
+	}
+	return nil
+}
+
+// runSessionsImport adds a session from a sessions export file under a
+// new ID
+func (app *App) runSessionsImport(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: claude-go sessions import <file>")
+	}
+
+	path, err := expandPath(args[0])
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read export: %w", err)
+	}
+
+	passphrase := ""
+	if session.IsEncryptedExport(data) {
+		passphrase, err = app.promptPassword("Passphrase: ", false)
+		if err != nil {
+			return err
+		}
+	}
+
+	s, dropped, err := app.sessionManager.ImportOne(bytes.NewReader(data), passphrase)
+	if errors.Is(err, vault.ErrWrongPassword) {
+		return fmt.Errorf("wrong passphrase, or the file was altered")
+	}
+	if err != nil {
+		return err
+	}
+
+	if app.opts.JSON {
+		return printJSON(s)
+	}
+	fmt.Printf(markOK+" Imported session %s (%s)\n", s.ID, s.Project.OriginalPath)
+	if len(dropped) > 0 {
+		fmt.Printf(markWarn+" Not imported from the shared file: %s\n", strings.Join(dropped, ", "))
+	}
+	return nil
+}
+
+// runSessionsVerify lists the files in the sessions directory that can't
+// be used as sessions. --repair moves the broken ones into quarantine.
+func (app *App) runSessionsVerify(args []string) error {
+	fs := flag.NewFlagSet("sessions verify", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "move corrupt and unreadable session files into sessions/quarantine")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage: claude-go sessions verify [--repair]")
+	}
+
+	problems, err := app.sessionManager.Verify()
+	if err != nil {
+		return fmt.Errorf("failed to verify sessions: %w", err)
+	}
+
+	moved := 0
+	if *repair {
+		moved, err = app.sessionManager.Quarantine(problems)
+		if err != nil {
+			return err
+		}
+	}
+
+	if app.opts.JSON {
+		if problems == nil {
+			problems = []session.Problem{}
+		}
+		return printJSON(map[string]interface{}{"problems": problems, "quarantined": moved})
+	}
+
+	if len(problems) == 0 {
+		fmt.Println(markOK + " All session files are valid")
+		return nil
+	}
+	for _, p := range problems {
+		mark := markFail
+		if p.Kind == session.ProblemStray {
+			mark = markWarn
+		}
+		if p.Detail != "" {
+			fmt.Printf("  %s %s: %s (%s)\n", mark, p.Name, p.Kind, p.Detail)
+		} else {
+			fmt.Printf("  %s %s: %s\n", mark, p.Name, p.Kind)
+		}
+	}
+
+	broken, _ := countProblems(problems)
+	switch {
+	case moved > 0:
+		fmt.Printf(markOK+" Moved %d file(s) to %s\n", moved, filepath.Join(app.dataDir("sessions"), "quarantine"))
+	case broken > 0:
+		fmt.Println("\nRun 'claude-go sessions verify --repair' to move the broken files aside.")
+		return fmt.Errorf("%d session file(s) are corrupt or unreadable", broken)
+	}
+	return nil
+}
+
+// countProblems splits Verify's problems into broken session files and
+// stray files
+func countProblems(problems []session.Problem) (broken, stray int) {
+	for _, p := range problems {
+		if p.Kind == session.ProblemStray {
+			stray++
+		} else {
+			broken++
+		}
+	}
+	return broken, stray
+}
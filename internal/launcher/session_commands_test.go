@@ -0,0 +1,143 @@
+package launcher
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/platform"
+	"github.com/cxt9/claude-go/internal/session"
+)
+
+func TestFormatSessionDetail(t *testing.T) {
+	now := time.Now()
+	s := &session.Session{
+		ID:          "3f2a9c1e-0000-4000-8000-000000000000",
+		HostMachine: "laptop",
+		Platform:    platform.LinuxAMD64,
+		CreatedAt:   now.Add(-48 * time.Hour),
+		LastUsedAt:  now.Add(-2 * time.Hour),
+		Summary:     "Refactor the parser",
+		Tags:        []string{"work", "parser"},
+		Env:         map[string]string{"NODE_ENV": "test", "DEBUG": "1"},
+		MCPProfile:  "debug",
+		Model:       "claude-opus-4",
+		Permissions: []session.Permission{
+			{Tool: "Bash", Pattern: "go test:*", GrantedAt: now.Add(-time.Hour)},
+			{Tool: "Edit", GrantedAt: now.Add(-time.Hour)},
+		},
+	}
+	s.Project.OriginalPath = "/home/me/parser"
+	s.Project.RemappedPath = "/mnt/work/parser"
+
+	out := formatSessionDetail(sessionDetail{Session: s, OriginalPathExists: true})
+	for _, want := range []string{
+		"Session 3f2a9c1e-0000-4000-8000-000000000000\n",
+		"  Project:     parser\n",
+		"  Original:    /home/me/parser\n",
+		"  Remapped:    /mnt/work/parser\n",
+		"  Host:        laptop (" + string(platform.LinuxAMD64) + ")\n",
+		"  Summary:     Refactor the parser\n",
+		"  Tags:        work, parser\n",
+		"  Environment: DEBUG, NODE_ENV\n", // names only, sorted
+		"  MCP profile: debug\n",
+		"  Model:       claude-opus-4\n",
+		"  Permissions:\n",
+		"Bash(go test:*) (granted",
+		"Edit (granted",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+
+	// A missing original path says a remap will be needed
+	s.Permissions = nil
+	out = formatSessionDetail(sessionDetail{Session: s})
+	if !strings.Contains(out, "/home/me/parser (not on this machine") {
+		t.Errorf("missing original path not flagged:\n%s", out)
+	}
+	if !strings.Contains(out, "  Permissions: none\n") {
+		t.Errorf("no permissions not shown:\n%s", out)
+	}
+}
+
+// backdateSession rewrites a session's file as last used age ago; Save
+// always records the current time
+func backdateSession(t *testing.T, app *App, s *session.Session, age time.Duration) {
+	t.Helper()
+
+	s.LastUsedAt = time.Now().Add(-age)
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(app.dataDir("sessions"), s.ID+".json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSessionsGC(t *testing.T) {
+	app := newTestApp(t)
+	app.out = io.Discard
+	app.config.Sessions.CleanupPeriodDays = 30
+
+	var old []string
+	for _, age := range []time.Duration{45 * 24 * time.Hour, 90 * 24 * time.Hour, time.Hour} {
+		s, err := app.sessionManager.Create(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		backdateSession(t, app, s, age)
+		if age > 24*time.Hour {
+			old = append(old, s.ID)
+		}
+	}
+	count := func() int {
+		sessions, err := app.sessionManager.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(sessions)
+	}
+
+	// A dry run lists the candidates and deletes nothing
+	out := captureJSON(t, app, func() error { return app.runSessionsGC([]string{"--dry-run"}) })
+	result := out.(map[string]interface{})
+	var listed []string
+	for _, item := range result["sessions"].([]interface{}) {
+		listed = append(listed, item.(map[string]interface{})["id"].(string))
+	}
+	if len(listed) != 2 || !containsString(listed, old[0]) || !containsString(listed, old[1]) {
+		t.Errorf("dry run listed %v, want %v", listed, old)
+	}
+	if result["dry_run"] != true || result["deleted"] != float64(0) {
+		t.Errorf("dry run result = %v", result)
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("%d sessions after a dry run, want all 3", n)
+	}
+
+	// Without --yes, declining the prompt deletes nothing either
+	app.opts.JSON = false
+	app.stdin = bufio.NewReader(strings.NewReader("n\n"))
+	if err := app.runSessionsGC(nil); !errors.Is(err, errCancelled) {
+		t.Fatalf("declining = %v, want errCancelled", err)
+	}
+	if n := count(); n != 3 {
+		t.Fatalf("%d sessions after declining, want all 3", n)
+	}
+
+	if err := app.runSessionsGC([]string{"--yes"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("%d sessions after gc --yes, want only the recent one", n)
+	}
+}
@@ -0,0 +1,158 @@
+package launcher
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+// stagedBinary is one binary a platform needs under bin/<platform>/.
+// Candidates are the paths it may be found at, relative to the USB root.
+type stagedBinary struct {
+	Name       string
+	Candidates []string
+}
+
+// stageFile is a binary found for a platform
+type stageFile struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// platformStage reports whether a platform's binaries are on the USB
+type platformStage struct {
+	Platform platform.Platform `json:"platform"`
+	Staged   bool              `json:"staged"`
+	Files    []stageFile       `json:"files,omitempty"`
+	Missing  []string          `json:"missing,omitempty"`
+}
+
+// stagedBinaries lists the binaries a platform needs, where the launcher
+// looks for them
+func stagedBinaries(p platform.Platform) []stagedBinary {
+	dir := filepath.Join("bin", string(p))
+	return []stagedBinary{
+		{Name: "claude", Candidates: []string{
+			filepath.Join(dir, p.BinaryName("claude")),
+		}},
+		{Name: "node", Candidates: []string{
+			filepath.Join(dir, "node", "bin", p.BinaryName("node")),
+			filepath.Join(dir, "node", p.BinaryName("node")), // Windows zip layout
+		}},
+	}
+}
+
+// checkStage reports which platforms have all their binaries under
+// usbRoot/bin, hashing them when checksums is set
+func checkStage(usbRoot string, checksums bool) ([]platformStage, error) {
+	var stages []platformStage
+	for _, p := range platform.AllPlatforms {
+		stage := platformStage{Platform: p}
+
+		for _, bin := range stagedBinaries(p) {
+			file, found, err := findStaged(usbRoot, bin, checksums)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				stage.Files = append(stage.Files, file)
+			} else {
+				stage.Missing = append(stage.Missing, filepath.ToSlash(bin.Candidates[0]))
+			}
+		}
+
+		stage.Staged = len(stage.Missing) == 0
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// findStaged looks for bin at its candidate paths
+func findStaged(usbRoot string, bin stagedBinary, checksums bool) (stageFile, bool, error) {
+	for _, rel := range bin.Candidates {
+		path := filepath.Join(usbRoot, rel)
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		file := stageFile{Name: bin.Name, Path: filepath.ToSlash(rel), Size: info.Size()}
+		if checksums {
+			if file.SHA256, _, err = fsutil.SHA256File(path); err != nil {
+				return stageFile{}, false, fmt.Errorf("failed to hash %s: %w", rel, err)
+			}
+		}
+		return file, true, nil
+	}
+	return stageFile{}, false, nil
+}
+
+// runStageCheck reports which platforms' claude and node binaries are
+// staged under bin/, for preparing a USB for a mixed fleet
+func (app *App) runStageCheck(args []string) error {
+	fs := flag.NewFlagSet("stage check", flag.ContinueOnError)
+	checksums := fs.Bool("checksums", false, "print each binary's SHA256")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	stages, err := checkStage(app.usbRoot, *checksums)
+	if err != nil {
+		return err
+	}
+
+	incomplete := 0
+	for _, stage := range stages {
+		if !stage.Staged {
+			incomplete++
+		}
+	}
+
+	if app.opts.JSON {
+		if err := printJSON(stages); err != nil {
+			return err
+		}
+	} else {
+		for _, stage := range stages {
+			if stage.Staged {
+				fmt.Printf("  "+markOK+" %s\n", stage.Platform)
+			} else {
+				fmt.Printf("  "+markFail+" %s\n", stage.Platform)
+			}
+			for _, file := range stage.Files {
+				fmt.Printf("      %s (%s)\n", file.Path, formatSize(file.Size))
+				if file.SHA256 != "" {
+					fmt.Printf("        sha256 %s\n", file.SHA256)
+				}
+			}
+			for _, missing := range stage.Missing {
+				fmt.Printf("      missing %s\n", missing)
+			}
+		}
+	}
+
+	if incomplete > 0 {
+		return fmt.Errorf("%d of %d platforms are missing binaries", incomplete, len(stages))
+	}
+	return nil
+}
+
+// formatSize renders a byte count for humans, e.g. "95.3 MB"
+func formatSize(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGT"[exp])
+}
@@ -0,0 +1,169 @@
+package launcher
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cxt9/claude-go/internal/update"
+)
+
+// updateCheckResult is the --json shape of "update check"
+type updateCheckResult struct {
+	CurrentVersion  string                `json:"current_version"`
+	LatestVersion   string                `json:"latest_version"`
+	UpdateAvailable bool                  `json:"update_available"`
+	ReleaseDate     string                `json:"release_date,omitempty"`
+	SignedBy        string                `json:"signed_by,omitempty"`
+	Changelog       []update.ReleaseNotes `json:"changelog,omitempty"`
+}
+
+// runUpdateCheck reports whether a newer release is available
+func (app *App) runUpdateCheck(args []string) error {
+	updater, err := update.NewUpdater(app.usbRoot)
+	if err != nil {
+		return err
+	}
+	if err := updater.SetPinnedKeys(app.config.Updates.PinnedKeys); err != nil {
+		return fmt.Errorf("updates.pinned_keys: %w", err)
+	}
+
+	manifest, hasUpdate, err := updater.CheckForUpdate(app.ctx)
+	if err != nil {
+		return err
+	}
+
+	if app.opts.JSON {
+		result := updateCheckResult{
+			CurrentVersion:  updater.CurrentVersion,
+			LatestVersion:   manifest.Version,
+			UpdateAvailable: hasUpdate,
+			ReleaseDate:     manifest.ReleaseDate,
+			SignedBy:        manifest.SignedBy,
+		}
+		if hasUpdate {
+			result.Changelog = manifest.ChangelogSince(updater.CurrentVersion)
+		}
+		return printJSON(result)
+	}
+
+	if !hasUpdate {
+		fmt.Printf(markOK+" Up to date (%s)\n", updater.CurrentVersion)
+		return nil
+	}
+
+	fmt.Printf("Update available: %s → %s\n", updater.CurrentVersion, manifest.Version)
+	printSignature(os.Stdout, updater, manifest)
+	printChangelog(os.Stdout, manifest.ChangelogSince(updater.CurrentVersion))
+	return nil
+}
+
+// runUpdateInstall shows what changed and installs the latest release once
+// confirmed, or installs a downloaded bundle with --file
+func (app *App) runUpdateInstall(args []string) error {
+	fs := flag.NewFlagSet("update install", flag.ContinueOnError)
+	yes := fs.Bool("yes", false, "install without asking for confirmation")
+	file := fs.String("file", "", "install this .zip or .tar.gz bundle instead of downloading")
+	allowDowngrade := fs.Bool("allow-downgrade", false, "install a version older than one installed before")
+	keepCache := fs.Bool("keep-cache", false, "don't clear cache/ or the profiles' caches after installing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	updater, err := update.NewUpdater(app.usbRoot)
+	if err != nil {
+		return err
+	}
+	if err := updater.SetPinnedKeys(app.config.Updates.PinnedKeys); err != nil {
+		return fmt.Errorf("updates.pinned_keys: %w", err)
+	}
+	updater.AllowDowngrade = *allowDowngrade
+	updater.DataRoot = app.dataRoot
+	updater.KeepCache = *keepCache || !app.config.Updates.ClearCacheOnUpdate
+	updater.KeepCacheDirs = app.config.Updates.KeepCacheDirs
+
+	if *file != "" {
+		fmt.Fprintf(app.out, "Installing %s...\n", *file)
+		if err := updater.PerformOfflineUpdate(*file); err != nil {
+			if errors.Is(err, update.ErrDowngrade) {
+				return fmt.Errorf("update failed: %w (pass --allow-downgrade to install it anyway)", err)
+			}
+			return fmt.Errorf("update failed: %w", err)
+		}
+		fmt.Fprintf(app.out, markOK+" Installed %s\n", updater.CurrentVersion)
+		return nil
+	}
+
+	manifest, hasUpdate, err := updater.CheckForUpdate(app.ctx)
+	if err != nil {
+		return err
+	}
+
+	if !hasUpdate {
+		fmt.Fprintf(app.out, markOK+" Up to date (%s)\n", updater.CurrentVersion)
+		return nil
+	}
+
+	fmt.Fprintf(app.out, "Update available: %s → %s\n", updater.CurrentVersion, manifest.Version)
+	printSignature(app.out, updater, manifest)
+	printChangelog(app.out, manifest.ChangelogSince(updater.CurrentVersion))
+
+	if !*yes {
+		if err := app.confirmInstall(); err != nil {
+			return err
+		}
+	}
+
+	err = updater.PerformUpdate(app.ctx, manifest, func(downloaded, total int64) {
+		if total > 0 {
+			fmt.Fprintf(app.out, "\rDownloading... %d%%", downloaded*100/total)
+		}
+	})
+	fmt.Fprintln(app.out)
+	if err != nil {
+		if errors.Is(err, update.ErrDowngrade) {
+			return fmt.Errorf("update failed: %w (pass --allow-downgrade to install it anyway)", err)
+		}
+		return fmt.Errorf("update failed: %w", err)
+	}
+
+	fmt.Fprintf(app.out, markOK+" Updated to %s\n", manifest.Version)
+	return nil
+}
+
+// confirmInstall asks before an update is installed; without a terminal
+// to ask on, the user must pass --yes
+func (app *App) confirmInstall() error {
+	if !stdinIsTerminal() {
+		return fmt.Errorf("not a terminal; pass --yes to install without confirmation")
+	}
+	if !app.confirm("Install now?") {
+		return fmt.Errorf("update %w", errCancelled)
+	}
+	return nil
+}
+
+// printSignature says which key signed the manifest, or that this build
+// can't check
+func printSignature(w io.Writer, updater *update.Updater, manifest *update.Manifest) {
+	if updater.Signed() {
+		fmt.Fprintf(w, markOK+" Manifest signed by key %s\n", manifest.SignedBy)
+	} else {
+		fmt.Fprintln(w, markWarn+" This build has no update signing keys; only the download checksum is verified")
+	}
+}
+
+// printChangelog lists release notes, newest release first
+func printChangelog(w io.Writer, notes []update.ReleaseNotes) {
+	for _, release := range notes {
+		fmt.Fprintf(w, "\nWhat's new in %s:\n", release.Version)
+		for _, change := range release.Changes {
+			fmt.Fprintf(w, "  "+markItem+" %s\n", change)
+		}
+	}
+	if len(notes) > 0 {
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,84 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/update"
+)
+
+func TestPrintChangelog(t *testing.T) {
+	manifest := &update.Manifest{
+		Version: "1.3.0",
+		Changelogs: map[string][]string{
+			"1.1.0": {"Already installed"},
+			"1.2.0": {"Add session tags"},
+			"1.3.0": {"Fix token refresh", "Faster startup"},
+		},
+	}
+
+	var buf bytes.Buffer
+	printChangelog(&buf, manifest.ChangelogSince("1.1.0"))
+	out := buf.String()
+
+	for _, want := range []string{"What's new in 1.3.0:", "Fix token refresh", "Faster startup", "What's new in 1.2.0:", "Add session tags"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("changelog is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Already installed") {
+		t.Errorf("changelog shows the installed release's notes:\n%s", out)
+	}
+	if strings.Index(out, "1.3.0") > strings.Index(out, "1.2.0") {
+		t.Errorf("changelog isn't newest first:\n%s", out)
+	}
+
+	// Without per-version notes, only the latest changelog is shown
+	manifest = &update.Manifest{Version: "1.3.0", Changelog: []string{"Fix token refresh"}}
+	buf.Reset()
+	printChangelog(&buf, manifest.ChangelogSince("1.0.0"))
+	if out := buf.String(); !strings.Contains(out, "What's new in 1.3.0:") || !strings.Contains(out, "Fix token refresh") {
+		t.Errorf("latest-only changelog = %q", out)
+	}
+
+	buf.Reset()
+	printChangelog(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("empty changelog printed %q", buf.String())
+	}
+}
+
+func TestConfirmInstall(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		answer   string
+		wantErr  bool
+	}{
+		{"yes", true, "y\n", false},
+		{"no", true, "n\n", true},
+		{"no answer", true, "", true},
+		{"not a terminal", false, "y\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTerminal(t, tt.terminal)
+			app := newTestApp(t)
+			app.out = io.Discard
+			app.stdin = bufio.NewReader(strings.NewReader(tt.answer))
+
+			err := app.confirmInstall()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("confirmInstall = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.terminal && err != nil && !errors.Is(err, errCancelled) {
+				t.Errorf("declining = %v, want errCancelled", err)
+			}
+		})
+	}
+}
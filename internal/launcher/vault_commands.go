@@ -0,0 +1,121 @@
+package launcher
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+// runVaultVerify checks the vault file structure without asking for the
+// master password
+func (app *App) runVaultVerify(args []string) error {
+	v, err := vault.Open(app.vaultPath())
+	if errors.Is(err, vault.ErrVaultIncomplete) {
+		// Open already found it cut short
+		return fmt.Errorf("vault is corrupt: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	if err := v.VerifyStructure(); err != nil {
+		return fmt.Errorf("vault is corrupt: %w", err)
+	}
+
+	fmt.Println(markOK + " Vault structure OK")
+	return nil
+}
+
+// resetConfirmation must be typed exactly before a vault is reset
+const resetConfirmation = "reset my vault"
+
+// runVaultReset moves a vault whose password is lost aside and runs
+// first-time setup again
+func (app *App) runVaultReset(args []string) error {
+	vaultPath := app.vaultPath()
+	if !vault.Exists(vaultPath) {
+		return fmt.Errorf("no vault at %s", vaultPath)
+	}
+
+	fmt.Println(markWarn + " Resetting the vault discards every stored credential.")
+	fmt.Println("  The current vault is kept as a backup, but without its master")
+	fmt.Println("  password it cannot be recovered.")
+	fmt.Printf("\nType %q to continue: ", resetConfirmation)
+
+	answer, err := app.stdinReader().ReadString('\n')
+	if err != nil && answer == "" {
+		return fmt.Errorf("vault reset %w", errCancelled)
+	}
+	if strings.TrimSpace(answer) != resetConfirmation {
+		return fmt.Errorf("vault reset %w", errCancelled)
+	}
+
+	backup, err := vault.MoveAside(vaultPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(markOK+" Old vault moved to %s\n", backup)
+
+	return app.runFirstTimeSetup(vaultPath, nil)
+}
+
+// runVaultReEncrypt re-derives the vault key with another Argon2 profile
+func (app *App) runVaultReEncrypt(args []string) error {
+	fs := flag.NewFlagSet("vault reencrypt", flag.ContinueOnError)
+	profile := fs.String("profile", "", "Argon2 profile: interactive, sensitive or paranoid (default from config)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params, err := app.kdfParams(*profile)
+	if err != nil {
+		return err
+	}
+
+	v, err := vault.Open(app.vaultPath())
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+	if wait := v.LockoutRemaining(); wait > 0 {
+		return &vault.LockoutError{RetryAfter: wait}
+	}
+
+	password, err := app.promptPassword("Master password: ", false)
+	if err != nil {
+		return err
+	}
+
+	if err := v.Unlock(password); err != nil {
+		return err
+	}
+	defer v.Lock()
+	app.vault = v
+
+	fmt.Println("Re-encrypting vault...")
+	if err := v.ReEncrypt(password, params); err != nil {
+		return fmt.Errorf("failed to re-encrypt vault: %w", err)
+	}
+
+	fmt.Printf(markOK+" Vault re-encrypted (time=%d, memory=%d MiB, threads=%d)\n",
+		params.Time, params.Memory/1024, params.Threads)
+	return nil
+}
+
+// kdfParams resolves an Argon2 profile name, falling back to the configured
+// profile and then to the paranoid-mode default
+func (app *App) kdfParams(profile string) (vault.KDFParams, error) {
+	if profile == "" {
+		profile = app.config.Vault.KDFProfile
+	}
+	if profile == "" {
+		profile = vault.ProfileInteractive
+		if app.config.Environment.ParanoidMode {
+			profile = vault.ProfileParanoid
+		}
+	}
+
+	return vault.ProfileParams(profile)
+}
@@ -0,0 +1,133 @@
+package launcher
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/vault"
+	"golang.org/x/term"
+)
+
+func TestKDFParamsProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		config   string
+		paranoid bool
+		want     string
+	}{
+		{"default", "", "", false, vault.ProfileInteractive},
+		{"paranoid mode", "", "", true, vault.ProfileParanoid},
+		{"config wins over paranoid mode", "", vault.ProfileSensitive, true, vault.ProfileSensitive},
+		{"flag wins over config", vault.ProfileInteractive, vault.ProfileParanoid, false, vault.ProfileInteractive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t)
+			app.config.Vault.KDFProfile = tt.config
+			app.config.Environment.ParanoidMode = tt.paranoid
+
+			got, err := app.kdfParams(tt.flag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := vault.ProfileParams(tt.want)
+			if got != want {
+				t.Errorf("kdfParams(%q) = %+v, want the %s profile %+v", tt.flag, got, tt.want, want)
+			}
+		})
+	}
+
+	app := newTestApp(t)
+	app.config.Vault.KDFProfile = "extreme"
+	if _, err := app.kdfParams(""); err == nil {
+		t.Error("kdfParams accepted an unknown profile from the config")
+	}
+}
+
+func TestRunVaultVerify(t *testing.T) {
+	app := newTestApp(t)
+	createTestVault(t, app, "password")
+	valid, err := os.ReadFile(app.vaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No password is asked for
+	app.stdin = nil
+	if err := app.runVaultVerify(nil); err != nil {
+		t.Fatalf("valid vault: %v", err)
+	}
+
+	flipped := append([]byte(nil), valid...)
+	flipped[0] ^= 0x01
+	corrupt := map[string][]byte{
+		"truncated":   valid[:20],
+		"bit-flipped": flipped,
+		"header only": valid[:16],
+	}
+	for name, data := range corrupt {
+		if err := os.WriteFile(app.vaultPath(), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		err := app.runVaultVerify(nil)
+		if err == nil || !strings.Contains(err.Error(), "vault is corrupt") {
+			t.Errorf("%s: err = %v, want the vault reported corrupt", name, err)
+		}
+	}
+}
+
+func TestRunVaultReset(t *testing.T) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("setup after the reset would prompt on the terminal")
+	}
+
+	app := newTestApp(t)
+	app.out = io.Discard
+	createTestVault(t, app, "forgotten password")
+	original, err := os.ReadFile(app.vaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups := func() []string {
+		matches, _ := filepath.Glob(app.vaultPath() + ".*.bak")
+		return matches
+	}
+
+	// Anything but the exact phrase leaves the vault alone
+	for _, answer := range []string{"", "y\n", "yes\n", "reset my vault please\n", "RESET MY VAULT\n"} {
+		app.stdin = bufio.NewReader(strings.NewReader(answer))
+		if err := app.runVaultReset(nil); !errors.Is(err, errCancelled) {
+			t.Errorf("answer %q: runVaultReset = %v, want errCancelled", answer, err)
+		}
+		if data, err := os.ReadFile(app.vaultPath()); err != nil || !bytes.Equal(data, original) {
+			t.Fatalf("answer %q changed the vault: %v", answer, err)
+		}
+		if b := backups(); len(b) != 0 {
+			t.Fatalf("answer %q made a backup: %v", answer, b)
+		}
+	}
+
+	// With it, the vault is moved to a backup before setup starts again;
+	// setup itself fails here with nothing more to read
+	app.stdin = bufio.NewReader(strings.NewReader(resetConfirmation + "\n"))
+	app.runVaultReset(nil)
+
+	b := backups()
+	if len(b) != 1 {
+		t.Fatalf("backups = %v, want one", b)
+	}
+	if data, err := os.ReadFile(b[0]); err != nil || !bytes.Equal(data, original) {
+		t.Errorf("backup doesn't hold the old vault: %v", err)
+	}
+	if data, err := os.ReadFile(app.vaultPath()); err == nil && bytes.Equal(data, original) {
+		t.Error("the old vault is still in place")
+	}
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/config"
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// now dates cached results and ages them against the TTL; replaced in tests
+var now = time.Now
+
+// statusCache is the on-disk record of recent availability checks
+type statusCache struct {
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+// cacheEntry is a single server's last availability result. Key identifies
+// the server definition it was computed for, so config edits invalidate it.
+type cacheEntry struct {
+	Key       string       `json:"key"`
+	Status    ServerStatus `json:"status"`
+	CheckedAt time.Time    `json:"checked_at"`
+}
+
+// cacheKey hashes the fields of a server that affect its availability
+func (m *Manager) cacheKey(name string, server config.MCPServer) string {
+	parts := []string{
+		name,
+		server.Portability,
+		server.Type,
+		server.URL,
+		strconv.FormatBool(server.InsecureSkipVerify),
+		m.substituteVars(server.Command),
+	}
+	for _, arg := range server.Args {
+		parts = append(parts, m.substituteVars(arg))
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// cachedStatus returns a fresh cached status for the server, if any
+func (m *Manager) cachedStatus(cache *statusCache, name string, server config.MCPServer) (ServerStatus, bool) {
+	entry, ok := cache.Entries[name]
+	if !ok || entry.Key != m.cacheKey(name, server) {
+		return ServerStatus{}, false
+	}
+
+	if entry.CheckedAt.Before(m.notBefore) || now().Sub(entry.CheckedAt) > m.cacheTTL() {
+		return ServerStatus{}, false
+	}
+
+	// Required isn't part of the key, so always take it from the live config
+	status := entry.Status
+	status.Required = server.Required
+	return status, true
+}
+
+func (m *Manager) cacheTTL() time.Duration {
+	return time.Duration(m.config.CacheTTLSeconds) * time.Second
+}
+
+func (m *Manager) cachePath() string {
+	return filepath.Join(m.cacheDir, "mcp-status.json")
+}
+
+// loadCache reads the status cache, returning an empty cache if it is
+// missing or unreadable
+func (m *Manager) loadCache() *statusCache {
+	cache := &statusCache{Entries: make(map[string]cacheEntry)}
+
+	data, err := os.ReadFile(m.cachePath())
+	if err != nil {
+		return cache
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil || cache.Entries == nil {
+		return &statusCache{Entries: make(map[string]cacheEntry)}
+	}
+
+	return cache
+}
+
+// saveCache writes the status cache atomically so concurrent launches never
+// observe a partially-written file
+func (m *Manager) saveCache(cache *statusCache) error {
+	path := m.cachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	return fsutil.WriteFileAtomic(path, data, 0600)
+}
@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestCachedStatusExpires(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return clock }
+
+	root := t.TempDir()
+	binary := filepath.Join(root, "tools", "server")
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.MCPConfig{
+		CacheTTLSeconds: 60,
+		Servers: map[string]config.MCPServer{
+			"local": {Portability: "usb-local", Type: "stdio", Command: binary},
+		},
+	}
+	m, err := NewManager(root, t.TempDir(), cfg)
+	if err != nil {
+		t.Skip(err)
+	}
+	ctx := context.Background()
+
+	available := func() bool {
+		t.Helper()
+		statuses, err := m.CheckServers(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statuses) != 1 {
+			t.Fatalf("statuses = %+v, want one", statuses)
+		}
+		return statuses[0].Available
+	}
+
+	if !available() {
+		t.Fatal("the server isn't available at first")
+	}
+
+	// Within the TTL the cached result stands, though the binary is gone
+	if err := os.Remove(binary); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(59 * time.Second)
+	if !available() {
+		t.Error("the cached result wasn't served within the TTL")
+	}
+
+	// Once it expires the server is probed again
+	clock = clock.Add(2 * time.Second)
+	if available() {
+		t.Error("the cached result was served after the TTL")
+	}
+}
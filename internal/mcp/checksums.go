@@ -0,0 +1,238 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// ChecksumsFile lists the expected SHA256 of server binaries. A release
+// ships mcp/bundled/checksums.json, which only vouches for files under
+// mcp/bundled/; usb-local binaries elsewhere on the USB are recorded in
+// mcp/user/checksums.json.
+const ChecksumsFile = "checksums.json"
+
+// Directories holding a checksums file, relative to the USB root
+const (
+	BundledDir = "mcp/bundled"
+	userDir    = "mcp/user"
+)
+
+// checksums is the format of a checksums file. Paths are slash-separated
+// and relative to the USB root.
+type checksums struct {
+	Files map[string]string `json:"files"`
+}
+
+// Binary check outcomes
+const (
+	BinaryOK         = "ok"
+	BinaryMismatch   = "mismatch"   // the file differs from its recorded hash
+	BinaryMissing    = "missing"    // the file is gone
+	BinaryUnrecorded = "unrecorded" // no hash is recorded for it
+	BinaryOffUSB     = "off-usb"    // not on the USB, so not checked
+)
+
+// BinaryCheck is the result of checking one server's binary
+type BinaryCheck struct {
+	Server   string `json:"server"`
+	Path     string `json:"path"` // relative to the USB root when on it
+	Status   string `json:"status"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// Failed reports whether the binary is damaged or gone
+func (c BinaryCheck) Failed() bool {
+	return c.Status == BinaryMismatch || c.Status == BinaryMissing
+}
+
+// VerifyBinaries checks the binary of each configured bundled and
+// usb-local server against the recorded hashes, sorted by server
+func (m *Manager) VerifyBinaries() ([]BinaryCheck, error) {
+	recorded, err := m.recordedChecksums()
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []BinaryCheck{}
+	for name, server := range m.config.Servers {
+		if server.Portability != "bundled" && server.Portability != "usb-local" {
+			continue
+		}
+
+		cmd, _, err := m.ResolveCommand(server)
+		if err != nil {
+			return nil, err
+		}
+		check := BinaryCheck{Server: name, Path: cmd}
+
+		rel, ok := m.relativeToRoot(cmd)
+		if !ok {
+			check.Status = BinaryOffUSB
+			checks = append(checks, check)
+			continue
+		}
+		check.Path = rel
+		check.Expected = recorded[rel]
+
+		check.Actual, _, err = fsutil.SHA256File(cmd)
+		switch {
+		case os.IsNotExist(err):
+			check.Status = BinaryMissing
+		case err != nil:
+			return nil, fmt.Errorf("failed to hash %s: %w", rel, err)
+		case check.Expected == "":
+			check.Status = BinaryUnrecorded
+		case check.Actual != check.Expected:
+			check.Status = BinaryMismatch
+		default:
+			check.Status = BinaryOK
+		}
+		checks = append(checks, check)
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Server < checks[j].Server
+	})
+
+	return checks, nil
+}
+
+// RecordChecksums records the current hash of each usb-local binary
+// outside mcp/bundled/ in mcp/user/checksums.json, so later changes to it
+// are caught. It returns the paths recorded.
+func (m *Manager) RecordChecksums(checks []BinaryCheck) ([]string, error) {
+	file := filepath.Join(m.usbRoot, filepath.FromSlash(userDir), ChecksumsFile)
+	user, err := readChecksums(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorded []string
+	for _, check := range checks {
+		if check.Actual == "" || within(check.Path, BundledDir) {
+			continue
+		}
+		user[check.Path] = check.Actual
+		recorded = append(recorded, check.Path)
+	}
+	if len(recorded) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.MarshalIndent(checksums{Files: user}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", userDir, err)
+	}
+	if err := fsutil.WriteFileAtomic(file, append(data, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return recorded, nil
+}
+
+// WriteBundledChecksums hashes every file under root's mcp/bundled/ into
+// its checksums.json, for building a release. It returns the number of
+// files hashed.
+func WriteBundledChecksums(root string) (int, error) {
+	dir := filepath.Join(root, filepath.FromSlash(BundledDir))
+	files := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == BundledDir+"/"+ChecksumsFile {
+			return nil
+		}
+
+		if files[rel], _, err = fsutil.SHA256File(p); err != nil {
+			return fmt.Errorf("failed to hash %s: %w", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := json.MarshalIndent(checksums{Files: files}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, ChecksumsFile), append(data, '\n'), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write checksums: %w", err)
+	}
+	return len(files), nil
+}
+
+// recordedChecksums merges the bundled and user checksums files. Each only
+// counts for its own side of mcp/bundled/, so a user entry can't vouch for
+// a replaced bundled binary.
+func (m *Manager) recordedChecksums() (map[string]string, error) {
+	recorded := make(map[string]string)
+	for _, dir := range []string{BundledDir, userDir} {
+		files, err := readChecksums(filepath.Join(m.usbRoot, filepath.FromSlash(dir), ChecksumsFile))
+		if err != nil {
+			return nil, err
+		}
+		for rel, sum := range files {
+			if within(rel, BundledDir) == (dir == BundledDir) {
+				recorded[rel] = strings.ToLower(sum)
+			}
+		}
+	}
+	return recorded, nil
+}
+
+// readChecksums reads a checksums file; a missing one is empty
+func readChecksums(file string) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed checksums
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", file, err)
+	}
+	if parsed.Files == nil {
+		parsed.Files = make(map[string]string)
+	}
+	return parsed.Files, nil
+}
+
+// relativeToRoot returns path relative to the USB root, slash-separated,
+// if it lies under it
+func (m *Manager) relativeToRoot(p string) (string, bool) {
+	if m.usbRoot == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(m.usbRoot, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// within reports whether the slash path name lies under dir
+func within(name, dir string) bool {
+	return strings.HasPrefix(path.Clean(name), dir+"/")
+}
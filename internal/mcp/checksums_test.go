@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// binaryManager returns a manager for a USB root holding a bundled and a
+// usb-local server binary, with the bundled one's checksums written as a
+// release would
+func binaryManager(t *testing.T) (*Manager, map[string]string) {
+	t.Helper()
+
+	root := t.TempDir()
+	m, err := NewManager(root, t.TempDir(), &config.MCPConfig{Servers: map[string]config.MCPServer{
+		"fetch": {Portability: "bundled", Type: "stdio", Command: "$USB_ROOT/mcp/bundled/fetch/server"},
+		"mine":  {Portability: "usb-local", Type: "stdio", Command: "$USB_ROOT/tools/mine"},
+		"host":  {Portability: "host-local", Type: "stdio", Command: "/usr/bin/host-server"},
+	}})
+	if err != nil {
+		t.Skip(err)
+	}
+
+	binaries := map[string]string{
+		"fetch": filepath.Join(root, "mcp", "bundled", "fetch", m.platform.BinaryName("server")),
+		"mine":  filepath.Join(root, "tools", m.platform.BinaryName("mine")),
+	}
+	for name, path := range binaries {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name+" binary"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := WriteBundledChecksums(root); err != nil {
+		t.Fatal(err)
+	}
+	return m, binaries
+}
+
+// checkStatus returns the status VerifyBinaries reports for server
+func checkStatus(t *testing.T, m *Manager, server string) BinaryCheck {
+	t.Helper()
+
+	checks, err := m.VerifyBinaries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, check := range checks {
+		if check.Server == server {
+			return check
+		}
+	}
+	t.Fatalf("no check for %s in %+v", server, checks)
+	return BinaryCheck{}
+}
+
+func TestVerifyBinariesDetectsCorruption(t *testing.T) {
+	m, binaries := binaryManager(t)
+
+	if check := checkStatus(t, m, "fetch"); check.Status != BinaryOK {
+		t.Fatalf("intact binary: %+v", check)
+	}
+
+	// A flipped byte no longer matches the hash shipped with the release
+	data, err := os.ReadFile(binaries["fetch"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0] ^= 0x01
+	if err := os.WriteFile(binaries["fetch"], data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	check := checkStatus(t, m, "fetch")
+	if check.Status != BinaryMismatch || !check.Failed() || check.Actual == check.Expected {
+		t.Errorf("corrupted binary: %+v, want a mismatch", check)
+	}
+
+	if err := os.Remove(binaries["fetch"]); err != nil {
+		t.Fatal(err)
+	}
+	if check := checkStatus(t, m, "fetch"); check.Status != BinaryMissing || !check.Failed() {
+		t.Errorf("deleted binary: %+v, want missing", check)
+	}
+}
+
+func TestRecordChecksumsUsbLocal(t *testing.T) {
+	m, binaries := binaryManager(t)
+
+	check := checkStatus(t, m, "mine")
+	if check.Status != BinaryUnrecorded || check.Failed() {
+		t.Fatalf("usb-local binary before recording: %+v", check)
+	}
+	if recorded, err := m.RecordChecksums([]BinaryCheck{check}); err != nil || len(recorded) != 1 {
+		t.Fatalf("RecordChecksums = %v, %v", recorded, err)
+	}
+	if check := checkStatus(t, m, "mine"); check.Status != BinaryOK {
+		t.Errorf("after recording: %+v", check)
+	}
+
+	if err := os.WriteFile(binaries["mine"], []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if check := checkStatus(t, m, "mine"); check.Status != BinaryMismatch {
+		t.Errorf("tampered usb-local binary: %+v, want a mismatch", check)
+	}
+
+	// The user's file can't vouch for a replaced bundled binary
+	if err := os.WriteFile(binaries["fetch"], []byte("replaced"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.RecordChecksums([]BinaryCheck{checkStatus(t, m, "fetch")}); err != nil {
+		t.Fatal(err)
+	}
+	if check := checkStatus(t, m, "fetch"); check.Status != BinaryMismatch {
+		t.Errorf("replaced bundled binary: %+v, want a mismatch", check)
+	}
+
+	// Host-local servers aren't on the USB and aren't checked
+	checks, _ := m.VerifyBinaries()
+	for _, check := range checks {
+		if check.Server == "host" {
+			t.Errorf("host-local server was checked: %+v", check)
+		}
+	}
+}
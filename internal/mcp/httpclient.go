@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// probeClient returns the HTTP client used to check a remote server. It
+// does not follow redirects, since any response shows the server is up,
+// and skips certificate verification only if the server opts in.
+func probeClient(server config.MCPServer, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if server.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// describeHTTPError tells a certificate problem apart from a server that
+// can't be reached at all
+func describeHTTPError(err error) string {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		unknownErr   x509.UnknownAuthorityError
+		invalidErr   x509.CertificateInvalidError
+		hostnameErr  x509.HostnameError
+		recordHdrErr tls.RecordHeaderError
+	)
+
+	switch {
+	case errors.As(err, &verifyErr), errors.As(err, &unknownErr),
+		errors.As(err, &invalidErr), errors.As(err, &hostnameErr):
+		return fmt.Sprintf("certificate invalid: %v", err)
+	case errors.As(err, &recordHdrErr):
+		return fmt.Sprintf("TLS handshake failed (not an https server?): %v", err)
+	default:
+		return fmt.Sprintf("unreachable: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestCheckRemoteServerTLS(t *testing.T) {
+	// httptest's TLS server has a self-signed certificate
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	m := &Manager{}
+	server := config.MCPServer{Type: "http", URL: srv.URL}
+	ok, msg := m.checkRemoteServer(context.Background(), server)
+	if ok || !strings.HasPrefix(msg, "certificate invalid") {
+		t.Errorf("self-signed without insecure_skip_verify = %v, %q; want a certificate error", ok, msg)
+	}
+
+	server.InsecureSkipVerify = true
+	if ok, msg := m.checkRemoteServer(context.Background(), server); !ok {
+		t.Errorf("self-signed with insecure_skip_verify = %v, %q; want available", ok, msg)
+	}
+
+	// A closed port is unreachable, not a certificate problem
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	ok, msg = m.checkRemoteServer(context.Background(), config.MCPServer{Type: "http", URL: "https://" + addr})
+	if ok || !strings.HasPrefix(msg, "unreachable") {
+		t.Errorf("closed port = %v, %q; want unreachable", ok, msg)
+	}
+}
+
+func TestCheckRemoteServerRedirect(t *testing.T) {
+	// The redirect points at nothing; following it would fail the check
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://127.0.0.1:1/login", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	m := &Manager{}
+	server := config.MCPServer{Type: "http", URL: srv.URL}
+	if ok, msg := m.checkRemoteServer(context.Background(), server); !ok {
+		t.Errorf("redirecting server = %v, %q; want available", ok, msg)
+	}
+}
+
+func TestCheckRemoteServerRejectsInvalidURL(t *testing.T) {
+	m := &Manager{}
+	for _, server := range []config.MCPServer{
+		{Type: "http", URL: "not a url"},
+		{Type: "http", URL: "http://127.0.0.1:1/mcp", CredentialRef: "mcp/tracker"},
+	} {
+		// Rejected before any request is made, so the message is the
+		// validation error rather than "unreachable"
+		ok, msg := m.checkRemoteServer(context.Background(), server)
+		if ok || msg == "" || strings.HasPrefix(msg, "unreachable") {
+			t.Errorf("%s = %v, %q; want a validation error", server.URL, ok, msg)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// localCheckTimeout bounds the filesystem calls of a local server check
+const localCheckTimeout = 3 * time.Second
+
+// LocalFS answers whether a local server's command exists
+type LocalFS interface {
+	Stat(name string) (os.FileInfo, error)
+	LookPath(file string) (string, error)
+}
+
+// DefaultLocalFS is used by local server checks; replace it to stub slow or
+// missing filesystems
+var DefaultLocalFS LocalFS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+// errLocalCheckTimeout means a local check didn't finish in time
+var errLocalCheckTimeout = fmt.Errorf("timed out after %s; is the path on a slow or disconnected network drive?", localCheckTimeout)
+
+// boundedCheck runs a local server check, giving up after
+// localCheckTimeout or when ctx is cancelled. A stat on a stale network
+// mount can't be interrupted, so an abandoned check finishes in the
+// background and its result is dropped.
+func boundedCheck(ctx context.Context, check func() (bool, string)) (bool, string, error) {
+	type result struct {
+		ok  bool
+		msg string
+	}
+	done := make(chan result, 1)
+	go func() {
+		ok, msg := check()
+		done <- result{ok, msg}
+	}()
+
+	timer := time.NewTimer(localCheckTimeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.ok, r.msg, nil
+	case <-timer.C:
+		return false, "", errLocalCheckTimeout
+	case <-ctx.Done():
+		return false, "", ctx.Err()
+	}
+}
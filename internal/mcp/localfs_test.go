@@ -0,0 +1,108 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// fakeFS stubs the filesystem calls of local server checks. A call blocks
+// until release is closed, as on a disconnected network share, when it is
+// set.
+type fakeFS struct {
+	exists  map[string]bool
+	release chan struct{}
+}
+
+func (f fakeFS) wait() {
+	if f.release != nil {
+		<-f.release
+	}
+}
+
+func (f fakeFS) Stat(name string) (os.FileInfo, error) {
+	f.wait()
+	if !f.exists[name] {
+		return nil, os.ErrNotExist
+	}
+	return nil, nil
+}
+
+func (f fakeFS) LookPath(file string) (string, error) {
+	f.wait()
+	if !f.exists[file] {
+		return "", errors.New("executable file not found in $PATH")
+	}
+	return "/usr/bin/" + file, nil
+}
+
+// useFS replaces DefaultLocalFS for the rest of the test
+func useFS(t *testing.T, fs LocalFS) {
+	t.Helper()
+
+	orig := DefaultLocalFS
+	DefaultLocalFS = fs
+	t.Cleanup(func() { DefaultLocalFS = orig })
+}
+
+func TestCheckLocalServer(t *testing.T) {
+	useFS(t, fakeFS{exists: map[string]bool{"/opt/server": true, "npx": true}})
+	m := &Manager{}
+
+	tests := []struct {
+		command string
+		ok      bool
+	}{
+		{"/opt/server", true},
+		{"/opt/missing", false},
+		{"npx", true},
+		{"uvx", false},
+	}
+	for _, tt := range tests {
+		ok, msg, timedOut := m.checkLocalServer(context.Background(), config.MCPServer{Type: "stdio", Command: tt.command}, false)
+		if ok != tt.ok || timedOut {
+			t.Errorf("%s: ok = %v (%s), timed out = %v; want ok = %v", tt.command, ok, msg, timedOut, tt.ok)
+		}
+	}
+}
+
+func TestCheckLocalServerSlowFilesystem(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	useFS(t, fakeFS{exists: map[string]bool{"/mnt/share/server": true}, release: release})
+	m := &Manager{}
+
+	// The launch gives up on a stat that never returns
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	ok, msg, timedOut := m.checkLocalServer(ctx, config.MCPServer{Type: "stdio", Command: "/mnt/share/server"}, false)
+	if ok || !timedOut || msg == "" {
+		t.Errorf("ok = %v, timed out = %v, msg = %q; want unavailable and timed out", ok, timedOut, msg)
+	}
+	if elapsed := time.Since(start); elapsed > localCheckTimeout {
+		t.Errorf("check took %s", elapsed)
+	}
+}
+
+func TestBoundedCheckTimeout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for localCheckTimeout")
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	_, _, err := boundedCheck(context.Background(), func() (bool, string) {
+		<-release
+		return true, ""
+	})
+	if err != errLocalCheckTimeout {
+		t.Errorf("err = %v, want errLocalCheckTimeout", err)
+	}
+}
@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// ExecCommand is the hidden launcher command claude starts in place of a
+// stdio server when server logs are on:
+//
+//	claude-go mcp-exec --log FILE --max-bytes N -- command args...
+//
+// It runs the server with claude's stdin and stdout and copies its stderr
+// to FILE.
+const ExecCommand = "mcp-exec"
+
+// serverLogs says where stdio servers' stderr is captured
+type serverLogs struct {
+	dir      string
+	maxBytes int64
+	launcher string
+}
+
+// SetServerLogs captures the stderr of stdio servers in dir/<name>.log,
+// rotated to <name>.log.1 beyond maxBytes. launcher is the claude-go
+// executable, which the generated config runs as a wrapper around each
+// server (see ExecCommand); TestServer captures directly.
+func (m *Manager) SetServerLogs(dir string, maxBytes int64, launcher string) {
+	m.logs = &serverLogs{dir: dir, maxBytes: maxBytes, launcher: launcher}
+}
+
+// LogPath returns where a server's stderr is captured, or "" when logs
+// are off or the server's name can't be used as a file name. Names come
+// from project .mcp.json files too, so one like "../x" must not place the
+// log outside the logs directory.
+func (m *Manager) LogPath(name string) string {
+	if m.logs == nil || !validLogName(name) {
+		return ""
+	}
+	return filepath.Join(m.logs.dir, name+".log")
+}
+
+// validLogName reports whether a server name is safe as a log file name
+func validLogName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\`) && !strings.Contains(name, "..")
+}
+
+// wrapCommand returns the command line that runs a stdio server through
+// ExecCommand, or the server's own when logs are off
+func (m *Manager) wrapCommand(name, command string, args []string) (string, []string) {
+	logPath := m.LogPath(name)
+	if logPath == "" {
+		return command, args
+	}
+
+	wrapped := []string{ExecCommand, "--log", logPath, "--max-bytes", strconv.FormatInt(m.logs.maxBytes, 10), "--", command}
+	return m.logs.launcher, append(wrapped, args...)
+}
+
+// openServerLog opens a server's stderr log, or returns nil when logs are
+// off or the log can't be opened
+func (m *Manager) openServerLog(name string) *fsutil.LogWriter {
+	logPath := m.LogPath(name)
+	if logPath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.logs.dir, 0700); err != nil {
+		return nil
+	}
+	w, err := fsutil.OpenLogWriter(logPath, m.logs.maxBytes, nil)
+	if err != nil {
+		return nil
+	}
+	return w
+}
@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogPathRejectsUnsafeNames(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mcp-logs")
+	m := &Manager{}
+	m.SetServerLogs(dir, 1<<20, "/usb/bin/claude-go")
+
+	if got, want := m.LogPath("github"), filepath.Join(dir, "github.log"); got != want {
+		t.Errorf("LogPath(github) = %q, want %q", got, want)
+	}
+
+	for _, name := range []string{"", "../escape", "..", "a/b", `a\b`, "/etc/passwd", "x..y"} {
+		if got := m.LogPath(name); got != "" {
+			t.Errorf("LogPath(%q) = %q, want no log", name, got)
+		}
+
+		// Such a server runs unwrapped rather than logging elsewhere
+		if cmd, args := m.wrapCommand(name, "server", []string{"--flag"}); cmd != "server" || len(args) != 1 {
+			t.Errorf("wrapCommand(%q) = %s %v, want the server's own command", name, cmd, args)
+		}
+		if w := m.openServerLog(name); w != nil {
+			w.Close()
+			t.Errorf("openServerLog(%q) opened a log", name)
+		}
+	}
+
+	cmd, args := m.wrapCommand("github", "server", []string{"--flag"})
+	if cmd != "/usb/bin/claude-go" || args[0] != ExecCommand || !strings.HasSuffix(args[2], "github.log") || args[len(args)-1] != "--flag" {
+		t.Errorf("wrapCommand(github) = %s %v", cmd, args)
+	}
+
+	m.logs = nil
+	if got := m.LogPath("github"); got != "" {
+		t.Errorf("LogPath with logs off = %q", got)
+	}
+}
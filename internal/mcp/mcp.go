@@ -1,12 +1,13 @@
 package mcp
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cxt9/claude-go/internal/config"
@@ -15,19 +16,48 @@ import (
 
 // ServerStatus represents the availability status of an MCP server
 type ServerStatus struct {
-	Name        string
-	Portability string
-	Available   bool
-	Required    bool
-	Error       string
+	Name        string `json:"name"`
+	Portability string `json:"portability"`
+	Available   bool   `json:"available"`
+	Required    bool   `json:"required"`
+	Error       string `json:"error,omitempty"`
+
+	// A host-local server whose command isn't installed on this computer,
+	// as opposed to a broken config or an unreachable server
+	NotInstalled bool   `json:"not_installed,omitempty"`
+	Hint         string `json:"hint,omitempty"`
+
+	// The project's .mcp.json defining a server by the same name, which
+	// claude uses instead
+	OverriddenBy string `json:"overridden_by,omitempty"`
+
+	// Turned off with "enabled": false; not checked or given to claude
+	Disabled bool `json:"disabled,omitempty"`
+
+	// The check gave up on a slow filesystem; not cached
+	timedOut bool
 }
 
 // Manager handles MCP server resolution and availability checking
 type Manager struct {
 	usbRoot    string
+	cacheDir   string
 	projectDir string
 	platform   platform.Platform
 	config     *config.MCPConfig
+
+	// Cached results checked before notBefore are ignored
+	mu        sync.Mutex
+	notBefore time.Time
+
+	// OAuth access tokens of servers with their own login, by server name
+	accessTokens map[string]string
+
+	// Servers of the profile chosen with SetProfile; nil for all
+	profile map[string]bool
+
+	// Where stdio servers' stderr goes; see SetServerLogs
+	logs *serverLogs
 }
 
 // NewManager creates a new MCP manager
@@ -37,45 +67,130 @@ func NewManager(usbRoot, projectDir string, cfg *config.MCPConfig) (*Manager, er
 		return nil, err
 	}
 
+	// No configuration means no servers
+	if cfg == nil {
+		cfg = &config.MCPConfig{}
+	}
+
 	return &Manager{
 		usbRoot:    usbRoot,
+		cacheDir:   filepath.Join(usbRoot, "cache"),
 		projectDir: projectDir,
 		platform:   plat,
 		config:     cfg,
 	}, nil
 }
 
-// CheckServers checks availability of all configured MCP servers
-func (m *Manager) CheckServers() ([]ServerStatus, error) {
-	var statuses []ServerStatus
+// SetCacheDir moves the availability cache, e.g. into a profile's cache/
+func (m *Manager) SetCacheDir(dir string) {
+	m.cacheDir = dir
+}
 
-	for name, server := range m.config.Servers {
-		status := ServerStatus{
-			Name:        name,
-			Portability: server.Portability,
-			Required:    server.Required,
-		}
+// SetRefresh forces servers to be re-probed instead of using cached results
+// from before this call
+func (m *Manager) SetRefresh(refresh bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		switch server.Portability {
-		case "remote":
-			status.Available, status.Error = m.checkRemoteServer(server)
-		case "bundled":
-			status.Available, status.Error = m.checkLocalServer(server, true)
-		case "usb-local":
-			status.Available, status.Error = m.checkLocalServer(server, true)
-		case "host-local":
-			status.Available, status.Error = m.checkLocalServer(server, false)
-		default:
-			status.Available = false
-			status.Error = fmt.Sprintf("unknown portability type: %s", server.Portability)
+	if refresh {
+		m.notBefore = now()
+	} else {
+		m.notBefore = time.Time{}
+	}
+}
+
+// CheckServers checks availability of all configured MCP servers, reusing
+// results cached within the configured TTL. Disabled servers aren't probed
+// and are reported as such. Cancelling ctx aborts the remaining probes.
+func (m *Manager) CheckServers(ctx context.Context) ([]ServerStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var statuses []ServerStatus
+	cache := m.loadCache()
+
+	for name, server := range m.servers() {
+		if !server.IsEnabled() {
+			statuses = append(statuses, ServerStatus{
+				Name:        name,
+				Portability: server.Portability,
+				Required:    server.Required,
+				Disabled:    true,
+			})
+			continue
+		}
+		if status, ok := m.cachedStatus(cache, name, server); ok {
+			statuses = append(statuses, status)
+			continue
 		}
 
+		status := m.checkServer(ctx, name, server)
+		if err := ctx.Err(); err != nil {
+			// Don't cache a probe that was cut short
+			return nil, err
+		}
+		if status.timedOut {
+			// A hung mount may answer next time
+			statuses = append(statuses, status)
+			continue
+		}
+		cache.Entries[name] = cacheEntry{
+			Key:       m.cacheKey(name, server),
+			Status:    status,
+			CheckedAt: now(),
+		}
 		statuses = append(statuses, status)
 	}
 
+	if m.cacheTTL() > 0 {
+		// A failed cache write only costs a re-probe next time
+		m.saveCache(cache)
+	}
+
+	// Not cached: the project's .mcp.json may change at any time
+	overridden := m.overridden()
+	for i := range statuses {
+		statuses[i].OverriddenBy = overridden[statuses[i].Name]
+	}
+
 	return statuses, nil
 }
 
+// checkServer probes a single server's availability
+func (m *Manager) checkServer(ctx context.Context, name string, server config.MCPServer) ServerStatus {
+	status := ServerStatus{
+		Name:        name,
+		Portability: server.Portability,
+		Required:    server.Required,
+	}
+
+	// Configs built in code skip config.Load's validation
+	if err := server.Validate(); err != nil {
+		status.Error = fmt.Sprintf("invalid config: %v", err)
+		return status
+	}
+
+	switch server.Portability {
+	case "remote":
+		status.Available, status.Error = m.checkRemoteServer(ctx, server)
+	case "bundled", "usb-local":
+		status.Available, status.Error, status.timedOut = m.checkLocalServer(ctx, server, true)
+	case "host-local":
+		// The config is valid, so a failure means the command is missing,
+		// unless the check itself timed out
+		status.Available, status.Error, status.timedOut = m.checkLocalServer(ctx, server, false)
+		if !status.Available && !status.timedOut {
+			status.NotInstalled = true
+			status.Hint = fmt.Sprintf("host-local servers run software installed on each computer; install %s here to use it", filepath.Base(server.Command))
+		}
+	default:
+		status.Available = false
+		status.Error = fmt.Sprintf("unknown portability type: %s", server.Portability)
+	}
+
+	return status
+}
+
 // ResolveCommand resolves a server command with variable substitution
 func (m *Manager) ResolveCommand(server config.MCPServer) (string, []string, error) {
 	cmd := m.substituteVars(server.Command)
@@ -103,9 +218,53 @@ func (m *Manager) ResolveEnv(server config.MCPServer) map[string]string {
 	return env
 }
 
-// GetAvailableServers returns only servers that are available
-func (m *Manager) GetAvailableServers() (map[string]config.MCPServer, []ServerStatus, error) {
-	statuses, err := m.CheckServers()
+// SetAccessToken sets the OAuth bearer token sent to the named server
+func (m *Manager) SetAccessToken(name, token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.accessTokens == nil {
+		m.accessTokens = make(map[string]string)
+	}
+	m.accessTokens[name] = token
+}
+
+// serverHeaders returns a server's configured headers plus its OAuth
+// bearer token, if one was set
+func (m *Manager) serverHeaders(name string, server config.MCPServer) map[string]string {
+	headers := m.ResolveHeaders(server)
+
+	m.mu.Lock()
+	token := m.accessTokens[name]
+	m.mu.Unlock()
+
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return headers
+}
+
+// ResolveHeaders resolves HTTP headers for a remote server
+func (m *Manager) ResolveHeaders(server config.MCPServer) map[string]string {
+	headers := make(map[string]string)
+	for k, v := range server.Headers {
+		headers[k] = m.substituteVars(v)
+	}
+	return headers
+}
+
+// Quiet reports whether an unavailable server should be left out of launch
+// warnings: a missing, optional host-local server with
+// mcp.quiet_missing_host_local set. It is omitted from the generated config
+// like any unavailable server.
+func (m *Manager) Quiet(status ServerStatus) bool {
+	return m.config.QuietMissingHostLocal && status.NotInstalled && !status.Required
+}
+
+// GetAvailableServers returns only servers that are available, and the
+// enabled ones that aren't
+func (m *Manager) GetAvailableServers(ctx context.Context) (map[string]config.MCPServer, []ServerStatus, error) {
+	statuses, err := m.CheckServers(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -114,6 +273,9 @@ func (m *Manager) GetAvailableServers() (map[string]config.MCPServer, []ServerSt
 	var unavailable []ServerStatus
 
 	for _, status := range statuses {
+		if status.Disabled {
+			continue
+		}
 		if status.Available {
 			available[status.Name] = m.config.Servers[status.Name]
 		} else {
@@ -124,13 +286,14 @@ func (m *Manager) GetAvailableServers() (map[string]config.MCPServer, []ServerSt
 	return available, unavailable, nil
 }
 
-// HasRequiredUnavailable checks if any required servers are unavailable
-func (m *Manager) HasRequiredUnavailable() (bool, []string) {
-	statuses, _ := m.CheckServers()
+// HasRequiredUnavailable checks if any required servers are unavailable.
+// A disabled server is never required.
+func (m *Manager) HasRequiredUnavailable(ctx context.Context) (bool, []string) {
+	statuses, _ := m.CheckServers(ctx)
 
 	var missing []string
 	for _, status := range statuses {
-		if status.Required && !status.Available {
+		if status.Required && !status.Available && !status.Disabled {
 			missing = append(missing, status.Name)
 		}
 	}
@@ -138,16 +301,20 @@ func (m *Manager) HasRequiredUnavailable() (bool, []string) {
 	return len(missing) > 0, missing
 }
 
-func (m *Manager) checkRemoteServer(server config.MCPServer) (bool, string) {
-	if server.URL == "" {
-		return false, "no URL configured"
+func (m *Manager) checkRemoteServer(ctx context.Context, server config.MCPServer) (bool, string) {
+	if err := server.ValidateURL(); err != nil {
+		return false, err.Error()
 	}
 
 	// Quick HTTP HEAD check with timeout
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Head(server.URL)
+	client := probeClient(server, 5*time.Second)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, server.URL, nil)
+	if err != nil {
+		return false, fmt.Sprintf("invalid URL: %v", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return false, fmt.Sprintf("unreachable: %v", err)
+		return false, describeHTTPError(err)
 	}
 	resp.Body.Close()
 
@@ -155,43 +322,53 @@ func (m *Manager) checkRemoteServer(server config.MCPServer) (bool, string) {
 	return true, ""
 }
 
-func (m *Manager) checkLocalServer(server config.MCPServer, resolveVars bool) (bool, string) {
+// checkLocalServer checks that a local server's command exists. It is
+// bounded by localCheckTimeout, since PATH or the command may be on a
+// network share that no longer answers.
+func (m *Manager) checkLocalServer(ctx context.Context, server config.MCPServer, resolveVars bool) (bool, string, bool) {
 	if server.Command == "" {
-		return false, "no command configured"
+		return false, "no command configured", false
 	}
 
-	cmd := server.Command
-	if resolveVars {
-		cmd = m.substituteVars(cmd)
-		cmd = m.resolvePlatformBinary(cmd)
-	}
+	fs := DefaultLocalFS
+	ok, msg, err := boundedCheck(ctx, func() (bool, string) {
+		cmd := server.Command
+		if resolveVars {
+			cmd = m.substituteVars(cmd)
+			cmd = m.resolvePlatformBinaryFS(fs, cmd)
+		}
 
-	// Check if command exists
-	if filepath.IsAbs(cmd) {
-		if _, err := os.Stat(cmd); os.IsNotExist(err) {
-			return false, fmt.Sprintf("not found: %s", cmd)
+		// Check if command exists
+		if filepath.IsAbs(cmd) {
+			if _, err := fs.Stat(cmd); os.IsNotExist(err) {
+				return false, fmt.Sprintf("not found: %s", cmd)
+			}
+			return true, ""
+		}
+
+		// Check in PATH
+		if _, err := fs.LookPath(cmd); err != nil {
+			return false, fmt.Sprintf("not in PATH: %s", cmd)
 		}
-		return true, ""
-	}
 
-	// Check in PATH
-	_, err := exec.LookPath(cmd)
+		return true, ""
+	})
 	if err != nil {
-		return false, fmt.Sprintf("not in PATH: %s", cmd)
+		return false, fmt.Sprintf("checking %s: %v", server.Command, err), true
 	}
 
-	return true, ""
+	return ok, msg, false
 }
 
 func (m *Manager) substituteVars(s string) string {
-	s = strings.ReplaceAll(s, "$USB_ROOT", m.usbRoot)
-	s = strings.ReplaceAll(s, "${USB_ROOT}", m.usbRoot)
-	s = strings.ReplaceAll(s, "$PROJECT_DIR", m.projectDir)
-	s = strings.ReplaceAll(s, "${PROJECT_DIR}", m.projectDir)
-	return s
+	return config.SubstituteVars(s, m.usbRoot, m.projectDir)
 }
 
 func (m *Manager) resolvePlatformBinary(path string) string {
+	return m.resolvePlatformBinaryFS(DefaultLocalFS, path)
+}
+
+func (m *Manager) resolvePlatformBinaryFS(fs LocalFS, path string) string {
 	// If path already contains platform, return as-is
 	if strings.Contains(path, string(m.platform)) {
 		return path
@@ -202,7 +379,7 @@ func (m *Manager) resolvePlatformBinary(path string) string {
 	base := filepath.Base(path)
 
 	platformPath := filepath.Join(dir, string(m.platform), m.platform.BinaryName(base))
-	if _, err := os.Stat(platformPath); err == nil {
+	if _, err := fs.Stat(platformPath); err == nil {
 		return platformPath
 	}
 
@@ -211,30 +388,50 @@ func (m *Manager) resolvePlatformBinary(path string) string {
 }
 
 // GenerateClaudeConfig generates MCP configuration for Claude Code
-func (m *Manager) GenerateClaudeConfig() (map[string]interface{}, error) {
-	available, _, err := m.GetAvailableServers()
+func (m *Manager) GenerateClaudeConfig(ctx context.Context) (map[string]interface{}, error) {
+	available, _, err := m.GetAvailableServers(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	mcpServers := make(map[string]interface{})
+	overridden := m.overridden()
 
 	for name, server := range available {
+		if overridden[name] != "" {
+			continue
+		}
+
 		serverConfig := make(map[string]interface{})
 
 		switch server.Type {
 		case "stdio":
 			cmd, args, _ := m.ResolveCommand(server)
-			serverConfig["command"] = cmd
+			cmd, args = m.wrapCommand(name, cmd, args)
+			serverConfig["command"] = m.portable(cmd)
+			for i := range args {
+				args[i] = m.portable(args[i])
+			}
 			if len(args) > 0 {
 				serverConfig["args"] = args
 			}
 			env := m.ResolveEnv(server)
+			for k, v := range env {
+				env[k] = m.portable(v)
+			}
 			if len(env) > 0 {
 				serverConfig["env"] = env
 			}
 
-		case "http", "websocket":
+		case "http", "sse":
+			serverConfig["type"] = server.Type
+			serverConfig["url"] = server.URL
+			headers := m.serverHeaders(name, server)
+			if len(headers) > 0 {
+				serverConfig["headers"] = headers
+			}
+
+		case "websocket":
 			serverConfig["url"] = server.URL
 		}
 
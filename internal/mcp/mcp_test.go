@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestDisabledServerOmitted(t *testing.T) {
+	root := t.TempDir()
+	binary := filepath.Join(root, "tools", "server")
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := false
+	cfg := &config.MCPConfig{Servers: map[string]config.MCPServer{
+		"on": {Portability: "usb-local", Type: "stdio", Command: binary},
+		// Required, and its binary is gone, but it is switched off
+		"off": {Portability: "usb-local", Type: "stdio", Command: filepath.Join(root, "missing"), Required: true, Enabled: &disabled},
+	}}
+	m, err := NewManager(root, t.TempDir(), cfg)
+	if err != nil {
+		t.Skip(err)
+	}
+	ctx := context.Background()
+
+	generated, err := m.GenerateClaudeConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := generated["mcpServers"].(map[string]interface{})
+	if _, ok := servers["on"]; !ok {
+		t.Error("the enabled server is missing from the generated config")
+	}
+	if _, ok := servers["off"]; ok {
+		t.Error("the disabled server is in the generated config")
+	}
+
+	if blocked, missing := m.HasRequiredUnavailable(ctx); blocked {
+		t.Errorf("a disabled server blocks the launch: %v", missing)
+	}
+
+	// It is reported as disabled, not unavailable
+	_, unavailable, err := m.GetAvailableServers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unavailable) != 0 {
+		t.Errorf("unavailable = %+v, want none", unavailable)
+	}
+	statuses, err := m.CheckServers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Name == "off" && (!status.Disabled || status.Error != "") {
+			t.Errorf("status of the disabled server = %+v", status)
+		}
+	}
+	if got := m.Disabled(); len(got) != 1 || got[0] != "off" {
+		t.Errorf("Disabled = %v, want [off]", got)
+	}
+
+	// Enabled again, the missing required server blocks
+	enabled := true
+	server := cfg.Servers["off"]
+	server.Enabled = &enabled
+	cfg.Servers["off"] = server
+	if blocked, missing := m.HasRequiredUnavailable(ctx); !blocked || len(missing) != 1 || missing[0] != "off" {
+		t.Errorf("HasRequiredUnavailable = %v, %v; want off missing", blocked, missing)
+	}
+}
+
+func TestCheckServersNilAndMalformedConfig(t *testing.T) {
+	m, err := NewManager(t.TempDir(), t.TempDir(), nil)
+	if err != nil {
+		t.Skip(err)
+	}
+	ctx := context.Background()
+
+	statuses, err := m.CheckServers(ctx)
+	if err != nil || len(statuses) != 0 {
+		t.Errorf("nil config: CheckServers = %v, %v; want no servers", statuses, err)
+	}
+	if missing, names := m.HasRequiredUnavailable(ctx); missing {
+		t.Errorf("nil config: required servers unavailable: %v", names)
+	}
+
+	// Built in code, so config.Load never validated them
+	m, _ = NewManager(t.TempDir(), t.TempDir(), &config.MCPConfig{Servers: map[string]config.MCPServer{
+		"no-command": {Portability: "usb-local", Type: "stdio", Required: true},
+		"no-url":     {Portability: "remote", Type: "http"},
+		"bad-type":   {Portability: "remote", Type: "carrier-pigeon", URL: "https://mcp.example.com"},
+	}})
+	statuses, err = m.CheckServers(ctx)
+	if err != nil {
+		t.Fatalf("CheckServers: %v", err)
+	}
+	if len(statuses) != 3 {
+		t.Fatalf("statuses = %+v, want one per server", statuses)
+	}
+	for _, status := range statuses {
+		if status.Available || !strings.HasPrefix(status.Error, "invalid config") {
+			t.Errorf("%s: available=%v, error=%q; want an invalid config error", status.Name, status.Available, status.Error)
+		}
+	}
+	if missing, names := m.HasRequiredUnavailable(ctx); !missing || len(names) != 1 || names[0] != "no-command" {
+		t.Errorf("HasRequiredUnavailable = %v, %v; want [no-command]", missing, names)
+	}
+}
+
+func TestHostLocalNotInstalled(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.MCPConfig{Servers: map[string]config.MCPServer{
+		"host-missing":  {Portability: "host-local", Type: "stdio", Command: "claude-go-test-not-installed"},
+		"host-required": {Portability: "host-local", Type: "stdio", Command: "claude-go-test-not-installed", Required: true},
+		"usb-missing":   {Portability: "usb-local", Type: "stdio", Command: filepath.Join(root, "missing")},
+		"invalid":       {Portability: "host-local", Type: "stdio"},
+	}}
+	m, err := NewManager(root, t.TempDir(), cfg)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	available, unavailable, err := m.GetAvailableServers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(available) != 0 {
+		t.Errorf("available = %v, want none", available)
+	}
+
+	byName := make(map[string]ServerStatus)
+	for _, status := range unavailable {
+		byName[status.Name] = status
+	}
+	for name, want := range map[string]bool{"host-missing": true, "host-required": true, "usb-missing": false, "invalid": false} {
+		status := byName[name]
+		if status.NotInstalled != want || (status.Hint != "") != want {
+			t.Errorf("%s: NotInstalled = %v, hint %q; want not installed %v", name, status.NotInstalled, status.Hint, want)
+		}
+	}
+
+	// Only optional, missing host-local servers are quiet, and only if asked
+	for name := range byName {
+		if m.Quiet(byName[name]) {
+			t.Errorf("%s is quiet without quiet_missing_host_local", name)
+		}
+	}
+	cfg.QuietMissingHostLocal = true
+	for name, want := range map[string]bool{"host-missing": true, "host-required": false, "usb-missing": false, "invalid": false} {
+		if got := m.Quiet(byName[name]); got != want {
+			t.Errorf("%s: Quiet = %v, want %v", name, got, want)
+		}
+	}
+}
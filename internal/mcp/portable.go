@@ -0,0 +1,49 @@
+package mcp
+
+import "strings"
+
+// USBRootEnv is the environment variable the launcher sets to the USB root
+// for claude and the servers it starts
+const USBRootEnv = "CLAUDE_CODE_GO_USB_ROOT"
+
+// usbRootRef is how a portable config refers to the USB root; claude
+// expands ${VAR} in MCP server commands, args and env
+const usbRootRef = "${" + USBRootEnv + "}"
+
+// portable rewrites the USB root at the start of any path in s to
+// usbRootRef when mcp.portable_paths is set, so the generated config works
+// wherever the drive is mounted next
+func (m *Manager) portable(s string) string {
+	if !m.config.PortablePaths || m.usbRoot == "" {
+		return s
+	}
+	return replaceRoot(s, m.usbRoot, usbRootRef)
+}
+
+// replaceRoot replaces root in s with ref wherever it forms a whole path
+// prefix: followed by a separator or the end of s, so /mnt/usb doesn't
+// match inside /mnt/usb2
+func replaceRoot(s, root, ref string) string {
+	root = strings.TrimRight(root, `/\`)
+	if root == "" {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(s, root)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+
+		end := i + len(root)
+		if end == len(s) || s[end] == '/' || s[end] == '\\' {
+			b.WriteString(s[:i])
+			b.WriteString(ref)
+		} else {
+			b.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+}
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestReplaceRoot(t *testing.T) {
+	tests := []struct {
+		s, root, want string
+	}{
+		{"/mnt/usb/tools/server", "/mnt/usb", "${ROOT}/tools/server"},
+		{"/mnt/usb", "/mnt/usb/", "${ROOT}"},
+		{"--config=/mnt/usb/a:/mnt/usb/b", "/mnt/usb", "--config=${ROOT}/a:${ROOT}/b"},
+		{"/mnt/usb2/tools/server", "/mnt/usb", "/mnt/usb2/tools/server"},
+		{`E:\tools\server.exe`, `E:\`, `${ROOT}\tools\server.exe`},
+		{"/elsewhere/server", "/mnt/usb", "/elsewhere/server"},
+	}
+
+	for _, tt := range tests {
+		if got := replaceRoot(tt.s, tt.root, "${ROOT}"); got != tt.want {
+			t.Errorf("replaceRoot(%q, %q) = %q, want %q", tt.s, tt.root, got, tt.want)
+		}
+	}
+}
+
+// generateAt returns the MCP config generated for a USB mounted at root
+func generateAt(t *testing.T, root string, portable bool) string {
+	t.Helper()
+
+	binary := filepath.Join(root, "tools", "server")
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.MCPConfig{
+		PortablePaths: portable,
+		Servers: map[string]config.MCPServer{
+			"tools": {
+				Portability: "usb-local",
+				Type:        "stdio",
+				Command:     "$USB_ROOT/tools/server",
+				Args:        []string{"--data", "$USB_ROOT/data"},
+				Env:         map[string]string{"TOOLS_HOME": "$USB_ROOT/tools"},
+			},
+		},
+	}
+	m, err := NewManager(root, t.TempDir(), cfg)
+	if err != nil {
+		t.Skip(err)
+	}
+	generated, err := m.GenerateClaudeConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(generated["mcpServers"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPortablePathsAcrossMounts(t *testing.T) {
+	first := filepath.Join(t.TempDir(), "usb")
+	second := filepath.Join(t.TempDir(), "other-mount")
+
+	// The root as it appears inside the JSON
+	quoted, _ := json.Marshal(first)
+	firstJSON := strings.Trim(string(quoted), `"`)
+
+	// Without portable paths, each mount point is baked into the config
+	a, b := generateAt(t, first, false), generateAt(t, second, false)
+	if a == b || !strings.Contains(a, firstJSON) {
+		t.Errorf("absolute configs:\n%s\n%s", a, b)
+	}
+
+	// With them, the config is the same wherever the drive is mounted
+	a, b = generateAt(t, first, true), generateAt(t, second, true)
+	if a != b {
+		t.Errorf("portable configs differ between mount points:\n%s\n%s", a, b)
+	}
+	if strings.Contains(a, firstJSON) || !strings.Contains(a, usbRootRef) {
+		t.Errorf("portable config = %s, want paths relative to %s", a, usbRootRef)
+	}
+}
@@ -0,0 +1,239 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+const (
+	// MCP protocol revision offered in the initialize handshake
+	protocolVersion = "2024-11-05"
+
+	// How long a server gets to complete the handshake
+	testTimeout = 15 * time.Second
+)
+
+// TestResult describes a server that completed the initialize handshake
+type TestResult struct {
+	Name            string                 `json:"name"`
+	ProtocolVersion string                 `json:"protocol_version"`
+	ServerName      string                 `json:"server_name,omitempty"`
+	ServerVersion   string                 `json:"server_version,omitempty"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response or notification from a server
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ServerInfo      struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"serverInfo"`
+}
+
+// TestServer launches (or connects to) the named server and performs an MCP
+// initialize handshake, proving it actually speaks the protocol rather than
+// merely existing
+func (m *Manager) TestServer(ctx context.Context, name string) (*TestResult, error) {
+	server, ok := m.config.Servers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown MCP server: %s", name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+
+	var resp *rpcResponse
+	var err error
+
+	switch server.Type {
+	case "stdio":
+		resp, err = m.initializeStdio(ctx, name, server)
+	case "http":
+		resp, err = m.initializeHTTP(ctx, name, server)
+	default:
+		return nil, fmt.Errorf("testing %s servers is not supported", server.Type)
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("no initialize response within %s", testTimeout)
+		}
+		return nil, err
+	}
+
+	if resp.Error != nil {
+		return nil, fmt.Errorf("initialize failed: %s (code %d)", resp.Error.Message, resp.Error.Code)
+	}
+
+	var result initializeResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return nil, fmt.Errorf("invalid initialize result: %w", err)
+	}
+
+	return &TestResult{
+		Name:            name,
+		ProtocolVersion: result.ProtocolVersion,
+		ServerName:      result.ServerInfo.Name,
+		ServerVersion:   result.ServerInfo.Version,
+		Capabilities:    result.Capabilities,
+	}, nil
+}
+
+func initializeRequest() []byte {
+	req, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params": map[string]interface{}{
+			"protocolVersion": protocolVersion,
+			"capabilities":    map[string]interface{}{},
+			"clientInfo": map[string]string{
+				"name":    "claude-go",
+				"version": "1.0",
+			},
+		},
+	})
+	return req
+}
+
+// initializeStdio spawns the server and exchanges newline-delimited JSON-RPC
+// messages over its stdin/stdout. The process is always torn down on return.
+// Its stderr goes to the server's log, if logs are on.
+func (m *Manager) initializeStdio(ctx context.Context, name string, server config.MCPServer) (*rpcResponse, error) {
+	command, args, err := m.ResolveCommand(server)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = m.projectDir
+	cmd.Env = os.Environ()
+	for k, v := range m.ResolveEnv(server) {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	if logw := m.openServerLog(name); logw != nil {
+		defer logw.Close()
+		cmd.Stderr = logw
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start server: %w", err)
+	}
+
+	// Closing stdin asks the server to exit; killing makes sure it does
+	defer func() {
+		stdin.Close()
+		if cmd.Process != nil {
+			cmd.Process.Kill()
+		}
+		cmd.Wait()
+	}()
+
+	if _, err := stdin.Write(append(initializeRequest(), '\n')); err != nil {
+		return nil, fmt.Errorf("failed to send initialize: %w", err)
+	}
+
+	return readResponse(ctx, stdout, "")
+}
+
+// initializeHTTP posts the handshake to a streamable-HTTP endpoint, which
+// may answer with plain JSON or an event stream
+func (m *Manager) initializeHTTP(ctx context.Context, name string, server config.MCPServer) (*rpcResponse, error) {
+	if err := server.ValidateURL(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, bytes.NewReader(initializeRequest()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	for k, v := range m.serverHeaders(name, server) {
+		req.Header.Set(k, v)
+	}
+
+	// The test timeout is bounded by ctx
+	resp, err := probeClient(server, 0).Do(req)
+	if err != nil {
+		return nil, errors.New(describeHTTPError(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status %s", resp.Status)
+	}
+
+	prefix := ""
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		prefix = "data:"
+	}
+
+	return readResponse(ctx, resp.Body, prefix)
+}
+
+// readResponse reads messages line by line until the response to the
+// initialize request arrives, skipping notifications and log lines. With a
+// prefix, only lines carrying it (e.g. SSE "data:") are considered.
+func readResponse(ctx context.Context, r io.Reader, prefix string) (*rpcResponse, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if prefix != "" {
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			line = strings.TrimPrefix(line, prefix)
+		}
+
+		var resp rpcResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			continue
+		}
+		if string(resp.ID) == "1" {
+			return &resp, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("server closed the connection without responding")
+}
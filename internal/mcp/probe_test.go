@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// initializeReply is what the fake servers answer an initialize request with
+const initializeReply = `{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2024-11-05","capabilities":{"tools":{}},"serverInfo":{"name":"fake","version":"0.1"}}}`
+
+// TestHelperMCPServer is run as a child process by TestTestServerStdio: a
+// stdio MCP server that logs and notifies before answering initialize
+func TestHelperMCPServer(t *testing.T) {
+	if os.Getenv("CLAUDE_GO_TEST_MCP_SERVER") != "1" {
+		t.Skip("run as a child process")
+	}
+
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	var req struct {
+		Method string `json:"method"`
+	}
+	if json.Unmarshal([]byte(line), &req) != nil || req.Method != "initialize" {
+		os.Exit(1)
+	}
+	fmt.Println("starting fake server")
+	fmt.Println(`{"jsonrpc":"2.0","method":"notifications/message","params":{}}`)
+	fmt.Println(initializeReply)
+	os.Exit(0)
+}
+
+func checkTestResult(t *testing.T, result *TestResult, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ProtocolVersion != "2024-11-05" || result.ServerName != "fake" || result.ServerVersion != "0.1" {
+		t.Errorf("result = %+v", result)
+	}
+	if _, ok := result.Capabilities["tools"]; !ok {
+		t.Errorf("capabilities = %v, want tools", result.Capabilities)
+	}
+}
+
+func TestTestServerStdio(t *testing.T) {
+	cfg := &config.MCPConfig{Servers: map[string]config.MCPServer{
+		"fake": {
+			Portability: "host-local",
+			Type:        "stdio",
+			Command:     os.Args[0],
+			Args:        []string{"-test.run=^TestHelperMCPServer$"},
+			Env:         map[string]string{"CLAUDE_GO_TEST_MCP_SERVER": "1"},
+		},
+		"silent": {
+			Portability: "host-local",
+			Type:        "stdio",
+			Command:     os.Args[0],
+			Args:        []string{"-test.run=^TestHelperMCPServer$"},
+		},
+	}}
+	m, err := NewManager(t.TempDir(), t.TempDir(), cfg)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	result, err := m.TestServer(context.Background(), "fake")
+	checkTestResult(t, result, err)
+
+	// Without the variable the helper is skipped and exits without answering
+	if _, err := m.TestServer(context.Background(), "silent"); err == nil {
+		t.Error("a server that never answered passed")
+	}
+	if _, err := m.TestServer(context.Background(), "missing"); err == nil {
+		t.Error("an unknown server passed")
+	}
+}
+
+func TestTestServerHTTP(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				http.Error(w, "bad accept", http.StatusBadRequest)
+				return
+			}
+			if stream {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", initializeReply)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintln(w, initializeReply)
+		}))
+		defer srv.Close()
+
+		cfg := &config.MCPConfig{Servers: map[string]config.MCPServer{
+			"fake": {Portability: "remote", Type: "http", URL: srv.URL},
+		}}
+		m, err := NewManager(t.TempDir(), t.TempDir(), cfg)
+		if err != nil {
+			t.Skip(err)
+		}
+
+		result, err := m.TestServer(context.Background(), "fake")
+		checkTestResult(t, result, err)
+	}
+}
@@ -0,0 +1,65 @@
+package mcp
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+// AllServersProfile selects every configured server, the default
+const AllServersProfile = config.AllMCPServersProfile
+
+// SetProfile limits the servers checked and written to the generated
+// config to those of a profile in mcp.profiles. "" or AllServersProfile
+// selects every server.
+func (m *Manager) SetProfile(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if name == "" || name == AllServersProfile {
+		m.profile = nil
+		return nil
+	}
+
+	servers, ok := m.config.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown mcp profile: %s", name)
+	}
+
+	m.profile = make(map[string]bool, len(servers))
+	for _, server := range servers {
+		m.profile[server] = true
+	}
+	return nil
+}
+
+// servers returns the configured servers in the current profile
+func (m *Manager) servers() map[string]config.MCPServer {
+	if m.profile == nil {
+		return m.config.Servers
+	}
+
+	servers := make(map[string]config.MCPServer, len(m.profile))
+	for name, server := range m.config.Servers {
+		if m.profile[name] {
+			servers[name] = server
+		}
+	}
+	return servers
+}
+
+// Disabled returns the disabled servers in the current profile, sorted
+func (m *Manager) Disabled() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var names []string
+	for name, server := range m.servers() {
+		if !server.IsEnabled() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestProfileFiltersGeneratedConfig(t *testing.T) {
+	root := t.TempDir()
+	binary := filepath.Join(root, "tools", "server")
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	server := config.MCPServer{Portability: "usb-local", Type: "stdio", Command: binary}
+	m, err := NewManager(root, t.TempDir(), &config.MCPConfig{
+		Servers: map[string]config.MCPServer{"fetch": server, "git": server, "debugger": server},
+		Profiles: map[string][]string{
+			"debug":  {"debugger", "git"},
+			"normal": {"fetch", "git"},
+		},
+	})
+	if err != nil {
+		t.Skip(err)
+	}
+
+	generated := func() string {
+		t.Helper()
+		cfg, err := m.GenerateClaudeConfig(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for name := range cfg["mcpServers"].(map[string]interface{}) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+
+	for _, tt := range []struct{ profile, want string }{
+		{"debug", "debugger,git"},
+		{"normal", "fetch,git"},
+		{AllServersProfile, "debugger,fetch,git"},
+		{"", "debugger,fetch,git"},
+	} {
+		if err := m.SetProfile(tt.profile); err != nil {
+			t.Fatalf("SetProfile(%q): %v", tt.profile, err)
+		}
+		if got := generated(); got != tt.want {
+			t.Errorf("profile %q: generated servers = %s, want %s", tt.profile, got, tt.want)
+		}
+	}
+
+	if err := m.SetProfile("missing"); err == nil {
+		t.Error("SetProfile accepted an unknown profile")
+	}
+}
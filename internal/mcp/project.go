@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ProjectConfigFile is the project-scoped MCP config claude reads from a
+// project's root alongside the config the launcher generates
+const ProjectConfigFile = ".mcp.json"
+
+// Collision is a server name defined both in the USB config and in the
+// project's .mcp.json. The project's definition wins: the USB one is left
+// out of the generated config, so claude never sees two servers by the
+// same name.
+type Collision struct {
+	Name        string `json:"name"`
+	ProjectFile string `json:"project_file"`
+}
+
+// projectConfig is the part of a project's .mcp.json needed to find names
+type projectConfig struct {
+	MCPServers map[string]json.RawMessage `json:"mcpServers"`
+}
+
+// Collisions returns the configured servers the project redefines, sorted
+// by name. A project without .mcp.json has none.
+func (m *Manager) Collisions() ([]Collision, error) {
+	if m.projectDir == "" {
+		return nil, nil
+	}
+
+	path := filepath.Join(m.projectDir, ProjectConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var project projectConfig
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var collisions []Collision
+	for name := range m.servers() {
+		if _, ok := project.MCPServers[name]; ok {
+			collisions = append(collisions, Collision{Name: name, ProjectFile: path})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].Name < collisions[j].Name
+	})
+
+	return collisions, nil
+}
+
+// overridden returns the names of servers the project redefines. An
+// unreadable .mcp.json overrides nothing; claude reports it itself.
+func (m *Manager) overridden() map[string]string {
+	collisions, _ := m.Collisions()
+
+	names := make(map[string]string, len(collisions))
+	for _, c := range collisions {
+		names[c.Name] = c.ProjectFile
+	}
+	return names
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/config"
+)
+
+func TestProjectCollisions(t *testing.T) {
+	root := t.TempDir()
+	binary := filepath.Join(root, "tools", "server")
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	project := t.TempDir()
+	m, err := NewManager(root, project, &config.MCPConfig{Servers: map[string]config.MCPServer{
+		"shared":   {Portability: "usb-local", Type: "stdio", Command: binary},
+		"usb-only": {Portability: "usb-local", Type: "stdio", Command: binary},
+	}})
+	if err != nil {
+		t.Skip(err)
+	}
+	ctx := context.Background()
+
+	// No .mcp.json, no collisions
+	if collisions, err := m.Collisions(); err != nil || len(collisions) != 0 {
+		t.Errorf("without .mcp.json: %v, %v", collisions, err)
+	}
+
+	projectFile := filepath.Join(project, ProjectConfigFile)
+	if err := os.WriteFile(projectFile, []byte(`{"mcpServers": {"shared": {"command": "npx"}, "project-only": {"command": "npx"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	collisions, err := m.Collisions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collisions) != 1 || collisions[0].Name != "shared" || collisions[0].ProjectFile != projectFile {
+		t.Errorf("collisions = %+v, want shared from %s", collisions, projectFile)
+	}
+
+	// Reported on the server's status, and the project's definition wins
+	statuses, err := m.CheckServers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if want := map[string]string{"shared": projectFile}[status.Name]; status.OverriddenBy != want {
+			t.Errorf("%s: OverriddenBy = %q, want %q", status.Name, status.OverriddenBy, want)
+		}
+	}
+	generated, err := m.GenerateClaudeConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	servers := generated["mcpServers"].(map[string]interface{})
+	if _, ok := servers["shared"]; ok {
+		t.Error("the overridden USB server is in the generated config")
+	}
+	if _, ok := servers["usb-only"]; !ok {
+		t.Error("the USB-only server is missing from the generated config")
+	}
+
+	// A broken .mcp.json is reported, but overrides nothing
+	if err := os.WriteFile(projectFile, []byte(`{"mcpServers": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Collisions(); err == nil {
+		t.Error("Collisions accepted a malformed .mcp.json")
+	}
+	generated, err = m.GenerateClaudeConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := generated["mcpServers"].(map[string]interface{})["shared"]; !ok {
+		t.Error("a malformed .mcp.json dropped the USB server")
+	}
+}
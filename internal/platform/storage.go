@@ -0,0 +1,24 @@
+package platform
+
+// Storage describes the kind of media a path lives on
+type Storage string
+
+const (
+	StorageRemovable Storage = "removable"
+	StorageFixed     Storage = "fixed"
+	StorageUnknown   Storage = "unknown"
+)
+
+// DetectStorage reports whether path is on removable media (a USB stick or
+// SD card) or a fixed disk. Detection is best-effort: USB enclosures for
+// SSDs often report themselves as fixed, and failures yield StorageUnknown.
+// The result is informational: it backs vault.require_removable and is
+// shown by doctor, but doesn't change any default.
+func DetectStorage(path string) Storage {
+	return detectStorage(path)
+}
+
+// IsRemovable reports whether the storage is known to be removable
+func (s Storage) IsRemovable() bool {
+	return s == StorageRemovable
+}
@@ -0,0 +1,55 @@
+//go:build darwin
+
+package platform
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+func detectStorage(path string) Storage {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return StorageUnknown
+	}
+
+	mount := make([]byte, 0, len(st.Mntonname))
+	for _, c := range st.Mntonname {
+		if c == 0 {
+			break
+		}
+		mount = append(mount, byte(c))
+	}
+
+	out, err := exec.Command("diskutil", "info", string(mount)).Output()
+	if err != nil {
+		return StorageUnknown
+	}
+
+	return parseDiskutilInfo(string(out))
+}
+
+// parseDiskutilInfo classifies the output of `diskutil info <mount>`
+func parseDiskutilInfo(info string) Storage {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	switch {
+	case fields["Removable Media"] == "Removable",
+		fields["Protocol"] == "USB",
+		fields["Device Location"] == "External":
+		return StorageRemovable
+	case fields["Removable Media"] == "Fixed":
+		return StorageFixed
+	default:
+		return StorageUnknown
+	}
+}
@@ -0,0 +1,89 @@
+//go:build linux
+
+package platform
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func detectStorage(path string) Storage {
+	return detectStorageFrom(path, "/proc/self/mountinfo", "/sys")
+}
+
+// detectStorageFrom resolves path's mount to its block device through
+// mountinfo and sysfs (both injectable so they can be faked)
+func detectStorageFrom(path, mountinfo, sysRoot string) Storage {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return StorageUnknown
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+
+	devID := mountDeviceID(abs, mountinfo)
+	if devID == "" {
+		return StorageUnknown
+	}
+
+	// /sys/dev/block/<major:minor> links to the device node in the sysfs tree
+	device, err := filepath.EvalSymlinks(filepath.Join(sysRoot, "dev", "block", devID))
+	if err != nil {
+		return StorageUnknown
+	}
+
+	if strings.Contains(device, "/usb") {
+		return StorageRemovable
+	}
+
+	// Partitions don't carry the flag; their parent disk does
+	for _, dir := range []string{device, filepath.Dir(device)} {
+		data, err := os.ReadFile(filepath.Join(dir, "removable"))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(data)) == "1" {
+			return StorageRemovable
+		}
+		return StorageFixed
+	}
+
+	return StorageUnknown
+}
+
+// mountDeviceID returns the major:minor of the mount containing path
+func mountDeviceID(path, mountinfo string) string {
+	f, err := os.Open(mountinfo)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var bestMount, bestID string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// id parent major:minor root mountpoint ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+
+		mount := strings.ReplaceAll(fields[4], `\040`, " ")
+		if !pathWithin(path, mount) || len(mount) < len(bestMount) {
+			continue
+		}
+		bestMount, bestID = mount, fields[2]
+	}
+
+	return bestID
+}
+
+func pathWithin(path, dir string) bool {
+	if dir == "/" || path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, dir+"/")
+}
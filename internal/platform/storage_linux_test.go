@@ -0,0 +1,75 @@
+//go:build linux
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeBlockDevice adds a device to a fake sysfs tree: dev/block/<id> links
+// to devices/<node>, and the disk holding it gets its removable flag
+func fakeBlockDevice(t *testing.T, sysRoot, id, node, disk, removable string) {
+	t.Helper()
+
+	nodeDir := filepath.Join(sysRoot, "devices", filepath.FromSlash(node))
+	if err := os.MkdirAll(nodeDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if disk != "" {
+		flag := filepath.Join(sysRoot, "devices", filepath.FromSlash(disk), "removable")
+		if err := os.WriteFile(flag, []byte(removable+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	link := filepath.Join(sysRoot, "dev", "block", id)
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(nodeDir, link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectStorageFrom(t *testing.T) {
+	sysRoot := t.TempDir()
+	fakeBlockDevice(t, sysRoot, "259:1", "pci0000:00/nvme/nvme0n1/nvme0n1p1", "pci0000:00/nvme/nvme0n1", "0")
+	fakeBlockDevice(t, sysRoot, "8:17", "pci0000:00/usb2/2-1/host6/block/sdb/sdb1", "", "")
+	fakeBlockDevice(t, sysRoot, "179:1", "platform/mmc0/block/mmcblk0/mmcblk0p1", "platform/mmc0/block/mmcblk0", "1")
+	fakeBlockDevice(t, sysRoot, "8:1", "pci0000:00/ata1/block/sda/sda1", "", "")
+
+	mountinfo := filepath.Join(t.TempDir(), "mountinfo")
+	lines := "" +
+		"22 1 259:1 / / rw,relatime - ext4 /dev/nvme0n1p1 rw\n" +
+		"36 22 8:17 / /media/USB\\040STICK rw,relatime - vfat /dev/sdb1 rw\n" +
+		"37 22 179:1 / /media/sd rw,relatime - exfat /dev/mmcblk0p1 rw\n" +
+		"38 22 8:1 / /mnt/data rw,relatime - ext4 /dev/sda1 rw\n" +
+		"39 22 0:50 / /media/sd/nested rw - tmpfs tmpfs rw\n" +
+		"short line\n"
+	if err := os.WriteFile(mountinfo, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want Storage
+	}{
+		{"/home/user/claude-go", StorageFixed},           // root filesystem on NVMe
+		{"/media/USB STICK/claude-go", StorageRemovable}, // under a USB bus, escaped space
+		{"/media/sd/claude-go", StorageRemovable},        // parent disk flagged removable
+		{"/mnt/data/claude-go", StorageUnknown},          // no removable flag anywhere
+		{"/media/sd/nested/x", StorageUnknown},           // longest mount has no block device
+	}
+
+	for _, tt := range tests {
+		if got := detectStorageFrom(tt.path, mountinfo, sysRoot); got != tt.want {
+			t.Errorf("detectStorageFrom(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	if got := detectStorageFrom("/media/sd", filepath.Join(t.TempDir(), "missing"), sysRoot); got != StorageUnknown {
+		t.Errorf("without mountinfo = %s, want %s", got, StorageUnknown)
+	}
+}
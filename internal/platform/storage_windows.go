@@ -0,0 +1,30 @@
+//go:build windows
+
+package platform
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+func detectStorage(path string) Storage {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return StorageUnknown
+	}
+
+	root, err := windows.UTF16PtrFromString(filepath.VolumeName(abs) + `\`)
+	if err != nil {
+		return StorageUnknown
+	}
+
+	switch windows.GetDriveType(root) {
+	case windows.DRIVE_REMOVABLE:
+		return StorageRemovable
+	case windows.DRIVE_FIXED:
+		return StorageFixed
+	default:
+		return StorageUnknown
+	}
+}
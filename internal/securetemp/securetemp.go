@@ -0,0 +1,76 @@
+package securetemp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Dir creates credential-bearing temp files in a private directory and
+// removes them on Cleanup. Unlike os.CreateTemp's default location, the
+// directory is owner-only and lives on the USB, not the host.
+type Dir struct {
+	path  string
+	mu    sync.Mutex
+	files []string
+}
+
+// New creates (or tightens) the private directory at path
+func New(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	// MkdirAll leaves an existing directory's mode alone
+	if err := os.Chmod(path, 0700); err != nil {
+		return nil, fmt.Errorf("failed to secure temp directory: %w", err)
+	}
+
+	return &Dir{path: path}, nil
+}
+
+// WriteFile writes data to a new 0600 file named after pattern (as in
+// os.CreateTemp) and registers it for cleanup
+func (d *Dir) WriteFile(pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp(d.path, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	d.mu.Lock()
+	d.files = append(d.files, f.Name())
+	d.mu.Unlock()
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to secure temp file: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+// Cleanup removes every file written through this Dir
+func (d *Dir) Cleanup() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []error
+	for _, path := range d.files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	d.files = nil
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+package securetemp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAndCleanup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "tmp")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// An existing, looser directory is tightened
+	d, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := d.WriteFile("gcp-*.json", []byte(`{"type":"service_account"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(file) != path {
+		t.Errorf("file written to %s, want under %s", file, path)
+	}
+	if data, err := os.ReadFile(file); err != nil || string(data) != `{"type":"service_account"}` {
+		t.Errorf("contents = %q, %v", data, err)
+	}
+
+	// Windows has no unix permission bits to check
+	if runtime.GOOS != "windows" {
+		for name, want := range map[string]os.FileMode{path: 0700, file: 0600} {
+			info, err := os.Stat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != want {
+				t.Errorf("%s mode = %v, want %v", name, got, want)
+			}
+		}
+	}
+
+	// Cleanup removes the files, tolerating one already gone
+	second, err := d.WriteFile("mcp-*.json", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("%s survived Cleanup", file)
+	}
+}
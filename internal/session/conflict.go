@@ -0,0 +1,41 @@
+package session
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrSessionConflict means a session's file changed after it was loaded,
+// e.g. by another claude-go on the same USB, so saving would lose that
+// change
+var ErrSessionConflict = errors.New("session was changed elsewhere since it was loaded")
+
+// checkUnchanged returns ErrSessionConflict if the session's file no
+// longer holds what this copy was loaded from or last wrote, and
+// ErrSessionDeleted if it's gone. Sessions never written aren't checked.
+func (m *Manager) checkUnchanged(session *Session) error {
+	if session.diskSum == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.sessionPath(session.ID))
+	if os.IsNotExist(err) {
+		return ErrSessionDeleted
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+
+	if sha256.Sum256(data) != *session.diskSum {
+		return fmt.Errorf("%s: %w", session.ID, ErrSessionConflict)
+	}
+	return nil
+}
+
+// remember records the file contents a session copy corresponds to
+func (s *Session) remember(data []byte) {
+	sum := sha256.Sum256(data)
+	s.diskSum = &sum
+}
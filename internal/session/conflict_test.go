@@ -0,0 +1,99 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestSaveDetectsOutOfBandChange(t *testing.T) {
+	m := NewManager(t.TempDir())
+	s, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another claude-go on the same USB saves the session meanwhile
+	other, err := m.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Summary = "changed elsewhere"
+	if err := m.Save(other); err != nil {
+		t.Fatal(err)
+	}
+
+	seq := m.currentSequence()
+	if err := m.Save(s); !errors.Is(err, ErrSessionConflict) {
+		t.Fatalf("Save of a stale copy: err = %v, want ErrSessionConflict", err)
+	}
+	if got := m.currentSequence(); got != seq {
+		t.Errorf("a conflicting save took sequence %d", got)
+	}
+
+	loaded, err := m.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Summary != "changed elsewhere" {
+		t.Errorf("Summary = %q, the other change was lost", loaded.Summary)
+	}
+
+	// An edit to the file by hand is caught too
+	if err := os.WriteFile(m.sessionPath(s.ID), []byte(`{"id":"`+s.ID+`"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetModel(loaded, "opus"); !errors.Is(err, ErrSessionConflict) {
+		t.Errorf("SetModel after a hand edit: err = %v, want ErrSessionConflict", err)
+	}
+}
+
+func TestConcurrentWritersConflict(t *testing.T) {
+	m := NewManager(t.TempDir())
+	s, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Copies loaded together; only the first write may succeed
+	const writers = 8
+	copies := make([]*Session, writers)
+	for i := range copies {
+		if copies[i], err = m.Load(s.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i, c := range copies {
+		wg.Add(1)
+		go func(i int, c *Session) {
+			defer wg.Done()
+			switch i % 3 {
+			case 0:
+				errs[i] = m.AddTag(c, fmt.Sprintf("tag%d", i))
+			case 1:
+				errs[i] = m.SetEnv(c, fmt.Sprintf("VAR%d", i), "value")
+			default:
+				errs[i] = m.Save(c)
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrSessionConflict):
+			t.Errorf("writer %d: %v", i, err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d writes of copies loaded together succeeded, want 1", succeeded)
+	}
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+// ErrForeignPath is returned for a path written in another OS's format, such
+// as C:\work\app entered on Linux
+var ErrForeignPath = errors.New("path is from another OS")
+
+// CheckPathFormat rejects a path that can only name a directory on another
+// OS, which would otherwise be taken as a relative name on this one
+func CheckPathFormat(path string) error {
+	return checkPathFormat(path, runtime.GOOS)
+}
+
+func checkPathFormat(path, goos string) error {
+	path = strings.TrimSpace(path)
+
+	if goos == "windows" {
+		if strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") {
+			return fmt.Errorf("%w: %s is a macOS/Linux path; enter the project's location on this Windows machine, e.g. C:\\Users\\you\\project", ErrForeignPath, path)
+		}
+		return nil
+	}
+
+	if windowsPath(path) {
+		return fmt.Errorf("%w: %s is a Windows path; enter the project's location on this %s machine, e.g. /home/you/project", ErrForeignPath, path, goos)
+	}
+	return nil
+}
+
+// windowsPath reports whether path has a drive letter or is a UNC path
+func windowsPath(path string) bool {
+	if strings.HasPrefix(path, `\\`) {
+		return true
+	}
+	return len(path) >= 2 && path[1] == ':' && isDriveLetter(path[0]) &&
+		(len(path) == 2 || path[2] == '\\' || path[2] == '/')
+}
+
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// CrossPlatform reports whether the session was last used on another OS, so
+// its project path can't carry over, and returns that platform
+func (s *Session) CrossPlatform() (platform.Platform, bool) {
+	current, err := platform.Current()
+	if err != nil || s.Platform == "" {
+		return s.Platform, false
+	}
+	return s.Platform, s.Platform.GOOS() != current.GOOS()
+}
+
+// ProjectName returns the last element of a project path, splitting on
+// either separator so a path from another OS still yields its folder name
+func ProjectName(path string) string {
+	path = strings.TrimRight(path, `/\`)
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
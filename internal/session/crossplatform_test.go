@@ -0,0 +1,78 @@
+package session
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+func TestCheckPathFormat(t *testing.T) {
+	tests := []struct {
+		path, goos string
+		foreign    bool
+	}{
+		{`C:\Users\me\project`, "linux", true},
+		{`c:/Users/me/project`, "darwin", true},
+		{`\\server\share\project`, "linux", true},
+		{`D:`, "linux", true},
+		{"/home/me/project", "linux", false},
+		{"C-drive/project", "linux", false},
+		{"/home/me/project", "windows", true},
+		{`C:\Users\me\project`, "windows", false},
+		{`\\server\share\project`, "windows", false},
+		{"//server/share/project", "windows", false},
+	}
+
+	for _, tt := range tests {
+		err := checkPathFormat(tt.path, tt.goos)
+		if foreign := errors.Is(err, ErrForeignPath); foreign != tt.foreign {
+			t.Errorf("checkPathFormat(%q, %s) = %v, want foreign=%v", tt.path, tt.goos, err, tt.foreign)
+		}
+	}
+}
+
+func TestRemapFromWindowsRejectsWindowsPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("remaps onto a macOS/Linux machine")
+	}
+
+	m := NewManager(t.TempDir())
+	s, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As created on Windows and copied over with the USB drive
+	s.Platform = platform.WindowsAMD64
+	s.Project.OriginalPath = `C:\Users\me\project`
+	s.Project.RemappedPath = s.Project.OriginalPath
+
+	if _, moved := s.CrossPlatform(); !moved {
+		t.Fatal("a Windows session isn't reported as cross-platform")
+	}
+
+	// The path as it was on Windows isn't normalized into a relative name
+	if err := m.RemapProjectPath(s, s.Project.OriginalPath); !errors.Is(err, ErrForeignPath) {
+		t.Fatalf("RemapProjectPath with the Windows path: err = %v, want ErrForeignPath", err)
+	}
+	if s.Platform != platform.WindowsAMD64 || s.Project.RemappedPath != `C:\Users\me\project` {
+		t.Errorf("a rejected remap changed the session: %s %s", s.Platform, s.Project.RemappedPath)
+	}
+
+	project := t.TempDir()
+	if err := m.RemapProjectPath(s, project); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := m.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, moved := loaded.CrossPlatform(); moved || loaded.Project.RemappedPath != project {
+		t.Errorf("after remapping: platform %s, path %s; want this platform and %s", loaded.Platform, loaded.Project.RemappedPath, project)
+	}
+	if loaded.Project.OriginalPath != `C:\Users\me\project` {
+		t.Errorf("OriginalPath = %s, want it kept", loaded.Project.OriginalPath)
+	}
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecretRefPrefix marks an env overlay value that names a vault secret,
+// e.g. "vault:db-url", instead of holding the value itself
+const SecretRefPrefix = "vault:"
+
+// CredentialEnv are the variables that carry provider credentials or pick
+// the provider and its endpoint. The launcher sets them from the vault, so
+// they can't come from a session or, in isolation, the host.
+var CredentialEnv = []string{
+	"ANTHROPIC_API_KEY",
+	"ANTHROPIC_AUTH_TOKEN",
+	"CLAUDE_CODE_OAUTH_TOKEN",
+	"CLAUDE_CODE_USE_BEDROCK",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AWS_REGION",
+	"AWS_PROFILE",
+	"CLAUDE_CODE_USE_VERTEX",
+	"GOOGLE_APPLICATION_CREDENTIALS",
+	"ANTHROPIC_VERTEX_PROJECT_ID",
+	"CLOUD_ML_REGION",
+}
+
+// reservedEnv are other variables the launcher sets itself, or that would
+// let an overlay run code inside claude
+var reservedEnv = []string{
+	"HOME",
+	"USER",
+	"PATH",
+	"NODE_OPTIONS",
+	"LD_PRELOAD",
+	"LD_LIBRARY_PATH",
+	"LD_AUDIT",
+}
+
+// reservedEnvPrefixes reserve whole families: claude-go's and claude's own
+// settings, every Anthropic endpoint and credential, AWS credentials and
+// config, and the macOS dynamic loader's injection hooks
+var reservedEnvPrefixes = []string{"CLAUDE_", "ANTHROPIC_", "AWS_", "DYLD_"}
+
+// ValidateEnvName checks that an env overlay may set name: a plain
+// variable name that the launcher doesn't set itself. Names are compared
+// case-insensitively, as Windows does.
+func ValidateEnvName(name string) error {
+	if name == "" {
+		return fmt.Errorf("variable name must not be empty")
+	}
+	for i, r := range name {
+		if !(r == '_' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+
+	upper := strings.ToUpper(name)
+	for _, prefix := range reservedEnvPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return fmt.Errorf("%s is reserved: %s* variables are set by claude-go", name, prefix)
+		}
+	}
+	for _, reserved := range append(CredentialEnv, reservedEnv...) {
+		if upper == reserved {
+			return fmt.Errorf("%s is reserved: it is set by claude-go", name)
+		}
+	}
+	return nil
+}
+
+// SetEnv sets a variable of the session's env overlay, or removes it when
+// value is empty, and saves the session. Like tagging, it doesn't count as
+// using the session.
+func (m *Manager) SetEnv(session *Session, name, value string) error {
+	if err := ValidateEnvName(name); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if value == "" {
+		delete(session.Env, name)
+	} else {
+		if session.Env == nil {
+			session.Env = make(map[string]string)
+		}
+		session.Env[name] = value
+	}
+	return m.write(session)
+}
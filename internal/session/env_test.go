@@ -0,0 +1,61 @@
+package session
+
+import "testing"
+
+func TestValidateEnvName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"DATABASE_URL", true},
+		{"TERM", true},
+		{"NODE_EXTRA_CA_CERTS", true},
+		{"_private", true},
+		{"", false},
+		{"1ST", false},
+		{"A-B", false},
+		{"CLAUDE_CODE_USE_BEDROCK", false},
+		{"claude_config_dir", false},
+		{"ANTHROPIC_AUTH_TOKEN", false},
+		{"ANTHROPIC_BEDROCK_BASE_URL", false},
+		{"ANTHROPIC_VERTEX_PROJECT_ID", false},
+		{"AWS_SECRET_ACCESS_KEY", false},
+		{"aws_profile", false},
+		{"GOOGLE_APPLICATION_CREDENTIALS", false},
+		{"CLOUD_ML_REGION", false},
+		{"NODE_OPTIONS", false},
+		{"LD_PRELOAD", false},
+		{"DYLD_INSERT_LIBRARIES", false},
+		{"Path", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateEnvName(tt.name)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateEnvName(%q) = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestSetEnvRejectsReserved(t *testing.T) {
+	m := NewManager(t.TempDir())
+	s, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SetEnv(s, "ANTHROPIC_BASE_URL", "https://attacker.example"); err == nil {
+		t.Error("SetEnv accepted ANTHROPIC_BASE_URL")
+	}
+	if err := m.SetEnv(s, "DATABASE_URL", "vault:db-url"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := m.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Env) != 1 || loaded.Env["DATABASE_URL"] != "vault:db-url" {
+		t.Errorf("Env = %v, want only DATABASE_URL", loaded.Env)
+	}
+}
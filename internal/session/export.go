@@ -0,0 +1,177 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/vault"
+)
+
+const (
+	// exportFormat identifies a file written by Export
+	exportFormat = "claude-go-session"
+
+	// exportVersion is the version of the export file layout
+	exportVersion = 1
+
+	// maxExportSize bounds how much of an export file ImportOne reads
+	maxExportSize = 16 << 20
+)
+
+// ErrPassphraseRequired means an export file is encrypted and ImportOne
+// was given no passphrase
+var ErrPassphraseRequired = errors.New("session export is encrypted; a passphrase is required")
+
+// exportFile is the single-file form of a shared session. Exactly one of
+// Session and Sealed is set; Sealed holds the session's JSON encrypted
+// with a passphrase.
+type exportFile struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	ExportedAt time.Time     `json:"exported_at"`
+	Encrypted  bool          `json:"encrypted"`
+	Session    *Session      `json:"session,omitempty"`
+	Sealed     *vault.Sealed `json:"sealed,omitempty"`
+}
+
+// Export writes session id to w as a self-describing file for sharing.
+// The copy is sanitized against the home directory and the WORKSPACE
+// environment variable, and encrypted if passphrase isn't empty. The
+// stored session is left unchanged.
+func (m *Manager) Export(id string, w io.Writer, passphrase string) error {
+	session, err := m.Load(id)
+	if err != nil {
+		return err
+	}
+
+	home, _ := os.UserHomeDir()
+	sanitize(session, home, os.Getenv("WORKSPACE"))
+	session.Sequence = 0
+
+	file := exportFile{Format: exportFormat, Version: exportVersion, ExportedAt: time.Now().UTC()}
+	if passphrase == "" {
+		file.Session = session
+	} else {
+		plaintext, err := json.Marshal(session)
+		if err != nil {
+			return fmt.Errorf("failed to serialize session: %w", err)
+		}
+		sealed, err := vault.Seal(passphrase, plaintext, vault.DefaultKDFParams())
+		if err != nil {
+			return fmt.Errorf("failed to encrypt session: %w", err)
+		}
+		file.Encrypted = true
+		file.Sealed = sealed
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize session: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write session export: %w", err)
+	}
+	return nil
+}
+
+// ImportOne reads a file written by Export and stores the session under a
+// fresh ID, so importing never overwrites an existing session. passphrase
+// is only used if the file is encrypted; a wrong one fails with
+// vault.ErrWrongPassword. Settings that would grant the file's author
+// anything on this machine are dropped; their names are returned.
+func (m *Manager) ImportOne(r io.Reader, passphrase string) (*Session, []string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxExportSize+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read session export: %w", err)
+	}
+	if len(data) > maxExportSize {
+		return nil, nil, fmt.Errorf("session export is larger than %d bytes", maxExportSize)
+	}
+
+	file, err := parseExport(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := file.Session
+	if file.Encrypted {
+		if passphrase == "" {
+			return nil, nil, ErrPassphraseRequired
+		}
+		plaintext, err := file.Sealed.Open(passphrase)
+		if err != nil {
+			return nil, nil, err
+		}
+		session = &Session{}
+		if err := json.Unmarshal(plaintext, session); err != nil {
+			return nil, nil, fmt.Errorf("invalid session export: %w", err)
+		}
+	}
+
+	session.ID = generateSessionID()
+	session.diskSum = nil
+	dropped := dropUntrusted(session)
+	if session.Project.RelativePath == "" {
+		session.Project.RelativePath = extractRelativePath(session.Project.OriginalPath)
+	}
+
+	if err := m.Save(session); err != nil {
+		return nil, nil, err
+	}
+	m.updateIndex(func(index *projectIndex) { index.add(session) })
+	return session, dropped, nil
+}
+
+// dropUntrusted clears the parts of an imported session that act on this
+// machine: permissions passed as --allowedTools, variables put in claude's
+// environment (which can run code or read vault secrets), and launch
+// choices made for the author's setup. It returns what was cleared.
+func dropUntrusted(s *Session) []string {
+	var dropped []string
+	if len(s.Permissions) > 0 {
+		dropped = append(dropped, "permissions")
+		s.Permissions = nil
+	}
+	if len(s.Env) > 0 {
+		dropped = append(dropped, "env")
+		s.Env = nil
+	}
+	if len(s.IgnoredRequiredMCP) > 0 {
+		dropped = append(dropped, "ignored_required_mcp")
+		s.IgnoredRequiredMCP = nil
+	}
+	if s.MCPProfile != "" {
+		dropped = append(dropped, "mcp_profile")
+		s.MCPProfile = ""
+	}
+	return dropped
+}
+
+// IsEncryptedExport reports whether data is an export file that needs a
+// passphrase to import
+func IsEncryptedExport(data []byte) bool {
+	file, err := parseExport(data)
+	return err == nil && file.Encrypted
+}
+
+// parseExport decodes and checks an export file's header
+func parseExport(data []byte) (*exportFile, error) {
+	var file exportFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid session export: %w", err)
+	}
+	if file.Format != exportFormat {
+		return nil, fmt.Errorf("not a session export (format %q)", file.Format)
+	}
+	if file.Version < 1 || file.Version > exportVersion {
+		return nil, fmt.Errorf("unsupported session export version %d", file.Version)
+	}
+	if file.Encrypted && file.Sealed == nil || !file.Encrypted && file.Session == nil {
+		return nil, fmt.Errorf("invalid session export: no session")
+	}
+	return &file, nil
+}
@@ -0,0 +1,164 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// indexFile maps project keys to session IDs. It is only a cache: a missing,
+// corrupt or stale index is rebuilt from the session files.
+const indexFile = "index.json"
+
+type projectIndex struct {
+	Projects map[string][]string `json:"projects"`
+}
+
+// projectKey identifies a project across machines by the last components of
+// its path, the same portable form stored in ProjectRef.RelativePath
+func projectKey(path string) string {
+	return filepath.ToSlash(extractRelativePath(path))
+}
+
+// sessionKeys returns the project keys a session is filed under: where it
+// was created and where it was last remapped to
+func sessionKeys(s *Session) []string {
+	keys := []string{filepath.ToSlash(s.Project.RelativePath)}
+	if s.Project.RemappedPath != "" {
+		if key := projectKey(s.Project.RemappedPath); key != keys[0] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (m *Manager) indexPath() string {
+	return filepath.Join(m.sessionsDir, indexFile)
+}
+
+// loadIndex reads the index, rebuilding it if it can't be read
+func (m *Manager) loadIndex() (*projectIndex, error) {
+	data, err := os.ReadFile(m.indexPath())
+	if err == nil {
+		index := &projectIndex{}
+		if json.Unmarshal(data, index) == nil && index.Projects != nil {
+			return index, nil
+		}
+	}
+
+	return m.rebuildIndex()
+}
+
+func (m *Manager) saveIndex(index *projectIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(m.indexPath(), data, 0600)
+}
+
+// RebuildIndex regenerates the project index from the session files
+func (m *Manager) RebuildIndex() error {
+	_, err := m.rebuildIndex()
+	return err
+}
+
+func (m *Manager) rebuildIndex() (*projectIndex, error) {
+	sessions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	index := &projectIndex{Projects: make(map[string][]string)}
+	for _, s := range sessions {
+		index.add(s)
+	}
+
+	if err := os.MkdirAll(m.sessionsDir, 0700); err != nil {
+		return nil, err
+	}
+	return index, m.saveIndex(index)
+}
+
+func (index *projectIndex) add(s *Session) {
+	for _, key := range sessionKeys(s) {
+		if !contains(index.Projects[key], s.ID) {
+			index.Projects[key] = append(index.Projects[key], s.ID)
+		}
+	}
+}
+
+func (index *projectIndex) remove(id string) {
+	for key, ids := range index.Projects {
+		kept := ids[:0]
+		for _, existing := range ids {
+			if existing != id {
+				kept = append(kept, existing)
+			}
+		}
+		if len(kept) == 0 {
+			delete(index.Projects, key)
+		} else {
+			index.Projects[key] = kept
+		}
+	}
+}
+
+// updateIndex applies fn to the index. Errors are ignored: the index is a
+// cache, and ListByProject rebuilds it when it finds it stale.
+func (m *Manager) updateIndex(fn func(index *projectIndex)) {
+	index, err := m.loadIndex()
+	if err != nil {
+		return
+	}
+	fn(index)
+	m.saveIndex(index)
+}
+
+// ListByProject returns the sessions of the project at path, most recently
+// used first. A project is matched by the last components of its path, so
+// sessions are found after the project moves to another machine or folder.
+func (m *Manager) ListByProject(path string) ([]*Session, error) {
+	index, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, stale := m.loadAll(index.Projects[projectKey(path)])
+	if stale {
+		if index, err = m.rebuildIndex(); err != nil {
+			return nil, err
+		}
+		sessions, _ = m.loadAll(index.Projects[projectKey(path)])
+	}
+
+	sortByUse(sessions)
+
+	return sessions, nil
+}
+
+// loadAll loads the given sessions, reporting whether any were missing
+func (m *Manager) loadAll(ids []string) ([]*Session, bool) {
+	var sessions []*Session
+	stale := false
+	for _, id := range ids {
+		s, err := m.Load(id)
+		if err != nil {
+			stale = true
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, stale
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mkProject creates a project directory under root
+func mkProject(t *testing.T, root string, parts ...string) string {
+	t.Helper()
+
+	dir := filepath.Join(append([]string{root}, parts...)...)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// sessionIDs returns the IDs of the sessions ListByProject finds for path
+func sessionIDs(t *testing.T, m *Manager, path string) []string {
+	t.Helper()
+
+	sessions, err := m.ListByProject(path)
+	if err != nil {
+		t.Fatalf("ListByProject(%s): %v", path, err)
+	}
+	var ids []string
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestListByProject(t *testing.T) {
+	m := NewManager(t.TempDir())
+	home := t.TempDir()
+	project := mkProject(t, home, "work", "api")
+	other := mkProject(t, home, "work", "web")
+
+	s, err := m.Create(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Create(other); err != nil {
+		t.Fatal(err)
+	}
+
+	// Found after create, including from the same project on another machine
+	if ids := sessionIDs(t, m, project); len(ids) != 1 || ids[0] != s.ID {
+		t.Errorf("after create: %v, want [%s]", ids, s.ID)
+	}
+	elsewhere := mkProject(t, t.TempDir(), "work", "api")
+	if ids := sessionIDs(t, m, elsewhere); len(ids) != 1 || ids[0] != s.ID {
+		t.Errorf("same project elsewhere: %v, want [%s]", ids, s.ID)
+	}
+
+	// Found under both names after the project moves and is remapped
+	moved := mkProject(t, home, "clients", "api-v2")
+	if err := m.RemapProjectPath(s, moved); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{project, moved} {
+		if ids := sessionIDs(t, m, path); len(ids) != 1 || ids[0] != s.ID {
+			t.Errorf("after remap, %s: %v, want [%s]", path, ids, s.ID)
+		}
+	}
+
+	if err := m.Delete(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{project, moved} {
+		if ids := sessionIDs(t, m, path); len(ids) != 0 {
+			t.Errorf("after delete, %s: %v, want none", path, ids)
+		}
+	}
+}
+
+func TestListByProjectRebuildsIndex(t *testing.T) {
+	m := NewManager(t.TempDir())
+	project := mkProject(t, t.TempDir(), "work", "api")
+	s, err := m.Create(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A corrupt index is rebuilt from the session files
+	if err := os.WriteFile(m.indexPath(), []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if ids := sessionIDs(t, m, project); len(ids) != 1 || ids[0] != s.ID {
+		t.Errorf("with a corrupt index: %v, want [%s]", ids, s.ID)
+	}
+
+	// So is one naming a session whose file was removed behind its back
+	if err := os.Remove(m.sessionPath(s.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if ids := sessionIDs(t, m, project); len(ids) != 0 {
+		t.Errorf("with a stale index: %v, want none", ids)
+	}
+	if index, err := m.loadIndex(); err != nil || len(index.Projects) != 0 {
+		t.Errorf("index after rebuild = %v, %v; want empty", index, err)
+	}
+}
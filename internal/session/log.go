@@ -0,0 +1,22 @@
+package session
+
+import (
+	"path/filepath"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// LogWriter appends child process output to a session log; see
+// fsutil.LogWriter
+type LogWriter = fsutil.LogWriter
+
+// LogPath returns the path of a session's child output log
+func (m *Manager) LogPath(id string) string {
+	return filepath.Join(m.sessionsDir, id+".log")
+}
+
+// OpenLog opens a session's child output log. Any of the given secrets
+// that appear in the output are masked before being written.
+func (m *Manager) OpenLog(id string, maxBytes int64, secrets []string) (*LogWriter, error) {
+	return fsutil.OpenLogWriter(m.LogPath(id), maxBytes, secrets)
+}
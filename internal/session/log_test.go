@@ -0,0 +1,55 @@
+package session
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOpenLogTee(t *testing.T) {
+	m := NewManager(t.TempDir())
+	logw, err := m.OpenLog("s1", 32, []string{"sk-ant-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The terminal gets every byte unchanged; the log gets a masked copy
+	// no bigger than the cap
+	var terminal bytes.Buffer
+	tee := io.MultiWriter(&terminal, logw)
+	lines := []string{
+		"starting with key sk-ant-secret\n",
+		strings.Repeat("x", 20) + "\n",
+		strings.Repeat("y", 20) + "\n",
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(tee, line); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := logw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := terminal.String(), strings.Join(lines, ""); got != want {
+		t.Errorf("terminal output = %q, want %q", got, want)
+	}
+
+	for _, path := range []string{m.LogPath("s1"), m.LogPath("s1") + ".1"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 32 {
+			t.Errorf("%s is %d bytes, over the 32 byte cap", path, len(data))
+		}
+		if bytes.Contains(data, []byte("sk-ant-secret")) {
+			t.Errorf("%s contains the secret: %q", path, data)
+		}
+	}
+	if data, _ := os.ReadFile(m.LogPath("s1")); string(data) != lines[2] {
+		t.Errorf("current log = %q, want the last line", data)
+	}
+}
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxModelLength bounds a model name; real ones are far shorter
+const maxModelLength = 128
+
+// ValidateModel checks that model could be a model name or alias, such as
+// "claude-opus-4-1" or "haiku": no spaces, control characters or '='
+func ValidateModel(model string) error {
+	if model == "" {
+		return fmt.Errorf("model must not be empty")
+	}
+	if len(model) > maxModelLength {
+		return fmt.Errorf("model name is longer than %d characters", maxModelLength)
+	}
+	if strings.IndexFunc(model, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) || r == '=' }) >= 0 {
+		return fmt.Errorf("invalid model %q", model)
+	}
+	return nil
+}
+
+// SetModel sets the model claude runs with in this session and saves it;
+// empty goes back to the configured default
+func (m *Manager) SetModel(session *Session, model string) error {
+	if model != "" {
+		if err := ValidateModel(model); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session.Model = model
+	return m.write(session)
+}
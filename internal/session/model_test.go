@@ -0,0 +1,36 @@
+package session
+
+import "testing"
+
+func TestSetModelPersists(t *testing.T) {
+	m := NewManager(t.TempDir())
+	s, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SetModel(s, "claude-haiku-4-5"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := m.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Model != "claude-haiku-4-5" {
+		t.Errorf("Model = %q after reloading, want claude-haiku-4-5", loaded.Model)
+	}
+
+	for _, bad := range []string{"opus 4", "model=x", "bad\nmodel"} {
+		if err := m.SetModel(loaded, bad); err == nil {
+			t.Errorf("SetModel(%q) was accepted", bad)
+		}
+	}
+
+	// Empty goes back to the configured default
+	if err := m.SetModel(loaded, ""); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, _ = m.Load(s.ID); loaded.Model != "" {
+		t.Errorf("Model = %q after clearing", loaded.Model)
+	}
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// claudeSettings is the part of a Claude Code settings file holding
+// permission rules, e.g. {"permissions": {"allow": ["Bash(npm test:*)"]}}
+type claudeSettings struct {
+	Permissions struct {
+		Allow []string `json:"allow"`
+	} `json:"permissions"`
+}
+
+// ProjectSettingsPath returns the settings file where Claude Code records
+// permissions granted in a project with "don't ask again"
+func ProjectSettingsPath(projectPath string) string {
+	return filepath.Join(projectPath, ".claude", "settings.local.json")
+}
+
+// ParsePermissionRule splits a Claude Code rule such as "Bash(git diff:*)"
+// into its tool and pattern. A bare tool name has an empty pattern.
+func ParsePermissionRule(rule string) Permission {
+	rule = strings.TrimSpace(rule)
+	if open := strings.Index(rule, "("); open > 0 && strings.HasSuffix(rule, ")") {
+		return Permission{Tool: rule[:open], Pattern: rule[open+1 : len(rule)-1]}
+	}
+	return Permission{Tool: rule}
+}
+
+// Rule formats the permission as a Claude Code rule
+func (p Permission) Rule() string {
+	if p.Pattern == "" {
+		return p.Tool
+	}
+	return p.Tool + "(" + p.Pattern + ")"
+}
+
+// ReadPermissionGrants returns the allow rules in a Claude Code settings
+// file. A missing file has none.
+func ReadPermissionGrants(path string) ([]Permission, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var settings claudeSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var grants []Permission
+	for _, rule := range settings.Permissions.Allow {
+		if p := ParsePermissionRule(rule); p.Tool != "" {
+			grants = append(grants, p)
+		}
+	}
+	return grants, nil
+}
+
+// UpdatePermissions applies the changes between the allow rules before and
+// after a run: rules added are granted, stamped with grantedAt, and rules
+// removed are revoked. Grants from elsewhere that weren't in the file to
+// begin with are kept. It returns how many permissions changed.
+func (s *Session) UpdatePermissions(before, after []Permission, grantedAt time.Time) int {
+	changed := 0
+	for _, grant := range after {
+		if s.hasPermission(grant) {
+			continue
+		}
+		grant.GrantedAt = grantedAt
+		s.Permissions = append(s.Permissions, grant)
+		changed++
+	}
+
+	for _, revoked := range before {
+		if containsPermission(after, revoked) || !s.hasPermission(revoked) {
+			continue
+		}
+		kept := s.Permissions[:0]
+		for _, p := range s.Permissions {
+			if !samePermission(p, revoked) {
+				kept = append(kept, p)
+			}
+		}
+		s.Permissions = kept
+		changed++
+	}
+	return changed
+}
+
+func (s *Session) hasPermission(grant Permission) bool {
+	return containsPermission(s.Permissions, grant)
+}
+
+func containsPermission(permissions []Permission, grant Permission) bool {
+	for _, p := range permissions {
+		if samePermission(p, grant) {
+			return true
+		}
+	}
+	return false
+}
+
+func samePermission(a, b Permission) bool {
+	return a.Tool == b.Tool && a.Pattern == b.Pattern
+}
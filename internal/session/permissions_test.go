@@ -0,0 +1,105 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSettings writes a Claude Code settings.local.json into project
+func writeSettings(t *testing.T, project, content string) {
+	t.Helper()
+
+	path := ProjectSettingsPath(project)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadPermissionGrants(t *testing.T) {
+	project := t.TempDir()
+
+	grants, err := ReadPermissionGrants(ProjectSettingsPath(project))
+	if err != nil || grants != nil {
+		t.Fatalf("missing file: grants = %v, err = %v", grants, err)
+	}
+
+	writeSettings(t, project, `{
+  "permissions": {
+    "allow": ["Bash(npm test:*)", "WebFetch", "  ", "Read(~/docs/**)"],
+    "deny": ["Bash(rm:*)"]
+  },
+  "model": "opus"
+}`)
+
+	grants, err = ReadPermissionGrants(ProjectSettingsPath(project))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Permission{
+		{Tool: "Bash", Pattern: "npm test:*"},
+		{Tool: "WebFetch"},
+		{Tool: "Read", Pattern: "~/docs/**"},
+	}
+	if len(grants) != len(want) {
+		t.Fatalf("grants = %v, want %v", grants, want)
+	}
+	for i := range want {
+		if !samePermission(grants[i], want[i]) {
+			t.Errorf("grant %d = %+v, want %+v", i, grants[i], want[i])
+		}
+	}
+
+	writeSettings(t, project, `{"permissions": `)
+	if _, err := ReadPermissionGrants(ProjectSettingsPath(project)); err == nil {
+		t.Error("malformed settings file parsed without error")
+	}
+}
+
+func TestUpdatePermissions(t *testing.T) {
+	earlier := time.Now().Add(-time.Hour)
+	now := time.Now()
+
+	s := &Session{Permissions: []Permission{
+		{Tool: "Bash", Pattern: "git diff:*", GrantedAt: earlier},    // still allowed
+		{Tool: "Bash", Pattern: "npm publish:*", GrantedAt: earlier}, // revoked during the run
+		{Tool: "WebFetch", GrantedAt: earlier},                       // from another machine
+	}}
+	before := []Permission{
+		{Tool: "Bash", Pattern: "git diff:*"},
+		{Tool: "Bash", Pattern: "npm publish:*"},
+	}
+	after := []Permission{
+		{Tool: "Bash", Pattern: "git diff:*"},
+		{Tool: "Bash", Pattern: "npm test:*"},
+	}
+
+	if changed := s.UpdatePermissions(before, after, now); changed != 2 {
+		t.Errorf("changed = %d, want 2", changed)
+	}
+
+	want := map[string]time.Time{
+		"Bash(git diff:*)": earlier,
+		"WebFetch":         earlier,
+		"Bash(npm test:*)": now,
+	}
+	if len(s.Permissions) != len(want) {
+		t.Fatalf("permissions = %v, want %v", s.Permissions, want)
+	}
+	for _, p := range s.Permissions {
+		grantedAt, ok := want[p.Rule()]
+		if !ok {
+			t.Errorf("unexpected permission %s", p.Rule())
+		} else if !p.GrantedAt.Equal(grantedAt) {
+			t.Errorf("%s granted at %v, want %v", p.Rule(), p.GrantedAt, grantedAt)
+		}
+	}
+
+	if changed := s.UpdatePermissions(after, after, now); changed != 0 {
+		t.Errorf("unchanged file: changed = %d, want 0", changed)
+	}
+}
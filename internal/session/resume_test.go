@@ -0,0 +1,63 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateOrResumeSameProject(t *testing.T) {
+	m := NewManager(t.TempDir())
+	project := t.TempDir()
+
+	first, resumed, err := m.CreateOrResume(project, time.Hour)
+	if err != nil || resumed {
+		t.Fatalf("first launch: resumed = %v, err = %v", resumed, err)
+	}
+	second, resumed, err := m.CreateOrResume(project, time.Hour)
+	if err != nil || !resumed || second.ID != first.ID {
+		t.Fatalf("second launch: session %s, resumed = %v, err = %v; want %s", second.ID, resumed, err, first.ID)
+	}
+
+	sessions, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Errorf("%d sessions after launching the same project twice, want 1", len(sessions))
+	}
+}
+
+func TestCreateOrResumeCreates(t *testing.T) {
+	m := NewManager(t.TempDir())
+	root := t.TempDir()
+	project := filepath.Join(root, "a", "app")
+	sameName := filepath.Join(root, "b", "app")
+	for _, dir := range []string{project, sameName} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, _, err := m.CreateOrResume(project, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A different project whose path ends the same way
+	if other, resumed, err := m.CreateOrResume(sameName, time.Hour); err != nil || resumed || other.ID == s.ID {
+		t.Errorf("same name, other project: resumed = %v, err = %v", resumed, err)
+	}
+
+	// Reuse switched off, as with --new or reuse_within_hours 0
+	if fresh, resumed, err := m.CreateOrResume(project, 0); err != nil || resumed || fresh.ID == s.ID {
+		t.Errorf("maxAge 0: resumed = %v, err = %v", resumed, err)
+	}
+
+	// Too long unused
+	time.Sleep(20 * time.Millisecond)
+	if fresh, resumed, err := m.CreateOrResume(project, 10*time.Millisecond); err != nil || resumed || fresh.ID == s.ID {
+		t.Errorf("stale session: resumed = %v, err = %v", resumed, err)
+	}
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Placeholders stand in for machine-specific directories in a sanitized
+// session's paths, so it can be shared without revealing them
+const (
+	HomePlaceholder      = "$HOME"
+	WorkspacePlaceholder = "$WORKSPACE"
+)
+
+// placeholderFor returns path with its leading dir replaced by placeholder
+// and the rest in forward slashes, or false if path isn't inside dir
+func placeholderFor(path, dir, placeholder string) (string, bool) {
+	if dir == "" || path == "" {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", false
+	}
+	if rel == "." {
+		return placeholder, true
+	}
+	return placeholder + "/" + filepath.ToSlash(rel), true
+}
+
+// sanitizePath replaces workspace or, failing that, home at the start of
+// path with its placeholder. Paths outside both are returned unchanged.
+func sanitizePath(path, home, workspace string) string {
+	if p, ok := placeholderFor(path, workspace, WorkspacePlaceholder); ok {
+		return p
+	}
+	if p, ok := placeholderFor(path, home, HomePlaceholder); ok {
+		return p
+	}
+	return path
+}
+
+// Sanitize replaces the home and workspace directories in the session's
+// project paths and permission patterns with placeholders, and forgets
+// the host it last ran on. RelativePath is kept, so the session still
+// matches its project; whoever resumes it gets the placeholders expanded
+// for their machine, or is asked for the path. workspace may be empty.
+func (m *Manager) Sanitize(session *Session, home, workspace string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sanitize(session, home, workspace)
+	return m.write(session)
+}
+
+// sanitize rewrites a session in memory; see Sanitize
+func sanitize(session *Session, home, workspace string) {
+	session.Project.OriginalPath = sanitizePath(session.Project.OriginalPath, home, workspace)
+	session.Project.RemappedPath = sanitizePath(session.Project.RemappedPath, home, workspace)
+	session.HostMachine = ""
+
+	for i, p := range session.Permissions {
+		session.Permissions[i].Pattern = sanitizePattern(p.Pattern, home, workspace)
+	}
+}
+
+// sanitizePattern sanitizes every absolute path in a permission pattern,
+// e.g. the directory in "Read(/home/me/proj/**)"
+func sanitizePattern(pattern, home, workspace string) string {
+	for _, dir := range []struct{ path, placeholder string }{{workspace, WorkspacePlaceholder}, {home, HomePlaceholder}} {
+		if dir.path == "" {
+			continue
+		}
+		prefix := filepath.Clean(dir.path)
+		for _, sep := range []string{string(filepath.Separator), "/"} {
+			pattern = strings.ReplaceAll(pattern, prefix+sep, dir.placeholder+"/")
+		}
+		if strings.HasSuffix(pattern, prefix) {
+			pattern = strings.TrimSuffix(pattern, prefix) + dir.placeholder
+		}
+	}
+	return pattern
+}
+
+// IsSanitized reports whether path starts with a placeholder
+func IsSanitized(path string) bool {
+	for _, placeholder := range []string{HomePlaceholder, WorkspacePlaceholder} {
+		if path == placeholder || strings.HasPrefix(path, placeholder+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandPlaceholders turns a sanitized path into one on this machine:
+// $HOME is the home directory and $WORKSPACE the WORKSPACE environment
+// variable. It returns false if the path isn't sanitized or the
+// placeholder has no value here.
+func ExpandPlaceholders(path string) (string, bool) {
+	placeholder, rest, _ := strings.Cut(path, "/")
+
+	var dir string
+	switch placeholder {
+	case HomePlaceholder:
+		dir, _ = os.UserHomeDir()
+	case WorkspacePlaceholder:
+		dir = os.Getenv("WORKSPACE")
+	default:
+		return "", false
+	}
+	if dir == "" {
+		return "", false
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(rest)), true
+}
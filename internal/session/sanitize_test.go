@@ -0,0 +1,111 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "alice")
+	workspace := filepath.Join(home, "work")
+	project := filepath.Join(workspace, "api")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(t.TempDir())
+	s, err := m.Create(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative := s.Project.RelativePath
+	s.Permissions = []Permission{
+		{Tool: "Read", Pattern: project + "/**"},
+		{Tool: "Edit", Pattern: filepath.Join(home, "notes")},
+		{Tool: "Bash", Pattern: "npm test"},
+	}
+	if err := m.Sanitize(s, home, workspace); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := m.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Project.OriginalPath != "$WORKSPACE/api" {
+		t.Errorf("OriginalPath = %q, want $WORKSPACE/api", loaded.Project.OriginalPath)
+	}
+	if loaded.Project.RelativePath != relative {
+		t.Errorf("RelativePath = %q, want %q kept", loaded.Project.RelativePath, relative)
+	}
+	if loaded.HostMachine != "" {
+		t.Errorf("HostMachine = %q, want it cleared", loaded.HostMachine)
+	}
+	wantPatterns := []string{"$WORKSPACE/api/**", "$HOME/notes", "npm test"}
+	for i, p := range loaded.Permissions {
+		if p.Pattern != wantPatterns[i] {
+			t.Errorf("permission %d = %q, want %q", i, p.Pattern, wantPatterns[i])
+		}
+	}
+	data, err := os.ReadFile(m.sessionPath(s.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), home) {
+		t.Errorf("sanitized session still contains %s:\n%s", home, data)
+	}
+
+	// A teammate's machine fills the placeholders in with its own paths,
+	// and the session remaps there
+	otherWorkspace := filepath.Join(t.TempDir(), "src")
+	if err := os.MkdirAll(filepath.Join(otherWorkspace, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WORKSPACE", otherWorkspace)
+	expanded, ok := ExpandPlaceholders(loaded.Project.OriginalPath)
+	if !ok || expanded != filepath.Join(otherWorkspace, "api") {
+		t.Fatalf("ExpandPlaceholders = %q, %v", expanded, ok)
+	}
+	if err := m.RemapProjectPath(loaded, expanded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Project.RemappedPath != expanded {
+		t.Errorf("RemappedPath = %q, want %q", loaded.Project.RemappedPath, expanded)
+	}
+}
+
+func TestSanitizePath(t *testing.T) {
+	home := filepath.FromSlash("/home/alice")
+	workspace := filepath.FromSlash("/home/alice/work")
+
+	tests := []struct {
+		path, want string
+	}{
+		{"/home/alice/work/api", "$WORKSPACE/api"},
+		{"/home/alice/work", "$WORKSPACE"},
+		{"/home/alice/notes", "$HOME/notes"},
+		{"/home/alice2/api", "/home/alice2/api"},
+		{"/srv/api", "/srv/api"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		path := filepath.FromSlash(tt.path)
+		want := tt.want
+		if !IsSanitized(want) {
+			want = filepath.FromSlash(want)
+		}
+		if got := sanitizePath(path, home, workspace); got != want {
+			t.Errorf("sanitizePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	t.Setenv("WORKSPACE", "")
+	if _, ok := ExpandPlaceholders("$WORKSPACE/api"); ok {
+		t.Error("expanded $WORKSPACE with WORKSPACE unset")
+	}
+	if _, ok := ExpandPlaceholders("/srv/api"); ok {
+		t.Error("expanded a path without placeholders")
+	}
+}
@@ -0,0 +1,65 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// sequenceFile holds the last sequence number given to a session. It
+// travels with the USB, so unlike timestamps it orders sessions used on
+// machines whose clocks disagree.
+const sequenceFile = "sequence"
+
+func (m *Manager) sequencePath() string {
+	return filepath.Join(m.sessionsDir, sequenceFile)
+}
+
+// currentSequence returns the last sequence number given out. A missing or
+// unreadable counter is recovered from the highest one in use.
+func (m *Manager) currentSequence() uint64 {
+	data, err := os.ReadFile(m.sequencePath())
+	if err == nil {
+		if seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			return seq
+		}
+	}
+
+	var highest uint64
+	sessions, _ := m.List()
+	for _, s := range sessions {
+		if s.Sequence > highest {
+			highest = s.Sequence
+		}
+	}
+	return highest
+}
+
+// nextSequence increments the counter and returns the new value. The
+// caller holds m.mu. Two processes saving at the same moment may get the
+// same number; LastUsedAt then breaks the tie.
+func (m *Manager) nextSequence() (uint64, error) {
+	seq := m.currentSequence() + 1
+	if err := os.MkdirAll(m.sessionsDir, 0700); err != nil {
+		return 0, err
+	}
+	if err := fsutil.WriteFileAtomic(m.sequencePath(), []byte(strconv.FormatUint(seq, 10)+"\n"), 0600); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// sortByUse orders sessions most recently used first: by sequence, then by
+// last used time for ties and for sessions saved before sequences existed
+func sortByUse(sessions []*Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].Sequence != sessions[j].Sequence {
+			return sessions[i].Sequence > sessions[j].Sequence
+		}
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+}
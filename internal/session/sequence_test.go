@@ -0,0 +1,73 @@
+package session
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSequenceOrderingIgnoresClockSkew(t *testing.T) {
+	m := NewManager(t.TempDir())
+	var ids []string
+	for i := 0; i < 3; i++ {
+		s, err := m.Create(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, s.ID)
+	}
+
+	// The last session was saved on a computer whose clock is a year
+	// behind; it was still used last
+	skewed, err := m.Load(ids[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	skewed.LastUsedAt = time.Now().AddDate(-1, 0, 0)
+	if err := m.write(skewed); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions[0].ID != ids[2] || sessions[1].ID != ids[1] || sessions[2].ID != ids[0] {
+		t.Errorf("order = %s %s %s, want the skewed session first", sessions[0].ID, sessions[1].ID, sessions[2].ID)
+	}
+
+	// A lost counter resumes above the highest sequence in use
+	if err := os.Remove(m.sequencePath()); err != nil {
+		t.Fatal(err)
+	}
+	first, err := m.Load(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Sequence <= skewed.Sequence {
+		t.Errorf("sequence after losing the counter = %d, want above %d", first.Sequence, skewed.Sequence)
+	}
+}
+
+func TestSortByUseLegacySessions(t *testing.T) {
+	now := time.Now()
+	sessions := []*Session{
+		{ID: "legacy-old", LastUsedAt: now.Add(-2 * time.Hour)},
+		{ID: "sequenced", Sequence: 1, LastUsedAt: now.Add(-48 * time.Hour)},
+		{ID: "legacy-new", LastUsedAt: now.Add(-time.Hour)},
+		{ID: "tie-newer", Sequence: 1, LastUsedAt: now},
+	}
+
+	// Saved before sequences existed, they follow any sequenced session;
+	// time orders them, and breaks ties between equal sequences
+	sortByUse(sessions)
+	want := []string{"tie-newer", "sequenced", "legacy-new", "legacy-old"}
+	for i, s := range sessions {
+		if s.ID != want[i] {
+			t.Errorf("position %d = %s, want %s", i, s.ID, want[i])
+		}
+	}
+}
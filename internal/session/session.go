@@ -2,13 +2,16 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/cxt9/claude-go/internal/fsutil"
 	"github.com/cxt9/claude-go/internal/platform"
 )
 
@@ -17,6 +20,7 @@ type Session struct {
 	ID          string            `json:"id"`
 	CreatedAt   time.Time         `json:"created_at"`
 	LastUsedAt  time.Time         `json:"last_used_at"`
+	Sequence    uint64            `json:"sequence,omitempty"` // orders sessions by use; see sortByUse
 	HostMachine string            `json:"host_machine"`
 	Platform    platform.Platform `json:"platform"`
 
@@ -28,6 +32,26 @@ type Session struct {
 
 	// Permissions granted during this session
 	Permissions []Permission `json:"permissions,omitempty"`
+
+	// Required MCP servers the user chose to launch without
+	IgnoredRequiredMCP []string `json:"ignored_required_mcp,omitempty"`
+
+	// User-assigned labels for grouping, e.g. "work"
+	Tags []string `json:"tags,omitempty"`
+
+	// The mcp.profiles entry last launched with; empty for all servers
+	MCPProfile string `json:"mcp_profile,omitempty"`
+
+	// Model claude runs with; empty uses environment.default_model
+	Model string `json:"model,omitempty"`
+
+	// Variables added to claude's environment when the session launches.
+	// A value starting with SecretRefPrefix names a vault secret.
+	Env map[string]string `json:"env,omitempty"`
+
+	// Hash of the file this copy was loaded from or last saved to; see
+	// checkUnchanged
+	diskSum *[32]byte
 }
 
 // ProjectRef stores project path information for cross-machine portability
@@ -47,6 +71,11 @@ type Permission struct {
 // Manager handles session storage and retrieval
 type Manager struct {
 	sessionsDir string
+
+	// mu serializes writes to session files, so a conflict check and the
+	// write it guards aren't interleaved with another write, and a touch
+	// can't recreate a session deleted by this process
+	mu sync.Mutex
 }
 
 // NewManager creates a new session manager
@@ -82,9 +111,45 @@ func (m *Manager) Create(projectPath string) (*Session, error) {
 		return nil, err
 	}
 
+	m.updateIndex(func(index *projectIndex) { index.add(session) })
 	return session, nil
 }
 
+// CreateOrResume returns the most recently used session of the project at
+// projectPath if it was used within maxAge, marking it used now, and
+// creates a new session otherwise. The bool reports whether an existing
+// session was returned.
+func (m *Manager) CreateOrResume(projectPath string, maxAge time.Duration) (*Session, bool, error) {
+	if maxAge > 0 {
+		sessions, err := m.ListByProject(projectPath)
+		if err != nil {
+			return nil, false, err
+		}
+
+		cutoff := time.Now().Add(-maxAge)
+		for _, s := range sessions {
+			if !samePath(s.Project.RemappedPath, projectPath) && !samePath(s.Project.OriginalPath, projectPath) {
+				continue // same last components, different project
+			}
+			if s.LastUsedAt.Before(cutoff) {
+				continue
+			}
+
+			touched, err := m.Touch(s.ID)
+			if errors.Is(err, ErrSessionDeleted) {
+				continue
+			}
+			if err != nil {
+				return nil, false, err
+			}
+			return touched, true, nil
+		}
+	}
+
+	s, err := m.Create(projectPath)
+	return s, false, err
+}
+
 // Load loads a session by ID
 func (m *Manager) Load(id string) (*Session, error) {
 	path := m.sessionPath(id)
@@ -98,17 +163,73 @@ func (m *Manager) Load(id string) (*Session, error) {
 	if err := json.Unmarshal(data, &session); err != nil {
 		return nil, fmt.Errorf("failed to parse session: %w", err)
 	}
+	session.remember(data)
 
 	return &session, nil
 }
 
-// Save persists a session to disk
+// Resolve finds a session by full ID or unique ID prefix
+func (m *Manager) Resolve(ref string) (*Session, error) {
+	if ref == "" || strings.ContainsAny(ref, `/\`) || strings.Contains(ref, "..") {
+		return nil, fmt.Errorf("invalid session id: %q", ref)
+	}
+
+	if _, err := os.Stat(m.sessionPath(ref)); err == nil {
+		return m.Load(ref)
+	}
+
+	sessions, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var match *Session
+	for _, s := range sessions {
+		if strings.HasPrefix(s.ID, ref) {
+			if match != nil {
+				return nil, fmt.Errorf("session id %q is ambiguous", ref)
+			}
+			match = s
+		}
+	}
+
+	if match == nil {
+		return nil, fmt.Errorf("session not found: %s", ref)
+	}
+
+	return match, nil
+}
+
+// Save marks a session as used now and persists it to disk
 func (m *Manager) Save(session *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Check before taking a sequence number, which a conflict would waste
+	if err := m.checkUnchanged(session); err != nil {
+		return err
+	}
+
+	seq, err := m.nextSequence()
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	session.Sequence = seq
+	session.LastUsedAt = time.Now()
+	return m.write(session)
+}
+
+// write persists a session without touching its last used time. It fails
+// with ErrSessionConflict if the file changed since this copy was loaded.
+// The caller holds m.mu.
+func (m *Manager) write(session *Session) error {
 	if err := os.MkdirAll(m.sessionsDir, 0700); err != nil {
 		return fmt.Errorf("failed to create sessions directory: %w", err)
 	}
 
-	session.LastUsedAt = time.Now()
+	if err := m.checkUnchanged(session); err != nil {
+		return err
+	}
 
 	data, err := json.MarshalIndent(session, "", "  ")
 	if err != nil {
@@ -116,20 +237,32 @@ func (m *Manager) Save(session *Session) error {
 	}
 
 	path := m.sessionPath(session.ID)
-	if err := os.WriteFile(path, data, 0600); err != nil {
+	if err := fsutil.WriteFileAtomic(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write session: %w", err)
 	}
+	session.remember(data)
 
 	return nil
 }
 
-// Delete removes a session
+// Delete removes a session and its child output logs
 func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	path := m.sessionPath(id)
-	return os.Remove(path)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+
+	os.Remove(m.LogPath(id))
+	os.Remove(m.LogPath(id) + ".1")
+
+	m.updateIndex(func(index *projectIndex) { index.remove(id) })
+	return nil
 }
 
-// List returns all sessions sorted by last used time (most recent first)
+// List returns all sessions, most recently used first
 func (m *Manager) List() ([]*Session, error) {
 	entries, err := os.ReadDir(m.sessionsDir)
 	if err != nil {
@@ -141,52 +274,73 @@ func (m *Manager) List() ([]*Session, error) {
 
 	var sessions []*Session
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || entry.Name() == indexFile {
 			continue
 		}
 
 		id := strings.TrimSuffix(entry.Name(), ".json")
 		session, err := m.Load(id)
-		if err != nil {
-			continue // Skip corrupted sessions
+		if err != nil || session.ID != id {
+			continue // Skip corrupted sessions; Verify reports them
 		}
 		sessions = append(sessions, session)
 	}
 
-	// Sort by last used time (most recent first)
-	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
-	})
+	sortByUse(sessions)
 
 	return sessions, nil
 }
 
-// Cleanup removes sessions older than the given duration
-func (m *Manager) Cleanup(maxAge time.Duration) (int, error) {
+// Expired returns the sessions Cleanup would remove: those not used within
+// maxAge, most recent first
+func (m *Manager) Expired(maxAge time.Duration) ([]*Session, error) {
 	sessions, err := m.List()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	cutoff := time.Now().Add(-maxAge)
-	removed := 0
+	var expired []*Session
 
 	for _, session := range sessions {
 		if session.LastUsedAt.Before(cutoff) {
-			if err := m.Delete(session.ID); err == nil {
-				removed++
-			}
+			expired = append(expired, session)
 		}
 	}
 
-	return removed, nil
+	return expired, nil
 }
 
-// RemapProjectPath updates the session's project path for the current machine
+// Cleanup removes sessions older than the given duration
+func (m *Manager) Cleanup(maxAge time.Duration) (int, error) {
+	expired, err := m.Expired(maxAge)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.DeleteAll(expired), nil
+}
+
+// DeleteAll deletes the given sessions, returning how many were removed
+func (m *Manager) DeleteAll(sessions []*Session) int {
+	removed := 0
+	for _, session := range sessions {
+		if err := m.Delete(session.ID); err == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// RemapProjectPath updates the session's project path for the current
+// machine and records its platform. A path in another OS's format is
+// rejected, since a session moving between OSes needs the project's path
+// on this one.
 func (m *Manager) RemapProjectPath(session *Session, newPath string) error {
-	if _, err := os.Stat(newPath); os.IsNotExist(err) {
-		return fmt.Errorf("project path does not exist: %s", newPath)
+	if err := ValidateProjectDir(newPath); err != nil {
+		return err
 	}
+	newPath = filepath.Clean(newPath)
 
 	hostname, _ := os.Hostname()
 	plat, _ := platform.Current()
@@ -195,7 +349,47 @@ func (m *Manager) RemapProjectPath(session *Session, newPath string) error {
 	session.HostMachine = hostname
 	session.Platform = plat
 
-	return m.Save(session)
+	if err := m.Save(session); err != nil {
+		return err
+	}
+
+	m.updateIndex(func(index *projectIndex) { index.add(session) })
+	return nil
+}
+
+// ValidateProjectDir checks that path is an existing, readable directory
+// in this platform's format
+func ValidateProjectDir(path string) error {
+	if err := CheckPathFormat(path); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("project path does not exist: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot access project path: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("project path is not a directory: %s", path)
+	}
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("project directory is not readable: %w", err)
+	}
+	defer dir.Close()
+
+	if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+		return fmt.Errorf("project directory is not readable: %w", err)
+	}
+
+	return nil
+}
+
+func samePath(a, b string) bool {
+	return a != "" && filepath.Clean(a) == filepath.Clean(b)
 }
 
 func (m *Manager) sessionPath(id string) string {
@@ -0,0 +1,84 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// countingSyncer counts what is synced
+type countingSyncer struct {
+	fsutil.Syncer
+	files, dirs int
+}
+
+func (c *countingSyncer) SyncFile(f *os.File) error {
+	c.files++
+	return c.Syncer.SyncFile(f)
+}
+
+func (c *countingSyncer) SyncDir(path string) error {
+	c.dirs++
+	return c.Syncer.SyncDir(path)
+}
+
+func TestSaveSyncs(t *testing.T) {
+	m := NewManager(t.TempDir())
+	s, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &countingSyncer{Syncer: fsutil.DefaultSyncer}
+	defer func(orig fsutil.Syncer) { fsutil.DefaultSyncer = orig }(fsutil.DefaultSyncer)
+	fsutil.DefaultSyncer = rec
+
+	s.Summary = "synced"
+	if err := m.Save(s); err != nil {
+		t.Fatal(err)
+	}
+	if rec.files == 0 || rec.dirs == 0 {
+		t.Errorf("synced %d files and %d directories, want the session file and its directory", rec.files, rec.dirs)
+	}
+}
+
+func TestValidateProjectDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, path, wantErr string
+	}{
+		{"directory", dir, ""},
+		{"file", file, "not a directory"},
+		{"missing", filepath.Join(dir, "missing"), "does not exist"},
+	}
+	for _, tt := range tests {
+		err := ValidateProjectDir(tt.path)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: ValidateProjectDir = %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: ValidateProjectDir = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// Remapping onto a file is refused and leaves the session alone
+	m := NewManager(t.TempDir())
+	s, err := m.Create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemapProjectPath(s, file); err == nil {
+		t.Error("RemapProjectPath accepted a file")
+	}
+	if s.Project.RemappedPath == file {
+		t.Error("RemapProjectPath recorded the rejected path")
+	}
+}
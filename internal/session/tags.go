@@ -0,0 +1,92 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NormalizeTag trims and lowercases a tag, rejecting empty tags and ones
+// containing whitespace or commas
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tag must not be empty")
+	}
+	if strings.ContainsAny(tag, ", \t\n") {
+		return "", fmt.Errorf("invalid tag %q: must not contain spaces or commas", tag)
+	}
+	return tag, nil
+}
+
+// HasTag reports whether the session carries tag
+func (s *Session) HasTag(tag string) bool {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return false
+	}
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTag adds a tag to the session and saves it. Tagging doesn't count as
+// using the session, so its position in the picker is unchanged.
+func (m *Manager) AddTag(session *Session, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if session.HasTag(tag) {
+		return nil
+	}
+
+	session.Tags = append(session.Tags, tag)
+	sort.Strings(session.Tags)
+	return m.write(session)
+}
+
+// RemoveTag removes a tag from the session and saves it
+func (m *Manager) RemoveTag(session *Session, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tags := session.Tags[:0]
+	for _, t := range session.Tags {
+		if t != tag {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) == len(session.Tags) {
+		return fmt.Errorf("session %s has no tag %q", session.ID, tag)
+	}
+
+	session.Tags = tags
+	if len(session.Tags) == 0 {
+		session.Tags = nil
+	}
+	return m.write(session)
+}
+
+// FilterByTag returns the sessions carrying tag, keeping their order
+func FilterByTag(sessions []*Session, tag string) []*Session {
+	var filtered []*Session
+	for _, s := range sessions {
+		if s.HasTag(tag) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	m := NewManager(t.TempDir())
+	work, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	personal, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tag := range []string{" Work ", "client-a", "work"} {
+		if err := m.AddTag(work, tag); err != nil {
+			t.Fatalf("AddTag(%q): %v", tag, err)
+		}
+	}
+	if err := m.AddTag(personal, "home"); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"", "two words", "a,b"} {
+		if err := m.AddTag(work, bad); err == nil {
+			t.Errorf("AddTag(%q) accepted an invalid tag", bad)
+		}
+	}
+
+	// Tags are normalized, deduplicated and saved
+	loaded, err := m.Load(work.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(loaded.Tags, ","); got != "client-a,work" {
+		t.Errorf("saved tags = %q, want client-a,work", got)
+	}
+
+	sessions := []*Session{work, personal}
+	if got := FilterByTag(sessions, "WORK"); len(got) != 1 || got[0].ID != work.ID {
+		t.Errorf("FilterByTag(work) = %v, want the work session", got)
+	}
+	if got := FilterByTag(sessions, "none"); len(got) != 0 {
+		t.Errorf("FilterByTag(none) = %v, want nothing", got)
+	}
+
+	if err := m.RemoveTag(work, "client-a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveTag(work, "client-a"); err == nil {
+		t.Error("removing a tag twice succeeded")
+	}
+	if err := m.RemoveTag(work, "work"); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = m.Load(work.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Tags != nil {
+		t.Errorf("tags after removing all = %q, want none", loaded.Tags)
+	}
+}
+
+func TestLoadUntaggedSession(t *testing.T) {
+	m := NewManager(t.TempDir())
+	s, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sessions saved before tags existed have no tags field at all
+	data, err := os.ReadFile(m.sessionPath(s.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"tags"`) {
+		t.Fatalf("untagged session was saved with a tags field: %s", data)
+	}
+	loaded, err := m.Load(s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Tags) != 0 || loaded.HasTag("work") {
+		t.Errorf("untagged session has tags %q", loaded.Tags)
+	}
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// touchInterval coalesces touches: a session used this recently that is
+// still the last one used already sorts first, so touching it writes nothing
+const touchInterval = time.Minute
+
+// ErrSessionDeleted means a session's file was removed, possibly by another
+// process, before it could be touched
+var ErrSessionDeleted = errors.New("session was deleted")
+
+// Touch marks session id as used now and returns it as saved.
+// Unlike Save it starts from the file on disk, so it never overwrites other
+// fields with a stale copy, and it skips the write entirely when the
+// session was touched within touchInterval and no other session has been
+// used since. A session deleted concurrently
+// is not recreated; Touch returns ErrSessionDeleted instead.
+func (m *Manager) Touch(id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, err := m.Load(id)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrSessionDeleted
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	recent := now.Sub(s.LastUsedAt) < touchInterval && !s.LastUsedAt.After(now)
+	if recent && s.Sequence != 0 && s.Sequence == m.currentSequence() {
+		return s, nil
+	}
+
+	// Narrow the window in which another process's Delete could be undone
+	if _, err := os.Stat(m.sessionPath(id)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSessionDeleted
+		}
+		return nil, fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	seq, err := m.nextSequence()
+	if err != nil {
+		return nil, fmt.Errorf("failed to touch session: %w", err)
+	}
+	s.Sequence = seq
+	s.LastUsedAt = now
+
+	if err := m.write(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestTouch(t *testing.T) {
+	m := NewManager(t.TempDir())
+	first, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another copy saves a field after this one was loaded
+	other, err := m.Load(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Summary = "edited elsewhere"
+	if err := m.Save(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(second); err != nil {
+		t.Fatal(err)
+	}
+
+	touched, err := m.Touch(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if touched.Summary != "edited elsewhere" {
+		t.Errorf("Summary = %q, want the saved edit kept", touched.Summary)
+	}
+
+	sessions, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortByUse(sessions)
+	if len(sessions) != 2 || sessions[0].ID != first.ID {
+		t.Errorf("after Touch, %s doesn't sort first", first.ID)
+	}
+
+	// Touching again straight away writes nothing
+	before, err := os.ReadFile(m.sessionPath(first.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := m.Touch(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.ReadFile(m.sessionPath(first.ID))
+	if string(before) != string(after) || again.Sequence != touched.Sequence {
+		t.Error("a second touch within the interval rewrote the session")
+	}
+
+	// A deleted session isn't brought back
+	if err := m.Delete(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Touch(second.ID); !errors.Is(err, ErrSessionDeleted) {
+		t.Errorf("Touch of a deleted session = %v, want ErrSessionDeleted", err)
+	}
+	if _, err := os.Stat(m.sessionPath(second.ID)); !os.IsNotExist(err) {
+		t.Errorf("Touch recreated the deleted session: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// quarantineDir is the subdirectory Quarantine moves broken session files
+// into, so they stop being reported but can still be inspected or restored
+const quarantineDir = "quarantine"
+
+// ProblemKind classifies a file Verify reports
+type ProblemKind string
+
+const (
+	// ProblemUnreadable is a session file that couldn't be read
+	ProblemUnreadable ProblemKind = "unreadable"
+	// ProblemCorrupt is a session file that isn't a valid session
+	ProblemCorrupt ProblemKind = "corrupt"
+	// ProblemStray is a file claude-go didn't create, e.g. a leftover
+	// temporary file or something copied in by hand
+	ProblemStray ProblemKind = "stray"
+)
+
+// Problem is a file in the sessions directory that List skips
+type Problem struct {
+	Name   string      `json:"name"` // file name within the sessions directory
+	Kind   ProblemKind `json:"kind"`
+	Detail string      `json:"detail,omitempty"`
+}
+
+// Verify reports the files in the sessions directory that List would skip
+// silently: session files that can't be read or parsed, and stray files.
+// The index, sequence counter, session logs and quarantine are expected.
+func (m *Manager) Verify() ([]Problem, error) {
+	entries, err := os.ReadDir(m.sessionsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var problems []Problem
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case name == indexFile || name == sequenceFile:
+			continue
+		case entry.IsDir():
+			if name != quarantineDir {
+				problems = append(problems, Problem{Name: name, Kind: ProblemStray, Detail: "unexpected directory"})
+			}
+			continue
+		case strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.1"):
+			continue
+		case !strings.HasSuffix(name, ".json"):
+			problems = append(problems, Problem{Name: name, Kind: ProblemStray})
+			continue
+		}
+
+		if problem, ok := m.verifyFile(name); !ok {
+			problems = append(problems, problem)
+		}
+	}
+	return problems, nil
+}
+
+// verifyFile checks that a .json file in the sessions directory holds the
+// session its name says
+func (m *Manager) verifyFile(name string) (Problem, bool) {
+	data, err := os.ReadFile(filepath.Join(m.sessionsDir, name))
+	if err != nil {
+		return Problem{Name: name, Kind: ProblemUnreadable, Detail: err.Error()}, false
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return Problem{Name: name, Kind: ProblemCorrupt, Detail: err.Error()}, false
+	}
+	if id := strings.TrimSuffix(name, ".json"); session.ID != id {
+		return Problem{Name: name, Kind: ProblemCorrupt, Detail: fmt.Sprintf("holds session %q", session.ID)}, false
+	}
+	return Problem{}, true
+}
+
+// Quarantine moves the unreadable and corrupt files among problems into
+// the quarantine subdirectory, returning how many were moved. Stray files
+// are left alone, since they may belong to the user.
+func (m *Manager) Quarantine(problems []Problem) (int, error) {
+	dir := filepath.Join(m.sessionsDir, quarantineDir)
+
+	moved := 0
+	for _, p := range problems {
+		if p.Kind == ProblemStray {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return moved, fmt.Errorf("failed to create quarantine directory: %w", err)
+		}
+
+		dest := filepath.Join(dir, p.Name)
+		if _, err := os.Lstat(dest); err == nil {
+			dest = fmt.Sprintf("%s.%d", dest, time.Now().UnixNano())
+		}
+		if err := fsutil.Rename(filepath.Join(m.sessionsDir, p.Name), dest); err != nil {
+			return moved, fmt.Errorf("failed to quarantine %s: %w", p.Name, err)
+		}
+		moved++
+
+		id := strings.TrimSuffix(p.Name, ".json")
+		m.updateIndex(func(index *projectIndex) { index.remove(id) })
+	}
+	return moved, nil
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyAndQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+	good, err := m.Create(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"0123456789abcdef.json": "{not json",
+		"fedcba9876543210.json": `{"id":"somebody-else"}`,
+		"notes.txt":             "stray",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// List stays resilient, Verify reports each file distinctly
+	sessions, err := m.List()
+	if err != nil || len(sessions) != 1 || sessions[0].ID != good.ID {
+		t.Fatalf("List = %v, %v; want only the good session", sessions, err)
+	}
+	problems, err := m.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]ProblemKind)
+	for _, p := range problems {
+		kinds[p.Name] = p.Kind
+	}
+	want := map[string]ProblemKind{
+		"0123456789abcdef.json": ProblemCorrupt,
+		"fedcba9876543210.json": ProblemCorrupt,
+		"notes.txt":             ProblemStray,
+	}
+	if len(kinds) != len(want) {
+		t.Errorf("problems = %+v, want %v", problems, want)
+	}
+	for name, kind := range want {
+		if kinds[name] != kind {
+			t.Errorf("%s: kind %q, want %q", name, kinds[name], kind)
+		}
+	}
+
+	// Repair moves the corrupt files aside and leaves stray ones
+	moved, err := m.Quarantine(problems)
+	if err != nil || moved != 2 {
+		t.Fatalf("Quarantine = %d, %v; want 2 moved", moved, err)
+	}
+	for _, name := range []string{"0123456789abcdef.json", "fedcba9876543210.json"} {
+		if _, err := os.Stat(filepath.Join(dir, quarantineDir, name)); err != nil {
+			t.Errorf("%s not in quarantine: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("a stray file was moved")
+	}
+
+	problems, err = m.Verify()
+	if err != nil || len(problems) != 1 || problems[0].Kind != ProblemStray {
+		t.Errorf("after repair: problems = %+v, %v; want only the stray file", problems, err)
+	}
+	if _, err := m.Load(good.ID); err != nil {
+		t.Errorf("the good session: %v", err)
+	}
+}
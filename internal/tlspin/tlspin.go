@@ -0,0 +1,102 @@
+// Package tlspin restricts HTTPS connections to servers presenting a known
+// public key, on top of the usual CA verification.
+package tlspin
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// pinPrefix starts every pin. curl's --pinnedpubkey takes the same hash
+// after "sha256//".
+const pinPrefix = "sha256/"
+
+// ErrPinMismatch means no certificate the server presented has a pinned key
+var ErrPinMismatch = errors.New("server public key does not match any pinned key")
+
+// Pin returns the pin of a certificate: "sha256/" and the base64 SHA-256
+// of its SubjectPublicKeyInfo
+func Pin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ParsePins checks a list of pins and returns them as a set
+func ParsePins(pins []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		encoded, ok := strings.CutPrefix(pin, pinPrefix)
+		if !ok {
+			return nil, fmt.Errorf("invalid pin %q: want sha256/<base64 SPKI hash>", pin)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid pin %q: not a base64 SHA-256 hash", pin)
+		}
+		set[pin] = true
+	}
+	return set, nil
+}
+
+// verifier accepts a connection only if some certificate in a verified
+// chain, leaf or CA, has a pinned key
+func verifier(pins map[string]bool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			for _, cert := range chain {
+				if pins[Pin(cert)] {
+					return nil
+				}
+			}
+		}
+
+		if len(verifiedChains) > 0 && len(verifiedChains[0]) > 0 {
+			return fmt.Errorf("%w (server presented %s)", ErrPinMismatch, Pin(verifiedChains[0][0]))
+		}
+		return ErrPinMismatch
+	}
+}
+
+// httpsOnly refuses plain HTTP requests, which would skip the pin check,
+// including redirects to them
+type httpsOnly struct {
+	next http.RoundTripper
+}
+
+func (t httpsOnly) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("refusing %s: pinned keys require https", req.URL.Redacted())
+	}
+	return t.next.RoundTrip(req)
+}
+
+// Client returns an HTTP client that only talks over https to servers
+// presenting one of the pinned keys, or http.DefaultClient if there are
+// none. Redirects must lead to a pinned server too.
+func Client(pins []string) (*http.Client, error) {
+	return client(pins, nil)
+}
+
+// client is Client verifying certificates against roots, or the system's
+// roots if nil
+func client(pins []string, roots *x509.CertPool) (*http.Client, error) {
+	if len(pins) == 0 {
+		return http.DefaultClient, nil
+	}
+
+	set, err := ParsePins(pins)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots, VerifyPeerCertificate: verifier(set)}
+
+	return &http.Client{Transport: httpsOnly{next: transport}}, nil
+}
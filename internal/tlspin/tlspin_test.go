@@ -0,0 +1,76 @@
+package tlspin
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientPins(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	otherPin := otherTestPin()
+
+	tests := []struct {
+		name string
+		pins []string
+		ok   bool
+	}{
+		{"matching", []string{Pin(srv.Certificate())}, true},
+		{"backup matches", []string{otherPin, Pin(srv.Certificate())}, true},
+		{"mismatching", []string{otherPin}, false},
+	}
+
+	for _, tt := range tests {
+		c, err := client(tt.pins, roots)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if tt.ok && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrPinMismatch) {
+			t.Errorf("%s: err = %v, want ErrPinMismatch", tt.name, err)
+		}
+	}
+}
+
+func TestClientRefusesHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c, err := Client([]string{otherTestPin()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := c.Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Error("a pinned client made a plain http request")
+	}
+}
+
+func TestParsePins(t *testing.T) {
+	for _, pin := range []string{"", "sha256//" + otherTestPin()[len(pinPrefix):], "sha1/AAAA", "sha256/not base64", "sha256/AAAA"} {
+		if _, err := ParsePins([]string{pin}); err == nil {
+			t.Errorf("ParsePins(%q) accepted an invalid pin", pin)
+		}
+	}
+	if _, err := ParsePins([]string{otherTestPin()}); err != nil {
+		t.Error(err)
+	}
+}
+
+// otherTestPin returns a valid pin no server presents
+func otherTestPin() string {
+	sum := sha256.Sum256([]byte("test key"))
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,341 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// protectedPaths hold user data or updater state, which no manifest may
+// let an update write to
+var protectedPaths = []string{"vault", "sessions", "config", "profiles", "cache", "mcp/user", ".version", ".staging", ".rollback"}
+
+// ValidateInstallPaths checks the extra paths a manifest lets an update
+// install: relative, slash-separated, inside the USB root and clear of
+// user data. A path ending in "/" covers everything under it.
+func ValidateInstallPaths(paths []string) error {
+	for _, p := range paths {
+		clean := strings.TrimSuffix(p, "/")
+		if clean == "" || strings.HasPrefix(p, "/") || strings.Contains(p, `\`) || path.Clean(clean) != clean || clean == ".." || strings.HasPrefix(clean, "../") {
+			return fmt.Errorf("invalid install path %q: want a relative path such as mcp/bundled/", p)
+		}
+		// Case-insensitive filesystems (FAT, exFAT, NTFS, APFS) would
+		// take Vault/ for vault/
+		for _, protected := range protectedPaths {
+			if withinFold(clean, protected) || withinFold(protected, clean) {
+				return fmt.Errorf("install path %q would touch %s, which updates never replace", p, protected)
+			}
+		}
+	}
+	return nil
+}
+
+// protectedBy returns the protected path that name, slash-separated and
+// relative to the USB root, lies under, or "" if none
+func protectedBy(name string) string {
+	for _, p := range protectedPaths {
+		if withinFold(name, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// within reports whether name is prefix or lies under it
+func within(name, prefix string) bool {
+	return name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+// withinFold is within, ignoring case
+func withinFold(name, prefix string) bool {
+	if len(name) < len(prefix) || !strings.EqualFold(name[:len(prefix)], prefix) {
+		return false
+	}
+	return len(name) == len(prefix) || name[len(prefix)] == '/'
+}
+
+// extractUpdate extracts the bin/ tree, the scripts and anything under
+// the manifest's extra install paths from a .zip or .tar.gz into destDir.
+// The format is sniffed from the file's first bytes, falling back to the
+// extension.
+func (u *Updater) extractUpdate(archivePath, destDir string, paths []string) error {
+	if err := ValidateInstallPaths(paths); err != nil {
+		return err
+	}
+
+	format, err := archiveFormat(archivePath)
+	if err != nil {
+		return err
+	}
+
+	want := func(name string) bool {
+		return wantEntry(name, paths)
+	}
+
+	switch format {
+	case "zip":
+		return extractZip(archivePath, destDir, want)
+	case "tar.gz":
+		return extractTarGz(archivePath, destDir, want)
+	default:
+		return fmt.Errorf("unsupported archive format: %s", filepath.Base(archivePath))
+	}
+}
+
+// ExtractFiles extracts the named files, slash-separated paths within the
+// bundle such as "mcp/bundled/fetch/server", from a .zip or .tar.gz bundle
+// into destDir, e.g. to restore damaged files. Names not in the bundle are
+// skipped; callers check what arrived.
+func ExtractFiles(archivePath, destDir string, names []string) error {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	format, err := archiveFormat(archivePath)
+	if err != nil {
+		return err
+	}
+
+	want := func(name string) bool {
+		return wanted[strings.TrimPrefix(name, "./")]
+	}
+
+	switch format {
+	case "zip":
+		return extractZip(archivePath, destDir, want)
+	case "tar.gz":
+		return extractTarGz(archivePath, destDir, want)
+	default:
+		return fmt.Errorf("unsupported archive format: %s", filepath.Base(archivePath))
+	}
+}
+
+// extractedSize returns the total uncompressed size of the entries
+// extractUpdate would write, read from the archive's headers
+func extractedSize(archivePath string, paths []string) (uint64, error) {
+	format, err := archiveFormat(archivePath)
+	if err != nil {
+		return 0, err
+	}
+
+	var total uint64
+	switch format {
+	case "zip":
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+
+		for _, f := range r.File {
+			if wantEntry(f.Name, paths) && !f.FileInfo().IsDir() {
+				total += f.UncompressedSize64
+			}
+		}
+	case "tar.gz":
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+			if hdr.Typeflag == tar.TypeReg && hdr.Size > 0 && wantEntry(hdr.Name, paths) {
+				total += uint64(hdr.Size)
+			}
+		}
+	default:
+		return 0, fmt.Errorf("unsupported archive format: %s", filepath.Base(archivePath))
+	}
+	return total, nil
+}
+
+func archiveFormat(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 4)
+	n, _ := io.ReadFull(f, header)
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, zipMagic):
+		return "zip", nil
+	case bytes.HasPrefix(header, gzipMagic):
+		return "tar.gz", nil
+	case strings.HasSuffix(path, ".zip"):
+		return "zip", nil
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return "tar.gz", nil
+	}
+
+	return "", nil
+}
+
+// wantEntry reports whether an archive entry is part of an update: the
+// bin/ tree, the launcher/update scripts at the archive root, the bundle's
+// .version and anything under the manifest's extra install paths
+func wantEntry(name string, paths []string) bool {
+	name = strings.TrimPrefix(name, "./")
+	if name == ".version" || strings.HasPrefix(name, "bin/") {
+		return true
+	}
+	if !strings.Contains(name, "/") && (strings.HasSuffix(name, ".sh") || strings.HasSuffix(name, ".bat")) {
+		return true
+	}
+
+	for _, p := range paths {
+		if within(strings.TrimSuffix(name, "/"), strings.TrimSuffix(p, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func extractZip(zipPath, destDir string, want func(string) bool) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if !want(f.Name) {
+			continue
+		}
+
+		destPath, err := safeJoin(destDir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			os.MkdirAll(destPath, f.Mode())
+			continue
+		}
+
+		if err := extractFile(f, destPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractTarGz(tarPath, destDir string, want func(string) bool) error {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !want(hdr.Name) {
+			continue
+		}
+
+		destPath, err := safeJoin(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := os.FileMode(hdr.Mode).Perm()
+
+		// Links and devices are never part of a release, and a symlink
+		// could point extraction outside destDir
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			os.MkdirAll(destPath, mode|0700)
+		case tar.TypeReg:
+			if err := writeFile(tr, destPath, mode); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// safeJoin joins an archive entry name onto dir, rejecting names that would
+// escape it
+func safeJoin(dir, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+
+	return path, nil
+}
+
+func extractFile(f *zip.File, destPath string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return writeFile(rc, destPath, f.Mode())
+}
+
+func writeFile(r io.Reader, destPath string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, r)
+	return err
+}
@@ -0,0 +1,86 @@
+package update
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTarGzBundle writes a .tar.gz update bundle holding files, with entry
+// names prefixed "./" as tar -C dir . produces
+func writeTarGzBundle(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: "./" + name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A symlink is never extracted, wherever it points
+	link := &tar.Header{Name: "./bin/link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink}
+	if err := tw.WriteHeader(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractUpdateFormats(t *testing.T) {
+	u := testUpdater(t)
+	files := map[string]string{
+		".version":           "1.1.0",
+		launcherPath(u):      "new launcher",
+		"claude-go.sh":       "#!/bin/sh",
+		"mcp/bundled/x/run":  "server",
+		"notes/unlisted.txt": "not installed",
+	}
+	want := map[string]string{
+		".version":           "1.1.0",
+		launcherPath(u):      "new launcher",
+		"claude-go.sh":       "#!/bin/sh",
+		"mcp/bundled/x/run":  "server",
+		"notes/unlisted.txt": "",
+	}
+
+	bundles := map[string]string{
+		"zip":    writeBundle(t, files),
+		"tar.gz": writeTarGzBundle(t, "bundle.tar.gz", files),
+		// The format comes from the content, not a misleading extension
+		"sniffed": writeTarGzBundle(t, "bundle.zip", files),
+	}
+	for name, bundle := range bundles {
+		t.Run(name, func(t *testing.T) {
+			dest := t.TempDir()
+			if err := u.extractUpdate(bundle, dest, []string{"mcp/bundled/"}); err != nil {
+				t.Fatalf("extractUpdate: %v", err)
+			}
+			checkTree(t, dest, want)
+
+			if _, err := os.Lstat(filepath.Join(dest, "bin", "link")); !os.IsNotExist(err) {
+				t.Errorf("symlink entry was extracted: %v", err)
+			}
+		})
+	}
+}
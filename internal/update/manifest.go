@@ -0,0 +1,85 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+// ManifestOptions describe the release BuildManifest writes a manifest for
+type ManifestOptions struct {
+	Version     string
+	ReleaseDate string // YYYY-MM-DD; empty means today
+	Changelog   []string
+	MinVersion  string
+	BaseURL     string   // prefix of the download URLs; empty means the GitHub release
+	Paths       []string // installed beyond bin/ and the scripts, e.g. "mcp/bundled/"
+}
+
+// BuildManifest produces the manifest the updater consumes from a directory
+// of release bundles named claude-go-<version>-<platform>.zip (or .tar.gz),
+// hashing each one. Every platform needs a bundle.
+func BuildManifest(dir string, opts ManifestOptions) (*Manifest, error) {
+	if opts.Version == "" {
+		return nil, fmt.Errorf("a version is required")
+	}
+
+	date := opts.ReleaseDate
+	if date == "" {
+		date = time.Now().UTC().Format("2006-01-02")
+	}
+
+	if err := ValidateInstallPaths(opts.Paths); err != nil {
+		return nil, err
+	}
+
+	baseURL := fmt.Sprintf(releaseURL, opts.Version)
+	if opts.BaseURL != "" {
+		baseURL = strings.TrimRight(opts.BaseURL, "/") + "/"
+	}
+
+	manifest := &Manifest{
+		Version:     opts.Version,
+		ReleaseDate: date,
+		Changelog:   opts.Changelog,
+		MinVersion:  opts.MinVersion,
+		Paths:       opts.Paths,
+		Downloads:   make(map[string]Download),
+	}
+
+	for _, plat := range platform.AllPlatforms {
+		name, err := findBundle(dir, opts.Version, plat)
+		if err != nil {
+			return nil, err
+		}
+
+		sum, size, err := fsutil.SHA256File(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", name, err)
+		}
+
+		manifest.Downloads[string(plat)] = Download{
+			URL:    baseURL + name,
+			SHA256: sum,
+			Size:   size,
+		}
+	}
+
+	return manifest, nil
+}
+
+// findBundle returns the file name of a platform's bundle in dir
+func findBundle(dir, version string, plat platform.Platform) (string, error) {
+	base := fmt.Sprintf("claude-go-%s-%s", version, plat)
+	for _, ext := range []string{".zip", ".tar.gz"} {
+		if _, err := os.Stat(filepath.Join(dir, base+ext)); err == nil {
+			return base + ext, nil
+		}
+	}
+	return "", fmt.Errorf("no bundle for %s in %s (expected %s.zip or .tar.gz)", plat, dir, base)
+}
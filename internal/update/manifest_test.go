@@ -0,0 +1,108 @@
+package update
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+// writeBundles writes a bundle for each platform into dir, each with
+// different contents
+func writeBundles(t *testing.T, dir, version string) {
+	t.Helper()
+
+	for i, plat := range platform.AllPlatforms {
+		name := filepath.Join(dir, "claude-go-"+version+"-"+string(plat)+".zip")
+		if err := os.WriteFile(name, []byte(strings.Repeat(string(plat), i+1)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuildManifestParsesBack(t *testing.T) {
+	dir := t.TempDir()
+	writeBundles(t, dir, "1.2.0")
+
+	opts := ManifestOptions{
+		Version:     "1.2.0",
+		ReleaseDate: "2026-03-01",
+		Changelog:   []string{"Fix token refresh", "Add session tags"},
+		MinVersion:  "1.0.0",
+		Paths:       []string{"mcp/bundled/"},
+	}
+	built, err := BuildManifest(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Encoded as 'export manifest' writes it, and read as the updater does
+	data, err := json.MarshalIndent(built, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := parseManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(manifest, built) {
+		t.Errorf("parsed manifest = %+v, want %+v", manifest, built)
+	}
+
+	u := testUpdater(t)
+	u.CurrentVersion = "1.1.0"
+	if compareVersions(manifest.Version, u.CurrentVersion) <= 0 {
+		t.Errorf("version %s isn't newer than %s", manifest.Version, u.CurrentVersion)
+	}
+	for _, plat := range platform.AllPlatforms {
+		download, ok := manifest.Downloads[string(plat)]
+		if !ok {
+			t.Errorf("no download for %s", plat)
+			continue
+		}
+
+		name := "claude-go-1.2.0-" + string(plat) + ".zip"
+		if want := "https://github.com/cxt9/claude-go/releases/download/v1.2.0/" + name; download.URL != want {
+			t.Errorf("%s URL = %s, want %s", plat, download.URL, want)
+		}
+		bundle := filepath.Join(dir, name)
+		if err := u.verifyChecksum(bundle, download.SHA256); err != nil {
+			t.Errorf("%s: %v", plat, err)
+		}
+		if info, err := os.Stat(bundle); err != nil || info.Size() != download.Size {
+			t.Errorf("%s size = %d, want the bundle's", plat, download.Size)
+		}
+	}
+}
+
+func TestBuildManifestBaseURL(t *testing.T) {
+	dir := t.TempDir()
+	writeBundles(t, dir, "1.2.0")
+
+	for _, base := range []string{"https://mirror.example.com/claude-go", "https://mirror.example.com/claude-go/"} {
+		manifest, err := BuildManifest(dir, ManifestOptions{Version: "1.2.0", BaseURL: base})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "https://mirror.example.com/claude-go/claude-go-1.2.0-linux-amd64.zip"
+		if got := manifest.Downloads["linux-amd64"].URL; got != want {
+			t.Errorf("--base-url %s: URL = %s, want %s", base, got, want)
+		}
+	}
+}
+
+func TestBuildManifestNeedsEveryPlatform(t *testing.T) {
+	dir := t.TempDir()
+	writeBundles(t, dir, "1.2.0")
+	if err := os.Remove(filepath.Join(dir, "claude-go-1.2.0-linux-amd64.zip")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := BuildManifest(dir, ManifestOptions{Version: "1.2.0"}); err == nil {
+		t.Error("a manifest was built without a linux-amd64 bundle")
+	}
+}
@@ -0,0 +1,135 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// signingKeys lists the ed25519 public keys trusted to sign release
+// manifests, as comma-separated id=base64 pairs, oldest first. It is set at
+// build time:
+//
+//	go build -ldflags "-X github.com/cxt9/claude-go/internal/update.signingKeys=2026a=...,2027a=..."
+//
+// To rotate, ship a release trusting both the current and the next key,
+// sign manifests with both while that release spreads, then sign with the
+// next key only and drop the old one from later builds.
+var signingKeys string
+
+// maxManifestSize bounds the manifest and signature downloads
+const maxManifestSize = 1 << 20
+
+// ErrUntrustedManifest means no signature on the manifest verifies with a
+// trusted key
+var ErrUntrustedManifest = errors.New("manifest is not signed by a trusted key")
+
+// trustedKey is a public key allowed to sign manifests
+type trustedKey struct {
+	ID  string
+	Key ed25519.PublicKey
+}
+
+// manifestSignatures is the .sig file published next to manifest.json.
+// During a key rotation it carries one signature per key.
+type manifestSignatures struct {
+	Signatures []manifestSignature `json:"signatures"`
+}
+
+type manifestSignature struct {
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"` // base64 ed25519 signature of manifest.json
+}
+
+// parseSigningKeys decodes a signingKeys list
+func parseSigningKeys(list string) ([]trustedKey, error) {
+	var keys []trustedKey
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(pair, "=")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid signing key %q: want id=base64", pair)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid signing key %q: not a base64 ed25519 public key", id)
+		}
+
+		keys = append(keys, trustedKey{ID: id, Key: ed25519.PublicKey(key)})
+	}
+	return keys, nil
+}
+
+// verifyManifest checks manifest against its signatures and returns the ID
+// of the newest trusted key with a valid one
+func verifyManifest(manifest []byte, sigs *manifestSignatures, keys []trustedKey) (string, error) {
+	for i := len(keys) - 1; i >= 0; i-- {
+		for _, sig := range sigs.Signatures {
+			if sig.KeyID != keys[i].ID {
+				continue
+			}
+
+			raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+			if err != nil {
+				continue
+			}
+			if ed25519.Verify(keys[i].Key, manifest, raw) {
+				return keys[i].ID, nil
+			}
+		}
+	}
+
+	return "", ErrUntrustedManifest
+}
+
+// fetchSignatures downloads the signatures published for a manifest
+func fetchSignatures(ctx context.Context, client *http.Client, url string) (*manifestSignatures, error) {
+	data, err := fetch(ctx, client, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest signature: %w", err)
+	}
+
+	var sigs manifestSignatures
+	if err := json.Unmarshal(data, &sigs); err != nil {
+		return nil, fmt.Errorf("invalid manifest signature: %w", err)
+	}
+	return &sigs, nil
+}
+
+// fetch GETs a small document
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("%s: larger than %d bytes", url, maxManifestSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,88 @@
+package update
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// testKey generates a signing key pair trusted under id
+func testKey(t *testing.T, id string) (trustedKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trustedKey{ID: id, Key: pub}, priv
+}
+
+// sign returns a signature of manifest by priv, published under id
+func sign(id string, priv ed25519.PrivateKey, manifest []byte) manifestSignature {
+	return manifestSignature{KeyID: id, Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, manifest))}
+}
+
+func TestVerifyManifestKeyRotation(t *testing.T) {
+	manifest := []byte(`{"version":"1.2.0"}`)
+	current, currentPriv := testKey(t, "2026a")
+	next, nextPriv := testKey(t, "2027a")
+	_, untrustedPriv := testKey(t, "other")
+	keys := []trustedKey{current, next}
+
+	tests := []struct {
+		name string
+		sigs []manifestSignature
+		want string
+	}{
+		{"current key", []manifestSignature{sign("2026a", currentPriv, manifest)}, "2026a"},
+		{"next key", []manifestSignature{sign("2027a", nextPriv, manifest)}, "2027a"},
+		{"both, newest preferred", []manifestSignature{sign("2026a", currentPriv, manifest), sign("2027a", nextPriv, manifest)}, "2027a"},
+		{"newest invalid", []manifestSignature{sign("2027a", nextPriv, []byte("other")), sign("2026a", currentPriv, manifest)}, "2026a"},
+		{"untrusted key", []manifestSignature{sign("other", untrustedPriv, manifest)}, ""},
+		{"untrusted key under a trusted ID", []manifestSignature{sign("2027a", untrustedPriv, manifest)}, ""},
+		{"unsigned", nil, ""},
+	}
+
+	for _, tt := range tests {
+		got, err := verifyManifest(manifest, &manifestSignatures{Signatures: tt.sigs}, keys)
+		if tt.want == "" {
+			if !errors.Is(err, ErrUntrustedManifest) {
+				t.Errorf("%s: verifyManifest = %q, %v; want ErrUntrustedManifest", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: verifyManifest = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	// Once the old key is dropped, only the next one is accepted
+	if _, err := verifyManifest(manifest, &manifestSignatures{Signatures: []manifestSignature{sign("2026a", currentPriv, manifest)}}, []trustedKey{next}); !errors.Is(err, ErrUntrustedManifest) {
+		t.Errorf("dropped key: err = %v, want ErrUntrustedManifest", err)
+	}
+}
+
+func TestParseSigningKeys(t *testing.T) {
+	current, _ := testKey(t, "2026a")
+	next, _ := testKey(t, "2027a")
+	list := "2026a=" + base64.StdEncoding.EncodeToString(current.Key) + ", 2027a=" + base64.StdEncoding.EncodeToString(next.Key)
+
+	keys, err := parseSigningKeys(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].ID != "2026a" || keys[1].ID != "2027a" || !keys[1].Key.Equal(next.Key) {
+		t.Errorf("parseSigningKeys = %+v", keys)
+	}
+
+	if keys, err := parseSigningKeys(""); err != nil || len(keys) != 0 {
+		t.Errorf("empty list: %v, %v", keys, err)
+	}
+	for _, bad := range []string{"nokey", "=abc", "2026a=not-base64!", "2026a=" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := parseSigningKeys(bad); err == nil {
+			t.Errorf("parseSigningKeys(%q) accepted an invalid key", bad)
+		}
+	}
+}
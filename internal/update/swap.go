@@ -0,0 +1,98 @@
+package update
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// renamePath moves files and directories during a swap; replaced in tests
+var renamePath = fsutil.Rename
+
+// swapJournal records each path an update replaced, so a failure partway
+// through can put every one of them back. The replaced originals are kept
+// under backupDir at the same relative paths.
+type swapJournal struct {
+	root      string
+	backupDir string
+	steps     []swapStep
+}
+
+type swapStep struct {
+	rel      string
+	backedUp bool // false if the update created the path
+}
+
+// replace moves the current root/rel, if any, under the backup directory and
+// renames staged into its place
+func (j *swapJournal) replace(staged, rel string) error {
+	dest := filepath.Join(j.root, rel)
+	step := swapStep{rel: rel}
+
+	if _, err := os.Lstat(dest); err == nil {
+		backup := filepath.Join(j.backupDir, rel)
+		if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+			return err
+		}
+		if err := renamePath(dest, backup); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", rel, err)
+		}
+		step.backedUp = true
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	j.steps = append(j.steps, step)
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := renamePath(staged, dest); err != nil {
+		return fmt.Errorf("failed to install %s: %w", rel, err)
+	}
+	return nil
+}
+
+// replaceFiles replaces each file under the staged directory dir, one by
+// one, at the same path under rel. A file that would land on user data or
+// updater state fails the swap.
+func (j *swapJournal) replaceFiles(dir, rel string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(rel, relPath)
+		if p := protectedBy(filepath.ToSlash(dest)); p != "" {
+			return fmt.Errorf("update would replace %s, under %s", filepath.ToSlash(dest), p)
+		}
+		return j.replace(path, dest)
+	})
+}
+
+// undo puts back what replace moved aside, newest first, and removes what
+// it added. It carries on past errors and returns the first.
+func (j *swapJournal) undo() error {
+	var first error
+	for i := len(j.steps) - 1; i >= 0; i-- {
+		step := j.steps[i]
+		dest := filepath.Join(j.root, step.rel)
+
+		if err := os.RemoveAll(dest); err != nil && first == nil {
+			first = err
+		}
+		if !step.backedUp {
+			continue
+		}
+		if err := renamePath(filepath.Join(j.backupDir, step.rel), dest); err != nil && first == nil {
+			first = fmt.Errorf("failed to restore %s: %w", step.rel, err)
+		}
+	}
+	j.steps = nil
+	return first
+}
@@ -1,33 +1,83 @@
 package update
 
 import (
-	"archive/zip"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/cxt9/claude-go/internal/fsutil"
 	"github.com/cxt9/claude-go/internal/platform"
+	"github.com/cxt9/claude-go/internal/tlspin"
 )
 
 const (
 	manifestURL = "https://github.com/cxt9/claude-go/releases/latest/download/manifest.json"
-	downloadURL = "https://github.com/cxt9/claude-go/releases/download/%s/claude-go-%s-%s.zip"
+	releaseURL  = "https://github.com/cxt9/claude-go/releases/download/v%s/"
+
+	// installSlack is room required beyond the bundle's size, for
+	// filesystem overhead and the version file
+	installSlack = 1 << 20
 )
 
+// requireSpace checks a volume has room before an update writes to it;
+// replaced in tests
+var requireSpace = fsutil.RequireSpace
+
 // Manifest represents the version manifest from GitHub
 type Manifest struct {
 	Version     string              `json:"version"`
 	ReleaseDate string              `json:"release_date"`
 	Changelog   []string            `json:"changelog"`
+	Changelogs  map[string][]string `json:"changelogs,omitempty"` // per-version notes, if provided
 	Downloads   map[string]Download `json:"downloads"`
 	MinVersion  string              `json:"min_version"`
+
+	// Paths beyond bin/ and the scripts this release installs, e.g.
+	// "mcp/bundled/"; see ValidateInstallPaths
+	Paths []string `json:"paths,omitempty"`
+
+	// ID of the trusted key whose signature verified; empty for builds
+	// without signing keys
+	SignedBy string `json:"-"`
+}
+
+// ReleaseNotes are the changelog entries of one release
+type ReleaseNotes struct {
+	Version string   `json:"version"`
+	Changes []string `json:"changes"`
+}
+
+// ChangelogSince returns the notes of every release newer than current, up
+// to the manifest's version, newest first. Manifests without per-version
+// notes yield just the latest changelog.
+func (m *Manifest) ChangelogSince(current string) []ReleaseNotes {
+	if len(m.Changelogs) == 0 {
+		if len(m.Changelog) == 0 {
+			return nil
+		}
+		return []ReleaseNotes{{Version: m.Version, Changes: m.Changelog}}
+	}
+
+	var notes []ReleaseNotes
+	for version, changes := range m.Changelogs {
+		if compareVersions(version, current) > 0 && compareVersions(version, m.Version) <= 0 {
+			notes = append(notes, ReleaseNotes{Version: version, Changes: changes})
+		}
+	}
+
+	sort.Slice(notes, func(i, j int) bool {
+		return compareVersions(notes[i].Version, notes[j].Version) > 0
+	})
+
+	return notes
 }
 
 // Download represents download information for a platform
@@ -42,8 +92,28 @@ type Updater struct {
 	USBRoot        string
 	CurrentVersion string
 	Platform       platform.Platform
+
+	// Install bundles older than the highest version ever installed
+	AllowDowngrade bool
+
+	// Directory holding cache/ and profiles/, when not USBRoot
+	DataRoot string
+
+	// Leave the caches alone after installing, or keep these
+	// subdirectories of them when clearing them
+	KeepCache     bool
+	KeepCacheDirs []string
+
+	// Keys trusted to sign manifests (from signingKeys)
+	keys []trustedKey
+
+	// Client for the release hosts; see SetPinnedKeys
+	client *http.Client
 }
 
+// ErrDowngrade means a bundle is older than a version already installed
+var ErrDowngrade = errors.New("update would downgrade")
+
 // NewUpdater creates a new updater
 func NewUpdater(usbRoot string) (*Updater, error) {
 	plat, err := platform.Current()
@@ -51,138 +121,352 @@ func NewUpdater(usbRoot string) (*Updater, error) {
 		return nil, err
 	}
 
+	keys, err := parseSigningKeys(signingKeys)
+	if err != nil {
+		return nil, err
+	}
+
 	version := readVersionFile(usbRoot)
 
 	return &Updater{
 		USBRoot:        usbRoot,
 		CurrentVersion: version,
 		Platform:       plat,
+		keys:           keys,
+		client:         http.DefaultClient,
 	}, nil
 }
 
+// SetPinnedKeys restricts downloads to servers presenting one of these
+// public keys (see tlspin); empty trusts any CA-verified certificate
+func (u *Updater) SetPinnedKeys(pins []string) error {
+	client, err := tlspin.Client(pins)
+	if err != nil {
+		return err
+	}
+	u.client = client
+	return nil
+}
+
 // CheckForUpdate checks if a newer version is available
-func (u *Updater) CheckForUpdate() (*Manifest, bool, error) {
-	resp, err := http.Get(manifestURL)
+func (u *Updater) CheckForUpdate(ctx context.Context) (*Manifest, bool, error) {
+	data, err := fetch(ctx, u.client, manifestURL)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to fetch manifest: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, false, fmt.Errorf("manifest not found: %s", resp.Status)
+	signedBy, err := u.verifySignature(ctx, data)
+	if err != nil {
+		return nil, false, err
 	}
 
-	var manifest Manifest
-	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
-		return nil, false, fmt.Errorf("invalid manifest: %w", err)
+	manifest, err := parseManifest(data)
+	if err != nil {
+		return nil, false, err
 	}
+	manifest.SignedBy = signedBy
 
 	hasUpdate := compareVersions(manifest.Version, u.CurrentVersion) > 0
 
-	return &manifest, hasUpdate, nil
+	return manifest, hasUpdate, nil
+}
+
+// parseManifest decodes a published manifest.json
+func parseManifest(data []byte) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if err := ValidateInstallPaths(manifest.Paths); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// Signed reports whether this build verifies manifest signatures
+func (u *Updater) Signed() bool {
+	return len(u.keys) > 0
+}
+
+// verifySignature checks the manifest's published signatures when this
+// build has signing keys, returning the ID of the key that signed it
+func (u *Updater) verifySignature(ctx context.Context, manifest []byte) (string, error) {
+	if len(u.keys) == 0 {
+		return "", nil
+	}
+
+	sigs, err := fetchSignatures(ctx, u.client, manifestURL+".sig")
+	if err != nil {
+		return "", err
+	}
+
+	return verifyManifest(manifest, sigs, u.keys)
 }
 
 // PerformUpdate downloads and installs an update
-func (u *Updater) PerformUpdate(manifest *Manifest, progressFn func(downloaded, total int64)) error {
+func (u *Updater) PerformUpdate(ctx context.Context, manifest *Manifest, progressFn func(downloaded, total int64)) error {
 	download, ok := manifest.Downloads[string(u.Platform)]
 	if !ok {
 		return fmt.Errorf("no download available for platform: %s", u.Platform)
 	}
 
-	// Create rollback backup
-	if err := u.createRollback(); err != nil {
-		return fmt.Errorf("failed to create backup: %w", err)
+	// The download lands in the system temp directory and is extracted
+	// onto the USB, which needs at least as much again
+	if download.Size > 0 {
+		if err := requireSpace(os.TempDir(), uint64(download.Size)); err != nil {
+			return fmt.Errorf("cannot download update: %w", err)
+		}
+		if err := requireSpace(u.USBRoot, uint64(download.Size)+installSlack); err != nil {
+			return fmt.Errorf("cannot install update: %w", err)
+		}
 	}
 
 	// Download update
-	tmpFile, err := u.downloadUpdate(download, progressFn)
+	tmpFile, err := u.downloadUpdate(ctx, download, progressFn)
 	if err != nil {
-		u.rollback()
 		return fmt.Errorf("download failed: %w", err)
 	}
 	defer os.Remove(tmpFile)
 
 	// Verify checksum
 	if err := u.verifyChecksum(tmpFile, download.SHA256); err != nil {
-		u.rollback()
 		return fmt.Errorf("checksum verification failed: %w", err)
 	}
 
-	// Extract update
-	if err := u.extractUpdate(tmpFile); err != nil {
-		u.rollback()
-		return fmt.Errorf("extraction failed: %w", err)
+	// Install update
+	if err := u.install(tmpFile, manifest.Version, manifest.Paths); err != nil {
+		return err
 	}
 
-	// Update version file
-	if err := u.writeVersionFile(manifest.Version); err != nil {
-		// Non-fatal
-		fmt.Printf("Warning: failed to update version file: %v\n", err)
+	// Cleanup
+	u.clearCache()
+
+	return nil
+}
+
+// DownloadInstalled downloads and checks the bundle of the installed
+// version, for restoring files from it, and returns its temporary path. The
+// manifest only lists the latest release, so once a newer one is out this
+// fails and the bundle has to be supplied by hand.
+func (u *Updater) DownloadInstalled(ctx context.Context, progressFn func(downloaded, total int64)) (string, error) {
+	manifest, _, err := u.CheckForUpdate(ctx)
+	if err != nil {
+		return "", err
+	}
+	if manifest.Version != u.CurrentVersion {
+		return "", fmt.Errorf("the latest release is %s, not the installed %s, so its bundle can't be downloaded", manifest.Version, u.CurrentVersion)
+	}
+
+	download, ok := manifest.Downloads[string(u.Platform)]
+	if !ok {
+		return "", fmt.Errorf("no download available for platform: %s", u.Platform)
+	}
+
+	tmpFile, err := u.downloadUpdate(ctx, download, progressFn)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	if err := u.verifyChecksum(tmpFile, download.SHA256); err != nil {
+		os.Remove(tmpFile)
+		return "", fmt.Errorf("checksum verification failed: %w", err)
+	}
+
+	return tmpFile, nil
+}
+
+// PerformOfflineUpdate installs from a local .zip or .tar.gz file
+func (u *Updater) PerformOfflineUpdate(zipPath string) error {
+	if err := u.install(zipPath, "", nil); err != nil {
+		return err
 	}
 
 	// Cleanup
-	u.cleanupRollback()
 	u.clearCache()
 
 	return nil
 }
 
-// PerformOfflineUpdate installs from a local zip file
-func (u *Updater) PerformOfflineUpdate(zipPath string) error {
-	// Create rollback backup
-	if err := u.createRollback(); err != nil {
-		return fmt.Errorf("failed to create backup: %w", err)
+// install extracts an update bundle into a staging directory and swaps it
+// into place only once extraction, the version check and the smoke test
+// have passed, so an interrupted update never leaves a half-written bin/.
+// A non-empty expectedVersion must match the bundle's own; paths are the
+// manifest's extra install paths.
+func (u *Updater) install(archivePath, expectedVersion string, paths []string) error {
+	stagingDir := filepath.Join(u.USBRoot, ".staging")
+
+	// Remove leftovers of an earlier interrupted update
+	os.RemoveAll(stagingDir)
+	defer os.RemoveAll(stagingDir)
+
+	// Staging needs room for the whole bundle; bin/ is then swapped and
+	// kept for rollback by renaming, which needs none
+	size, err := extractedSize(archivePath, paths)
+	if err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+	if err := requireSpace(u.USBRoot, size+installSlack); err != nil {
+		return fmt.Errorf("cannot install update: %w", err)
 	}
 
-	// Extract update
-	if err := u.extractUpdate(zipPath); err != nil {
-		u.rollback()
+	if err := u.extractUpdate(archivePath, stagingDir, paths); err != nil {
 		return fmt.Errorf("extraction failed: %w", err)
 	}
 
-	// Cleanup
-	u.cleanupRollback()
-	u.clearCache()
+	version, err := u.checkBundleVersion(stagingDir, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("update bundle rejected: %w", err)
+	}
+
+	if err := u.smokeTest(stagingDir); err != nil {
+		return fmt.Errorf("update bundle rejected: %w", err)
+	}
+
+	if err := u.swapIn(stagingDir); err != nil {
+		return err
+	}
+
+	if err := u.writeVersionFile(version); err != nil {
+		// Non-fatal
+		fmt.Printf("Warning: failed to update version file: %v\n", err)
+	}
 
 	return nil
 }
 
-func (u *Updater) createRollback() error {
-	binDir := filepath.Join(u.USBRoot, "bin")
-	rollbackDir := filepath.Join(u.USBRoot, ".rollback")
+// checkBundleVersion reads the version a staged bundle declares in its
+// .version file, which the manifest can't vouch for, and refuses versions
+// below the highest one installed unless AllowDowngrade is set. The staged
+// .version is removed so that swapIn doesn't overwrite ours.
+func (u *Updater) checkBundleVersion(stagingDir, expectedVersion string) (string, error) {
+	stagedFile := filepath.Join(stagingDir, ".version")
+	defer os.Remove(stagedFile)
+
+	info, err := parseVersionFile(stagedFile)
+	if err != nil || info.Version == "" {
+		return "", fmt.Errorf("bundle does not declare its version")
+	}
+	version := info.Version
 
-	// Remove old rollback if exists
-	os.RemoveAll(rollbackDir)
+	if expectedVersion != "" && compareVersions(version, expectedVersion) != 0 {
+		return "", fmt.Errorf("bundle is version %s, but the manifest announced %s", version, expectedVersion)
+	}
+
+	if highest := u.HighestVersion(); compareVersions(version, highest) < 0 && !u.AllowDowngrade {
+		return "", fmt.Errorf("%w: bundle is %s but %s was installed before", ErrDowngrade, version, highest)
+	}
 
-	// Copy bin to rollback
-	return copyDir(binDir, rollbackDir)
+	return version, nil
 }
 
-func (u *Updater) rollback() error {
-	rollbackDir := filepath.Join(u.USBRoot, ".rollback")
-	binDir := filepath.Join(u.USBRoot, "bin")
+// smokeTest checks that the staged bundle contains a usable launcher for
+// this platform
+func (u *Updater) smokeTest(stagingDir string) error {
+	launcher := filepath.Join(stagingDir, "bin", string(u.Platform), u.Platform.BinaryName("claude-go"))
 
-	if _, err := os.Stat(rollbackDir); os.IsNotExist(err) {
-		return fmt.Errorf("no rollback available")
+	info, err := os.Stat(launcher)
+	if err != nil {
+		return fmt.Errorf("no launcher for %s", u.Platform)
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("empty launcher for %s", u.Platform)
 	}
 
-	os.RemoveAll(binDir)
-	return os.Rename(rollbackDir, binDir)
+	return nil
 }
 
-func (u *Updater) cleanupRollback() {
+// swapIn moves the current bin/ aside as the rollback copy, renames the
+// staged bin/ into place and then replaces the staged scripts and extra
+// paths one by one, keeping each file it replaces. Windows won't rename a
+// directory holding a running program, such as this one, but will rename
+// the program itself, so there bin/ is replaced file by file instead. If
+// any step fails, every path is put back as it was.
+func (u *Updater) swapIn(stagingDir string) error {
 	rollbackDir := filepath.Join(u.USBRoot, ".rollback")
+
+	if err := os.RemoveAll(rollbackDir); err != nil {
+		return fmt.Errorf("failed to remove the previous rollback copy: %w", err)
+	}
+	if err := os.MkdirAll(rollbackDir, 0755); err != nil {
+		return fmt.Errorf("failed to create rollback directory: %w", err)
+	}
+	journal := &swapJournal{root: u.USBRoot, backupDir: rollbackDir}
+
+	fail := func(what string, err error) error {
+		if undoErr := journal.undo(); undoErr != nil {
+			return fmt.Errorf("failed to install %s: %w (rollback incomplete: %v; the previous files are in %s)", what, err, undoErr, rollbackDir)
+		}
+		return fmt.Errorf("failed to install %s: %w", what, err)
+	}
+
+	stagedBin := filepath.Join(stagingDir, "bin")
+	if err := journal.replace(stagedBin, "bin"); err != nil {
+		// Nothing was moved if bin/ itself couldn't be
+		if len(journal.steps) > 0 {
+			return fail("binaries", err)
+		}
+		if err := journal.replaceFiles(stagedBin, "bin"); err != nil {
+			return fail("binaries", err)
+		}
+	}
+
+	// Scripts and the manifest's extra paths live alongside user data, so
+	// they are replaced one by one
+	if err := journal.replaceFiles(stagingDir, ""); err != nil {
+		return fail("scripts", err)
+	}
+
+	// A running program moved aside on Windows can't be removed until it
+	// exits; the next update clears it
 	os.RemoveAll(rollbackDir)
+	return nil
 }
 
+// clearCache empties cache/ and each profile's cache/, which may hold data
+// from the old version, unless KeepCache is set. Entries named in
+// KeepCacheDirs survive.
 func (u *Updater) clearCache() {
-	cacheDir := filepath.Join(u.USBRoot, "cache")
-	os.RemoveAll(cacheDir)
+	if u.KeepCache {
+		return
+	}
+
+	keep := make(map[string]bool, len(u.KeepCacheDirs))
+	for _, dir := range u.KeepCacheDirs {
+		keep[dir] = true
+	}
+
+	dataRoot := u.DataRoot
+	if dataRoot == "" {
+		dataRoot = u.USBRoot
+	}
+	cacheDir := filepath.Join(dataRoot, "cache")
+	clearDir(cacheDir, keep)
 	os.MkdirAll(cacheDir, 0700)
+
+	profiles, _ := os.ReadDir(filepath.Join(dataRoot, "profiles"))
+	for _, profile := range profiles {
+		if profile.IsDir() {
+			clearDir(filepath.Join(dataRoot, "profiles", profile.Name(), "cache"), keep)
+		}
+	}
+}
+
+// clearDir removes the entries of dir not named in keep
+func clearDir(dir string, keep map[string]bool) {
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if !keep[entry.Name()] {
+			os.RemoveAll(filepath.Join(dir, entry.Name()))
+		}
+	}
 }
 
-func (u *Updater) downloadUpdate(download Download, progressFn func(downloaded, total int64)) (string, error) {
-	resp, err := http.Get(download.URL)
+func (u *Updater) downloadUpdate(ctx context.Context, download Download, progressFn func(downloaded, total int64)) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, download.URL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := u.client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -192,7 +476,13 @@ func (u *Updater) downloadUpdate(download Download, progressFn func(downloaded,
 		return "", fmt.Errorf("download failed: %s", resp.Status)
 	}
 
-	tmpFile, err := os.CreateTemp("", "claude-go-update-*.zip")
+	// Keep the archive's extension; extraction sniffs the format anyway
+	ext := ".zip"
+	if strings.HasSuffix(download.URL, ".tar.gz") || strings.HasSuffix(download.URL, ".tgz") {
+		ext = ".tar.gz"
+	}
+
+	tmpFile, err := os.CreateTemp("", "claude-go-update-*"+ext)
 	if err != nil {
 		return "", err
 	}
@@ -215,6 +505,9 @@ func (u *Updater) downloadUpdate(download Download, progressFn func(downloaded,
 			break
 		}
 		if err != nil {
+			// Cancelled or broken download; don't leave a partial file
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
 			return "", err
 		}
 	}
@@ -223,18 +516,11 @@ func (u *Updater) downloadUpdate(download Download, progressFn func(downloaded,
 }
 
 func (u *Updater) verifyChecksum(filePath, expectedHash string) error {
-	f, err := os.Open(filePath)
+	actualHash, _, err := fsutil.SHA256File(filePath)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	h := sha256.New()
-	if _, err := io.Copy(h, f); err != nil {
-		return err
-	}
-
-	actualHash := hex.EncodeToString(h.Sum(nil))
 	if !strings.EqualFold(actualHash, expectedHash) {
 		return fmt.Errorf("checksum mismatch: expected %s, got %s", expectedHash, actualHash)
 	}
@@ -242,116 +528,73 @@ func (u *Updater) verifyChecksum(filePath, expectedHash string) error {
 	return nil
 }
 
-func (u *Updater) extractUpdate(zipPath string) error {
-	r, err := zip.OpenReader(zipPath)
+// versionInfo is the contents of .version. HighestVersion is the newest
+// release ever installed, kept across downgrades so that a later update
+// can't quietly roll back again.
+type versionInfo struct {
+	Version        string `json:"version"`
+	HighestVersion string `json:"highest_version,omitempty"`
+	UpdatedAt      string `json:"updated_at,omitempty"`
+}
+
+func (u *Updater) writeVersionFile(version string) error {
+	highest := u.HighestVersion()
+	if compareVersions(version, highest) > 0 {
+		highest = version
+	}
+
+	data, err := json.Marshal(versionInfo{
+		Version:        version,
+		HighestVersion: highest,
+		UpdatedAt:      time.Now().Format(time.RFC3339),
+	})
 	if err != nil {
 		return err
 	}
-	defer r.Close()
-
-	for _, f := range r.File {
-		// Only extract bin/ and scripts
-		if !strings.HasPrefix(f.Name, "bin/") &&
-			!strings.HasSuffix(f.Name, ".sh") &&
-			!strings.HasSuffix(f.Name, ".bat") {
-			continue
-		}
-
-		destPath := filepath.Join(u.USBRoot, f.Name)
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(destPath, f.Mode())
-			continue
-		}
-
-		if err := extractFile(f, destPath); err != nil {
-			return err
-		}
+	versionFile := filepath.Join(u.USBRoot, ".version")
+	if err := os.WriteFile(versionFile, data, 0644); err != nil {
+		return err
 	}
 
+	u.CurrentVersion = version
 	return nil
 }
 
-func (u *Updater) writeVersionFile(version string) error {
-	versionFile := filepath.Join(u.USBRoot, ".version")
-	data := fmt.Sprintf(`{"version":"%s","updated_at":"%s"}`, version, time.Now().Format(time.RFC3339))
-	return os.WriteFile(versionFile, []byte(data), 0644)
-}
-
-func readVersionFile(usbRoot string) string {
-	versionFile := filepath.Join(usbRoot, ".version")
-	data, err := os.ReadFile(versionFile)
+// HighestVersion returns the newest version ever installed on this USB
+func (u *Updater) HighestVersion() string {
+	info, err := parseVersionFile(filepath.Join(u.USBRoot, ".version"))
 	if err != nil {
-		return "0.0.0"
+		return u.CurrentVersion
 	}
 
-	var v struct {
-		Version string `json:"version"`
-	}
-	if err := json.Unmarshal(data, &v); err != nil {
-		return "0.0.0"
+	highest := info.Version
+	if compareVersions(info.HighestVersion, highest) > 0 {
+		highest = info.HighestVersion
 	}
-
-	return v.Version
+	return highest
 }
 
-func extractFile(f *zip.File, destPath string) error {
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return err
-	}
-
-	rc, err := f.Open()
+func parseVersionFile(path string) (*versionInfo, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer rc.Close()
 
-	out, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-	if err != nil {
-		return err
+	var info versionInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
 	}
-	defer out.Close()
-
-	_, err = io.Copy(out, rc)
-	return err
-}
-
-func copyDir(src, dst string) error {
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-
-		destPath := filepath.Join(dst, relPath)
-
-		if info.IsDir() {
-			return os.MkdirAll(destPath, info.Mode())
-		}
-
-		return copyFile(path, destPath, info.Mode())
-	})
+	return &info, nil
 }
 
-func copyFile(src, dst string, mode os.FileMode) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-
-	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+func readVersionFile(usbRoot string) string {
+	info, err := parseVersionFile(filepath.Join(usbRoot, ".version"))
 	if err != nil {
-		return err
+		return "0.0.0"
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, in)
-	return err
+	return info.Version
 }
 
 // Simple version comparison (assumes semver format x.y.z)
@@ -0,0 +1,435 @@
+package update
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+	"github.com/cxt9/claude-go/internal/platform"
+)
+
+// testUpdater returns an updater for a USB root in a temporary directory
+func testUpdater(t *testing.T) *Updater {
+	t.Helper()
+
+	plat, err := platform.Current()
+	if err != nil {
+		t.Skip(err)
+	}
+	return &Updater{USBRoot: t.TempDir(), CurrentVersion: "1.0.0", Platform: plat}
+}
+
+// launcherPath is where a bundle keeps the platform's launcher
+func launcherPath(u *Updater) string {
+	return "bin/" + string(u.Platform) + "/" + u.Platform.BinaryName("claude-go")
+}
+
+// writeTree creates files, named by slash-separated paths, under root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkTree fails unless each file under root has the given content, or
+// doesn't exist when the content is empty
+func checkTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		switch {
+		case want == "" && !os.IsNotExist(err):
+			t.Errorf("%s exists, want it absent", name)
+		case want != "" && err != nil:
+			t.Errorf("%s: %v", name, err)
+		case want != "" && string(data) != want:
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
+
+// writeBundle writes a .zip update bundle holding files
+func writeBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateInstallPaths(t *testing.T) {
+	valid := []string{"mcp/bundled/", "docs/", "LICENSE", "mcp/users-guide.md"}
+	if err := ValidateInstallPaths(valid); err != nil {
+		t.Errorf("ValidateInstallPaths(%v): %v", valid, err)
+	}
+
+	invalid := []string{
+		"", "/etc/", `bin\x`, "../outside/", "a/../../b", "./mcp/",
+		"vault/", "Vault/", "SESSIONS", "Config/settings.json", "mcp/", "MCP/User/x", ".Rollback",
+	}
+	for _, p := range invalid {
+		if err := ValidateInstallPaths([]string{p}); err == nil {
+			t.Errorf("ValidateInstallPaths(%q) accepted a path it must refuse", p)
+		}
+	}
+}
+
+func TestInstallExtraPaths(t *testing.T) {
+	u := testUpdater(t)
+	writeTree(t, u.USBRoot, map[string]string{
+		launcherPath(u):            "old launcher",
+		"start.sh":                 "old script",
+		"mcp/bundled/fetch/server": "old server",
+		"mcp/user/mine/server":     "user data",
+		".version":                 `{"version":"1.0.0"}`,
+	})
+
+	bundle := writeBundle(t, map[string]string{
+		launcherPath(u):            "new launcher",
+		"start.sh":                 "new script",
+		".version":                 `{"version":"1.1.0"}`,
+		"mcp/bundled/fetch/server": "new server",
+		"mcp/bundled/git/server":   "added server",
+		"docs/guide.md":            "not allowed",
+	})
+
+	if err := u.install(bundle, "1.1.0", []string{"mcp/bundled/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	checkTree(t, u.USBRoot, map[string]string{
+		launcherPath(u):            "new launcher",
+		"start.sh":                 "new script",
+		"mcp/bundled/fetch/server": "new server",
+		"mcp/bundled/git/server":   "added server",
+		"mcp/user/mine/server":     "user data",
+		"docs/guide.md":            "",
+	})
+	if u.CurrentVersion != "1.1.0" {
+		t.Errorf("CurrentVersion = %s, want 1.1.0", u.CurrentVersion)
+	}
+	if _, err := os.Stat(filepath.Join(u.USBRoot, ".rollback")); !os.IsNotExist(err) {
+		t.Error("the rollback copy was left behind")
+	}
+}
+
+func TestInstallIgnoresNestedScripts(t *testing.T) {
+	u := testUpdater(t)
+	writeTree(t, u.USBRoot, map[string]string{
+		launcherPath(u): "old launcher",
+		"vault/keep":    "user data",
+		".version":      `{"version":"1.0.0"}`,
+	})
+
+	bundle := writeBundle(t, map[string]string{
+		launcherPath(u):      "new launcher",
+		"start.bat":          "new script",
+		".version":           `{"version":"1.1.0"}`,
+		"vault/evil.sh":      "not allowed",
+		"sessions/evil.bat":  "not allowed",
+		"mcp/bundled/run.sh": "not allowed",
+	})
+
+	if err := u.install(bundle, "1.1.0", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	checkTree(t, u.USBRoot, map[string]string{
+		launcherPath(u):      "new launcher",
+		"start.bat":          "new script",
+		"vault/keep":         "user data",
+		"vault/evil.sh":      "",
+		"sessions/evil.bat":  "",
+		"mcp/bundled/run.sh": "",
+	})
+}
+
+func TestReplaceFilesRefusesProtectedPaths(t *testing.T) {
+	root := t.TempDir()
+	staging := t.TempDir()
+	writeTree(t, root, map[string]string{"Vault/credentials.vault": "user data"})
+	writeTree(t, staging, map[string]string{"Vault/credentials.vault": "staged"})
+
+	journal := &swapJournal{root: root, backupDir: filepath.Join(root, ".rollback")}
+	if err := journal.replaceFiles(staging, ""); err == nil {
+		t.Fatal("replaceFiles installed a file under vault/")
+	}
+	checkTree(t, root, map[string]string{"Vault/credentials.vault": "user data"})
+}
+
+func TestSwapInRestoresExtraPaths(t *testing.T) {
+	original := map[string]string{
+		"bin/tool":                 "old tool",
+		"start.sh":                 "old script",
+		"mcp/bundled/fetch/server": "old server",
+	}
+	staged := map[string]string{
+		"bin/tool":                 "new tool",
+		"start.sh":                 "new script",
+		"mcp/bundled/fetch/server": "new server",
+		"mcp/bundled/git/server":   "added server",
+	}
+
+	defer func(orig func(string, string) error) { renamePath = orig }(renamePath)
+
+	// Fail each rename in turn; whichever it is, nothing may change, except
+	// that failing to move bin/ aside falls back to replacing its files
+	for failAt := 1; ; failAt++ {
+		u := testUpdater(t)
+		writeTree(t, u.USBRoot, original)
+		stagingDir := filepath.Join(u.USBRoot, ".staging")
+		writeTree(t, stagingDir, staged)
+
+		calls := 0
+		renamePath = func(oldpath, newpath string) error {
+			calls++
+			if calls == failAt {
+				return errors.New("injected failure")
+			}
+			return os.Rename(oldpath, newpath)
+		}
+		err := u.swapIn(stagingDir)
+
+		if calls < failAt {
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTree(t, u.USBRoot, staged)
+			break
+		}
+		if err == nil {
+			if failAt != 1 {
+				t.Fatalf("rename %d failed, but swapIn succeeded", failAt)
+			}
+			checkTree(t, u.USBRoot, staged)
+			continue
+		}
+		if !strings.Contains(err.Error(), "injected failure") {
+			t.Fatalf("rename %d: unexpected error %v", failAt, err)
+		}
+
+		checkTree(t, u.USBRoot, original)
+		checkTree(t, u.USBRoot, map[string]string{"mcp/bundled/git/server": ""})
+	}
+}
+
+func TestSwapInRunningExecutable(t *testing.T) {
+	u := testUpdater(t)
+	writeTree(t, u.USBRoot, map[string]string{
+		"bin/claude-go": "running launcher",
+		"bin/node":      "old node",
+		"start.sh":      "old script",
+	})
+	stagingDir := filepath.Join(u.USBRoot, ".staging")
+	bundle := map[string]string{
+		"bin/claude-go": "new launcher",
+		"bin/node":      "new node",
+		"start.sh":      "new script",
+	}
+	writeTree(t, stagingDir, bundle)
+
+	// As on Windows: bin/ holds a running program, so it can't be moved
+	binDir := filepath.Join(u.USBRoot, "bin")
+	defer func(orig func(string, string) error) { renamePath = orig }(renamePath)
+	var failNode bool
+	renamePath = func(oldpath, newpath string) error {
+		if oldpath == binDir {
+			return errors.New("access is denied")
+		}
+		if failNode && strings.HasSuffix(newpath, "node") && strings.HasPrefix(oldpath, stagingDir) {
+			return errors.New("injected failure")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	// An error partway through the files leaves the original bin/ intact
+	failNode = true
+	if err := u.swapIn(stagingDir); err == nil {
+		t.Fatal("swapIn succeeded despite a failed rename")
+	}
+	checkTree(t, u.USBRoot, map[string]string{
+		"bin/claude-go": "running launcher",
+		"bin/node":      "old node",
+		"start.sh":      "old script",
+	})
+
+	failNode = false
+	writeTree(t, stagingDir, bundle)
+	if err := u.swapIn(stagingDir); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, u.USBRoot, bundle)
+}
+
+// versionBundle writes a bundle for u declaring version
+func versionBundle(t *testing.T, u *Updater, version string) string {
+	t.Helper()
+
+	return writeBundle(t, map[string]string{
+		launcherPath(u): "launcher " + version,
+		".version":      `{"version":"` + version + `"}`,
+	})
+}
+
+func TestOfflineDowngrade(t *testing.T) {
+	u := testUpdater(t)
+	writeTree(t, u.USBRoot, map[string]string{
+		launcherPath(u): "launcher 1.2.0",
+		".version":      `{"version":"1.2.0","highest_version":"1.3.0"}`,
+	})
+
+	// Older than the highest version installed, though newer than the
+	// current one
+	for _, version := range []string{"1.1.0", "1.2.5"} {
+		err := u.PerformOfflineUpdate(versionBundle(t, u, version))
+		if !errors.Is(err, ErrDowngrade) {
+			t.Errorf("installing %s: err = %v, want ErrDowngrade", version, err)
+		}
+	}
+	checkTree(t, u.USBRoot, map[string]string{launcherPath(u): "launcher 1.2.0"})
+
+	if err := u.PerformOfflineUpdate(versionBundle(t, u, "1.3.0")); err != nil {
+		t.Fatalf("installing the highest version again: %v", err)
+	}
+
+	u.AllowDowngrade = true
+	if err := u.PerformOfflineUpdate(versionBundle(t, u, "1.1.0")); err != nil {
+		t.Fatalf("allowed downgrade: %v", err)
+	}
+	checkTree(t, u.USBRoot, map[string]string{launcherPath(u): "launcher 1.1.0"})
+
+	// The allowed downgrade doesn't lower the mark for later updates
+	if got := u.HighestVersion(); got != "1.3.0" {
+		t.Errorf("HighestVersion = %s after the downgrade, want 1.3.0", got)
+	}
+	u.AllowDowngrade = false
+	if err := u.PerformOfflineUpdate(versionBundle(t, u, "1.2.0")); !errors.Is(err, ErrDowngrade) {
+		t.Errorf("second downgrade: err = %v, want ErrDowngrade", err)
+	}
+}
+
+func TestInstallChecksBundleVersion(t *testing.T) {
+	u := testUpdater(t)
+	writeTree(t, u.USBRoot, map[string]string{".version": `{"version":"1.2.0"}`})
+
+	// The manifest is untrusted: its version must match the bundle's own
+	if err := u.install(versionBundle(t, u, "1.1.0"), "1.4.0", nil); err == nil || errors.Is(err, ErrDowngrade) {
+		t.Errorf("mismatched manifest version: err = %v", err)
+	}
+	if err := u.install(versionBundle(t, u, "1.1.0"), "1.1.0", nil); !errors.Is(err, ErrDowngrade) {
+		t.Errorf("older release: err = %v, want ErrDowngrade", err)
+	}
+
+	noVersion := writeBundle(t, map[string]string{launcherPath(u): "launcher"})
+	if err := u.install(noVersion, "", nil); err == nil {
+		t.Error("a bundle without a version was installed")
+	}
+}
+
+func TestClearCache(t *testing.T) {
+	cache := map[string]string{
+		"cache/mcp-status.json":                  "stale",
+		"cache/old/data":                         "stale",
+		"cache/offline-docs/index":               "kept",
+		"cache/models/weights":                   "kept",
+		"profiles/work/cache/mcp-status.json":    "stale",
+		"profiles/work/cache/offline-docs/index": "kept",
+		"profiles/work/sessions/s.json":          "session",
+		"profiles/home/cache/mcp-status.json":    "stale",
+		"profiles/home/cache/models/weights":     "kept",
+	}
+
+	// Disabled, nothing is removed
+	u := testUpdater(t)
+	writeTree(t, u.USBRoot, cache)
+	u.KeepCache = true
+	u.clearCache()
+	checkTree(t, u.USBRoot, cache)
+
+	// Enabled, only the allowlisted directories survive, in every profile
+	u.KeepCache = false
+	u.KeepCacheDirs = []string{"offline-docs", "models"}
+	u.clearCache()
+	checkTree(t, u.USBRoot, map[string]string{
+		"cache/mcp-status.json":                  "",
+		"cache/old/data":                         "",
+		"cache/offline-docs/index":               "kept",
+		"cache/models/weights":                   "kept",
+		"profiles/work/cache/mcp-status.json":    "",
+		"profiles/work/cache/offline-docs/index": "kept",
+		"profiles/work/sessions/s.json":          "session",
+		"profiles/home/cache/mcp-status.json":    "",
+		"profiles/home/cache/models/weights":     "kept",
+	})
+
+	// With the data on another drive, its caches are cleared instead
+	data := t.TempDir()
+	writeTree(t, u.USBRoot, map[string]string{"cache/app": "app"})
+	writeTree(t, data, map[string]string{"cache/stale": "stale", "profiles/work/cache/stale": "stale", "profiles/home/cache/stale": "stale"})
+	u.DataRoot = data
+	u.clearCache()
+	checkTree(t, data, map[string]string{"cache/stale": "", "profiles/work/cache/stale": "", "profiles/home/cache/stale": ""})
+	checkTree(t, u.USBRoot, map[string]string{"cache/app": "app"})
+}
+
+func TestInstallInsufficientSpace(t *testing.T) {
+	u := testUpdater(t)
+	original := map[string]string{
+		launcherPath(u): "launcher 1.0.0",
+		".version":      `{"version":"1.0.0"}`,
+	}
+	writeTree(t, u.USBRoot, original)
+
+	defer func(orig func(string, uint64) error) { requireSpace = orig }(requireSpace)
+	requireSpace = func(path string, need uint64) error {
+		return &fsutil.SpaceError{Path: path, Need: need, Free: 1024}
+	}
+
+	err := u.PerformOfflineUpdate(versionBundle(t, u, "1.1.0"))
+	if !errors.Is(err, fsutil.ErrInsufficientSpace) {
+		t.Fatalf("err = %v, want ErrInsufficientSpace", err)
+	}
+
+	// The preflight fails before staging or moving anything
+	checkTree(t, u.USBRoot, original)
+	entries, err := os.ReadDir(u.USBRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() == ".staging" || e.Name() == ".rollback" {
+			t.Errorf("%s was created", e.Name())
+		}
+	}
+}
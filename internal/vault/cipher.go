@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"crypto/cipher"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Algorithm is the AEAD a vault's contents are encrypted with
+type Algorithm string
+
+const (
+	// AlgorithmAESGCM is AES-256-GCM, the default
+	AlgorithmAESGCM Algorithm = "aes-256-gcm"
+	// AlgorithmChaCha20 is ChaCha20-Poly1305, faster than AES-GCM on
+	// processors without AES instructions, like some ARM boards
+	AlgorithmChaCha20 Algorithm = "chacha20-poly1305"
+)
+
+// Algorithm identifiers in a version 4 header
+const (
+	algorithmIDAESGCM   byte = 1
+	algorithmIDChaCha20 byte = 2
+)
+
+// ParseAlgorithm resolves an algorithm name; empty means AlgorithmAESGCM
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch Algorithm(name) {
+	case "", AlgorithmAESGCM:
+		return AlgorithmAESGCM, nil
+	case AlgorithmChaCha20:
+		return AlgorithmChaCha20, nil
+	}
+	return "", fmt.Errorf("unknown vault algorithm %q (want %s or %s)", name, AlgorithmAESGCM, AlgorithmChaCha20)
+}
+
+// id returns the algorithm's header identifier
+func (a Algorithm) id() byte {
+	if a == AlgorithmChaCha20 {
+		return algorithmIDChaCha20
+	}
+	return algorithmIDAESGCM
+}
+
+// algorithmFromID maps a header identifier back to its algorithm
+func algorithmFromID(id byte) (Algorithm, bool) {
+	switch id {
+	case algorithmIDAESGCM:
+		return AlgorithmAESGCM, true
+	case algorithmIDChaCha20:
+		return AlgorithmChaCha20, true
+	}
+	return "", false
+}
+
+// newAEAD creates the cipher for an algorithm. Both take a 256-bit key and
+// use 12-byte nonces and 16-byte tags, so the file layout is the same.
+func newAEAD(algorithm Algorithm, key []byte) (cipher.AEAD, error) {
+	if algorithm != AlgorithmChaCha20 {
+		return newGCM(key)
+	}
+
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
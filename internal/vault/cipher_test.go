@@ -0,0 +1,177 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+func TestAlgorithmRoundTrip(t *testing.T) {
+	for _, algorithm := range []Algorithm{AlgorithmAESGCM, AlgorithmChaCha20} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "credentials.vault")
+			v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF, Algorithm: algorithm})
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry := &Entry{ID: "auth/console", Type: CredentialAPIKey, Data: json.RawMessage(`{"api_key":"sk-test"}`)}
+			if err := v.SetEntry(entry); err != nil {
+				t.Fatal(err)
+			}
+			v.Lock()
+
+			v, err = Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := v.Unlock("wrong"); err == nil {
+				t.Fatal("a wrong password unlocked the vault")
+			}
+			if err := v.Unlock(fuzzPassword); err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Algorithm(); got != algorithm {
+				t.Errorf("Algorithm = %s, want %s", got, algorithm)
+			}
+			got, err := v.GetEntry("auth/console")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got.Data) != string(entry.Data) {
+				t.Errorf("Data = %s, want %s", got.Data, entry.Data)
+			}
+		})
+	}
+}
+
+// legacyVaultFile returns a vault file in format version 1 or 2, which
+// encrypt with AES-GCM and don't authenticate the header
+func legacyVaultFile(t *testing.T, version uint16, params KDFParams, data *vaultData) []byte {
+	t.Helper()
+
+	header := binary.BigEndian.AppendUint32(nil, magicNumber)
+	header = binary.BigEndian.AppendUint16(header, version)
+	if version == vaultVersion {
+		header = binary.BigEndian.AppendUint32(header, params.Time)
+		header = binary.BigEndian.AppendUint32(header, params.Memory)
+		header = append(header, params.Threads)
+	}
+
+	salt := make([]byte, saltSize)
+	nonce := make([]byte, nonceSize)
+	rand.Read(salt)
+	rand.Read(nonce)
+
+	block, err := aes.NewCipher(params.deriveKey(fuzzPassword, salt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := append(append(header, salt...), nonce...)
+	return gcm.Seal(file, nonce, plaintext, nil)
+}
+
+func TestUnlockLegacyVersions(t *testing.T) {
+	tests := []struct {
+		version uint16
+		params  KDFParams
+	}{
+		{vaultVersionV1, DefaultKDFParams()},
+		{vaultVersion, fuzzKDF},
+	}
+
+	for _, tt := range tests {
+		now := time.Now().UTC()
+		data := &vaultData{
+			Version: 1,
+			Entries: map[string]*Entry{
+				"auth/console": {ID: "auth/console", Type: CredentialAPIKey, Data: json.RawMessage(`{"api_key":"sk-legacy"}`), CreatedAt: now, UpdatedAt: now},
+			},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		path := filepath.Join(t.TempDir(), "credentials.vault")
+		if err := os.WriteFile(path, legacyVaultFile(t, tt.version, tt.params, data), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := Open(path)
+		if err != nil {
+			t.Fatalf("version %d: %v", tt.version, err)
+		}
+		if err := v.Unlock(fuzzPassword); err != nil {
+			t.Fatalf("version %d: %v", tt.version, err)
+		}
+		entry, err := v.GetEntry("auth/console")
+		if err != nil {
+			t.Fatalf("version %d: %v", tt.version, err)
+		}
+		if string(entry.Data) != `{"api_key":"sk-legacy"}` {
+			t.Errorf("version %d: Data = %s", tt.version, entry.Data)
+		}
+		if got := v.Algorithm(); got != AlgorithmAESGCM {
+			t.Errorf("version %d: Algorithm = %s, want %s", tt.version, got, AlgorithmAESGCM)
+		}
+		v.Lock()
+
+		// Unlocking upgrades the file, which must still open afterwards
+		v, _ = Open(path)
+		if err := v.Unlock(fuzzPassword); err != nil {
+			t.Errorf("version %d after the upgrade: %v", tt.version, err)
+		}
+	}
+}
+
+// vaultSyncFailer fails to sync everything but the lockout counter
+type vaultSyncFailer struct{ fsutil.Syncer }
+
+func (s vaultSyncFailer) SyncFile(f *os.File) error {
+	if strings.Contains(f.Name(), ".attempts.") {
+		return s.Syncer.SyncFile(f)
+	}
+	return errors.New("sync failed")
+}
+
+func TestUnlockLegacyUpgradeError(t *testing.T) {
+	now := time.Now().UTC()
+	data := &vaultData{Version: 1, Entries: map[string]*Entry{}, CreatedAt: now, UpdatedAt: now}
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	legacy := legacyVaultFile(t, vaultVersion, fuzzKDF, data)
+	if err := os.WriteFile(path, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(orig fsutil.Syncer) { fsutil.DefaultSyncer = orig }(fsutil.DefaultSyncer)
+	fsutil.DefaultSyncer = vaultSyncFailer{fsutil.DefaultSyncer}
+
+	v, _ := Open(path)
+	if err := v.Unlock(fuzzPassword); err == nil {
+		t.Fatal("Unlock succeeded although the upgraded header wasn't saved")
+	}
+	if v.IsUnlocked() {
+		t.Error("vault left unlocked after a failed upgrade")
+	}
+
+	// The legacy file is untouched
+	if got, _ := os.ReadFile(path); string(got) != string(legacy) {
+		t.Error("vault file changed by a failed upgrade")
+	}
+}
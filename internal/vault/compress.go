@@ -0,0 +1,43 @@
+package vault
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// maxPlaintextSize bounds decompression. The payload is authenticated before
+// it is decompressed, so this only guards against a buggy writer.
+const maxPlaintextSize = 64 << 20
+
+// compress zlib-compresses the serialized vault
+func compress(plaintext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress reverses compress
+func decompress(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	plaintext, err := io.ReadAll(io.LimitReader(r, maxPlaintextSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext) > maxPlaintextSize {
+		return nil, fmt.Errorf("vault plaintext exceeds %d bytes", maxPlaintextSize)
+	}
+	return plaintext, nil
+}
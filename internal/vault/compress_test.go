@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	// Metadata-heavy entries compress well
+	blob, _ := json.Marshal(map[string]string{"token": strings.Repeat("abcdefgh", 4096)})
+	sizes := make(map[bool]int64)
+
+	for _, compressed := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "credentials.vault")
+		v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF, Compress: compressed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := v.SetEntry(&Entry{ID: "mcp/tracker", Type: CredentialMCP, Data: blob}); err != nil {
+			t.Fatal(err)
+		}
+		v.Lock()
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes[compressed] = info.Size()
+
+		v, _ = Open(path)
+		if err := v.Unlock(fuzzPassword); err != nil {
+			t.Fatalf("compress=%v: %v", compressed, err)
+		}
+		if v.Compressed() != compressed {
+			t.Errorf("Compressed = %v, want %v", v.Compressed(), compressed)
+		}
+		entry, err := v.GetEntry("mcp/tracker")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(entry.Data) != string(blob) {
+			t.Errorf("compress=%v: entry data changed in the round trip", compressed)
+		}
+	}
+
+	if sizes[true] >= sizes[false]/4 {
+		t.Errorf("compressed vault is %d bytes, uncompressed %d", sizes[true], sizes[false])
+	}
+}
+
+func TestCompressedVaultAuthenticated(t *testing.T) {
+	tamper := map[string]func(data []byte){
+		// The flag is in the authenticated header
+		"flag cleared": func(data []byte) { data[6+kdfParamsSize] &^= flagCompressed },
+		// The compressed payload is encrypted, then authenticated
+		"ciphertext": func(data []byte) { data[len(data)-gcmTagSize-1] ^= 0x01 },
+	}
+
+	for name, fn := range tamper {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "credentials.vault")
+			v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF, Compress: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.Lock()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn(data)
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			v, _ = Open(path)
+			if err := v.Unlock(fuzzPassword); !errors.Is(err, ErrWrongPassword) {
+				t.Errorf("err = %v, want ErrWrongPassword", err)
+			}
+		})
+	}
+}
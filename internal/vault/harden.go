@@ -0,0 +1,160 @@
+package vault
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+// Steps reported by Harden, in order
+const (
+	HardenCheck  = "check"  // re-deriving the current key to check the password
+	HardenDerive = "derive" // deriving the new key
+	HardenVerify = "verify" // unlocking the written copy
+)
+
+// Harden re-encrypts the vault with params like ReEncrypt, but leaves the
+// vault file alone until a complete copy has been written beside it and
+// unlocked with password. progress, if not nil, is called as each step
+// starts. Cancelling ctx before the copy replaces the vault leaves the
+// original as it was; Argon2 can't be stopped midway, so a derivation that
+// is cancelled finishes in the background and is thrown away.
+func (v *Vault) Harden(ctx context.Context, password string, params KDFParams, progress func(step string)) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.usable() {
+		return ErrVaultLocked
+	}
+	if !params.valid() {
+		return fmt.Errorf("invalid argon2 parameters: %+v", params)
+	}
+	if progress == nil {
+		progress = func(string) {}
+	}
+
+	progress(HardenCheck)
+	current, err := deriveKeyContext(ctx, v.params, password, v.salt)
+	if err != nil {
+		return err
+	}
+	defer zero(current)
+	if subtle.ConstantTimeCompare(current, v.key) != 1 {
+		return ErrWrongPassword
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	progress(HardenDerive)
+	key, err := deriveKeyContext(ctx, params, password, salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newAEAD(v.algorithm, key)
+	if err != nil {
+		zero(key)
+		return err
+	}
+
+	// The copy shares the decrypted data but not the file
+	copyPath := v.path + ".harden"
+	defer os.Remove(copyPath)
+	defer os.Remove(lockoutPath(copyPath))
+
+	hardened := &Vault{
+		path:      copyPath,
+		salt:      salt,
+		params:    params,
+		key:       key,
+		gcm:       gcm,
+		compress:  v.compress,
+		algorithm: v.algorithm,
+		data:      v.data,
+		unlocked:  true,
+	}
+	if err := writeLockout(lockoutPath(copyPath), salt, &lockoutState{}); err != nil {
+		zero(key)
+		return err
+	}
+	if err := hardened.save(); err != nil {
+		zero(key)
+		return err
+	}
+
+	progress(HardenVerify)
+	if err := verifyCopy(ctx, copyPath, password, len(v.data.Entries)); err != nil {
+		zero(key)
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		zero(key)
+		return err
+	}
+	if err := fsutil.Rename(copyPath, v.path); err != nil {
+		zero(key)
+		return fmt.Errorf("failed to replace vault: %w", err)
+	}
+
+	zero(v.key)
+	v.salt, v.params, v.key, v.gcm = salt, params, key, gcm
+
+	// The copy's counter is MACed with the new salt, so it follows the vault
+	if err := fsutil.Rename(lockoutPath(copyPath), v.lockoutPath()); err != nil {
+		if err := v.resetLockout(salt); err != nil {
+			return err
+		}
+	}
+	fsutil.DefaultSyncer.SyncDir(filepath.Dir(v.path))
+
+	return nil
+}
+
+// verifyCopy unlocks the vault written at path as a fresh vault would be
+func verifyCopy(ctx context.Context, path, password string, entries int) error {
+	check, err := Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to verify hardened vault: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- check.Unlock(password) }()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to verify hardened vault: %w", err)
+		}
+	}
+	defer check.Lock()
+
+	if len(check.data.Entries) != entries {
+		return errors.New("failed to verify hardened vault: entries differ from the original")
+	}
+	return nil
+}
+
+// deriveKeyContext derives a key, giving up early if ctx is cancelled
+func deriveKeyContext(ctx context.Context, params KDFParams, password string, salt []byte) ([]byte, error) {
+	done := make(chan []byte, 1)
+	go func() { done <- params.deriveKey(password, salt) }()
+
+	select {
+	case <-ctx.Done():
+		go func() { zero(<-done) }()
+		return nil, ctx.Err()
+	case key := <-done:
+		return key, nil
+	}
+}
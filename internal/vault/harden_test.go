@@ -0,0 +1,107 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHardenCancelledKeepsVault(t *testing.T) {
+	params := fuzzKDF
+	params.Time = 2
+
+	for _, step := range []string{HardenCheck, HardenDerive, HardenVerify} {
+		t.Run(step, func(t *testing.T) {
+			path := newLockoutVault(t)
+			v, _ := Open(path)
+			if err := v.Unlock(fuzzPassword); err != nil {
+				t.Fatal(err)
+			}
+			entry := &Entry{ID: "auth/console", Type: CredentialAPIKey, Data: json.RawMessage(`{"api_key":"sk-test"}`)}
+			if err := v.SetEntry(entry); err != nil {
+				t.Fatal(err)
+			}
+			original, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			err = v.Harden(ctx, fuzzPassword, params, func(s string) {
+				if s == step {
+					cancel()
+				}
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("err = %v, want context.Canceled", err)
+			}
+
+			if got, _ := os.ReadFile(path); string(got) != string(original) {
+				t.Error("vault file changed by a cancelled Harden")
+			}
+			leftovers, _ := filepath.Glob(path + ".harden*")
+			if len(leftovers) != 0 {
+				t.Errorf("copy left behind: %v", leftovers)
+			}
+
+			// The original still unlocks, from this handle and a fresh one
+			v.Lock()
+			if err := v.Unlock(fuzzPassword); err != nil {
+				t.Fatalf("unlock after cancelling: %v", err)
+			}
+			if v.params != fuzzKDF {
+				t.Errorf("params = %+v, want %+v", v.params, fuzzKDF)
+			}
+			fresh, _ := Open(path)
+			if err := fresh.Unlock(fuzzPassword); err != nil {
+				t.Fatalf("fresh unlock after cancelling: %v", err)
+			}
+			if _, err := fresh.GetEntry("auth/console"); err != nil {
+				t.Errorf("entry lost: %v", err)
+			}
+		})
+	}
+}
+
+func TestHardenMovesCounter(t *testing.T) {
+	params := fuzzKDF
+	params.Time = 2
+
+	path := newLockoutVault(t)
+	v, _ := Open(path)
+	if err := v.Unlock(fuzzPassword); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Harden(context.Background(), fuzzPassword, params, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(lockoutPath(path + ".harden")); !os.IsNotExist(err) {
+		t.Errorf("copy's counter left behind: %v", err)
+	}
+
+	// The counter in place is the copy's, MACed with the new salt
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := parseFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(lockoutPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state lockoutState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	if string(state.MAC) != string(state.sum(header.salt)) {
+		t.Error("counter isn't MACed for the hardened vault")
+	}
+}
@@ -0,0 +1,60 @@
+package vault
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDFParams are the Argon2id cost parameters stored in each vault's header
+type KDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory_kib"`
+	Threads uint8  `json:"threads"`
+}
+
+// Named Argon2id cost profiles
+const (
+	ProfileInteractive = "interactive"
+	ProfileSensitive   = "sensitive"
+	ProfileParanoid    = "paranoid"
+)
+
+var profiles = map[string]KDFParams{
+	// OWASP recommended; the parameters of every version 1 vault
+	ProfileInteractive: {Time: 3, Memory: 64 * 1024, Threads: 4},
+	ProfileSensitive:   {Time: 4, Memory: 256 * 1024, Threads: 4},
+	ProfileParanoid:    {Time: 6, Memory: 1024 * 1024, Threads: 4},
+}
+
+// Bounds accepted from a vault header, so a crafted file can't demand an
+// absurd amount of memory or time before the password is even checked
+const (
+	minKDFMemory = 8 * 1024
+	maxKDFMemory = 4 * 1024 * 1024
+	maxKDFTime   = 64
+)
+
+// DefaultKDFParams returns the interactive profile
+func DefaultKDFParams() KDFParams {
+	return profiles[ProfileInteractive]
+}
+
+// ProfileParams returns the parameters of a named profile
+func ProfileParams(name string) (KDFParams, error) {
+	params, ok := profiles[name]
+	if !ok {
+		return KDFParams{}, fmt.Errorf("unknown argon2 profile: %s", name)
+	}
+	return params, nil
+}
+
+func (p KDFParams) valid() bool {
+	return p.Time >= 1 && p.Time <= maxKDFTime &&
+		p.Memory >= minKDFMemory && p.Memory <= maxKDFMemory &&
+		p.Threads >= 1
+}
+
+func (p KDFParams) deriveKey(password string, salt []byte) []byte {
+	return argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, argonKeyLen)
+}
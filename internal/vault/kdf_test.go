@@ -0,0 +1,56 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileParamsInHeader(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    KDFParams
+	}{
+		{ProfileInteractive, KDFParams{Time: 3, Memory: 64 * 1024, Threads: 4}},
+		{ProfileSensitive, KDFParams{Time: 4, Memory: 256 * 1024, Threads: 4}},
+		{ProfileParanoid, KDFParams{Time: 6, Memory: 1024 * 1024, Threads: 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			params, err := ProfileParams(tt.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if params != tt.want {
+				t.Fatalf("ProfileParams(%s) = %+v, want %+v", tt.profile, params, tt.want)
+			}
+			if testing.Short() && tt.profile != ProfileInteractive {
+				t.Skip("derives a key with the full profile cost")
+			}
+
+			path := filepath.Join(t.TempDir(), "credentials.vault")
+			v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: params})
+			if err != nil {
+				t.Fatal(err)
+			}
+			v.Lock()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, _, err := parseFile(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if header.params != tt.want {
+				t.Errorf("header params = %+v, want %+v", header.params, tt.want)
+			}
+		})
+	}
+
+	if _, err := ProfileParams("extreme"); err == nil {
+		t.Error("ProfileParams accepted an unknown profile")
+	}
+}
@@ -0,0 +1,80 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestLockedVaultMethods(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Lock()
+
+	calls := map[string]func() error{
+		"SetEntry":    func() error { return v.SetEntry(&Entry{ID: "secret/db", Type: CredentialSecret}) },
+		"GetEntry":    func() error { _, err := v.GetEntry("secret/db"); return err },
+		"DeleteEntry": func() error { return v.DeleteEntry("secret/db") },
+		"ListEntries": func() error { _, err := v.ListEntries(); return err },
+		"ReEncrypt":   func() error { return v.ReEncrypt(fuzzPassword, fuzzKDF) },
+		"Harden":      func() error { return v.Harden(context.Background(), fuzzPassword, fuzzKDF, nil) },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrVaultLocked) {
+			t.Errorf("%s on a locked vault: err = %v, want ErrVaultLocked", name, err)
+		}
+	}
+}
+
+func TestConcurrentLockAndSetEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As the auto-lock timer firing while credentials are written
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				err := v.SetEntry(&Entry{ID: fmt.Sprintf("secret/%d-%d", i, j), Type: CredentialSecret, Data: json.RawMessage(`"x"`)})
+				if err != nil && !errors.Is(err, ErrVaultLocked) {
+					t.Errorf("SetEntry: %v", err)
+					return
+				}
+				if _, err := v.GetEntry(fmt.Sprintf("secret/%d-%d", i, j)); err != nil && !errors.Is(err, ErrVaultLocked) {
+					t.Errorf("GetEntry: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		v.Lock()
+	}()
+	wg.Wait()
+
+	if v.IsUnlocked() {
+		t.Fatal("the vault is unlocked after Lock")
+	}
+	if err := v.SetEntry(&Entry{ID: "secret/late", Type: CredentialSecret}); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("SetEntry after Lock: err = %v, want ErrVaultLocked", err)
+	}
+
+	// Whatever was saved before the lock is intact
+	if err := v.Unlock(fuzzPassword); err != nil {
+		t.Fatalf("unlock after the race: %v", err)
+	}
+}
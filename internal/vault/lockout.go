@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+const (
+	// Wrong passwords allowed before delays start
+	lockoutThreshold = 3
+
+	// First enforced delay, doubling with each further failure
+	lockoutBaseDelay = 30 * time.Second
+	lockoutMaxDelay  = time.Hour
+)
+
+// lockoutMACLabel separates the counter's MAC key from other uses of the salt
+const lockoutMACLabel = "claude-go vault lockout"
+
+// LockoutError is returned by Unlock while a delay from repeated wrong
+// passwords is still in effect
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+// lockoutState counts consecutive wrong passwords. It can't be encrypted
+// because it must be updated without the key, so it lives beside the vault,
+// MACed with a key derived from the vault's salt so a counter left over from
+// another vault, or from before a re-key, isn't applied to this one.
+//
+// The counter is advisory only. The salt isn't secret, so anyone who can
+// write the file can forge it, and a counter that is missing or doesn't
+// verify counts as no failures so that restoring just the vault file never
+// locks its owner out. It slows down guessing through the app; an attacker
+// with a copy of the file can still brute-force it offline, limited by the
+// Argon2 cost.
+type lockoutState struct {
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	MAC         []byte    `json:"mac,omitempty"`
+}
+
+// lockoutDelay returns how long to wait after the given number of failures
+func lockoutDelay(failures int) time.Duration {
+	if failures < lockoutThreshold {
+		return 0
+	}
+
+	delay := lockoutBaseDelay
+	for i := lockoutThreshold; i < failures && delay < lockoutMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > lockoutMaxDelay {
+		delay = lockoutMaxDelay
+	}
+
+	return delay
+}
+
+// remaining returns how much of the current delay is left. A last failure
+// in the future, from a clock set back, never means a longer wait than the
+// delay itself.
+func (s *lockoutState) remaining(now time.Time) time.Duration {
+	delay := lockoutDelay(s.Failures)
+	wait := s.LastFailure.Add(delay).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	if wait > delay {
+		return delay
+	}
+	return wait
+}
+
+// sum computes the state's MAC for a vault with the given salt
+func (s *lockoutState) sum(salt []byte) []byte {
+	key := sha256.Sum256(append([]byte(lockoutMACLabel), salt...))
+
+	var msg [16]byte
+	binary.BigEndian.PutUint64(msg[0:], uint64(s.Failures))
+	binary.BigEndian.PutUint64(msg[8:], uint64(s.LastFailure.UnixNano()))
+
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(msg[:])
+	return mac.Sum(nil)
+}
+
+// LockoutRemaining returns how long Unlock will keep refusing attempts
+func (v *Vault) LockoutRemaining() time.Duration {
+	data, err := os.ReadFile(v.path)
+	if err != nil {
+		return 0
+	}
+	header, _, err := parseFile(data)
+	if err != nil {
+		return 0
+	}
+	return v.loadLockout(header).remaining(time.Now())
+}
+
+func (v *Vault) lockoutPath() string {
+	return lockoutPath(v.path)
+}
+
+func lockoutPath(vaultPath string) string {
+	return vaultPath + ".attempts"
+}
+
+// loadLockout reads the failure counter of the vault with header. A missing
+// or unreadable file counts as no failures, as does one that doesn't verify
+// for a vault written with flagLockout; older vaults wrote no MAC.
+func (v *Vault) loadLockout(header *fileHeader) *lockoutState {
+	state := &lockoutState{}
+
+	data, err := os.ReadFile(v.lockoutPath())
+	if err == nil {
+		err = json.Unmarshal(data, state)
+	}
+	if err != nil {
+		return &lockoutState{}
+	}
+
+	if header.flags&flagLockout != 0 && !hmac.Equal(state.MAC, state.sum(header.salt)) {
+		return &lockoutState{}
+	}
+	return state
+}
+
+func (v *Vault) recordFailure(header *fileHeader, state *lockoutState) error {
+	state.Failures++
+	state.LastFailure = time.Now()
+	return writeLockout(v.lockoutPath(), header.salt, state)
+}
+
+// resetLockout records no failures for the vault with salt
+func (v *Vault) resetLockout(salt []byte) error {
+	return writeLockout(v.lockoutPath(), salt, &lockoutState{})
+}
+
+// writeLockout stores state, MACed for the vault with salt, at path
+func writeLockout(path string, salt []byte, state *lockoutState) error {
+	state.MAC = state.sum(salt)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if err := fsutil.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to record failed attempts: %w", err)
+	}
+	return nil
+}
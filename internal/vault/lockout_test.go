@@ -0,0 +1,246 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockoutDelayEscalates(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{lockoutThreshold - 1, 0},
+		{lockoutThreshold, 30 * time.Second},
+		{lockoutThreshold + 1, time.Minute},
+		{lockoutThreshold + 2, 2 * time.Minute},
+		{lockoutThreshold + 6, 32 * time.Minute},
+		{lockoutThreshold + 7, time.Hour},
+		{lockoutThreshold + 10, time.Hour},
+		{1000, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDelay(tt.failures); got != tt.want {
+			t.Errorf("lockoutDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLockoutRemainingClamped(t *testing.T) {
+	now := time.Now()
+
+	state := &lockoutState{Failures: lockoutThreshold, LastFailure: now.Add(-10 * time.Second)}
+	if got := state.remaining(now); got != 20*time.Second {
+		t.Errorf("remaining = %s, want 20s", got)
+	}
+
+	// A failure recorded in the future never means more than the delay
+	state.LastFailure = now.Add(24 * time.Hour)
+	if got := state.remaining(now); got != lockoutBaseDelay {
+		t.Errorf("remaining with a future failure = %s, want %s", got, lockoutBaseDelay)
+	}
+}
+
+// newLockoutVault creates a locked vault with fuzzKDF and returns its path
+func newLockoutVault(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Lock()
+	return path
+}
+
+// backdateLockout moves the recorded last failure into the past, as waiting
+// out the delay would
+func backdateLockout(t *testing.T, path string, by time.Duration) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, err := parseFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := &Vault{path: path}
+	state := v.loadLockout(header)
+	state.LastFailure = state.LastFailure.Add(-by)
+	if err := writeLockout(lockoutPath(path), header.salt, state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnlockDelaysEscalate(t *testing.T) {
+	path := newLockoutVault(t)
+
+	for i := 0; i < lockoutThreshold; i++ {
+		v, _ := Open(path)
+		if err := v.Unlock("wrong"); !errors.Is(err, ErrWrongPassword) {
+			t.Fatalf("attempt %d: err = %v, want ErrWrongPassword", i+1, err)
+		}
+	}
+
+	// Reopening doesn't reset the counter, and the right password waits too
+	var lockout *LockoutError
+	v, _ := Open(path)
+	if err := v.Unlock(fuzzPassword); !errors.As(err, &lockout) {
+		t.Fatalf("after %d failures: err = %v, want a LockoutError", lockoutThreshold, err)
+	}
+	if lockout.RetryAfter <= 0 || lockout.RetryAfter > lockoutBaseDelay {
+		t.Errorf("RetryAfter = %s, want up to %s", lockout.RetryAfter, lockoutBaseDelay)
+	}
+
+	// Each further failure doubles the delay
+	backdateLockout(t, path, lockoutBaseDelay)
+	if err := v.Unlock("wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("after the delay: err = %v, want ErrWrongPassword", err)
+	}
+	if got := v.LockoutRemaining(); got <= lockoutBaseDelay || got > 2*lockoutBaseDelay {
+		t.Errorf("LockoutRemaining = %s, want up to %s", got, 2*lockoutBaseDelay)
+	}
+
+	backdateLockout(t, path, 2*lockoutBaseDelay)
+	if err := v.Unlock(fuzzPassword); err != nil {
+		t.Fatalf("right password after the delay: %v", err)
+	}
+	if got := v.FailedAttempts(); got != lockoutThreshold+1 {
+		t.Errorf("FailedAttempts = %d, want %d", got, lockoutThreshold+1)
+	}
+	if got := v.LockoutRemaining(); got != 0 {
+		t.Errorf("LockoutRemaining after unlocking = %s, want 0", got)
+	}
+}
+
+func TestLockoutCounterAdvisory(t *testing.T) {
+	// A counter that can't be trusted counts as no failures, so restoring
+	// just the vault file never locks its owner out
+	tamper := map[string]func(t *testing.T, counter string){
+		"deleted": func(t *testing.T, counter string) {
+			if err := os.Remove(counter); err != nil {
+				t.Fatal(err)
+			}
+		},
+		"unreadable": func(t *testing.T, counter string) {
+			if err := os.WriteFile(counter, []byte("not json"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		},
+		"edited": func(t *testing.T, counter string) {
+			data, err := os.ReadFile(counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var state lockoutState
+			if err := json.Unmarshal(data, &state); err != nil {
+				t.Fatal(err)
+			}
+			state.Failures = 100
+			data, _ = json.Marshal(state)
+			if err := os.WriteFile(counter, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+
+	for name, fn := range tamper {
+		t.Run(name, func(t *testing.T) {
+			path := newLockoutVault(t)
+			for i := 0; i < lockoutThreshold; i++ {
+				v, _ := Open(path)
+				v.Unlock("wrong")
+			}
+
+			fn(t, lockoutPath(path))
+
+			v, _ := Open(path)
+			if got := v.LockoutRemaining(); got != 0 {
+				t.Errorf("LockoutRemaining = %s, want 0", got)
+			}
+			if err := v.Unlock(fuzzPassword); err != nil {
+				t.Fatalf("err = %v, want nil", err)
+			}
+			if got := v.FailedAttempts(); got != 0 {
+				t.Errorf("FailedAttempts = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestLockoutFlagAuthenticated(t *testing.T) {
+	path := newLockoutVault(t)
+	if err := os.Remove(lockoutPath(path)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Clearing the flag skips the counter check, but the header is
+	// authenticated, so even the right password then fails
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[6+kdfParamsSize] &^= flagLockout
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v, _ := Open(path)
+	if err := v.Unlock(fuzzPassword); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("err = %v, want ErrWrongPassword", err)
+	}
+}
+
+func TestNewSaltKeepsCounterValid(t *testing.T) {
+	params := fuzzKDF
+	params.Time = 2
+
+	rekey := map[string]func(v *Vault) error{
+		"ReEncrypt": func(v *Vault) error {
+			return v.ReEncrypt(fuzzPassword, params)
+		},
+		"Harden": func(v *Vault) error {
+			return v.Harden(context.Background(), fuzzPassword, params, nil)
+		},
+	}
+
+	for name, fn := range rekey {
+		t.Run(name, func(t *testing.T) {
+			path := newLockoutVault(t)
+
+			v, _ := Open(path)
+			if err := v.Unlock(fuzzPassword); err != nil {
+				t.Fatal(err)
+			}
+			if err := fn(v); err != nil {
+				t.Fatal(err)
+			}
+			v.Lock()
+
+			if err := v.Unlock(fuzzPassword); err != nil {
+				t.Fatalf("unlock after %s: %v", name, err)
+			}
+			v.Lock()
+
+			// A counter MACed with the old salt would be ignored
+			for i := 0; i < lockoutThreshold; i++ {
+				v.Unlock("wrong")
+			}
+			var lockout *LockoutError
+			if err := v.Unlock(fuzzPassword); !errors.As(err, &lockout) {
+				t.Errorf("after %s and %d failures: err = %v, want a LockoutError", name, lockoutThreshold, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package vault
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fuzzKDF keeps Argon2 cheap enough to unlock thousands of inputs
+var fuzzKDF = KDFParams{Time: 1, Memory: minKDFMemory, Threads: 1}
+
+const fuzzPassword = "correct horse battery"
+
+// validVaultFile returns the bytes of a real vault file
+func validVaultFile(t testing.TB, opts Options) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	v, err := CreateWithOptions(path, fuzzPassword, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.SetEntry(&Entry{ID: "auth/anthropic", Type: CredentialAPIKey, Provider: "anthropic", Data: []byte(`"sk-test"`)}); err != nil {
+		t.Fatal(err)
+	}
+	v.Lock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// vaultSeeds are well-formed files, every truncation of their headers, and
+// headers with out-of-range fields
+func vaultSeeds(t testing.TB) [][]byte {
+	var seeds [][]byte
+	valid := [][]byte{
+		validVaultFile(t, Options{KDF: fuzzKDF}),
+		validVaultFile(t, Options{KDF: fuzzKDF, Compress: true}),
+		validVaultFile(t, Options{KDF: fuzzKDF, Algorithm: AlgorithmChaCha20}),
+	}
+
+	for _, data := range valid {
+		seeds = append(seeds, data)
+
+		// Truncated headers, up to and just past the tag
+		for n := 0; n <= 6+kdfParamsSize+2+saltSize+nonceSize+gcmTagSize && n < len(data); n++ {
+			seeds = append(seeds, data[:n])
+		}
+	}
+
+	header := valid[2]
+	mutate := func(offset int, value []byte) {
+		seed := append([]byte(nil), header...)
+		copy(seed[offset:], value)
+		seeds = append(seeds, seed)
+	}
+	max32 := []byte{0xff, 0xff, 0xff, 0xff}
+	mutate(4, []byte{0xff, 0xff})          // unknown version
+	mutate(4, []byte{0x00, 0x00})          // version 0
+	mutate(6, max32)                       // time
+	mutate(10, max32)                      // memory
+	mutate(10, []byte{0, 0, 0, 0})         // no memory
+	mutate(14, []byte{0})                  // no threads
+	mutate(6+kdfParamsSize, []byte{0xff})  // flags
+	mutate(6+kdfParamsSize+1, []byte{0})   // algorithm 0
+	mutate(6+kdfParamsSize+1, []byte{255}) // unknown algorithm
+
+	return seeds
+}
+
+// checkParseError fails unless err is one of the malformed-file errors
+func checkParseError(t *testing.T, err error) {
+	t.Helper()
+
+	if !errors.Is(err, ErrInvalidVault) && !errors.Is(err, ErrVaultCorrupted) {
+		t.Fatalf("malformed input gave %v, want ErrInvalidVault or ErrVaultCorrupted", err)
+	}
+}
+
+func FuzzParseFile(f *testing.F) {
+	for _, seed := range vaultSeeds(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		header, ciphertext, err := parseFile(data)
+		if err != nil {
+			checkParseError(t, err)
+			return
+		}
+
+		if len(header.salt) != saltSize || len(header.nonce) != nonceSize {
+			t.Fatalf("salt %d bytes, nonce %d bytes", len(header.salt), len(header.nonce))
+		}
+		if len(ciphertext) < gcmTagSize {
+			t.Fatalf("ciphertext of %d bytes has no room for a tag", len(ciphertext))
+		}
+		if !header.params.valid() {
+			t.Fatalf("accepted invalid KDF parameters %+v", header.params)
+		}
+	})
+}
+
+func FuzzUnlock(f *testing.F) {
+	for _, seed := range vaultSeeds(f) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Don't spend the fuzzing budget on costly but valid parameters
+		header, _, err := parseFile(data)
+		if err == nil && header.params != fuzzKDF {
+			t.Skip()
+		}
+
+		path := filepath.Join(t.TempDir(), "credentials.vault")
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		// Give a parseable file the counter it was written with
+		if err == nil {
+			if err := writeLockout(lockoutPath(path), header.salt, &lockoutState{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		v, err := Open(path)
+		if err != nil {
+			checkParseError(t, err)
+			return
+		}
+		err = v.Unlock(fuzzPassword)
+		if err == nil || errors.Is(err, ErrWrongPassword) {
+			return
+		}
+		checkParseError(t, err)
+	})
+}
+
+func TestParseFileHeaderOnly(t *testing.T) {
+	data := validVaultFile(t, Options{KDF: fuzzKDF})
+
+	// Magic, version, KDF parameters and flags, but no salt or payload
+	_, _, err := parseFile(data[:6+kdfParamsSize+1])
+	if !errors.Is(err, ErrVaultIncomplete) || !errors.Is(err, ErrVaultCorrupted) {
+		t.Errorf("header-only file: err = %v, want ErrVaultIncomplete", err)
+	}
+
+	bad := append([]byte(nil), data...)
+	binary.BigEndian.PutUint16(bad[4:], 0xffff)
+	if _, _, err := parseFile(bad); !errors.Is(err, ErrInvalidVault) {
+		t.Errorf("unknown version: err = %v, want ErrInvalidVault", err)
+	}
+}
+
+func TestOpenHeaderOnly(t *testing.T) {
+	data := validVaultFile(t, Options{KDF: fuzzKDF})
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+
+	// Every cut before the payload, down to an empty file, is incomplete
+	for _, n := range []int{0, 4, 6, 6 + kdfParamsSize + 1, 6 + kdfParamsSize + 1 + saltSize} {
+		if err := os.WriteFile(path, data[:n], 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path); !errors.Is(err, ErrVaultIncomplete) {
+			t.Errorf("%d bytes: err = %v, want ErrVaultIncomplete", n, err)
+		}
+	}
+}
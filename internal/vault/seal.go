@@ -0,0 +1,68 @@
+package vault
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Sealed is data encrypted with a passphrase rather than the vault's key,
+// for files that leave the USB such as exported sessions. It carries its
+// own salt and Argon2id parameters and marshals to JSON.
+type Sealed struct {
+	KDF   KDFParams `json:"kdf"`
+	Salt  []byte    `json:"salt"`
+	Nonce []byte    `json:"nonce"`
+	Data  []byte    `json:"data"`
+}
+
+// Seal encrypts plaintext with AES-256-GCM under a key derived from
+// passphrase with params
+func Seal(passphrase string, plaintext []byte, params KDFParams) (*Sealed, error) {
+	if !params.valid() {
+		return nil, fmt.Errorf("invalid argon2 parameters: %+v", params)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	key := params.deriveKey(passphrase, salt)
+	defer zero(key)
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealed{
+		KDF:   params,
+		Salt:  salt,
+		Nonce: nonce,
+		Data:  gcm.Seal(nil, nonce, plaintext, nil),
+	}, nil
+}
+
+// Open decrypts sealed data, returning ErrWrongPassword if passphrase
+// doesn't match or the data was altered
+func (s *Sealed) Open(passphrase string) ([]byte, error) {
+	if !s.KDF.valid() || len(s.Salt) != saltSize || len(s.Nonce) != nonceSize {
+		return nil, fmt.Errorf("invalid sealed data")
+	}
+
+	key := s.KDF.deriveKey(passphrase, s.Salt)
+	defer zero(key)
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, s.Nonce, s.Data, nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
@@ -1,9 +1,11 @@
 package vault
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -14,34 +16,54 @@ import (
 	"sync"
 	"time"
 
-	"golang.org/x/crypto/argon2"
+	"github.com/cxt9/claude-go/internal/fsutil"
 )
 
 const (
 	// File format magic number: "CCGO" (Claude Code Go)
 	magicNumber uint32 = 0x4343474F
 
-	// Current vault format version
-	vaultVersion uint16 = 1
+	// Current vault format version. Version 1 had no KDF parameters in the
+	// header and always used the interactive profile. Version 3 adds a flags
+	// byte and is only written when a flag is set; since flagLockout, that
+	// is every vault this release saves. Version 4 adds an algorithm byte
+	// after the flags and is only written for a cipher other than AES-GCM.
+	vaultVersion          uint16 = 2
+	vaultVersionV1        uint16 = 1
+	vaultVersionFlags     uint16 = 3
+	vaultVersionAlgorithm uint16 = 4
 
-	// Argon2id parameters (OWASP recommended)
-	argonTime    = 3
-	argonMemory  = 64 * 1024 // 64 MB
-	argonThreads = 4
-	argonKeyLen  = 32 // 256 bits for AES-256
+	// Derived key length: 256 bits for AES-256 and ChaCha20
+	argonKeyLen = 32
+
+	// KDF parameters in a version 2 header: time(4) + memory(4) + threads(1)
+	kdfParamsSize = 9
+
+	// Header flags (version 3 and later)
+	flagCompressed byte = 1 << 0 // plaintext is zlib-compressed
+	flagLockout    byte = 1 << 1 // failed attempts are counted beside the vault, MACed; see lockoutState
+	knownFlags          = flagCompressed | flagLockout
 
 	// Salt and nonce sizes
 	saltSize  = 32
-	nonceSize = 12 // GCM standard nonce size
+	nonceSize = 12 // GCM standard nonce size, as for ChaCha20-Poly1305
+
+	// Authentication tag appended to every ciphertext
+	gcmTagSize = 16
 )
 
 var (
-	ErrVaultLocked     = errors.New("vault is locked")
-	ErrWrongPassword   = errors.New("incorrect password")
-	ErrInvalidVault    = errors.New("invalid vault file")
-	ErrVaultNotFound   = errors.New("vault not found")
-	ErrEntryNotFound   = errors.New("credential entry not found")
-	ErrVaultCorrupted  = errors.New("vault file corrupted")
+	ErrVaultLocked    = errors.New("vault is locked")
+	ErrWrongPassword  = errors.New("incorrect password")
+	ErrInvalidVault   = errors.New("invalid vault file")
+	ErrVaultNotFound  = errors.New("vault not found")
+	ErrEntryNotFound  = errors.New("credential entry not found")
+	ErrVaultCorrupted = errors.New("vault file corrupted")
+
+	// ErrVaultIncomplete means the file stops before its encrypted payload,
+	// e.g. creation was interrupted, so it holds no credentials. It is a
+	// kind of ErrVaultCorrupted.
+	ErrVaultIncomplete = fmt.Errorf("%w: the file stops before its encrypted contents", ErrVaultCorrupted)
 )
 
 // CredentialType identifies the type of stored credential
@@ -53,25 +75,49 @@ const (
 	CredentialAWS    CredentialType = "aws"
 	CredentialGCP    CredentialType = "gcp"
 	CredentialMCP    CredentialType = "mcp"
+	CredentialSecret CredentialType = "secret" // a named value for session env overlays
+	CredentialAudit  CredentialType = "audit"  // the audit log's signing key
 )
 
 // Entry represents a single credential stored in the vault
 type Entry struct {
 	ID        string            `json:"id"`
 	Type      CredentialType    `json:"type"`
-	Provider  string            `json:"provider"`  // claudeai, console, bedrock, vertex
-	Data      json.RawMessage   `json:"data"`      // Type-specific credential data
+	Provider  string            `json:"provider"` // claudeai, console, bedrock, vertex
+	Data      json.RawMessage   `json:"data"`     // Type-specific credential data
 	CreatedAt time.Time         `json:"created_at"`
 	UpdatedAt time.Time         `json:"updated_at"`
 	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
 }
 
+// redactedData replaces Data when an entry is marshaled through the public path
+var redactedData = json.RawMessage(`"[redacted]"`)
+
+// plainEntry has Entry's fields without its methods, so it marshals in full
+type plainEntry Entry
+
+// MarshalJSON omits the credential data, so an entry that ends up in a log
+// or in command output never carries secrets. MarshalUnsafeJSON keeps it.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	redacted := plainEntry(e)
+	if len(redacted.Data) > 0 {
+		redacted.Data = redactedData
+	}
+	return json.Marshal(redacted)
+}
+
+// MarshalUnsafeJSON marshals the entry including its secret credential data
+func (e *Entry) MarshalUnsafeJSON() ([]byte, error) {
+	return json.Marshal((*plainEntry)(e))
+}
+
 // OAuthData stores OAuth token information
 type OAuthData struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	TokenType    string    `json:"token_type"`
+	IssuedAt     time.Time `json:"issued_at"` // by the token server's clock, if known
 	ExpiresAt    time.Time `json:"expires_at"`
 	Scope        string    `json:"scope,omitempty"`
 }
@@ -83,25 +129,77 @@ type APIKeyData struct {
 
 // vaultData is the decrypted contents of the vault
 type vaultData struct {
-	Version   int                `json:"version"`
-	Entries   map[string]*Entry  `json:"entries"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
+	Version   int               `json:"version"`
+	Entries   map[string]*Entry `json:"entries"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// MarshalJSON writes entries in full; this is the form encrypted on save
+func (d *vaultData) MarshalJSON() ([]byte, error) {
+	entries := make(map[string]*plainEntry, len(d.Entries))
+	for id, entry := range d.Entries {
+		entries[id] = (*plainEntry)(entry)
+	}
+
+	type plainData vaultData
+	return json.Marshal(&struct {
+		*plainData
+		Entries map[string]*plainEntry `json:"entries"`
+	}{(*plainData)(d), entries})
 }
 
 // Vault manages encrypted credential storage
 type Vault struct {
 	path     string
 	salt     []byte
+	params   KDFParams
 	key      []byte
 	gcm      cipher.AEAD
+	compress bool
 	data     *vaultData
+
+	// Cipher the contents are encrypted with
+	algorithm Algorithm
+
 	mu       sync.RWMutex
 	unlocked bool
+
+	// Wrong passwords entered before the last successful unlock
+	failedAttempts int
+}
+
+// Options control how a new vault is created
+type Options struct {
+	// Argon2id cost; zero means DefaultKDFParams
+	KDF KDFParams
+
+	// Compress the plaintext before encrypting it
+	Compress bool
+
+	// Cipher to encrypt with; empty means AlgorithmAESGCM
+	Algorithm Algorithm
 }
 
 // Create initializes a new vault with the given password
 func Create(path string, password string) (*Vault, error) {
+	return CreateWithOptions(path, password, Options{})
+}
+
+// CreateWithOptions initializes a new vault with the given password and options
+func CreateWithOptions(path string, password string, opts Options) (*Vault, error) {
+	params := opts.KDF
+	if params == (KDFParams{}) {
+		params = DefaultKDFParams()
+	}
+	if !params.valid() {
+		return nil, fmt.Errorf("invalid argon2 parameters: %+v", params)
+	}
+	algorithm, err := ParseAlgorithm(string(opts.Algorithm))
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate random salt
 	salt := make([]byte, saltSize)
 	if _, err := rand.Read(salt); err != nil {
@@ -109,26 +207,23 @@ func Create(path string, password string) (*Vault, error) {
 	}
 
 	// Derive key from password
-	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
-
-	// Create AES-GCM cipher
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create cipher: %w", err)
-	}
+	key := params.deriveKey(password, salt)
 
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newAEAD(algorithm, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create GCM: %w", err)
+		return nil, err
 	}
 
 	now := time.Now()
 	v := &Vault{
-		path:     path,
-		salt:     salt,
-		key:      key,
-		gcm:      gcm,
-		unlocked: true,
+		path:      path,
+		salt:      salt,
+		params:    params,
+		key:       key,
+		gcm:       gcm,
+		compress:  opts.Compress,
+		algorithm: algorithm,
+		unlocked:  true,
 		data: &vaultData{
 			Version:   1,
 			Entries:   make(map[string]*Entry),
@@ -142,6 +237,11 @@ func Create(path string, password string) (*Vault, error) {
 		return nil, fmt.Errorf("failed to create vault directory: %w", err)
 	}
 
+	// The vault's header promises a counter, so write that first
+	if err := writeLockout(lockoutPath(path), salt, &lockoutState{}); err != nil {
+		return nil, err
+	}
+
 	// Save initial vault
 	if err := v.save(); err != nil {
 		return nil, fmt.Errorf("failed to save vault: %w", err)
@@ -150,11 +250,102 @@ func Create(path string, password string) (*Vault, error) {
 	return v, nil
 }
 
-// Open loads an existing vault (but doesn't unlock it)
+// ReEncrypt re-derives the key with a fresh salt and new Argon2 parameters
+// and rewrites the vault. The password must match the current one.
+func (v *Vault) ReEncrypt(password string, params KDFParams) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.usable() {
+		return ErrVaultLocked
+	}
+	if !params.valid() {
+		return fmt.Errorf("invalid argon2 parameters: %+v", params)
+	}
+
+	current := v.params.deriveKey(password, v.salt)
+	if subtle.ConstantTimeCompare(current, v.key) != 1 {
+		return ErrWrongPassword
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := params.deriveKey(password, salt)
+	gcm, err := newAEAD(v.algorithm, key)
+	if err != nil {
+		return err
+	}
+
+	oldSalt, oldParams, oldKey, oldGCM := v.salt, v.params, v.key, v.gcm
+	v.salt, v.params, v.key, v.gcm = salt, params, key, gcm
+
+	if err := v.save(); err != nil {
+		// The file on disk is untouched, so keep using the old key
+		v.salt, v.params, v.key, v.gcm = oldSalt, oldParams, oldKey, oldGCM
+		return err
+	}
+
+	zero(oldKey)
+
+	// The counter's MAC follows the salt
+	if err := v.resetLockout(salt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Compressed reports whether the vault's plaintext is compressed (known once
+// created or unlocked)
+func (v *Vault) Compressed() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.compress
+}
+
+// Algorithm returns the vault's cipher (known once created or unlocked)
+func (v *Vault) Algorithm() Algorithm {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.algorithm
+}
+
+// Params returns the vault's Argon2 parameters (known once created or unlocked)
+func (v *Vault) Params() KDFParams {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.params
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return gcm, nil
+}
+
+// Open loads an existing vault (but doesn't unlock it). A file cut off
+// within its header yields ErrVaultIncomplete.
 func Open(path string) (*Vault, error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
 		return nil, ErrVaultNotFound
 	}
+	if err == nil {
+		if _, _, err := parseFile(data); errors.Is(err, ErrVaultIncomplete) {
+			return nil, ErrVaultIncomplete
+		}
+	}
 
 	return &Vault{
 		path:     path,
@@ -174,66 +365,198 @@ func (v *Vault) Unlock(password string) error {
 	}
 
 	// Parse header
-	if len(data) < 6 { // magic(4) + version(2) minimum
-		return ErrInvalidVault
+	header, ciphertext, err := parseFile(data)
+	if err != nil {
+		return err
 	}
 
-	magic := binary.BigEndian.Uint32(data[0:4])
-	if magic != magicNumber {
-		return ErrInvalidVault
+	// Enforce any delay from previous wrong passwords
+	lockout := v.loadLockout(header)
+	if wait := lockout.remaining(time.Now()); wait > 0 {
+		return &LockoutError{RetryAfter: wait}
+	}
+	// Decrypt into locals so a failed attempt leaves the vault as it was,
+	// whether locked or already unlocked
+	key := header.params.deriveKey(password, header.salt)
+	gcm, err := newAEAD(header.algorithm, key)
+	if err != nil {
+		zero(key)
+		return err
 	}
 
-	version := binary.BigEndian.Uint16(data[4:6])
-	if version != vaultVersion {
-		return fmt.Errorf("unsupported vault version: %d", version)
+	plaintext, err := gcm.Open(nil, header.nonce, ciphertext, header.aad)
+	if err != nil {
+		zero(key)
+		v.recordFailure(header, lockout)
+		return ErrWrongPassword
 	}
 
-	offset := 6
+	compressed := header.flags&flagCompressed != 0
+	if compressed {
+		if plaintext, err = decompress(plaintext); err != nil {
+			zero(key)
+			return ErrVaultCorrupted
+		}
+	}
 
-	// Read salt
-	if len(data) < offset+saltSize {
+	contents := &vaultData{}
+	if err := json.Unmarshal(plaintext, contents); err != nil {
+		zero(key)
 		return ErrVaultCorrupted
 	}
+	if contents.Entries == nil {
+		contents.Entries = make(map[string]*Entry)
+	}
+	for _, entry := range contents.Entries {
+		if entry == nil {
+			zero(key)
+			return ErrVaultCorrupted
+		}
+	}
+
+	zero(v.key)
 	v.salt = make([]byte, saltSize)
-	copy(v.salt, data[offset:offset+saltSize])
-	offset += saltSize
+	copy(v.salt, header.salt)
+	v.params = header.params
+	v.compress = compressed
+	v.algorithm = header.algorithm
+	v.key = key
+	v.gcm = gcm
+	v.data = contents
+
+	v.failedAttempts = lockout.Failures
+	v.unlocked = true
+
+	// A vault from before flagLockout gets the flag once its counter is
+	// written, so only a counter MACed for it is applied from then on
+	if err := v.resetLockout(header.salt); err == nil && header.flags&flagLockout == 0 {
+		if err := v.save(); err != nil {
+			zero(v.key)
+			v.key, v.gcm, v.data, v.unlocked = nil, nil, nil, false
+			return fmt.Errorf("failed to upgrade vault header: %w", err)
+		}
+	}
 
-	// Derive key
-	v.key = argon2.IDKey([]byte(password), v.salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return nil
+}
+
+// FailedAttempts returns how many wrong passwords were entered before the
+// most recent successful Unlock
+func (v *Vault) FailedAttempts() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.failedAttempts
+}
 
-	// Create cipher
-	block, err := aes.NewCipher(v.key)
+// VerifyStructure checks the vault file layout without decrypting it, so it
+// needs no password. It catches truncation and header damage, not tampering
+// with the encrypted payload.
+func (v *Vault) VerifyStructure() error {
+	data, err := os.ReadFile(v.path)
 	if err != nil {
-		return fmt.Errorf("failed to create cipher: %w", err)
+		return fmt.Errorf("failed to read vault: %w", err)
 	}
 
-	v.gcm, err = cipher.NewGCM(block)
-	if err != nil {
-		return fmt.Errorf("failed to create GCM: %w", err)
+	_, _, err = parseFile(data)
+	return err
+}
+
+// fileHeader is the unencrypted prefix of a vault file
+type fileHeader struct {
+	version   uint16
+	params    KDFParams
+	flags     byte
+	algorithm Algorithm
+	salt      []byte
+	nonce     []byte
+
+	// Header bytes authenticated along with the ciphertext (version 3
+	// and later)
+	aad []byte
+}
+
+// parseFile splits a vault file into its header and ciphertext, validating
+// the header and section lengths
+func parseFile(data []byte) (*fileHeader, []byte, error) {
+	var magic [4]byte
+	binary.BigEndian.PutUint32(magic[:], magicNumber)
+
+	if len(data) < 6 { // magic(4) + version(2) minimum
+		// An empty file or a bare magic number is an interrupted write
+		if bytes.HasPrefix(magic[:], data[:min(len(data), 4)]) {
+			return nil, nil, ErrVaultIncomplete
+		}
+		return nil, nil, ErrInvalidVault
 	}
 
-	// Read nonce
-	if len(data) < offset+nonceSize {
-		return ErrVaultCorrupted
+	if !bytes.Equal(data[0:4], magic[:]) {
+		return nil, nil, ErrInvalidVault
 	}
-	nonce := data[offset : offset+nonceSize]
-	offset += nonceSize
 
-	// Decrypt payload
-	ciphertext := data[offset:]
-	plaintext, err := v.gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return ErrWrongPassword
+	header := &fileHeader{version: binary.BigEndian.Uint16(data[4:6]), algorithm: AlgorithmAESGCM}
+	offset := 6
+
+	switch header.version {
+	case vaultVersionV1:
+		header.params = DefaultKDFParams()
+	case vaultVersion, vaultVersionFlags, vaultVersionAlgorithm:
+		if len(data) < offset+kdfParamsSize {
+			return nil, nil, ErrVaultIncomplete
+		}
+		header.params = KDFParams{
+			Time:    binary.BigEndian.Uint32(data[offset:]),
+			Memory:  binary.BigEndian.Uint32(data[offset+4:]),
+			Threads: data[offset+8],
+		}
+		offset += kdfParamsSize
+
+		if !header.params.valid() {
+			return nil, nil, ErrVaultCorrupted
+		}
+
+		if header.version >= vaultVersionFlags {
+			if len(data) < offset+1 {
+				return nil, nil, ErrVaultIncomplete
+			}
+			header.flags = data[offset]
+			offset++
+
+			if header.flags&^knownFlags != 0 {
+				return nil, nil, fmt.Errorf("%w: unsupported flags %#x", ErrInvalidVault, header.flags)
+			}
+		}
+
+		if header.version == vaultVersionAlgorithm {
+			if len(data) < offset+1 {
+				return nil, nil, ErrVaultIncomplete
+			}
+			algorithm, ok := algorithmFromID(data[offset])
+			if !ok {
+				return nil, nil, fmt.Errorf("%w: unsupported algorithm %#x", ErrInvalidVault, data[offset])
+			}
+			header.algorithm = algorithm
+			offset++
+		}
+
+		if header.version >= vaultVersionFlags {
+			header.aad = data[:offset]
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidVault, header.version)
 	}
 
-	// Parse decrypted data
-	v.data = &vaultData{}
-	if err := json.Unmarshal(plaintext, v.data); err != nil {
-		return ErrVaultCorrupted
+	// Salt, nonce and at least a tag must follow the header
+	if len(data) < offset+saltSize+nonceSize+gcmTagSize {
+		return nil, nil, ErrVaultIncomplete
 	}
 
-	v.unlocked = true
-	return nil
+	header.salt = data[offset : offset+saltSize]
+	offset += saltSize
+
+	header.nonce = data[offset : offset+nonceSize]
+	offset += nonceSize
+
+	return header, data[offset:], nil
 }
 
 // Lock clears sensitive data from memory
@@ -242,9 +565,7 @@ func (v *Vault) Lock() {
 	defer v.mu.Unlock()
 
 	// Zero out sensitive data
-	for i := range v.key {
-		v.key[i] = 0
-	}
+	zero(v.key)
 	v.key = nil
 	v.gcm = nil
 	v.data = nil
@@ -258,9 +579,24 @@ func (v *Vault) IsUnlocked() bool {
 	return v.unlocked
 }
 
+// usable reports whether the vault is unlocked with its key and contents in
+// memory. Callers must hold v.mu; everything touching the key or contents
+// checks it, so use after Lock fails with ErrVaultLocked rather than a nil
+// dereference.
+func (v *Vault) usable() bool {
+	return v.unlocked && v.gcm != nil && v.data != nil
+}
+
+// zero overwrites key material
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
 // save writes the encrypted vault to disk
 func (v *Vault) save() error {
-	if !v.unlocked {
+	if !v.usable() {
 		return ErrVaultLocked
 	}
 
@@ -270,51 +606,63 @@ func (v *Vault) save() error {
 		return fmt.Errorf("failed to serialize vault: %w", err)
 	}
 
+	flags := flagLockout
+	if v.compress {
+		flags |= flagCompressed
+		if plaintext, err = compress(plaintext); err != nil {
+			return fmt.Errorf("failed to compress vault: %w", err)
+		}
+	}
+
+	version := vaultVersion
+	switch {
+	case v.algorithm != "" && v.algorithm != AlgorithmAESGCM:
+		version = vaultVersionAlgorithm
+	case flags != 0:
+		version = vaultVersionFlags
+	}
+
 	// Generate nonce
 	nonce := make([]byte, nonceSize)
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return fmt.Errorf("failed to generate nonce: %w", err)
 	}
 
-	// Encrypt
-	ciphertext := v.gcm.Seal(nil, nonce, plaintext, nil)
-
-	// Build file: magic + version + salt + nonce + ciphertext
-	fileSize := 4 + 2 + saltSize + nonceSize + len(ciphertext)
-	file := make([]byte, fileSize)
-
-	offset := 0
-
-	// Magic number
-	binary.BigEndian.PutUint32(file[offset:], magicNumber)
-	offset += 4
-
-	// Version
-	binary.BigEndian.PutUint16(file[offset:], vaultVersion)
-	offset += 2
-
-	// Salt
-	copy(file[offset:], v.salt)
-	offset += saltSize
+	// Build header: magic + version + kdf params [+ flags [+ algorithm]]
+	header := make([]byte, 4+2+kdfParamsSize, 4+2+kdfParamsSize+2)
+
+	binary.BigEndian.PutUint32(header[0:], magicNumber)
+	binary.BigEndian.PutUint16(header[4:], version)
+	binary.BigEndian.PutUint32(header[6:], v.params.Time)
+	binary.BigEndian.PutUint32(header[10:], v.params.Memory)
+	header[14] = v.params.Threads
+
+	// A version 3 or 4 header is authenticated, so its flags and
+	// algorithm can't be altered
+	var aad []byte
+	if version >= vaultVersionFlags {
+		header = append(header, flags)
+		if version == vaultVersionAlgorithm {
+			header = append(header, v.algorithm.id())
+		}
+		aad = header
+	}
 
-	// Nonce
-	copy(file[offset:], nonce)
-	offset += nonceSize
+	// Encrypt
+	ciphertext := v.gcm.Seal(nil, nonce, plaintext, aad)
 
-	// Ciphertext
-	copy(file[offset:], ciphertext)
+	// Build file: header + salt + nonce + ciphertext
+	file := make([]byte, 0, len(header)+saltSize+nonceSize+len(ciphertext))
+	file = append(file, header...)
+	file = append(file, v.salt...)
+	file = append(file, nonce...)
+	file = append(file, ciphertext...)
 
-	// Write atomically (write to temp, then rename)
-	tmpPath := v.path + ".tmp"
-	if err := os.WriteFile(tmpPath, file, 0600); err != nil {
+	// Write atomically (write and sync temp, then rename)
+	if err := fsutil.WriteFileAtomic(v.path, file, 0600); err != nil {
 		return fmt.Errorf("failed to write vault: %w", err)
 	}
 
-	if err := os.Rename(tmpPath, v.path); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("failed to finalize vault: %w", err)
-	}
-
 	return nil
 }
 
@@ -323,7 +671,7 @@ func (v *Vault) SetEntry(entry *Entry) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
-	if !v.unlocked {
+	if !v.usable() {
 		return ErrVaultLocked
 	}
 
@@ -344,7 +692,7 @@ func (v *Vault) GetEntry(id string) (*Entry, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
-	if !v.unlocked {
+	if !v.usable() {
 		return nil, ErrVaultLocked
 	}
 
@@ -361,7 +709,7 @@ func (v *Vault) DeleteEntry(id string) error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
-	if !v.unlocked {
+	if !v.usable() {
 		return ErrVaultLocked
 	}
 
@@ -380,7 +728,7 @@ func (v *Vault) ListEntries() ([]Entry, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
-	if !v.unlocked {
+	if !v.usable() {
 		return nil, ErrVaultLocked
 	}
 
@@ -401,6 +749,19 @@ func (v *Vault) ListEntries() ([]Entry, error) {
 	return entries, nil
 }
 
+// MoveAside renames the vault file to a timestamped backup next to it and
+// clears its lockout state, so a new vault can be created at path. It
+// returns the backup's path.
+func MoveAside(path string) (string, error) {
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err := fsutil.Rename(path, backup); err != nil {
+		return "", fmt.Errorf("failed to back up vault: %w", err)
+	}
+
+	os.Remove(lockoutPath(path))
+	return backup, nil
+}
+
 // Exists checks if a vault file exists at the given path
 func Exists(path string) bool {
 	_, err := os.Stat(path)
@@ -0,0 +1,211 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cxt9/claude-go/internal/fsutil"
+)
+
+func TestLargeEntryRoundTrip(t *testing.T) {
+	// Random bytes don't compress, so the file can't hide any inflation
+	raw := make([]byte, 3<<20)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(map[string]string{"bundle": base64.StdEncoding.EncodeToString(raw)})
+
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.SetEntry(&Entry{ID: "mcp/bundle", Type: CredentialMCP, Data: data}); err != nil {
+		t.Fatal(err)
+	}
+	v.Lock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit := int64(len(data)) + 64<<10; info.Size() > limit {
+		t.Errorf("vault is %d bytes for a %d-byte credential", info.Size(), len(data))
+	}
+
+	v, _ = Open(path)
+	if err := v.Unlock(fuzzPassword); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := v.GetEntry("mcp/bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(entry.Data, data) {
+		t.Errorf("read %d bytes back, want the %d stored", len(entry.Data), len(data))
+	}
+
+	entries, err := v.ListEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].ID != "mcp/bundle" {
+		t.Errorf("ListEntries = %v, want only mcp/bundle", entries)
+	}
+}
+
+func TestVerifyStructure(t *testing.T) {
+	path := newLockoutVault(t)
+	valid, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, _ := Open(path)
+	if err := v.VerifyStructure(); err != nil {
+		t.Fatalf("valid vault: %v", err)
+	}
+
+	// Version 3 header: magic(4) version(2) kdf(9) flags(1), then the salt
+	flip := func(i int, mask byte) []byte {
+		data := bytes.Clone(valid)
+		data[i] ^= mask
+		return data
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrVaultIncomplete},
+		{"magic only", valid[:4], ErrVaultIncomplete},
+		{"mid header", valid[:10], ErrVaultIncomplete},
+		{"before the nonce", valid[:16+saltSize+4], ErrVaultIncomplete},
+		{"no tag", valid[:16+saltSize+nonceSize+gcmTagSize-1], ErrVaultIncomplete},
+		{"magic flipped", flip(0, 0x01), ErrInvalidVault},
+		{"version flipped", flip(5, 0x10), ErrInvalidVault},
+		{"kdf memory flipped", flip(10, 0x80), ErrVaultCorrupted},
+		{"unknown flag", flip(15, 0x80), ErrInvalidVault},
+	}
+
+	for _, tt := range tests {
+		if err := os.WriteFile(path, tt.data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := v.VerifyStructure(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: VerifyStructure() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A flipped bit in the encrypted payload keeps the structure intact;
+	// only unlocking finds it
+	if err := os.WriteFile(path, flip(len(valid)-1, 0x01), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.VerifyStructure(); err != nil {
+		t.Errorf("payload flipped: VerifyStructure() = %v, want nil", err)
+	}
+	if err := v.Unlock(fuzzPassword); err == nil {
+		t.Error("payload flipped: Unlock succeeded")
+	}
+}
+
+// countingSyncer counts the files synced, and the directories by path
+type countingSyncer struct {
+	fsutil.Syncer
+	files int
+	dirs  map[string]int
+}
+
+func (c *countingSyncer) SyncFile(f *os.File) error {
+	c.files++
+	return c.Syncer.SyncFile(f)
+}
+
+func (c *countingSyncer) SyncDir(path string) error {
+	c.dirs[path]++
+	return c.Syncer.SyncDir(path)
+}
+
+func TestSaveSyncs(t *testing.T) {
+	path := newLockoutVault(t)
+	v, _ := Open(path)
+	if err := v.Unlock(fuzzPassword); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Lock()
+
+	rec := &countingSyncer{Syncer: fsutil.DefaultSyncer, dirs: make(map[string]int)}
+	defer func(orig fsutil.Syncer) { fsutil.DefaultSyncer = orig }(fsutil.DefaultSyncer)
+	fsutil.DefaultSyncer = rec
+
+	if err := v.SetEntry(&Entry{ID: "auth/console", Type: CredentialAPIKey, Data: json.RawMessage(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.files != 1 || rec.dirs[filepath.Dir(path)] != 1 {
+		t.Errorf("synced %d files and dirs %v, want the vault and its directory", rec.files, rec.dirs)
+	}
+}
+
+func TestEntryMarshalRedacts(t *testing.T) {
+	const secret = "sk-ant-marshal-test-secret"
+
+	path := filepath.Join(t.TempDir(), "credentials.vault")
+	v, err := CreateWithOptions(path, fuzzPassword, Options{KDF: fuzzKDF})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(APIKeyData{APIKey: secret})
+	if err := v.SetEntry(&Entry{ID: "console", Type: CredentialAPIKey, Provider: "console", Data: data}); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := v.GetEntry("console")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pointers, values and entries nested in other values all go through
+	// the redacting MarshalJSON
+	for name, value := range map[string]interface{}{
+		"pointer": entry,
+		"value":   *entry,
+		"slice":   []*Entry{entry},
+		"map":     map[string]Entry{"console": *entry},
+	} {
+		out, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if bytes.Contains(out, []byte(secret)) {
+			t.Errorf("%s: marshaled entry contains the secret: %s", name, out)
+		}
+		if !bytes.Contains(out, []byte(`"console"`)) {
+			t.Errorf("%s: marshaled entry lost its ID: %s", name, out)
+		}
+	}
+
+	if out, err := entry.MarshalUnsafeJSON(); err != nil || !bytes.Contains(out, []byte(secret)) {
+		t.Errorf("MarshalUnsafeJSON = %s, %v; want the secret kept", out, err)
+	}
+
+	// The vault itself still saves the data in full
+	v.Lock()
+	if err := v.Unlock(fuzzPassword); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Lock()
+	entry, err = v.GetEntry("console")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got APIKeyData
+	if err := json.Unmarshal(entry.Data, &got); err != nil || got.APIKey != secret {
+		t.Errorf("API key after reopening = %q, %v; want %q", got.APIKey, err, secret)
+	}
+}
@@ -6,6 +6,10 @@ set -e
 VERSION="${1:-dev}"
 OUTPUT_DIR="${2:-dist}"
 
+# Trusted manifest signing keys (id=base64 ed25519 public key, comma-separated,
+# oldest first). Builds without keys don't verify manifest signatures.
+SIGNING_KEYS="${UPDATE_SIGNING_KEYS:-}"
+
 echo "Building Claude Code Go v${VERSION}..."
 
 # Platforms to build for
@@ -38,7 +42,7 @@ for PLATFORM in "${PLATFORMS[@]}"; do
     mkdir -p "$(dirname "$OUTPUT_PATH")"
 
     GOOS=$GOOS GOARCH=$GOARCH go build \
-        -ldflags "-s -w -X main.Version=${VERSION}" \
+        -ldflags "-s -w -X main.Version=${VERSION} -X github.com/cxt9/claude-go/internal/update.signingKeys=${SIGNING_KEYS}" \
         -o "$OUTPUT_PATH" \
         ./cmd/claude-go
 done
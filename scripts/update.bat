@@ -25,9 +25,19 @@ if exist "%SCRIPT_DIR%\.version" (
     )
 )
 
+REM The highest version ever installed, which the launcher won't go below
+set "HIGHEST_VERSION=%CURRENT_VERSION%"
+if exist "%SCRIPT_DIR%\.version" (
+    for /f "delims=" %%v in ('powershell -NoProfile -Command "$i = Get-Content '%SCRIPT_DIR%\.version' -Raw | ConvertFrom-Json; if ($i.highest_version -and [version]$i.highest_version -gt [version]'%CURRENT_VERSION%') { $i.highest_version } else { '%CURRENT_VERSION%' }"') do set "HIGHEST_VERSION=%%v"
+)
+
 echo Current version: %CURRENT_VERSION%
 echo.
 
+REM The launcher verifies the manifest's signature and refuses downgrades;
+REM the rest of this script is for a USB whose launcher is missing
+set "LAUNCHER=%SCRIPT_DIR%\bin\%PLATFORM%\claude-go.exe"
+
 REM Handle offline update
 if "%1"=="--offline" (
     if not "%2"=="" (
@@ -38,14 +48,40 @@ if "%1"=="--offline" (
             exit /b 1
         )
 
+        if exist "%LAUNCHER%" (
+            "%LAUNCHER%" update install --file %2 %3
+            exit /b !errorlevel!
+        )
+
+        REM Extract into a staging folder first to read the bundle's version
+        echo Extracting update...
+        if exist "%SCRIPT_DIR%\.staging" rd /s /q "%SCRIPT_DIR%\.staging"
+        powershell -Command "Expand-Archive -Path '%2' -DestinationPath '%SCRIPT_DIR%\.staging' -Force"
+
+        set "BUNDLE_VERSION="
+        for /f "delims=" %%v in ('powershell -NoProfile -Command "(Get-Content '%SCRIPT_DIR%\.staging\.version' -Raw | ConvertFrom-Json).version" 2^>nul') do set "BUNDLE_VERSION=%%v"
+        if "!BUNDLE_VERSION!"=="" (
+            echo Error: The bundle does not declare its version
+            rd /s /q "%SCRIPT_DIR%\.staging"
+            exit /b 1
+        )
+        powershell -NoProfile -Command "if ([version]'!BUNDLE_VERSION!' -lt [version]'%HIGHEST_VERSION%') { exit 1 }"
+        if errorlevel 1 if not "%3"=="--allow-downgrade" (
+            echo Error: The bundle is !BUNDLE_VERSION! but %HIGHEST_VERSION% was installed before ^(pass --allow-downgrade after the file to install it anyway^)
+            rd /s /q "%SCRIPT_DIR%\.staging"
+            exit /b 5
+        )
+
         REM Create backup
         echo Creating backup...
         if exist "%SCRIPT_DIR%\.rollback" rd /s /q "%SCRIPT_DIR%\.rollback"
         xcopy /e /i /q "%SCRIPT_DIR%\bin" "%SCRIPT_DIR%\.rollback\bin" >nul
 
-        REM Extract update (requires PowerShell)
-        echo Extracting update...
-        powershell -Command "Expand-Archive -Path '%2' -DestinationPath '%SCRIPT_DIR%' -Force"
+        xcopy /e /i /q /y "%SCRIPT_DIR%\.staging\bin" "%SCRIPT_DIR%\bin" >nul
+        copy /y "%SCRIPT_DIR%\.staging\*.bat" "%SCRIPT_DIR%" >nul 2>nul
+        copy /y "%SCRIPT_DIR%\.staging\*.sh" "%SCRIPT_DIR%" >nul 2>nul
+        rd /s /q "%SCRIPT_DIR%\.staging"
+        call :write_version !BUNDLE_VERSION!
 
         REM Cleanup
         if exist "%SCRIPT_DIR%\.rollback" rd /s /q "%SCRIPT_DIR%\.rollback"
@@ -58,6 +94,11 @@ if "%1"=="--offline" (
     )
 )
 
+if exist "%LAUNCHER%" (
+    "%LAUNCHER%" update install
+    exit /b !errorlevel!
+)
+
 echo Checking for updates...
 echo.
 
@@ -112,7 +153,7 @@ if "%LATEST_VERSION%" gtr "%CURRENT_VERSION%" (
             powershell -Command "Expand-Archive -Path '!TEMP_ZIP!' -DestinationPath '%SCRIPT_DIR%' -Force"
 
             REM Update version file
-            echo {"version":"%LATEST_VERSION%","updated_at":"%DATE%T%TIME%"} > "%SCRIPT_DIR%\.version"
+            call :write_version %LATEST_VERSION%
 
             REM Cleanup
             del "!TEMP_ZIP!" 2>nul
@@ -138,3 +179,10 @@ if "%LATEST_VERSION%" gtr "%CURRENT_VERSION%" (
 )
 
 echo.
+exit /b 0
+
+REM Record an installed version, keeping the highest one ever installed so
+REM the launcher goes on refusing older bundles
+:write_version
+powershell -NoProfile -Command "$h = '%HIGHEST_VERSION%'; if ([version]'%1' -gt [version]$h) { $h = '%1' }; [ordered]@{ version = '%1'; highest_version = $h; updated_at = (Get-Date).ToUniversalTime().ToString('yyyy-MM-ddTHH:mm:ssZ') } | ConvertTo-Json -Compress | Set-Content -Encoding ASCII '%SCRIPT_DIR%\.version'"
+exit /b 0
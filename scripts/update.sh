@@ -39,12 +39,34 @@ echo "│       Claude Code Go - Update Check         │"
 echo "╰─────────────────────────────────────────────╯"
 echo ""
 
-# Read current version
+# Compare versions
+version_gt() {
+    test "$(echo "$@" | tr " " "\n" | sort -V | head -n 1)" != "$1"
+}
+
+# Read current version, and the highest ever installed
 if [ -f "${SCRIPT_DIR}/.version" ]; then
     CURRENT_VERSION=$(cat "${SCRIPT_DIR}/.version" | grep -o '"version":"[^"]*"' | cut -d'"' -f4)
-else
-    CURRENT_VERSION="0.0.0"
+    HIGHEST_VERSION=$(cat "${SCRIPT_DIR}/.version" | grep -o '"highest_version":"[^"]*"' | cut -d'"' -f4)
 fi
+CURRENT_VERSION="${CURRENT_VERSION:-0.0.0}"
+if [ -z "$HIGHEST_VERSION" ] || version_gt "$CURRENT_VERSION" "$HIGHEST_VERSION"; then
+    HIGHEST_VERSION="$CURRENT_VERSION"
+fi
+
+# Record an installed version, keeping the highest one ever installed so
+# the launcher goes on refusing older bundles
+write_version() {
+    local highest="$HIGHEST_VERSION"
+    if version_gt "$1" "$highest"; then
+        highest="$1"
+    fi
+    echo "{\"version\":\"$1\",\"highest_version\":\"${highest}\",\"updated_at\":\"$(date -u +%Y-%m-%dT%H:%M:%SZ)\"}" > "${SCRIPT_DIR}/.version"
+}
+
+# The launcher verifies the manifest's signature and refuses downgrades;
+# the rest of this script is for a USB whose launcher is missing
+LAUNCHER="${SCRIPT_DIR}/bin/${PLATFORM}/claude-go"
 
 echo "Current version: ${CURRENT_VERSION}"
 echo ""
@@ -58,6 +80,22 @@ if [ "$1" = "--offline" ] && [ -n "$2" ]; then
         exit 1
     fi
 
+    # Prefer the launcher, which refuses bundles older than a version
+    # installed before (pass --allow-downgrade after the file to override)
+    if [ -x "$LAUNCHER" ]; then
+        exec "$LAUNCHER" update install --file "$2" "${@:3}"
+    fi
+
+    BUNDLE_VERSION=$(unzip -p "$2" .version 2>/dev/null | grep -o '"version":"[^"]*"' | head -1 | cut -d'"' -f4)
+    if [ -z "$BUNDLE_VERSION" ]; then
+        echo -e "${RED}Error: The bundle does not declare its version${NC}"
+        exit 1
+    fi
+    if version_gt "$HIGHEST_VERSION" "$BUNDLE_VERSION" && [ "$3" != "--allow-downgrade" ]; then
+        echo -e "${RED}Error: The bundle is ${BUNDLE_VERSION} but ${HIGHEST_VERSION} was installed before (pass --allow-downgrade after the file to install it anyway)${NC}"
+        exit 5
+    fi
+
     # Create backup
     echo "Creating backup..."
     rm -rf "${SCRIPT_DIR}/.rollback"
@@ -66,6 +104,7 @@ if [ "$1" = "--offline" ] && [ -n "$2" ]; then
     # Extract update
     echo "Extracting update..."
     unzip -o "$2" -d "${SCRIPT_DIR}" bin/* *.sh *.bat 2>/dev/null || true
+    write_version "$BUNDLE_VERSION"
 
     # Cleanup
     rm -rf "${SCRIPT_DIR}/.rollback"
@@ -76,6 +115,10 @@ if [ "$1" = "--offline" ] && [ -n "$2" ]; then
     exit 0
 fi
 
+if [ -x "$LAUNCHER" ]; then
+    exec "$LAUNCHER" update install
+fi
+
 # Check for updates
 echo "Checking for updates..."
 
@@ -93,12 +136,7 @@ if [ -z "$LATEST_VERSION" ]; then
     exit 1
 fi
 
-# Compare versions
-version_gt() {
-    test "$(echo "$@" | tr " " "\n" | sort -V | head -n 1)" != "$1"
-}
-
-if version_gt "$LATEST_VERSION" "$CURRENT_VERSION"; then
+if version_gt "$LATEST_VERSION" "$CURRENT_VERSION" && ! version_gt "$HIGHEST_VERSION" "$LATEST_VERSION"; then
     echo -e "${GREEN}✓ New version available: ${LATEST_VERSION}${NC}"
     echo ""
     echo "Changes:"
@@ -128,7 +166,7 @@ if version_gt "$LATEST_VERSION" "$CURRENT_VERSION"; then
             unzip -o "$TEMP_FILE" -d "${SCRIPT_DIR}" bin/* *.sh *.bat 2>/dev/null || true
 
             # Update version file
-            echo "{\"version\":\"${LATEST_VERSION}\",\"updated_at\":\"$(date -u +%Y-%m-%dT%H:%M:%SZ)\"}" > "${SCRIPT_DIR}/.version"
+            write_version "$LATEST_VERSION"
 
             # Cleanup
             rm -f "$TEMP_FILE"